@@ -2,18 +2,31 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	log "gopkg.in/inconshreveable/log15.v2"
 	"gopkg.in/inconshreveable/log15.v2/stack"
+	"gopkg.in/inconshreveable/log15.v2/term"
 
 	"github.com/gothyra/thyra/pkg/server"
 )
 
-func customFormat() log.Format {
+// customFormat formats records as text, coloring the level when colored is true.
+func customFormat(colored bool) log.Format {
 	return log.FormatFunc(func(r *log.Record) []byte {
+		b := &bytes.Buffer{}
+		call := stack.Call(r.CallPC[0])
+		if !colored {
+			fmt.Fprintf(b, "%s [%s %s:%d] %s\n", r.Lvl, r.Time.Format("2006-01-02|15:04:05.000"), call, call, r.Msg)
+			return b.Bytes()
+		}
+
 		var color = 0
 		switch r.Lvl {
 		case log.LvlCrit:
@@ -27,24 +40,223 @@ func customFormat() log.Format {
 		case log.LvlDebug:
 			color = 36
 		}
-		b := &bytes.Buffer{}
-		call := stack.Call(r.CallPC[0])
 		fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m [%s %s:%d] %s\n", color, r.Lvl, r.Time.Format("2006-01-02|15:04:05.000"), call, call, r.Msg)
 		return b.Bytes()
 	})
 }
 
-func init() {
-	h := log.StreamHandler(os.Stdout, customFormat())
-	log.Root().SetHandler(h)
-	flag.Parse()
+// jsonFormat formats records as JSON objects, one per line, including the
+// level and the call site of every record. Context values keep their JSON
+// types, except for errors and Stringers which are written as strings.
+// Context keys clashing with the fields of the record are prefixed with
+// ctx_ so that they do not overwrite them.
+func jsonFormat() log.Format {
+	return log.FormatFunc(func(r *log.Record) []byte {
+		call := stack.Call(r.CallPC[0])
+		props := map[string]interface{}{
+			"t":    r.Time.Format(time.RFC3339Nano),
+			"lvl":  r.Lvl.String(),
+			"call": fmt.Sprintf("%s:%d", call, call),
+			"msg":  r.Msg,
+		}
+		for i := 0; i+1 < len(r.Ctx); i += 2 {
+			key := fmt.Sprint(r.Ctx[i])
+			if _, ok := props[key]; ok {
+				key = "ctx_" + key
+			}
+			props[key] = jsonValue(r.Ctx[i+1])
+		}
+
+		b, err := json.Marshal(props)
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{"lvl": "eror", "msg": fmt.Sprintf("Record could not be formatted: %v", err)})
+		}
+		return append(b, '\n')
+	})
+}
+
+// jsonValue returns the value as it is written in JSON logs.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+// logFormat returns the log format with the given name. Text logs are colored
+// when colored is true.
+func logFormat(name string, colored bool) (log.Format, error) {
+	switch name {
+	case "", "text":
+		return customFormat(colored), nil
+	case "json":
+		return jsonFormat(), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", name)
+}
+
+// setupDefaultLogging logs to stdout in the format given by the -logformat
+// flag, until the configuration is loaded.
+func setupDefaultLogging() {
+	f, err := logFormat(*logFormatName, term.IsTty(os.Stdout.Fd()))
+	if err != nil {
+		f = customFormat(term.IsTty(os.Stdout.Fd()))
+	}
+	log.Root().SetHandler(log.StreamHandler(os.Stdout, f))
+	if err != nil {
+		log.Warn(fmt.Sprintf("Falling back to text logs: %v", err))
+	}
+}
+
+// setupLogging configures the root logger with the log level, format and file
+// found in the server configuration. The -logformat flag takes precedence
+// over the configured format. Logs written to a file are never colored.
+func setupLogging(s *server.Server) error {
+	lvl := log.LvlDebug
+	if s.Config.LogLevel != "" {
+		var err error
+		if lvl, err = log.LvlFromString(s.Config.LogLevel); err != nil {
+			return err
+		}
+	}
+
+	name := s.Config.LogFormat
+	if *logFormatName != "" {
+		name = *logFormatName
+	}
+
+	var h log.Handler
+	if s.Config.LogFile != "" {
+		f, err := logFormat(name, false)
+		if err != nil {
+			return err
+		}
+		if h, err = log.FileHandler(s.StaticPath(s.Config.LogFile), f); err != nil {
+			return err
+		}
+	} else {
+		f, err := logFormat(name, term.IsTty(os.Stdout.Fd()))
+		if err != nil {
+			return err
+		}
+		h = log.StreamHandler(os.Stdout, f)
+	}
+
+	log.Root().SetHandler(log.LvlFilterHandler(lvl, h))
+	return nil
 }
 
 // Flags
-var port = flag.Int64("port", 4000, "Port to listen on incoming connections")
+var (
+	port     = flag.Int64("port", 4000, "Port to listen on incoming connections")
+	validate = flag.Bool("validate", false, "Validate the static content and exit without starting the server")
+	dev      = flag.Bool("dev", false, "Reload areas as soon as their files change")
+
+	exportPath = flag.String("export", "", "Export all players to the given JSON archive and exit")
+	importPath = flag.String("import", "", "Import the players of the given JSON archive and exit, skipping players that already exist")
+
+	logFormatName = flag.String("logformat", "", "Log format, either text or json (default: logFormat from server.toml, or text)")
+)
+
+// Exit codes of -validate, telling what kind of problem was found.
+const (
+	exitInvalid  = 1
+	exitNotFound = 2
+	exitUnread   = 3
+	exitUnparsed = 4
+)
+
+// exitCode returns the exit code for the given problems found in static
+// content, looking through errors that wrap them. Files missing, unreadable
+// or undecodable take precedence over invalid content, since they keep the
+// rest of the content from being checked.
+func exitCode(problems []error) int {
+	code := exitInvalid
+	for _, problem := range problems {
+		var (
+			notFound *server.NotFoundError
+			unread   *server.ReadError
+			unparsed *server.ParseError
+		)
+		switch {
+		case errors.As(problem, &notFound):
+			return exitNotFound
+		case errors.As(problem, &unread):
+			code = exitUnread
+		case errors.As(problem, &unparsed):
+			if code != exitUnread {
+				code = exitUnparsed
+			}
+		}
+	}
+	return code
+}
+
+// reportPlayers logs the players exported or imported, and the ones skipped.
+// It exits the program if err is set or any player was skipped.
+func reportPlayers(verb string, nicks []string, skipped []error, err error) {
+	if err != nil {
+		log.Error(fmt.Sprintf("Players could not be %s: %v", verb, err))
+		os.Exit(exitCode([]error{err}))
+	}
+	for _, problem := range skipped {
+		log.Warn(problem.Error())
+	}
+	log.Info(fmt.Sprintf("%s %d player(s): %s", strings.Title(verb), len(nicks), strings.Join(nicks, ", ")))
+	if len(skipped) > 0 {
+		log.Error(fmt.Sprintf("Skipped %d player(s)", len(skipped)))
+		os.Exit(exitInvalid)
+	}
+}
 
 func main() {
+	flag.Parse()
+	setupDefaultLogging()
+
+	// Logging is set up before anything else is loaded, so that everything
+	// is logged the configured way.
+	s, err := server.LoadConfig()
+	if err != nil {
+		log.Error(err.Error())
+		os.Exit(exitCode([]error{err}))
+	}
+	if err := setupLogging(s); err != nil {
+		log.Error(fmt.Sprintf("Logging could not be set up: %v", err))
+		os.Exit(1)
+	}
+
+	if *exportPath != "" {
+		nicks, skipped, err := s.ExportPlayers(*exportPath)
+		reportPlayers("exported", nicks, skipped, err)
+		return
+	}
+	if *importPath != "" {
+		nicks, skipped, err := s.ImportPlayers(*importPath)
+		reportPlayers("imported", nicks, skipped, err)
+		return
+	}
+
+	if *validate {
+		problems := s.Validate()
+		for _, problem := range problems {
+			log.Error(problem.Error())
+		}
+		if len(problems) > 0 {
+			log.Error(fmt.Sprintf("Found %d problem(s) in static content", len(problems)))
+			os.Exit(exitCode(problems))
+		}
+		log.Info("Static content is valid.")
+		return
+	}
+
 	// Setup and start the server
-	s := server.NewServer()
+	if err := s.Load(); err != nil {
+		log.Error(fmt.Sprintf("Static content could not be loaded: %v", err))
+		os.Exit(exitCode([]error{err}))
+	}
+	s.DevMode = *dev
 	s.Start(*port)
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/server"
+)
+
+func TestJSONFormat(t *testing.T) {
+	r := &log.Record{
+		Time: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Lvl:  log.LvlWarn,
+		Msg:  "Player left",
+		Ctx: []interface{}{
+			"player", "Bob",
+			"hp", 7,
+			"err", errors.New("connection reset"),
+			"msg", "clashes with the message",
+			"lvl", "clashes with the level",
+		},
+	}
+
+	line := jsonFormat().Format(r)
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		t.Fatalf("record %q does not end with a newline", line)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(line, &got); err != nil {
+		t.Fatalf("record %q is not valid JSON: %v", line, err)
+	}
+
+	want := map[string]interface{}{
+		"t":       "2020-01-02T03:04:05Z",
+		"lvl":     "warn",
+		"msg":     "Player left",
+		"player":  "Bob",
+		"hp":      float64(7),
+		"err":     "connection reset",
+		"ctx_msg": "clashes with the message",
+		"ctx_lvl": "clashes with the level",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("got %s = %#v, want %#v", key, got[key], value)
+		}
+	}
+	if _, ok := got["call"]; !ok {
+		t.Errorf("record %s has no call site", line)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	invalid := &server.ValidationError{Err: errors.New("exit to nowhere")}
+	unparsed := fmt.Errorf("ban list could not be loaded: %w", &server.ParseError{Path: "banlist.toml", Err: errors.New("bad")})
+	unread := &server.ReadError{Path: "areas/town.toml", Err: errors.New("denied")}
+	missing := &server.NotFoundError{Path: "server.toml"}
+
+	tests := []struct {
+		problems []error
+		want     int
+	}{
+		{problems: []error{invalid}, want: exitInvalid},
+		{problems: []error{errors.New("static does not exist")}, want: exitInvalid},
+		{problems: []error{invalid, unparsed}, want: exitUnparsed},
+		{problems: []error{unparsed, unread}, want: exitUnread},
+		{problems: []error{unread, unparsed}, want: exitUnread},
+		{problems: []error{unparsed, missing, unread}, want: exitNotFound},
+	}
+	for _, test := range tests {
+		if got := exitCode(test.problems); got != test.want {
+			t.Errorf("%v: got %d, want %d", test.problems, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package area
+
+import "fmt"
+
+// Access holds what players need to enter an area or a room.
+type Access struct {
+	// MinLevel is the lowest level players need to be.
+	MinLevel int `toml:"minLevel" json:"minLevel"`
+	// Requires holds the flags players need to have.
+	Requires map[string]string `toml:"requires" json:"requires"`
+	// Denied is told to players missing the flags. A generic message is
+	// used when it is empty.
+	Denied string `toml:"denied" json:"denied"`
+}
+
+// Allows returns true if the player meets the requirements, otherwise a
+// message telling the player why not.
+func (a Access) Allows(p *Player) (bool, string) {
+	if p.Level < a.MinLevel {
+		return false, fmt.Sprintf("You need to be level %d to go there.", a.MinLevel)
+	}
+	if !hasFlags(p, a.Requires) {
+		if a.Denied != "" {
+			return false, a.Denied
+		}
+		return false, "You may not go there yet."
+	}
+	return true, ""
+}
+
+// hasFlags returns true if the player has all the given flags set to the
+// given values.
+func hasFlags(p *Player, flags map[string]string) bool {
+	for key, value := range flags {
+		if v, ok := p.Flags[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package area
+
+import "testing"
+
+func TestAccessAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		access Access
+		player Player
+		ok     bool
+		msg    string
+	}{
+		{name: "no requirements", access: Access{}, player: Player{}, ok: true},
+		{name: "level too low", access: Access{MinLevel: 5}, player: Player{}, msg: "You need to be level 5 to go there."},
+		{name: "level high enough", access: Access{MinLevel: 5}, player: func() Player { p := Player{}; p.Level = 5; return p }(), ok: true},
+		{
+			name:   "flag missing",
+			access: Access{Requires: map[string]string{"quest.rats": "done"}},
+			player: Player{Flags: map[string]string{"quest.rats": "started"}},
+			msg:    "You may not go there yet.",
+		},
+		{
+			name:   "flag missing with a message",
+			access: Access{Requires: map[string]string{"quest.rats": "done"}, Denied: "The guard blocks the way."},
+			player: Player{},
+			msg:    "The guard blocks the way.",
+		},
+		{
+			name:   "flag set",
+			access: Access{Requires: map[string]string{"quest.rats": "done"}},
+			player: Player{Flags: map[string]string{"quest.rats": "done", "guild": "thieves"}},
+			ok:     true,
+		},
+	}
+	for _, test := range tests {
+		ok, msg := test.access.Allows(&test.player)
+		if ok != test.ok || msg != test.msg {
+			t.Errorf("%s: got %t, %q, want %t, %q", test.name, ok, msg, test.ok, test.msg)
+		}
+	}
+}
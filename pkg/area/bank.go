@@ -0,0 +1,10 @@
+package area
+
+import "github.com/gothyra/thyra/pkg/game"
+
+// Bank holds the gold and items a player keeps with bankers. Unlike what the
+// player carries, it is never lost.
+type Bank struct {
+	Gold  int         `toml:"gold"`
+	Items []game.Item `toml:"items"`
+}
@@ -2,21 +2,64 @@ package area
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gothyra/thyra/pkg/game"
 )
 
 type Area struct {
-	Name  string          `toml:"name"`
-	Intro string          `toml:"intro"`
-	Rooms map[string]Room `toml:"rooms"`
+	Name  string          `toml:"name" json:"name"`
+	Intro string          `toml:"intro" json:"intro"`
+	Rooms map[string]Room `toml:"rooms" json:"rooms"`
+	// Weather holds the kinds of weather the area can have. The area has
+	// no weather when it is empty.
+	Weather []string `toml:"weather" json:"weather"`
+	// Ambient holds messages players anywhere in the area read every now
+	// and then.
+	Ambient []string `toml:"ambient" json:"ambient"`
+	// Access holds what players need to enter the area.
+	Access Access `toml:"access" json:"access"`
+	// Hidden areas are left out of the areas players are shown.
+	Hidden bool `toml:"hidden" json:"hidden"`
 }
 
 type Room struct {
-	Name        string `toml:"name"`
-	Description string `toml:"description"`
-	Cubes       []Cube `toml:"cubes"`
+	Name        string `toml:"name" json:"name"`
+	Description string `toml:"description" json:"description"`
+	Cubes       []Cube `toml:"cubes" json:"cubes"`
+	// Indoors rooms are sheltered from the weather.
+	Indoors bool `toml:"indoors" json:"indoors"`
+	// Items holds the items lying in the room when the server starts.
+	Items []game.Item `toml:"items" json:"items"`
+	// ItemIDs holds the IDs of items defined in the items directory that
+	// lie in the room, along with those in Items.
+	ItemIDs []string `toml:"itemIds" json:"itemIds"`
+	// NPCs holds the characters in the room.
+	NPCs []NPC `toml:"npcs" json:"npcs"`
+	// Trainer rooms let players practice their skills.
+	Trainer bool `toml:"trainer" json:"trainer"`
+	// NPCIDs holds the IDs of NPCs defined in the npcs directory that are
+	// in the room, along with those in NPCs.
+	NPCIDs []string `toml:"npcIds" json:"npcIds"`
+	// Spawns holds the rules keeping NPCs in the room.
+	Spawns []Spawn `toml:"spawns" json:"spawns"`
+	// MaxOccupants is the number of players the room can hold. The room
+	// can hold any number of players when it is zero.
+	MaxOccupants int `toml:"maxOccupants" json:"maxOccupants"`
+	// Access holds what players need to enter the room.
+	Access Access `toml:"access" json:"access"`
+	// Ambient holds messages players in the room read every now and then,
+	// along with those of the area.
+	Ambient []string `toml:"ambient" json:"ambient"`
+	// Darkness is when the room is too dark to see in without a light:
+	// "always", "night" for the night of the game clock, or never when it
+	// is empty.
+	Darkness string `toml:"darkness" json:"darkness"`
+	// Spawn is the ID of the cube players whose saved cube in the room is
+	// gone are put at.
+	Spawn string `toml:"spawn" json:"spawn"`
 }
 
 // Player holds all variables for a character.
@@ -28,20 +71,63 @@ type Player struct {
 	Position     string `toml:"position"`
 	PreviousRoom string `toml:"previousRoom"`
 	PreviousArea string `toml:"previousArea"`
+	// Admin allows the player to use admin commands. It is kept in line
+	// with Permission for older servers reading the player file.
+	Admin bool `toml:"admin"`
+	// Permission is the permission level of the player, deciding which
+	// commands the player can use.
+	Permission int `toml:"permission"`
+	// LastLogin is the last time the player logged in.
+	LastLogin time.Time `toml:"lastLogin"`
+	// LastLogout is the last time the player logged out.
+	LastLogout time.Time `toml:"lastLogout"`
+	// Bank holds what the player keeps with bankers.
+	Bank Bank `toml:"bank"`
+	// Flags holds arbitrary state content keeps about the player, such as
+	// the progress of quests.
+	Flags map[string]string `toml:"flags"`
+	// Gagged keeps the player from chatting until GaggedUntil, or until
+	// the gag is lifted when GaggedUntil is zero.
+	Gagged      bool      `toml:"gagged"`
+	GaggedUntil time.Time `toml:"gaggedUntil"`
+	// Notice is shown to the player right after logging in.
+	Notice string `toml:"-"`
+	// HideIntro leaves the description of the room out of what the player
+	// sees, until the player looks again.
+	HideIntro bool `toml:"-"`
+	// Glance shows only the name and the exits of the room, until the
+	// player looks again.
+	Glance bool `toml:"-"`
+	// ClearScreen clears the terminal of the player before the room is
+	// shown next.
+	ClearScreen bool `toml:"-"`
+	// Seen holds the rooms the player has been to since logging in.
+	Seen map[string]bool `toml:"-"`
+	// AFK marks the player as away from the keyboard, and AFKMessage is
+	// told to players sending tells in the meantime.
+	AFK        bool   `toml:"-"`
+	AFKMessage string `toml:"-"`
+	// Back tells the player that the away mark was cleared, along with
+	// what the player is shown next.
+	Back bool `toml:"-"`
+	// LastActive is the last time the player gave a command.
+	LastActive time.Time `toml:"-"`
+	// Settings holds the preferences of the player.
+	Settings Settings `toml:"settings"`
 }
 
 type Cube struct {
-	ID    string `toml:"id"`
-	POSX  string `toml:"posx"`
-	POSY  string `toml:"posy"`
-	Exits []Exit `toml:"exits"`
-	Type  string `toml:"type"`
+	ID    string `toml:"id" json:"id"`
+	POSX  string `toml:"posx" json:"posx"`
+	POSY  string `toml:"posy" json:"posy"`
+	Exits []Exit `toml:"exits" json:"exits"`
+	Type  string `toml:"type" json:"type"`
 }
 
 type Exit struct {
-	ToArea   string `toml:"toarea"`
-	ToRoom   string `toml:"toroom"`
-	ToCubeID string `toml:"tocubeid"`
+	ToArea   string `toml:"toarea" json:"toarea"`
+	ToRoom   string `toml:"toroom" json:"toroom"`
+	ToCubeID string `toml:"tocubeid" json:"tocubeid"`
 }
 
 func FindExits(s [][]Cube, area, room, pos string) [][]string {
@@ -147,26 +233,57 @@ func FindExits(s [][]Cube, area, room, pos string) [][]string {
 	return exitarr
 }
 
+// exitDirections holds the names of the directions in the order FindExits
+// returns exits in.
+var exitDirections = []string{"East", "West", "North", "South"}
+
 func PrintExits(exit_array [][]string) bytes.Buffer { //Print exits,From returned [5]string findExits
 	var buffer bytes.Buffer
 
 	buffer.WriteString("Exits  : [ ")
 
-	if exit_array[0][1] != "0" {
-		buffer.WriteString("East ")
+	for i, exit := range exit_array {
+		if exit[1] == "0" {
+			continue
+		}
+		buffer.WriteString(exitDirections[i])
+		if exit[3] == "door" {
+			buffer.WriteString("(" + exit[2] + ")")
+		}
+		buffer.WriteString(" ")
 	}
+	buffer.WriteString("]\n")
+	return buffer
+}
 
-	if exit_array[1][1] != "0" {
-		buffer.WriteString("West ")
-	}
-	if exit_array[2][1] != "0" {
-		buffer.WriteString("North ")
+// PrintCompass draws a compass rose showing the directions of the exits
+// returned by FindExits. Directions without an exit are drawn as dots.
+func PrintCompass(exit_array [][]string) string {
+	marks := []string{".", ".", ".", "."}
+	for i, exit := range exit_array {
+		if exit[1] != "0" {
+			marks[i] = exitDirections[i][:1]
+		}
 	}
-	if exit_array[3][1] != "0" {
-		buffer.WriteString("South ")
+	// marks holds East, West, North and South in this order.
+	return fmt.Sprintf("  %s\n%s-+-%s\n  %s", marks[2], marks[1], marks[0], marks[3])
+}
+
+// ListExits returns a line for every exit returned by FindExits, naming its
+// direction and, for doors, where it leads to.
+func ListExits(exit_array [][]string) []string {
+	var lines []string
+	for i, exit := range exit_array {
+		if exit[1] == "0" {
+			continue
+		}
+		if exit[3] == "door" {
+			lines = append(lines, fmt.Sprintf("%-5s - door to %s in %s", exitDirections[i], exit[2], exit[0]))
+		} else {
+			lines = append(lines, exitDirections[i])
+		}
 	}
-	buffer.WriteString("]\n")
-	return buffer
+	return lines
 }
 
 func PrintMap(p *Player, online map[string]bool, s [][]Cube) bytes.Buffer {
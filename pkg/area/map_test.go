@@ -0,0 +1,30 @@
+package area
+
+import "testing"
+
+func TestPrintCompass(t *testing.T) {
+	// The grid is indexed by x, then y:
+	//   1 2
+	//   3 O
+	grid := [][]Cube{
+		{{ID: "1"}, {ID: "3"}},
+		{{ID: "2"}, {ID: "4", Type: "door", Exits: []Exit{{ToArea: "Town", ToRoom: "Inn", ToCubeID: "1"}}}},
+	}
+
+	tests := []struct {
+		pos  string
+		want string
+	}{
+		{pos: "1", want: "  .\n.-+-E\n  S"},
+		{pos: "2", want: "  .\nW-+-.\n  S"},
+		{pos: "3", want: "  N\n.-+-E\n  ."},
+	}
+	for _, test := range tests {
+		if got := PrintCompass(FindExits(grid, "Town", "Square", test.pos)); got != test.want {
+			t.Errorf("at %s: got\n%s\nwant\n%s", test.pos, got, test.want)
+		}
+	}
+	if got, want := PrintCompass(nil), "  .\n.-+-.\n  ."; got != want {
+		t.Errorf("without exits: got\n%s\nwant\n%s", got, want)
+	}
+}
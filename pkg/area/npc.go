@@ -0,0 +1,79 @@
+package area
+
+import "strings"
+
+// StartNode is the dialogue node conversations with an NPC start from.
+const StartNode = "start"
+
+// NPC is a character in a room that players can talk to.
+type NPC struct {
+	// ID is the ID of the definition the NPC was made from, if any.
+	ID   string `toml:"id" json:"id"`
+	Name string `toml:"name" json:"name"`
+	// Dialogue holds the nodes of the dialogue tree of the NPC by name.
+	// Conversations start from the node named start.
+	Dialogue map[string]DialogueNode `toml:"dialogue" json:"dialogue"`
+	// Vendor NPCs sell the items with the IDs in Sells to players, and buy
+	// any item of value from them.
+	Vendor bool     `toml:"vendor" json:"vendor"`
+	Sells  []string `toml:"sells" json:"sells"`
+	// Banker NPCs keep gold and items for players.
+	Banker bool `toml:"banker" json:"banker"`
+	// Spawned is true for NPCs a spawn rule put in their room.
+	Spawned bool `toml:"-" json:"-"`
+}
+
+// Spawn is a rule keeping Count of the NPC defined in the npcs directory
+// with the ID NPCID in a room. Killed NPCs come back RespawnSeconds after
+// they were killed.
+type Spawn struct {
+	NPCID          string `toml:"npc" json:"npc"`
+	Count          int    `toml:"count" json:"count"`
+	RespawnSeconds int    `toml:"respawnSeconds" json:"respawnSeconds"`
+}
+
+// DialogueNode is something an NPC says along with the responses players
+// can give to it.
+type DialogueNode struct {
+	Text      string     `toml:"text" json:"text"`
+	Responses []Response `toml:"responses" json:"responses"`
+}
+
+// Response is something players can answer to an NPC.
+type Response struct {
+	Text string `toml:"text" json:"text"`
+	// Next is the node the conversation continues with. The conversation
+	// ends when it is empty.
+	Next string `toml:"next" json:"next"`
+	// Requires holds the flags players need to have to give the response.
+	Requires map[string]string `toml:"requires" json:"requires"`
+	// SetFlags holds the flags set on players giving the response.
+	SetFlags map[string]string `toml:"setFlags" json:"setFlags"`
+}
+
+// FindNPC returns the NPC in the room with the given name, ignoring case.
+func (r Room) FindNPC(name string) (NPC, bool) {
+	for _, npc := range r.NPCs {
+		if strings.EqualFold(npc.Name, name) {
+			return npc, true
+		}
+	}
+	return NPC{}, false
+}
+
+// Available returns the responses of the node the player can give.
+func (n DialogueNode) Available(p *Player) []Response {
+	var available []Response
+	for _, r := range n.Responses {
+		if r.allowed(p) {
+			available = append(available, r)
+		}
+	}
+	return available
+}
+
+// allowed returns true if the player has all the flags the response
+// requires.
+func (r Response) allowed(p *Player) bool {
+	return hasFlags(p, r.Requires)
+}
@@ -0,0 +1,37 @@
+package area
+
+import "testing"
+
+func TestFindNPC(t *testing.T) {
+	room := Room{NPCs: []NPC{{Name: "Mayor"}, {Name: "Old Guard"}}}
+
+	if npc, ok := room.FindNPC("old guard"); !ok || npc.Name != "Old Guard" {
+		t.Errorf("got %+v, %t", npc, ok)
+	}
+	if _, ok := room.FindNPC("guard"); ok {
+		t.Error("found an NPC by part of its name")
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	node := DialogueNode{Responses: []Response{
+		{Text: "Hello."},
+		{Text: "The rats are dead.", Requires: map[string]string{"quest.rats": "done"}},
+		{Text: "I am a thief.", Requires: map[string]string{"guild": "thieves", "quest.rats": "done"}},
+	}}
+
+	tests := []struct {
+		flags map[string]string
+		want  int
+	}{
+		{flags: nil, want: 1},
+		{flags: map[string]string{"quest.rats": "started"}, want: 1},
+		{flags: map[string]string{"quest.rats": "done"}, want: 2},
+		{flags: map[string]string{"quest.rats": "done", "guild": "thieves"}, want: 3},
+	}
+	for _, test := range tests {
+		if got := node.Available(&Player{Flags: test.flags}); len(got) != test.want {
+			t.Errorf("%v: got %d responses, want %d", test.flags, len(got), test.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package area
+
+// Permission levels of players. Levels in between are allowed too, and
+// commands can ask for any of them.
+const (
+	PermissionPlayer  = 0
+	PermissionBuilder = 50
+	PermissionAdmin   = 100
+)
+
+// PermissionLevel returns the permission level of the player. Players saved
+// as admins before there were levels are admins.
+func (p *Player) PermissionLevel() int {
+	if p.Admin && p.Permission < PermissionAdmin {
+		return PermissionAdmin
+	}
+	return p.Permission
+}
+
+// SetPermissionLevel changes the permission level of the player.
+func (p *Player) SetPermissionLevel(level int) {
+	p.Permission = level
+	p.Admin = level >= PermissionAdmin
+}
+
+// IsAdmin returns true if the player is an admin.
+func (p *Player) IsAdmin() bool {
+	return p.PermissionLevel() >= PermissionAdmin
+}
@@ -0,0 +1,46 @@
+package area
+
+// Settings holds the preferences of a player.
+type Settings struct {
+	// AutoLook shows the room again every time the player moves. Otherwise
+	// moving only tells the player the direction taken.
+	AutoLook bool `toml:"autoLook"`
+	// Ambient shows the ambient messages of the rooms the player is in.
+	Ambient bool `toml:"ambient"`
+	// Brief shows only the name and the exits of rooms the player has
+	// already seen when walking back into them.
+	Brief bool `toml:"brief"`
+	// Color shows text colored by the theme of the server.
+	Color bool `toml:"color"`
+	// Compass shows a compass rose of the exits next to the map.
+	Compass bool `toml:"compass"`
+	// Charset is what the player is shown in, either utf8 or ascii. The
+	// default charset of the server is used when it is empty.
+	Charset string `toml:"charset"`
+	// Prompt is the template of the prompt shown to the player. The
+	// default prompt is used when it is empty.
+	Prompt string `toml:"prompt"`
+	// Filter masks profanity in the chat the player sees.
+	Filter bool `toml:"filter"`
+	// PageLength is the number of lines of long output shown at a time.
+	// Paging is off when it is zero.
+	PageLength int `toml:"pageLength"`
+	// Muted holds the chat channels the player does not listen to.
+	Muted map[string]bool `toml:"muted"`
+	// Ignored holds the nicknames of the players whose chat the player
+	// does not receive.
+	Ignored []string `toml:"ignored"`
+}
+
+// DefaultSettings returns the settings of new players. Player files are
+// decoded on top of them so settings missing from a file keep their
+// default.
+func DefaultSettings() Settings {
+	return Settings{
+		Ambient:    true,
+		AutoLook:   true,
+		Color:      true,
+		Filter:     true,
+		PageLength: 20,
+	}
+}
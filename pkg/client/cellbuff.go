@@ -28,6 +28,9 @@ const (
 	ColorWhite
 )
 
+// AttrBold can be combined with a color to make it bold.
+const AttrBold Attribute = 1 << 9
+
 func New(width, height int, foreground, background Attribute) *Cellbuf {
 	cb := &Cellbuf{
 		Width:  width,
@@ -0,0 +1,61 @@
+package client
+
+// Charsets players can choose for what they are shown.
+const (
+	// CharsetUTF8 shows everything as it is.
+	CharsetUTF8 = "utf8"
+	// CharsetASCII shows ASCII fallbacks instead of anything else, for
+	// terminals that cannot show UTF-8.
+	CharsetASCII = "ascii"
+)
+
+// IsCharset returns true if players can choose the given charset.
+func IsCharset(name string) bool {
+	return name == CharsetUTF8 || name == CharsetASCII
+}
+
+// asciiFallbacks holds the ASCII characters shown instead of the glyphs
+// that have a close enough one.
+var asciiFallbacks = map[rune]rune{
+	'─': '-', '━': '-', '═': '=',
+	'│': '|', '┃': '|', '║': '|',
+	'┌': '+', '┐': '+', '└': '+', '┘': '+',
+	'├': '+', '┤': '+', '┬': '+', '┴': '+', '┼': '+',
+	'╔': '+', '╗': '+', '╚': '+', '╝': '+',
+	'‘': '\'', '’': '\'', '“': '"', '”': '"',
+	'–': '-', '—': '-', '…': '.', '•': '*', '·': '.',
+	'←': '<', '→': '>', '↑': '^', '↓': 'v',
+	' ': ' ',
+}
+
+// ToASCII returns the ASCII character shown instead of r, which is r itself
+// if it is ASCII already and ? if it has no fallback.
+func ToASCII(r rune) rune {
+	if r < 0x80 {
+		return r
+	}
+	if fallback, ok := asciiFallbacks[r]; ok {
+		return fallback
+	}
+	return '?'
+}
+
+// charset returns the charset the player is shown, which is the default one
+// unless the player chose another.
+func (c *Client) charset() string {
+	if c.Player.Settings.Charset != "" {
+		return c.Player.Settings.Charset
+	}
+	if c.DefaultCharset != "" {
+		return c.DefaultCharset
+	}
+	return CharsetUTF8
+}
+
+// glyph returns what is shown to the player for r.
+func (c *Client) glyph(r rune) rune {
+	if c.charset() == CharsetASCII {
+		return ToASCII(r)
+	}
+	return r
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		in, want rune
+	}{
+		{'a', 'a'},
+		{'~', '~'},
+		{'│', '|'},
+		{'┌', '+'},
+		{'─', '-'},
+		{'“', '"'},
+		{'’', '\''},
+		{'—', '-'},
+		{'→', '>'},
+		{'é', '?'},
+		{'龍', '?'},
+	}
+	for _, tt := range tests {
+		if got := ToASCII(tt.in); got != tt.want {
+			t.Errorf("ToASCII(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGlyph(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     string
+		setting string
+		want    rune
+	}{
+		{"no setting", "", "", '│'},
+		{"server default", CharsetASCII, "", '|'},
+		{"player choice", "", CharsetASCII, '|'},
+		{"player overrides server", CharsetASCII, CharsetUTF8, '│'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{Player: &area.Player{}, DefaultCharset: tt.def}
+			c.Player.Settings.Charset = tt.setting
+			if got := c.glyph('│'); got != tt.want {
+				t.Errorf("glyph('│') = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCharset(t *testing.T) {
+	for _, name := range []string{CharsetUTF8, CharsetASCII} {
+		if !IsCharset(name) {
+			t.Errorf("IsCharset(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"", "default", "latin1"} {
+		if IsCharset(name) {
+			t.Errorf("IsCharset(%q) = true, want false", name)
+		}
+	}
+}
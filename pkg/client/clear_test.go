@@ -0,0 +1,37 @@
+package client
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// recordingConn is a connection that keeps everything written to it.
+type recordingConn struct {
+	net.Conn
+	buf bytes.Buffer
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) { return c.buf.Write(b) }
+
+func TestResetScreen(t *testing.T) {
+	t.Setenv("TERM", "xterm")
+	for _, color := range []bool{true, false} {
+		conn := &recordingConn{}
+		p := &area.Player{Nickname: "Alice"}
+		p.Settings.Color = color
+		c := NewClient(conn, p, nil)
+
+		c.resetScreen()
+		out := conn.buf.String()
+		if !strings.Contains(out, c.funcs[tClearScreen]) {
+			t.Errorf("color %v: the screen was not cleared: %q", color, out)
+		}
+		if got := strings.Contains(out, sgr(ColorDefault)); got != color {
+			t.Errorf("color %v: colors reset is %v in %q", color, got, out)
+		}
+	}
+}
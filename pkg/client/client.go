@@ -4,8 +4,8 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
-	"io"
 	"net"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
@@ -36,16 +36,28 @@ type Reply struct {
 	Events string
 	Intro  []byte
 	Exits  string
+	// Compass is drawn above the exits when not empty.
+	Compass string
+	// Clear starts the terminal over before drawing, getting rid of
+	// anything left on it.
+	Clear bool
 }
 
 type Event struct {
 	Client *Client
 	Etype  string
+	// Cmd is the command typed by the player that caused the event.
+	Cmd string
+	// Args holds the arguments given to the command that caused the event.
+	Args []string
 }
 
 type Request struct {
 	Client *Client
 	Cmd    string
+	// TooLong is true when the player sent a line longer than allowed, in
+	// which case Cmd is empty.
+	TooLong bool
 }
 
 type LoginRequest struct {
@@ -64,6 +76,17 @@ func (cl Clients) String() string {
 	return fmt.Sprintf("%#v", clients)
 }
 
+// Conversation is a conversation of a player with an NPC.
+type Conversation struct {
+	// Area, Room and NPC locate the NPC the player is talking to. The player
+	// is not talking to anybody when NPC is empty.
+	Area string
+	Room string
+	NPC  string
+	// Node is the dialogue node the conversation is at.
+	Node string
+}
+
 type Client struct {
 	// Conn is the connection used by the user to play.
 	Conn net.Conn
@@ -75,6 +98,28 @@ type Client struct {
 	// operated by the Panel thread which runs in parallel with the main client thread
 	// and is responsible for updating the output users see.
 	Reply chan Reply
+	// refresh asks the Panel thread to draw the last reply again, eg. to
+	// show the prompt again after the user sent a blank line.
+	refresh chan struct{}
+	// lastReply is the last reply drawn by the Panel thread.
+	lastReply Reply
+	// Conversation is the conversation the player is having with an NPC. It
+	// is shared by all copies of the client.
+	Conversation *Conversation
+	// Pager holds the long output the player is reading page by page. It is
+	// shared by all copies of the client.
+	Pager *Pager
+	// Throttle limits how fast the player can send commands. It is shared
+	// by all copies of the client.
+	Throttle *Throttle
+	// conn is the state of Conn.
+	conn *connState
+	// MaxLineLength is the longest line in bytes the player can send.
+	// DefaultMaxLineLength is used when it is not set.
+	MaxLineLength int
+	// DefaultCharset is the charset the player is shown unless the player
+	// chose another. CharsetUTF8 is used when it is not set.
+	DefaultCharset string
 
 	Buff    bytes.Buffer
 	Bbuffer *Cellbuf
@@ -102,6 +147,12 @@ func NewClient(c net.Conn, player *area.Player, req chan<- Request) *Client {
 		Player:  player,
 		Request: req,
 		Reply:   make(chan Reply, 1),
+		refresh: make(chan struct{}, 1),
+
+		Conversation: &Conversation{},
+		Pager:        &Pager{},
+		Throttle:     &Throttle{},
+		conn:         &connState{done: make(chan struct{})},
 
 		Bbuffer: new(Cellbuf),
 		Fbuffer: new(Cellbuf),
@@ -126,16 +177,34 @@ func NewClient(c net.Conn, player *area.Player, req chan<- Request) *Client {
 // This function is responsible for returning output to the user.
 func (c *Client) Redraw(wg *sync.WaitGroup, quit <-chan struct{}) {
 	defer wg.Done()
-	defer io.WriteString(c.Conn, "\033[2J")
+	// A panic while drawing closes the connection of the player, who then
+	// gets logged out, instead of taking the server down.
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error(fmt.Sprintf("Recovered from a panic in the panel for %q: %v\n%s", c.Player.Nickname, r, debug.Stack()))
+			c.Close()
+		}
+	}()
 
 	c.initScreen()
 
 	for {
 		select {
 		case reply := <-c.Reply:
+			if reply.Clear {
+				c.resetScreen()
+				reply.Clear = false
+			}
+			c.lastReply = reply
 			c.redraw(reply)
+		case <-c.refresh:
+			c.redraw(c.lastReply)
+		case <-c.Done():
+			log.Info(fmt.Sprintf("Panel for %q closed", c.Player.Nickname))
+			return
 		case <-quit:
 			log.Warn(fmt.Sprintf("Panel for %q quit", c.Player.Nickname))
+			c.Send("\033[2J")
 			return
 		}
 	}
@@ -149,13 +218,23 @@ func (c *Client) initScreen() {
 		return
 	}
 
-	io.WriteString(c.Conn, c.funcs[tEnterCa])
-	io.WriteString(c.Conn, c.funcs[tClearScreen])
+	c.Send(c.funcs[tEnterCa])
+	c.Send(c.funcs[tClearScreen])
 
 	c.Bbuffer = New(c.termW, c.termH, c.foreground, c.background)
 	c.Fbuffer = New(c.termW, c.termH, c.foreground, c.background)
 }
 
+// resetScreen clears the terminal and forgets what was drawn on it, so that
+// the next redraw draws everything again. Colors are reset too for players
+// who have them on; plain terminals get no color escapes.
+func (c *Client) resetScreen() {
+	if c.Player.Settings.Color {
+		c.Send(sgr(ColorDefault))
+	}
+	c.initScreen()
+}
+
 // TOOD: A huge comment is needed here about what exactly redraw is doing
 func (c *Client) redraw(reply Reply) {
 	log.Debug(fmt.Sprintf("Redraw: %s, W: %d H: %d ", c.Player.Nickname, c.Bbuffer.Width, c.Bbuffer.Height))
@@ -177,8 +256,11 @@ func (c *Client) redraw(reply Reply) {
 	c.fill(midx, midy-1, editBoxWidth, 1, Cell{Ch: '─'})
 	c.fill(midx, midy+1, editBoxWidth, 1, Cell{Ch: '─'})
 
+	prompt := c.prompt()
+	c.tbprint(midx, midy, ColorDefault, ColorDefault, prompt)
+
 	// setCursor writes to the connection!
-	c.setCursor(midx, midy)
+	c.setCursor(midx+c.stringWidth(prompt), midy)
 
 	counter := 20
 	buf := bytes.NewBuffer(reply.World)
@@ -206,8 +288,15 @@ func (c *Client) redraw(reply Reply) {
 		counter2--
 	}
 
-	c.tbprint(midx, midy-10, ColorDefault, ColorDefault, reply.Events)
+	for i, line := range strings.Split(reply.Events, "\n") {
+		c.tbprint(midx, midy-10+i, ColorDefault, ColorDefault, line)
+	}
 	c.tbprint(midx+90, midy-3, ColorDefault, ColorDefault, reply.Exits)
+	if reply.Compass != "" {
+		for i, line := range strings.Split(reply.Compass, "\n") {
+			c.tbprint(midx+92, midy-7+i, ColorDefault, ColorDefault, line)
+		}
+	}
 
 	// So far we have been filling backBuffer; i guess now it's time to flush the content
 	// to the user.
@@ -220,7 +309,7 @@ func (c *Client) clearScreen(fg, bg Attribute) {
 	c.Bbuffer.resize(c.termW, c.termH, c.foreground, c.background)
 	c.Fbuffer.resize(c.termW, c.termH, c.foreground, c.background)
 	c.Fbuffer.initialized(c.foreground, c.background)
-	io.WriteString(c.Conn, "\033[2J")
+	c.Send("\033[2J")
 
 	if !isCursorHidden(c.cursorX, c.cursorY) {
 		c.writeCursor(c.cursorX, c.cursorY)
@@ -246,7 +335,7 @@ func (c *Client) writeCursor(x, y int) {
 	c.Buff.Write(strconv.AppendUint(c.intbuf, uint64(x+1), 10))
 	c.Buff.WriteString("H")
 
-	io.WriteString(c.Conn, c.Buff.String())
+	c.Send(c.Buff.String())
 }
 
 // Changes cell's parameters in the internal back buffer at the specified
@@ -259,7 +348,7 @@ func (c *Client) setCell(x, y int, ch rune, fg, bg Attribute) {
 		return
 	}
 
-	c.Bbuffer.Cells[y*c.Bbuffer.Width+x] = Cell{ch, fg, bg}
+	c.Bbuffer.Cells[y*c.Bbuffer.Width+x] = Cell{c.glyph(ch), fg, bg}
 }
 
 // TOOD: A comment is needed here about what exactly fill is doing
@@ -279,13 +368,73 @@ func (c *Client) setCursor(x, y int) {
 	}
 }
 
+// tbprint prints msg starting at the given position. ANSI SGR escape
+// sequences in msg change the foreground color of what follows them if the
+// player has color turned on, and are dropped otherwise.
 func (c *Client) tbprint(x, y int, fg, bg Attribute, msg string) {
-	for _, rune := range msg {
-		c.setCell(x, y, rune, fg, bg)
-		x += runewidth.RuneWidth(rune)
+	cur := fg
+	for i := 0; i < len(msg); {
+		if msg[i] == '\033' && i+1 < len(msg) && msg[i+1] == '[' {
+			if end := strings.IndexByte(msg[i:], 'm'); end >= 0 {
+				if c.Player.Settings.Color {
+					cur = sgrAttribute(msg[i+2:i+end], fg)
+				}
+				i += end + 1
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(msg[i:])
+		r = c.glyph(r)
+		c.setCell(x, y, r, cur, bg)
+		x += runewidth.RuneWidth(r)
+		i += size
 	}
 }
 
+// stringWidth returns the number of cells msg takes when shown to the
+// player.
+func (c *Client) stringWidth(msg string) int {
+	width := 0
+	for _, r := range msg {
+		width += runewidth.RuneWidth(c.glyph(r))
+	}
+	return width
+}
+
+// sgrAttribute returns the attribute the given ANSI SGR codes set, starting
+// from def, which is also what code 0 resets to.
+func sgrAttribute(codes string, def Attribute) Attribute {
+	attr := def
+	for _, code := range strings.Split(codes, ";") {
+		n, err := strconv.Atoi(code)
+		switch {
+		case err != nil:
+		case n == 0:
+			attr = def
+		case n == 1:
+			attr |= AttrBold
+		case n >= 30 && n <= 37:
+			attr = attr&AttrBold | ColorBlack + Attribute(n-30)
+		case n == 39:
+			attr = attr&AttrBold | ColorDefault
+		}
+	}
+	return attr
+}
+
+// sgr returns the ANSI escape sequence setting the given foreground.
+func sgr(fg Attribute) string {
+	codes := "0"
+	if fg&AttrBold != 0 {
+		codes += ";1"
+	}
+	if color := fg &^ AttrBold; color != ColorDefault {
+		codes += ";" + strconv.Itoa(30+int(color-ColorBlack))
+	}
+	return "\033[" + codes + "m"
+}
+
 // Synchronizes the internal back buffer with the terminal.
 // TOOD: A huge comment is needed here about what exactly flush is doing
 func (c *Client) flush() {
@@ -297,6 +446,7 @@ func (c *Client) flush() {
 
 	width := c.Fbuffer.Width
 	height := c.Fbuffer.Height
+	lastFg := attrInvalid
 
 	for y := 0; y < height; y++ {
 
@@ -327,6 +477,10 @@ func (c *Client) flush() {
 				c.sendChar(x, y, ' ')
 
 			} else {
+				if back.Fg != lastFg {
+					c.Buff.WriteString(sgr(back.Fg))
+					lastFg = back.Fg
+				}
 				c.sendChar(x, y, back.Ch)
 				if w == 2 {
 					next := cellOffset + 1
@@ -342,6 +496,9 @@ func (c *Client) flush() {
 		}
 	}
 	log.Debug(fmt.Sprintf("Flush After FOR : %s", c.Player.Nickname))
+	if lastFg != attrInvalid {
+		c.Buff.WriteString(sgr(ColorDefault))
+	}
 
 	if !isCursorHidden(c.cursorX, c.cursorY) {
 		c.writeCursor(c.cursorX, c.cursorY)
@@ -353,22 +510,35 @@ func (c *Client) flush() {
 // ReadLinesInto accepts input from a user and sends it back to the server in the
 // form of requests.
 // TODO: Make this exit gracefully on a server shutdown.
-func (c Client) ReadLinesInto(quit <-chan struct{}) {
+func (c *Client) ReadLinesInto(quit <-chan struct{}) {
 	bufc := bufio.NewReader(c.Conn)
+	max := c.MaxLineLength
+	if max <= 0 {
+		max = DefaultMaxLineLength
+	}
 
 	for {
-		line, err := bufc.ReadString('\n')
+		line, tooLong, err := ReadLine(bufc, max)
 		if err != nil {
 			log.Error(fmt.Sprintf("%#v", err))
 			return
 		}
+		if tooLong {
+			log.Warn(fmt.Sprintf("Player %q sent a line longer than %d bytes", c.Player.Nickname, max))
+		}
 		line = strings.TrimSpace(line)
-		if len(line) == 0 {
+		if len(line) == 0 && !tooLong && !c.Pager.Pending() {
+			// Blank lines are not commands; just show the prompt again,
+			// unless they ask for the next page.
+			select {
+			case c.refresh <- struct{}{}:
+			default:
+			}
 			continue
 		}
 
 		select {
-		case c.Request <- Request{Client: &c, Cmd: line}:
+		case c.Request <- Request{Client: c, Cmd: line, TooLong: tooLong}:
 		case <-quit:
 			log.Info(fmt.Sprintf("Player %q quit", c.Player.Nickname))
 			return
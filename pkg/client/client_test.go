@@ -0,0 +1,67 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// printed returns the text and the foreground of every cell of the first
+// line the client prints msg on.
+func printed(color bool, msg string) (string, []Attribute) {
+	p := &area.Player{Settings: area.DefaultSettings()}
+	p.Settings.Color = color
+	c := NewClient(nil, p, nil)
+	c.Bbuffer = New(8, 1, ColorDefault, ColorDefault)
+
+	c.tbprint(0, 0, ColorDefault, ColorDefault, msg)
+	var text []rune
+	var fg []Attribute
+	for _, cell := range c.Bbuffer.Cells {
+		text = append(text, cell.Ch)
+		fg = append(fg, cell.Fg)
+	}
+	return string(text), fg
+}
+
+func TestTbprintColor(t *testing.T) {
+	msg := "a" + theme.Theme{theme.Error: "1;31"}.Paint(theme.Error, "bc") + "d"
+
+	text, fg := printed(true, msg)
+	if text != "abcd    " {
+		t.Errorf("got %q", text)
+	}
+	if want := ColorRed | AttrBold; fg[0] != ColorDefault || fg[1] != want || fg[2] != want || fg[3] != ColorDefault {
+		t.Errorf("got colors %v", fg[:4])
+	}
+
+	// Without color the escape sequences are dropped and nothing is colored.
+	text, fg = printed(false, msg)
+	if text != "abcd    " {
+		t.Errorf("without color: got %q", text)
+	}
+	for i, attr := range fg {
+		if attr != ColorDefault {
+			t.Errorf("without color: cell %d has color %v", i, attr)
+		}
+	}
+}
+
+func TestSgrAttribute(t *testing.T) {
+	tests := []struct {
+		codes string
+		want  Attribute
+	}{
+		{codes: "31", want: ColorRed},
+		{codes: "1;32", want: ColorGreen | AttrBold},
+		{codes: "0", want: ColorYellow},
+		{codes: "39", want: ColorDefault},
+		{codes: "x", want: ColorYellow},
+	}
+	for _, test := range tests {
+		if got := sgrAttribute(test.codes, ColorYellow); got != test.want {
+			t.Errorf("sgrAttribute(%q) = %v, want %v", test.codes, got, test.want)
+		}
+	}
+}
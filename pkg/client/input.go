@@ -0,0 +1,43 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// DefaultMaxLineLength is the longest line in bytes players can send when no
+// other limit is configured.
+const DefaultMaxLineLength = 512
+
+// ReadLine reads a line from r, without the line ending. At most max bytes
+// of the line are kept in memory; the rest of an overlong line is discarded
+// and tooLong is true.
+func ReadLine(r *bufio.Reader, max int) (line string, tooLong bool, err error) {
+	var buf bytes.Buffer
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if buf.Len()+len(chunk) > max+2 {
+			// Keep reading until the end of the line but stop keeping it.
+			tooLong = true
+		} else {
+			buf.Write(chunk)
+		}
+
+		switch err {
+		case bufio.ErrBufferFull:
+			continue
+		case nil:
+		default:
+			return "", tooLong, err
+		}
+
+		if tooLong {
+			return "", true, nil
+		}
+		line := bytes.TrimRight(buf.Bytes(), "\r\n")
+		if len(line) > max {
+			return "", true, nil
+		}
+		return string(line), false, nil
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestReadLine(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("look\r\n" + strings.Repeat("a", 1<<20) + "\nsay hi\n" + strings.Repeat("b", 10) + "\n"))
+
+	tests := []struct {
+		line    string
+		tooLong bool
+	}{
+		{line: "look"},
+		{tooLong: true},
+		{line: "say hi"},
+		{line: strings.Repeat("b", 10)},
+	}
+	for i, test := range tests {
+		line, tooLong, err := ReadLine(r, 10)
+		if err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if line != test.line || tooLong != test.tooLong {
+			t.Errorf("line %d: got %q, %t, want %q, %t", i, line, tooLong, test.line, test.tooLong)
+		}
+	}
+	if _, _, err := ReadLine(r, 10); err != io.EOF {
+		t.Errorf("got %v, want EOF", err)
+	}
+}
+
+// readRequests runs ReadLinesInto for a client reading what is written to
+// the other end of the returned connection.
+func readRequests(t *testing.T, max int) (net.Conn, <-chan Request) {
+	server, conn := net.Pipe()
+	t.Cleanup(func() { conn.Close() })
+	req := make(chan Request)
+	c := NewClient(server, &area.Player{Nickname: "Alice"}, req)
+	c.MaxLineLength = max
+
+	quit := make(chan struct{})
+	t.Cleanup(func() { close(quit) })
+	go c.ReadLinesInto(quit)
+	return conn, req
+}
+
+// nextRequest returns the next request read, failing the test if there is
+// none.
+func nextRequest(t *testing.T, req <-chan Request) Request {
+	t.Helper()
+	select {
+	case r := <-req:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("no request was read")
+	}
+	return Request{}
+}
+
+func TestReadLinesIntoTooLong(t *testing.T) {
+	conn, req := readRequests(t, 16)
+
+	go io.WriteString(conn, strings.Repeat("x", 100000)+"\nlook\n")
+	if r := nextRequest(t, req); !r.TooLong || r.Cmd != "" {
+		t.Errorf("got %+v, want a line that is too long", r)
+	}
+	if r := nextRequest(t, req); r.TooLong || r.Cmd != "look" {
+		t.Errorf("got %+v, want look", r)
+	}
+}
+
+func TestReadLineLongerThanBuffer(t *testing.T) {
+	long := strings.Repeat("say ", 100)
+	r := bufio.NewReaderSize(strings.NewReader(long+"\nlook\n"), 16)
+
+	for _, want := range []string{long, "look"} {
+		line, tooLong, err := ReadLine(r, 1000)
+		if err != nil || tooLong || line != want {
+			t.Errorf("got %q, %t, %v, want %q", line, tooLong, err, want)
+		}
+	}
+}
+
+func TestReadLinesIntoBlank(t *testing.T) {
+	conn, req := readRequests(t, 0)
+
+	go io.WriteString(conn, "\n   \r\n\nlook\n")
+	if r := nextRequest(t, req); r.Cmd != "look" {
+		t.Errorf("got %+v, want look", r)
+	}
+}
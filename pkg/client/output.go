@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+// connState is the state of the connection of a client, shared by all
+// copies of the client.
+type connState struct {
+	once sync.Once
+	// done is closed once the connection is closed.
+	done chan struct{}
+}
+
+// WriteAll writes msg to w, carrying on after short writes until all of msg
+// is written or writing fails.
+func WriteAll(w io.Writer, msg string) error {
+	b := []byte(msg)
+	for len(b) > 0 {
+		n, err := w.Write(b)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return io.ErrShortWrite
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// Send writes msg to the player. The connection is closed if writing fails,
+// so that the player gets logged out instead of lingering on a dead
+// connection.
+func (c *Client) Send(msg string) error {
+	if err := WriteAll(c.Conn, msg); err != nil {
+		log.Info(fmt.Sprintf("Writing to %q failed, closing the connection: %v", c.Player.Nickname, err))
+		c.Close()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection of the player. It is safe to call more than
+// once.
+func (c *Client) Close() {
+	c.conn.once.Do(func() {
+		c.Conn.Close()
+		close(c.conn.done)
+	})
+}
+
+// Done returns a channel that is closed once the connection of the player is
+// closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.conn.done
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// shortWriter writes at most n bytes at a time.
+type shortWriter struct {
+	n   int
+	buf bytes.Buffer
+}
+
+func (w *shortWriter) Write(b []byte) (int, error) {
+	if len(b) > w.n {
+		b = b[:w.n]
+	}
+	return w.buf.Write(b)
+}
+
+// brokenConn is a connection whose writes fail, as they do once the other
+// end is gone.
+type brokenConn struct {
+	net.Conn
+	closed bool
+}
+
+func (c *brokenConn) Write([]byte) (int, error) { return 0, syscall.EPIPE }
+
+func (c *brokenConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWriteAll(t *testing.T) {
+	w := &shortWriter{n: 3}
+	if err := WriteAll(w, "You see a rat."); err != nil || w.buf.String() != "You see a rat." {
+		t.Errorf("got %q, %v", w.buf.String(), err)
+	}
+
+	if err := WriteAll(&shortWriter{n: 0}, "hello"); err != io.ErrShortWrite {
+		t.Errorf("got %v, want %v", err, io.ErrShortWrite)
+	}
+}
+
+func TestSendBrokenConnection(t *testing.T) {
+	conn := &brokenConn{}
+	c := NewClient(conn, &area.Player{Nickname: "Alice"}, nil)
+
+	if err := c.Send("hello"); !errors.Is(err, syscall.EPIPE) {
+		t.Errorf("got %v, want a broken pipe", err)
+	}
+	if !conn.closed {
+		t.Error("the connection was not closed")
+	}
+	select {
+	case <-c.Done():
+	default:
+		t.Error("the client is not done")
+	}
+	// Sending again does not close the connection again.
+	c.Send("hello")
+}
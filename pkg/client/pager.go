@@ -0,0 +1,61 @@
+package client
+
+import (
+	"strings"
+	"sync"
+)
+
+// MorePrompt ends every page that is followed by more output.
+const MorePrompt = "--More-- (Enter for more, q to stop)"
+
+// Pager holds the output left to show to a player who reads long output
+// page by page. It is shared by all copies of the client.
+type Pager struct {
+	sync.Mutex
+	pending []string
+}
+
+// Page returns the first page of text, keeping the rest for later. Text
+// that fits in a page is returned as is and leaves any output still pending
+// alone. Paging is off when length is not positive.
+func (p *Pager) Page(text string, length int) string {
+	lines := strings.Split(text, "\n")
+	if length <= 0 || len(lines) <= length {
+		return text
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.pending = lines[length:]
+	return strings.Join(lines[:length], "\n") + "\n" + MorePrompt
+}
+
+// Rest returns the output still pending and stops paging. Paging it again
+// with Page shows the next page.
+func (p *Pager) Rest() string {
+	p.Lock()
+	defer p.Unlock()
+	rest := strings.Join(p.pending, "\n")
+	p.pending = nil
+	return rest
+}
+
+// Stop drops the output still pending.
+func (p *Pager) Stop() {
+	p.Lock()
+	p.pending = nil
+	p.Unlock()
+}
+
+// Pending returns true if there is output left to show.
+func (p *Pager) Pending() bool {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.pending) > 0
+}
+
+// IsPagerKey returns true if the given input answers MorePrompt rather
+// than being a command.
+func IsPagerKey(input string) bool {
+	return input == "" || input == "q"
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPager(t *testing.T) {
+	p := &Pager{}
+	text := "1\n2\n3\n4\n5"
+
+	if got := p.Page("1\n2", 3); got != "1\n2" || p.Pending() {
+		t.Errorf("short text: got %q, pending %t", got, p.Pending())
+	}
+	if got := p.Page(text, 0); got != text || p.Pending() {
+		t.Errorf("paging off: got %q, pending %t", got, p.Pending())
+	}
+
+	steps := []struct {
+		want    string
+		pending bool
+	}{
+		{want: "1\n2\n" + MorePrompt, pending: true},
+		{want: "3\n4\n" + MorePrompt, pending: true},
+		{want: "5", pending: false},
+	}
+	page := p.Page(text, 2)
+	for i, step := range steps {
+		if page != step.want || p.Pending() != step.pending {
+			t.Errorf("page %d: got %q, pending %t, want %q, pending %t", i, page, p.Pending(), step.want, step.pending)
+		}
+		page = p.Page(p.Rest(), 2)
+	}
+
+	p.Page(text, 2)
+	p.Stop()
+	if p.Pending() || p.Rest() != "" {
+		t.Error("output is still pending after stopping")
+	}
+}
+
+func TestIsPagerKey(t *testing.T) {
+	for _, input := range []string{"", "q"} {
+		if !IsPagerKey(input) {
+			t.Errorf("IsPagerKey(%q) = false", input)
+		}
+	}
+	for _, input := range []string{"look", "quit", " ", strings.Repeat("q", 2)} {
+		if IsPagerKey(input) {
+			t.Errorf("IsPagerKey(%q) = true", input)
+		}
+	}
+}
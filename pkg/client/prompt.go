@@ -0,0 +1,45 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+const (
+	// DefaultPrompt is the prompt of players who have not set their own.
+	DefaultPrompt = "[%h HP] > "
+	// MaxPromptLength is the longest prompt template players can set.
+	MaxPromptLength = 40
+
+	// maxPromptWidth is the most room a prompt can take in the edit box.
+	maxPromptWidth = editBoxWidth / 2
+)
+
+// FormatPrompt fills in the given prompt template with the details of the
+// player. %h is replaced by the hit points, %l by the level, %r by the room
+// name and %% by a percent sign.
+func FormatPrompt(template string, p *area.Player) string {
+	r := strings.NewReplacer(
+		"%%", "%",
+		"%h", strconv.Itoa(p.HP),
+		"%l", strconv.Itoa(p.Level),
+		"%r", p.Room,
+	)
+
+	prompt := []rune(r.Replace(template))
+	if len(prompt) > maxPromptWidth {
+		prompt = prompt[:maxPromptWidth]
+	}
+	return string(prompt)
+}
+
+// prompt returns the prompt shown to the player of this client.
+func (c *Client) prompt() string {
+	template := c.Player.Settings.Prompt
+	if template == "" {
+		template = DefaultPrompt
+	}
+	return FormatPrompt(template, c.Player)
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestFormatPrompt(t *testing.T) {
+	p := &area.Player{Room: "Square"}
+	p.HP = 17
+	p.Level = 3
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{template: DefaultPrompt, want: "[17 HP] > "},
+		{template: "%l/%h %r> ", want: "3/17 Square> "},
+		{template: "100%% %h", want: "100% 17"},
+		// Escaped percent signs are not substituted again.
+		{template: "%%h", want: "%h"},
+		// Unknown substitutions are left alone.
+		{template: "%x %", want: "%x %"},
+		{template: "", want: ""},
+	}
+	for _, test := range tests {
+		if got := FormatPrompt(test.template, p); got != test.want {
+			t.Errorf("FormatPrompt(%q) = %q, want %q", test.template, got, test.want)
+		}
+	}
+}
+
+func TestFormatPromptLimit(t *testing.T) {
+	p := &area.Player{Room: strings.Repeat("é", 200)}
+
+	got := FormatPrompt("%r%r", p)
+	if n := len([]rune(got)); n != maxPromptWidth {
+		t.Errorf("got a prompt of %d characters, want %d", n, maxPromptWidth)
+	}
+	if !strings.HasPrefix(strings.Repeat("é", 200), got) {
+		t.Errorf("prompt was not cut at a character boundary: %q", got)
+	}
+}
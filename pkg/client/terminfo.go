@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -48,7 +49,7 @@ func load_terminfo() ([]byte, error) {
 	// next, consider ~/.terminfo
 	home := os.Getenv("HOME")
 	if home != "" {
-		data, err = ti_try_path(home + "/.terminfo")
+		data, err = ti_try_path(filepath.Join(home, ".terminfo"))
 		if err == nil {
 			return data, nil
 		}
@@ -78,14 +79,14 @@ func ti_try_path(path string) (data []byte, err error) {
 	term := os.Getenv("TERM")
 
 	// first try, the typical *nix path
-	terminfo := path + "/" + term[0:1] + "/" + term
+	terminfo := filepath.Join(path, term[0:1], term)
 	data, err = ioutil.ReadFile(terminfo)
 	if err == nil {
 		return
 	}
 
 	// fallback to darwin specific dirs structure
-	terminfo = path + "/" + hex.EncodeToString([]byte(term[:1])) + "/" + term
+	terminfo = filepath.Join(path, hex.EncodeToString([]byte(term[:1])), term)
 	data, err = ioutil.ReadFile(terminfo)
 	return
 }
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle limits how fast a player can send commands. It is a token bucket
+// holding up to a burst of commands and refilling at a steady rate. It is
+// shared by all copies of the client.
+type Throttle struct {
+	sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// Allow returns true if a command sent at now is within the given rate, in
+// commands per second, and burst, taking a token from the bucket if so.
+func (t *Throttle) Allow(now time.Time, rate float64, burst int) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.last.IsZero() {
+		t.tokens = float64(burst)
+	} else if elapsed := now.Sub(t.last); elapsed > 0 {
+		t.tokens += elapsed.Seconds() * rate
+	}
+	if t.tokens > float64(burst) {
+		t.tokens = float64(burst)
+	}
+	t.last = now
+
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
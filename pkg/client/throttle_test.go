@@ -0,0 +1,35 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottleRefill(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	th := &Throttle{}
+
+	steps := []struct {
+		after time.Duration
+		want  bool
+	}{
+		// The bucket starts full.
+		{after: 0, want: true},
+		{after: 0, want: true},
+		{after: 0, want: false},
+		// It refills one token a second, so a token is only back after a
+		// whole second.
+		{after: 999 * time.Millisecond, want: false},
+		{after: time.Second, want: true},
+		{after: time.Second, want: false},
+		// It never holds more than the burst.
+		{after: 10 * time.Second, want: true},
+		{after: 10 * time.Second, want: true},
+		{after: 10 * time.Second, want: false},
+	}
+	for _, step := range steps {
+		if got := th.Allow(start.Add(step.after), 1, 2); got != step.want {
+			t.Errorf("after %v: got %t, want %t", step.after, got, step.want)
+		}
+	}
+}
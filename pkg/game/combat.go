@@ -0,0 +1,67 @@
+package game
+
+// Skills characters avoid hits with. Parrying takes a weapon.
+const (
+	SkillDodge = "dodge"
+	SkillParry = "parry"
+)
+
+// AvoidDifficulty is the difficulty of the skill checks for avoiding a hit.
+const AvoidDifficulty = 100
+
+// Attack rolls an attack of the attacker against the defender with r and
+// returns the damage dealt, which is zero on a miss, along with the skill the
+// defender avoided the hit with, if any.
+func Attack(r *Rand, attacker, defender *PC) (int, string) {
+	if r.Roll(1, 20) < toHit(attacker, defender) {
+		return 0, ""
+	}
+	for _, skill := range defender.avoidSkills() {
+		if RollSkillCheck(r, defender.Skills[skill], AvoidDifficulty) {
+			return 0, skill
+		}
+	}
+	if attacker.Weapondie < 1 {
+		return 1, ""
+	}
+	return r.Roll(1, attacker.Weapondie), ""
+}
+
+// avoidSkills returns the skills the character learned that it can avoid
+// hits with, in the order they are tried.
+func (pc *PC) avoidSkills() []string {
+	var skills []string
+	if _, ok := pc.Skills[SkillDodge]; ok {
+		skills = append(skills, SkillDodge)
+	}
+	if _, ok := pc.Skills[SkillParry]; ok && pc.Weapon != "fist" {
+		skills = append(skills, SkillParry)
+	}
+	return skills
+}
+
+// AttackOdds returns the chance in percent that an attack of the attacker
+// hits the defender without the defender avoiding it, and the least and most
+// damage a hit deals, without rolling anything.
+func AttackOdds(attacker, defender *PC) (int, int, int) {
+	chance := (21 - toHit(attacker, defender)) * 5
+	if chance < 0 {
+		chance = 0
+	}
+	if chance > 100 {
+		chance = 100
+	}
+	for _, skill := range defender.avoidSkills() {
+		chance = chance * (100 - SkillChance(defender.Skills[skill], AvoidDifficulty)) / 100
+	}
+	if attacker.Weapondie < 1 {
+		return chance, 1, 1
+	}
+	return chance, 1, attacker.Weapondie
+}
+
+// toHit returns the lowest d20 roll an attack of the attacker needs to hit
+// the defender.
+func toHit(attacker, defender *PC) int {
+	return defender.AC - attacker.BAB - attrModifier(attacker.STR)
+}
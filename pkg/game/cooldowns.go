@@ -0,0 +1,47 @@
+package game
+
+import "time"
+
+// CooldownLeft returns how long before the character can use the action
+// with the given name again, or zero if it can use it now.
+func (pc *PC) CooldownLeft(name string, now time.Time) time.Duration {
+	ready, ok := pc.Cooldowns[name]
+	if !ok || !now.Before(ready) {
+		return 0
+	}
+	return ready.Sub(now)
+}
+
+// OnCooldown returns true if the character cannot use the action with the
+// given name yet.
+func (pc *PC) OnCooldown(name string) bool {
+	return pc.CooldownLeft(name, time.Now()) > 0
+}
+
+// SetCooldown keeps the character from using the action with the given name
+// for the given duration.
+func (pc *PC) SetCooldown(name string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if pc.Cooldowns == nil {
+		pc.Cooldowns = make(map[string]time.Time)
+	}
+	pc.Cooldowns[name] = time.Now().Add(d)
+}
+
+// ExpireCooldowns forgets the cooldowns of the character that are over by
+// now.
+func (pc *PC) ExpireCooldowns(now time.Time) {
+	for name, ready := range pc.Cooldowns {
+		if !now.Before(ready) {
+			delete(pc.Cooldowns, name)
+		}
+	}
+}
+
+// InCombat returns true if the character dealt or took damage within the
+// given window before now.
+func (pc *PC) InCombat(now time.Time, window time.Duration) bool {
+	return !pc.LastCombat.IsZero() && now.Sub(pc.LastCombat) < window
+}
@@ -0,0 +1,53 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCooldowns(t *testing.T) {
+	pc := &PC{}
+	now := time.Now()
+
+	if left := pc.CooldownLeft("heal", now); left != 0 {
+		t.Errorf("without cooldowns: got %v", left)
+	}
+	pc.SetCooldown("heal", 0)
+	if pc.Cooldowns != nil {
+		t.Errorf("a zero cooldown was kept: %v", pc.Cooldowns)
+	}
+
+	pc.SetCooldown("heal", time.Minute)
+	if !pc.OnCooldown("heal") || pc.OnCooldown("spark") {
+		t.Errorf("got %v", pc.Cooldowns)
+	}
+	ready := pc.Cooldowns["heal"]
+	if left := pc.CooldownLeft("heal", ready.Add(-10*time.Second)); left != 10*time.Second {
+		t.Errorf("10s before: got %v", left)
+	}
+	if left := pc.CooldownLeft("heal", ready); left != 0 {
+		t.Errorf("when ready: got %v", left)
+	}
+
+	pc.Cooldowns["spark"] = ready.Add(time.Minute)
+	pc.ExpireCooldowns(ready)
+	if _, ok := pc.Cooldowns["heal"]; ok || len(pc.Cooldowns) != 1 {
+		t.Errorf("after expiring: got %v", pc.Cooldowns)
+	}
+}
+
+func TestInCombat(t *testing.T) {
+	now := time.Now()
+	pc := &PC{}
+	if pc.InCombat(now, time.Minute) {
+		t.Error("never fought but in combat")
+	}
+
+	pc.LastCombat = now.Add(-30 * time.Second)
+	if !pc.InCombat(now, time.Minute) {
+		t.Error("fought 30s ago but not in combat for a minute")
+	}
+	if pc.InCombat(now, 30*time.Second) {
+		t.Error("fought 30s ago but in combat for 30s")
+	}
+}
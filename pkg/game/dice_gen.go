@@ -9,6 +9,7 @@ import (
 */
 
 func create_character_dice() {
+	r := NewTimeRand()
 
 	player := &PC{
 		STR: 0,
@@ -43,7 +44,7 @@ func create_character_dice() {
 		}
 
 		for i := 0; i < 4; i++ {
-			dice[i] = random(1, 6)
+			dice[i] = r.Roll(1, 6)
 		}
 
 		sort.Ints(dice)
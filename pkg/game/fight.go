@@ -5,7 +5,6 @@ package game
 */
 import (
 	"fmt"
-	"math/rand"
 	"strconv"
 	"time"
 )
@@ -28,43 +27,134 @@ type PC struct { //Τα στοιχεια του χαρακτήρα.
 	Class      string `toml:"class"`      //Τύπος εξειδίκευσης του χαρακτήρα
 	Armor      string `toml:"armor"`      //Τύπος πανοπλίας που φοράει ο χαρακτήρας
 	Weapon     string `toml:"weapon"`     //Τύπος όπλου που κρατάει ο χαρακτήρας
+	// Inventory holds the items the character carries.
+	Inventory []Item `toml:"inventory"`
+	// Equipment holds the items the character wears, by slot.
+	Equipment map[string]Item `toml:"equipment"`
+	// Gold is the money the character carries.
+	Gold int `toml:"gold"`
+	// XP is the experience of the character.
+	XP int `toml:"xp"`
+	// Skills holds the proficiency of the character, from 0 to 100, in
+	// every skill it has learned.
+	Skills map[string]int `toml:"skills"`
+	// Practices is the number of practice sessions the character can spend
+	// to improve its skills.
+	Practices int `toml:"practices"`
+	// MaxHP is the most hit points the character can have.
+	MaxHP int `toml:"maxhp"`
+	// Mana is spent to cast spells.
+	Mana int `toml:"mana"`
+	// MaxMana is the most mana the character can have.
+	MaxMana int `toml:"maxmana"`
+	// Buffs holds the spells currently raising the armor class of the
+	// character.
+	Buffs []Buff `toml:"buffs"`
+	// Cooldowns holds when the character can use each of the actions it used
+	// recently again.
+	Cooldowns map[string]time.Time `toml:"cooldowns"`
+	// LastCombat is the last time the character dealt or took damage.
+	LastCombat time.Time `toml:"lastCombat"`
 }
 
 /* Εκτελώντας την generateAttrib(), δίνουμε μια τυχαία τιμή από 8 ώς 18 σε κάθε ένα χαρακτηριστικό, και επιλέγουμε μια
    τυχαία κλάσση, από τις τρείς που διαθέτουμε για αυτό το παράδειγμα, με την assignClass().
 */
-func NewPC() *PC {
+func NewPC(r *Rand) *PC {
+	return NewPCWith(r, Stats{})
+}
+
+// Stats holds the attributes, class and hit points new characters start
+// with. Whatever is left zero is rolled or calculated as usual.
+type Stats struct {
+	STR   int    `toml:"str"`
+	DEX   int    `toml:"dex"`
+	CON   int    `toml:"con"`
+	INT   int    `toml:"int"`
+	WIS   int    `toml:"wis"`
+	CHA   int    `toml:"cha"`
+	HP    int    `toml:"hp"`
+	Class string `toml:"class"`
+}
+
+// Validate makes sure the stats are ones characters can have.
+func (stats Stats) Validate() error {
+	attributes := map[string]int{
+		"str": stats.STR, "dex": stats.DEX, "con": stats.CON,
+		"int": stats.INT, "wis": stats.WIS, "cha": stats.CHA,
+	}
+	for name, value := range attributes {
+		if value < 0 || value > 30 {
+			return fmt.Errorf("%s must be 0 (rolled) or 1 to 30, got %d", name, value)
+		}
+	}
+	if stats.HP < 0 {
+		return fmt.Errorf("hp must not be negative, got %d", stats.HP)
+	}
+	if stats.Class != "" && !IsClass(stats.Class) {
+		return fmt.Errorf("unknown class %q", stats.Class)
+	}
+	return nil
+}
+
+// IsClass returns true if characters can be of the given class.
+func IsClass(name string) bool {
+	return name == "Commoner" || name == "Fighter" || name == "Rogue"
+}
+
+// NewPCWith creates a character starting with the given stats, rolling
+// the rest with r.
+func NewPCWith(r *Rand, stats Stats) *PC {
 	player := &PC{
-		STR:   generateAttrib(),
-		DEX:   generateAttrib(),
-		CON:   generateAttrib(),
-		INT:   generateAttrib(),
-		WIS:   generateAttrib(),
-		CHA:   generateAttrib(),
+		STR:   stats.STR,
+		DEX:   stats.DEX,
+		CON:   stats.CON,
+		INT:   stats.INT,
+		WIS:   stats.WIS,
+		CHA:   stats.CHA,
 		Level: 1,
-		Class: assignClass(),
+		Class: stats.Class,
+	}
+	for _, attribute := range []*int{&player.STR, &player.DEX, &player.CON, &player.INT, &player.WIS, &player.CHA} {
+		if *attribute == 0 {
+			*attribute = generateAttrib(r)
+		}
+	}
+	if player.Class == "" {
+		player.Class = assignClass(r)
 	}
 	// Όπλο και πανοπλία φοράνε τυχαία οι χαρακτηρες, αλλά τα Hit Points και ΒΑΒ υπολογίζονται βάση αλγορίθμου.
-	player.Armor, player.AC = wearArmor(player.DEX)
-	player.HP = calcHP(player.Class, player.Level)
+	player.Armor, player.AC = wearArmor(r, player.DEX)
+	player.HP = stats.HP
+	if player.HP == 0 {
+		player.HP = calcHP(r, player.Class, player.Level)
+	}
+	player.MaxHP = player.HP
+	player.MaxMana = maxMana(player.INT)
+	player.Mana = player.MaxMana
 	player.BAB = calcBAB(player.Class, player.Level)
-	player.Weapon, player.Weapondie = weildWeapon()
-	player.Initiative = random(1, 20) + attrModifier(player.DEX)
+	player.Weapon, player.Weapondie = weildWeapon(r)
+	player.Initiative = r.Roll(1, 20) + attrModifier(player.DEX)
 
 	return player
 }
 
-//------------Functions----------------
-// μια random οπως την ξερουμε
-func random(min, max int) int {
-	max = max + 1
-	rand.Seed(time.Now().UTC().UnixNano()) // Μετράει πολύ μια sleep τελικά
-	return rand.Intn(max-min) + min
+// FillMissingStats fills in the stats that characters created before the
+// stats were introduced lack.
+func (pc *PC) FillMissingStats() {
+	if pc.MaxHP == 0 {
+		pc.MaxHP = pc.HP
+	}
+	if pc.MaxMana == 0 {
+		pc.MaxMana = maxMana(pc.INT)
+		pc.Mana = pc.MaxMana
+	}
 }
 
+//------------Functions----------------
 // γενικη μεθοδος για να δημιουργουμε τα stats, δηλ. strength, constitution etc.
-func generateAttrib() int {
-	return random(8, 18)
+func generateAttrib(r *Rand) int {
+	return r.Roll(8, 18)
 }
 
 // Βασικη μεθοδος υπολογισμου του attribute bonus. Θελει προβλεψη για τις αρνητικες τιμες, γιατι παει ανα δυο
@@ -73,78 +163,93 @@ func attrModifier(attribute int) int {
 	return (attribute - 10) / 2
 }
 
+// armor is the armor bonus of a kind of armor and the most dexterity
+// modifier points that can be added to it.
+type armor struct {
+	bonus  int
+	maxDex int
+}
+
+// armors holds every kind of armor characters can wear, by name.
+var armors = map[string]armor{
+	"Leather Armor":    {bonus: 2, maxDex: 8},
+	"Chain Shirt":      {bonus: 4, maxDex: 4},
+	"Scale Mail":       {bonus: 4, maxDex: 4},
+	"Breastplate":      {bonus: 5, maxDex: 3},
+	"Full Plate Armor": {bonus: 8, maxDex: 1},
+}
+
+// armorNames holds the names of the armors in the order wearArmor draws them.
+var armorNames = []string{"Leather Armor", "Chain Shirt", "Scale Mail", "Breastplate", "Full Plate Armor"}
+
+// weapons holds the sides of the damage die of every weapon characters can
+// wield, by name.
+var weapons = map[string]int{
+	"fist":        3,
+	"dagger":      4,
+	"short sword": 6,
+	"longsword":   8,
+	"greataxe":    12, // δηλαδη, το ζαρι που ριχνεις για να κανεις damage με το πελεκυ ειναι δωδεκαπλευρο, 1d12
+}
+
+// weaponNames holds the names of the weapons in the order weildWeapon draws
+// them.
+var weaponNames = []string{"fist", "dagger", "short sword", "longsword", "greataxe"}
+
 // τωρα αυτη διαλεγει στην τυχη μια πανοπλια. Αργοτερα, απλα θα παιρνει το αναγνωριστικο της πανοπλιας απο την βαση δεδομενων
 //και θα υπολογιζει το συνολο του AC
-func wearArmor(dexterity int) (string, int) {
-	lottery := random(1, 5)
-	var armorname string
-	var armorBonus, dexBonus int
-	dexBonus = attrModifier(dexterity)
-	switch lottery {
-	case 1:
-		armorname = "Leather Armor"
-		armorBonus = 2
-		if dexBonus > 8 { // καθε πανοπλια εχει κατωφλι στους ποσους ποντους dexterity modifier μπορουν να προστεθουν
-			dexBonus = 8
-		}
-	case 2:
-		armorname = "Chain Shirt"
-		armorBonus = 4
-		if dexBonus > 4 {
-			dexBonus = 4
-		}
-	case 3:
-		armorname = "Scale Mail"
-		armorBonus = 4
-		if dexBonus > 4 {
-			dexBonus = 4
-		}
-	case 4:
-		armorname = "Breastplate"
-		armorBonus = 5
-		if dexBonus > 3 {
-			dexBonus = 3
-		}
-	case 5:
-		armorname = "Full Plate Armor"
-		armorBonus = 8
-		if dexBonus > 1 {
-			dexBonus = 1
-		}
+func wearArmor(r *Rand, dexterity int) (string, int) {
+	name := armorNames[r.Roll(1, len(armorNames))-1]
+	return name, armorClass(name, dexterity)
+}
+
+// armorClass returns the armor class of a character with the given dexterity
+// wearing the named armor.
+func armorClass(name string, dexterity int) int {
+	a := armors[name]
+	dexBonus := attrModifier(dexterity)
+	if dexBonus > a.maxDex { // καθε πανοπλια εχει κατωφλι στους ποσους ποντους dexterity modifier μπορουν να προστεθουν
+		dexBonus = a.maxDex
 	}
-	return armorname, 10 + armorBonus + dexBonus
+	return 10 + a.bonus + dexBonus
 }
 
 // Η μέθοδος αυτή, δίνει όπλο στον χαρακτήρα. Το weapon είναι το όνομα του όπλου και το weapondie είναι πόσες πλευρές έχει το ζάρι
 // που κάνει το damage
-func weildWeapon() (string, int) {
-	lottery := random(1, 5)
-	var weapon string
-	var weapondie int // Στην ουσια ειναι σαν το damroll που ελεγες Νικο οτι εχει το MUD αλλα πιο ξεκαθαρα τα πραγματα
-	switch lottery {
-	case 1:
-		weapon = "fist"
-		weapondie = 3
-	case 2:
-		weapon = "dagger"
-		weapondie = 4
-	case 3:
-		weapon = "short sword"
-		weapondie = 6
-	case 4:
-		weapon = "longsword"
-		weapondie = 8
-	case 5:
-		weapon = "greataxe"
-		weapondie = 12 // δηλαδη, το ζαρι που ριχνεις για να κανεις damage με το πελεκυ ειναι δωδεκαπλευρο, 1d12
-	}
-	return weapon, weapondie
+func weildWeapon(r *Rand) (string, int) {
+	name := weaponNames[r.Roll(1, len(weaponNames))-1]
+	return name, weapons[name]
+}
+
+// IsArmor returns true if characters can wear armor with the given name.
+func IsArmor(name string) bool {
+	_, ok := armors[name]
+	return ok
+}
+
+// IsWeapon returns true if characters can wield a weapon with the given
+// name.
+func IsWeapon(name string) bool {
+	_, ok := weapons[name]
+	return ok
+}
+
+// WearArmor makes the character wear the named armor, which must exist.
+func (pc *PC) WearArmor(name string) {
+	pc.Armor = name
+	pc.AC = armorClass(name, pc.DEX)
+}
+
+// Wield makes the character wield the named weapon, which must exist.
+func (pc *PC) Wield(name string) {
+	pc.Weapon = name
+	pc.Weapondie = weapons[name]
 }
 
 // Μια μέθοδος που δίνει τυχαία μια κλάσση στον χαρακτήρα. Αυτό θα χρειαστεί για να υπολογιστούν άλλοι παράγοντες,
 // όπως Hit Points, ΒΑΒ κ.α.
-func assignClass() string { //Τρεις κλασσεις για αρχη και βλεπουμε
-	lottery := random(1, 3)
+func assignClass(r *Rand) string { //Τρεις κλασσεις για αρχη και βλεπουμε
+	lottery := r.Roll(1, 3)
 	var class string
 	switch lottery {
 	case 1:
@@ -177,7 +282,7 @@ func calcBAB(class string, level int) int {
 // Μέθοδος υπολογισμού των Hit Points. Παίζει ρόλο τι κλάσση είναι ο χαρακτήρας και τι επίπεδο
 // Στην ουσία, κάθε κλάσση έχει ένα τύπο πολύπλευρου ζαριού που το ρίχνει για να προσθέσει το αποτέλεσμα του στα υπάρχοντα
 // ΗΡ κάθε φορά που παίρνει επίπεδο. Στο πρώτο επίπεδο παίρνει τον μέγιστο αριθμό.
-func calcHP(class string, level int) int { // Εχει και προβλεψη για αν βαλουμε μεγαλυτερα level
+func calcHP(r *Rand, class string, level int) int { // Εχει και προβλεψη για αν βαλουμε μεγαλυτερα level
 	var HP int
 	var HD int
 	switch class {
@@ -187,7 +292,7 @@ func calcHP(class string, level int) int { // Εχει και προβλεψη 
 		level -= 1
 		if level != 0 {
 			for i := 0; i < level; i++ {
-				HP += random(1, HD)
+				HP += r.Roll(1, HD)
 			}
 		}
 	case "Fighter":
@@ -196,7 +301,7 @@ func calcHP(class string, level int) int { // Εχει και προβλεψη 
 		level = level - 1
 		if level != 0 {
 			for i := 0; i < level; i++ {
-				HP += random(1, HD)
+				HP += r.Roll(1, HD)
 			}
 		}
 	case "Rogue":
@@ -205,7 +310,7 @@ func calcHP(class string, level int) int { // Εχει και προβλεψη 
 		level = level - 1
 		if level != 0 {
 			for i := 0; i < level; i++ {
-				HP += random(1, HD)
+				HP += r.Roll(1, HD)
 			}
 		}
 	}
@@ -215,12 +320,12 @@ func calcHP(class string, level int) int { // Εχει και προβλεψη 
 // Μεθοδος μαχης. Πρωτα βαραει ο comb1 και μετα ο comb2. Το initiative καθοριζεται στην main()
 // δοκιμασα "for comb1.HP > 0 || comb2.HP > 0 {" και κανει οτι να'ναι. Γιατι; Για τωρα δουλευει
 //  με αρχικο check των hit points σε ατερμονα βρογχο
-func fight(comb1, comb2 *PC) {
+func fight(r *Rand, comb1, comb2 *PC) {
 	for comb1.HP > 0 && comb2.HP > 0 {
-		if (random(1, 20) + comb1.BAB + attrModifier(comb1.STR)) >= comb2.AC {
-			hit := random(1, comb1.Weapondie)
+		if (r.Roll(1, 20) + comb1.BAB + attrModifier(comb1.STR)) >= comb2.AC {
+			hit := r.Roll(1, comb1.Weapondie)
 			comb2.HP -= hit
-			descrip := random(1, 4)
+			descrip := r.Roll(1, 4)
 
 			strhit := strconv.Itoa(hit)
 
@@ -241,10 +346,10 @@ func fight(comb1, comb2 *PC) {
 		if comb2.HP < 0 {
 			break
 		}
-		if (random(1, 20) + comb2.BAB + attrModifier(comb2.STR)) >= comb1.AC {
-			hit := random(1, comb2.Weapondie)
+		if (r.Roll(1, 20) + comb2.BAB + attrModifier(comb2.STR)) >= comb1.AC {
+			hit := r.Roll(1, comb2.Weapondie)
 			comb1.HP -= hit
-			descrip := random(1, 4)
+			descrip := r.Roll(1, 4)
 
 			strhit := strconv.Itoa(hit)
 
@@ -276,12 +381,11 @@ func fight(comb1, comb2 *PC) {
 //------Main code------
 
 func do_fight() {
-	rand.Seed(time.Now().Unix())
-
 	// Setting up player 1
-	player1 := NewPC()
+	r := NewTimeRand()
+	player1 := NewPC(r)
 	// Setting up player 2
-	player2 := NewPC()
+	player2 := NewPC(r)
 
 	// τελικο output
 	fmt.Println("-----@@@@@@----@@@@@@@-----\nMy, what a characters you have here?\n-----@@@@@@----@@@@@@@-----")
@@ -308,15 +412,15 @@ func do_fight() {
 
 	//Υπολογισμός initiative, σε περιπτωση ισοπαλιας ξαναριχνουν ζαρια, αλλιως τοποθετουνται με αντιστοιχια στην μεθοδο fight()
 	for player1.Initiative == player2.Initiative {
-		player1.Initiative = random(1, 20) + attrModifier(player1.DEX)
-		player2.Initiative = random(1, 20) + attrModifier(player2.DEX)
+		player1.Initiative = r.Roll(1, 20) + attrModifier(player1.DEX)
+		player2.Initiative = r.Roll(1, 20) + attrModifier(player2.DEX)
 	}
 
 	switch {
 	case player1.Initiative > player2.Initiative:
-		fight(player1, player2)
+		fight(r, player1, player2)
 	case player1.Initiative < player2.Initiative:
-		fight(player2, player1)
+		fight(r, player2, player1)
 	default:
 		fmt.Println("Problem!")
 	}
@@ -0,0 +1,44 @@
+package game
+
+import "testing"
+
+func TestNewPCWith(t *testing.T) {
+	r := NewRand(1)
+	pc := NewPCWith(r, Stats{DEX: 14, Class: "Rogue"})
+	if pc.DEX != 14 || pc.Class != "Rogue" {
+		t.Errorf("got %d dex and class %s, want 14 and Rogue", pc.DEX, pc.Class)
+	}
+	// Hit points left out are those of a first level character of the class.
+	if want := calcHP(r, "Rogue", 1); pc.HP != want || pc.MaxHP != want {
+		t.Errorf("got %d/%d hp, want %d", pc.HP, pc.MaxHP, want)
+	}
+	for name, value := range map[string]int{"str": pc.STR, "con": pc.CON, "int": pc.INT, "wis": pc.WIS, "cha": pc.CHA} {
+		if value < 8 || value > 18 {
+			t.Errorf("rolled %d %s", value, name)
+		}
+	}
+
+	pc = NewPCWith(r, Stats{HP: 50})
+	if pc.HP != 50 || pc.MaxHP != 50 || !IsClass(pc.Class) {
+		t.Errorf("got %d/%d hp and class %q", pc.HP, pc.MaxHP, pc.Class)
+	}
+}
+
+func TestStatsValidate(t *testing.T) {
+	tests := []struct {
+		stats Stats
+		ok    bool
+	}{
+		{stats: Stats{}, ok: true},
+		{stats: Stats{STR: 18, CHA: 1, HP: 12, Class: "Fighter"}, ok: true},
+		{stats: Stats{WIS: 31}, ok: false},
+		{stats: Stats{INT: -1}, ok: false},
+		{stats: Stats{HP: -5}, ok: false},
+		{stats: Stats{Class: "Wizard"}, ok: false},
+	}
+	for _, test := range tests {
+		if err := test.stats.Validate(); (err == nil) != test.ok {
+			t.Errorf("%+v: got %v", test.stats, err)
+		}
+	}
+}
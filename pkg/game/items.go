@@ -0,0 +1,273 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Equipment slots characters can wear items in.
+const (
+	SlotWeapon = "weapon"
+	SlotArmor  = "armor"
+)
+
+// Slots holds every equipment slot.
+var Slots = []string{SlotWeapon, SlotArmor}
+
+// Item is something characters can carry. Items with a slot can be worn in
+// it, changing the stats of the character wearing them.
+type Item struct {
+	// ID is the ID of the definition the item was made from, if any.
+	ID   string `toml:"id" json:"id"`
+	Name string `toml:"name" json:"name"`
+	// Slot is the equipment slot the item is worn in. Items without a slot
+	// cannot be worn.
+	Slot string `toml:"slot" json:"slot"`
+	// Damage is the number of sides of the damage die of weapons.
+	Damage int `toml:"damage" json:"damage"`
+	// ArmorBonus is the armor bonus of armor, and MaxDex the most dexterity
+	// modifier points that can be added to it. Any number of them can be
+	// added when MaxDex is zero.
+	ArmorBonus int `toml:"armorBonus" json:"armorBonus"`
+	MaxDex     int `toml:"maxDex" json:"maxDex"`
+	// Stackable items of the same kind are kept together as a single item
+	// holding Quantity of them, such as arrows. Quantity is 1 for items
+	// that are not stackable, and so is a zero Quantity.
+	Stackable bool `toml:"stackable" json:"stackable"`
+	Quantity  int  `toml:"quantity" json:"quantity"`
+	// Plural is the name of more than one of the item. It is the name
+	// followed by an s when it is empty.
+	Plural string `toml:"plural" json:"plural"`
+	// Value is the price of one of the item in gold.
+	Value int `toml:"value" json:"value"`
+}
+
+// Validate makes sure the item is one characters can have.
+func (item Item) Validate() error {
+	if strings.TrimSpace(item.Name) == "" {
+		return fmt.Errorf("item has no name")
+	}
+	if item.Value < 0 {
+		return fmt.Errorf("item %q cannot have a negative value", item.Name)
+	}
+	if item.Quantity < 0 || (!item.Stackable && item.Quantity > 1) {
+		return fmt.Errorf("item %q cannot have a quantity of %d", item.Name, item.Quantity)
+	}
+	switch item.Slot {
+	case "":
+	case SlotWeapon:
+		if item.Damage < 1 {
+			return fmt.Errorf("weapon %q needs a damage die", item.Name)
+		}
+	case SlotArmor:
+		if item.ArmorBonus < 0 || item.MaxDex < 0 {
+			return fmt.Errorf("armor %q cannot have a negative armor bonus or dexterity limit", item.Name)
+		}
+	default:
+		return fmt.Errorf("item %q has unknown slot %q, expected %s or %s", item.Name, item.Slot, SlotWeapon, SlotArmor)
+	}
+	return nil
+}
+
+// Count returns how many of the item there are.
+func (item Item) Count() int {
+	if item.Quantity < 1 {
+		return 1
+	}
+	return item.Quantity
+}
+
+// PluralName returns the name of more than one of the item.
+func (item Item) PluralName() string {
+	if item.Plural != "" {
+		return item.Plural
+	}
+	return item.Name + "s"
+}
+
+// String describes the item along with how many of it there are, eg.
+// "5 Arrows".
+func (item Item) String() string {
+	if item.Count() == 1 {
+		return item.Name
+	}
+	return fmt.Sprintf("%d %s", item.Count(), item.PluralName())
+}
+
+// Matches returns true if name is the name of the item or one of the words
+// in it, in the singular or the plural, ignoring case.
+func (item Item) Matches(name string) bool {
+	for _, n := range []string{item.Name, item.PluralName()} {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+		for _, word := range strings.Fields(n) {
+			if strings.EqualFold(word, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stacksWith returns true if the item and other are stackable items of the
+// same kind.
+func (item Item) stacksWith(other Item) bool {
+	return item.Stackable && other.Stackable && item.ID == other.ID && item.Name == other.Name
+}
+
+// FindItem returns the index of the first of the items matching name.
+func FindItem(items []Item, name string) (int, bool) {
+	for i, item := range items {
+		if item.Matches(name) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// AddItem returns the items with item added to them. Stackable items are
+// merged into the stack of the same kind, if there is one.
+func AddItem(items []Item, item Item) []Item {
+	for i, other := range items {
+		if other.stacksWith(item) {
+			rest := append([]Item{}, items...)
+			rest[i].Quantity = other.Count() + item.Count()
+			return rest
+		}
+	}
+	return append(items, item)
+}
+
+// TakeItem returns the items without count of the one at index i, along
+// with what was taken. All of it is taken when count is more than there is.
+func TakeItem(items []Item, i, count int) ([]Item, Item) {
+	item := items[i]
+	if count < item.Count() {
+		rest := append([]Item{}, items...)
+		rest[i].Quantity = item.Count() - count
+		item.Quantity = count
+		return rest, item
+	}
+	rest := append([]Item{}, items[:i]...)
+	return append(rest, items[i+1:]...), item
+}
+
+// TakeItems returns the items without count of those matching name, along
+// with what was taken. Every item matching name is taken when count is
+// negative, and every item when name is empty too.
+func TakeItems(items []Item, name string, count int) ([]Item, []Item) {
+	var taken []Item
+	rest := append([]Item{}, items...)
+	for count != 0 {
+		i := -1
+		for j, item := range rest {
+			if name == "" || item.Matches(name) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			break
+		}
+
+		n := rest[i].Count()
+		if count > 0 && count < n {
+			n = count
+		}
+		var item Item
+		rest, item = TakeItem(rest, i, n)
+		taken = AddItem(taken, item)
+		if count > 0 {
+			count -= n
+		}
+	}
+	return rest, taken
+}
+
+// RemoveItems returns the items without those in remove. It returns false,
+// leaving the items alone, if they do not hold all of remove.
+func RemoveItems(items, remove []Item) ([]Item, bool) {
+	rest := items
+	for _, r := range remove {
+		i := -1
+		for j, item := range rest {
+			if item.sameKind(r) && item.Count() >= r.Count() {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return items, false
+		}
+		rest, _ = TakeItem(rest, i, r.Count())
+	}
+	return rest, true
+}
+
+// sameKind returns true if the item and other are the same apart from how
+// many of them there are.
+func (item Item) sameKind(other Item) bool {
+	item.Quantity, other.Quantity = 0, 0
+	return item == other
+}
+
+// Wear moves the item at index i of the inventory into its slot. Whatever
+// was worn there goes back to the inventory.
+func (pc *PC) Wear(i int) Item {
+	var item Item
+	pc.Inventory, item = TakeItem(pc.Inventory, i, 1)
+	if worn, ok := pc.Equipment[item.Slot]; ok {
+		pc.Inventory = AddItem(pc.Inventory, worn)
+	}
+	if pc.Equipment == nil {
+		pc.Equipment = make(map[string]Item)
+	}
+	pc.Equipment[item.Slot] = item
+	pc.applySlot(item.Slot)
+	return item
+}
+
+// Remove moves the item worn in the slot back to the inventory.
+func (pc *PC) Remove(slot string) (Item, bool) {
+	item, ok := pc.Equipment[slot]
+	if !ok {
+		return Item{}, false
+	}
+	delete(pc.Equipment, slot)
+	pc.Inventory = AddItem(pc.Inventory, item)
+	pc.applySlot(slot)
+	return item, true
+}
+
+// applySlot updates the stats depending on what is worn in the slot.
+// Characters fight with their fists without a weapon, and with their
+// dexterity alone for armor without armor.
+func (pc *PC) applySlot(slot string) {
+	item, worn := pc.Equipment[slot]
+	switch slot {
+	case SlotWeapon:
+		if !worn {
+			pc.Weapon, pc.Weapondie = "fist", 3
+			return
+		}
+		pc.Weapon, pc.Weapondie = item.Name, item.Damage
+	case SlotArmor:
+		dexBonus := attrModifier(pc.DEX)
+		if worn && item.MaxDex > 0 && dexBonus > item.MaxDex {
+			dexBonus = item.MaxDex
+		}
+		pc.AC = 10 + item.ArmorBonus + dexBonus + pc.buffedAC()
+		pc.Armor = item.Name
+	}
+}
+
+// buffedAC returns how much the buffs of the character raise its armor
+// class.
+func (pc *PC) buffedAC() int {
+	ac := 0
+	for _, b := range pc.Buffs {
+		ac += b.AC
+	}
+	return ac
+}
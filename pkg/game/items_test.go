@@ -0,0 +1,238 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestItemValidate(t *testing.T) {
+	tests := []struct {
+		item Item
+		ok   bool
+	}{
+		{item: Item{Name: "Rope"}, ok: true},
+		{item: Item{Name: "Dagger", Slot: SlotWeapon, Damage: 4}, ok: true},
+		{item: Item{Name: "Robe", Slot: SlotArmor}, ok: true},
+		{item: Item{Name: "Chain Shirt", Slot: SlotArmor, ArmorBonus: 4, MaxDex: 4}, ok: true},
+		{item: Item{Slot: SlotWeapon, Damage: 4}, ok: false},
+		{item: Item{Name: "Stick", Slot: SlotWeapon}, ok: false},
+		{item: Item{Name: "Cursed Mail", Slot: SlotArmor, ArmorBonus: -1}, ok: false},
+		{item: Item{Name: "Hat", Slot: "head"}, ok: false},
+		{item: Item{Name: "Gem", Value: -1}, ok: false},
+	}
+	for _, test := range tests {
+		if err := test.item.Validate(); (err == nil) != test.ok {
+			t.Errorf("%+v: got error %v, want ok %v", test.item, err, test.ok)
+		}
+	}
+}
+
+func TestItemMatches(t *testing.T) {
+	item := Item{Name: "Short Sword"}
+	for _, name := range []string{"short sword", "Sword", "SHORT"} {
+		if !item.Matches(name) {
+			t.Errorf("%q does not match %q", name, item.Name)
+		}
+	}
+	for _, name := range []string{"sw", "long sword", ""} {
+		if item.Matches(name) {
+			t.Errorf("%q matches %q", name, item.Name)
+		}
+	}
+}
+
+func TestWearWeapon(t *testing.T) {
+	dagger := Item{Name: "Dagger", Slot: SlotWeapon, Damage: 4}
+	axe := Item{Name: "Greataxe", Slot: SlotWeapon, Damage: 12}
+	pc := &PC{Inventory: []Item{dagger, axe}}
+
+	pc.Wear(0)
+	if pc.Weapon != "Dagger" || pc.Weapondie != 4 {
+		t.Errorf("wielding %s, 1d%d", pc.Weapon, pc.Weapondie)
+	}
+
+	// Wielding another weapon puts the first one away.
+	pc.Wear(0)
+	if pc.Weapon != "Greataxe" || pc.Weapondie != 12 {
+		t.Errorf("wielding %s, 1d%d", pc.Weapon, pc.Weapondie)
+	}
+	if len(pc.Inventory) != 1 || pc.Inventory[0].Name != "Dagger" {
+		t.Errorf("carrying %+v", pc.Inventory)
+	}
+
+	if _, ok := pc.Remove(SlotWeapon); !ok {
+		t.Fatal("nothing to remove")
+	}
+	if pc.Weapon != "fist" || pc.Weapondie != 3 {
+		t.Errorf("wielding %s, 1d%d after removing the weapon", pc.Weapon, pc.Weapondie)
+	}
+	if len(pc.Inventory) != 2 || len(pc.Equipment) != 0 {
+		t.Errorf("carrying %+v, wearing %+v", pc.Inventory, pc.Equipment)
+	}
+	if _, ok := pc.Remove(SlotWeapon); ok {
+		t.Error("removed a weapon twice")
+	}
+}
+
+func TestWearArmorItem(t *testing.T) {
+	tests := []struct {
+		name  string
+		dex   int
+		armor Item
+		want  int
+	}{
+		{name: "dexterity within the limit", dex: 14, armor: Item{Name: "Chain Shirt", Slot: SlotArmor, ArmorBonus: 4, MaxDex: 4}, want: 16},
+		{name: "dexterity over the limit", dex: 18, armor: Item{Name: "Full Plate", Slot: SlotArmor, ArmorBonus: 8, MaxDex: 1}, want: 19},
+		{name: "no limit", dex: 18, armor: Item{Name: "Robe", Slot: SlotArmor, ArmorBonus: 1}, want: 15},
+		{name: "clumsy", dex: 6, armor: Item{Name: "Breastplate", Slot: SlotArmor, ArmorBonus: 5, MaxDex: 3}, want: 13},
+	}
+	for _, test := range tests {
+		pc := &PC{DEX: test.dex, Inventory: []Item{test.armor}}
+		pc.Wear(0)
+		if pc.AC != test.want || pc.Armor != test.armor.Name {
+			t.Errorf("%s: AC %d wearing %q, want %d", test.name, pc.AC, pc.Armor, test.want)
+		}
+	}
+}
+
+func TestArmorKeepsBuffs(t *testing.T) {
+	shirt := Item{Name: "Chain Shirt", Slot: SlotArmor, ArmorBonus: 4, MaxDex: 4}
+	pc := &PC{DEX: 10, Inventory: []Item{shirt}, Buffs: []Buff{{Spell: "shield", AC: 2}}}
+
+	pc.Wear(0)
+	if pc.AC != 16 {
+		t.Errorf("AC %d, want 16", pc.AC)
+	}
+	pc.Remove(SlotArmor)
+	if pc.AC != 12 || pc.Armor != "" {
+		t.Errorf("AC %d wearing %q after removing the armor, want 12", pc.AC, pc.Armor)
+	}
+}
+
+func TestTakeItem(t *testing.T) {
+	items := []Item{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+
+	rest, item := TakeItem(items, 1, 1)
+	if item.Name != "b" || len(rest) != 2 || rest[0].Name != "a" || rest[1].Name != "c" {
+		t.Errorf("took %+v, left %+v", item, rest)
+	}
+	// The items taken from are left alone.
+	if items[1].Name != "b" {
+		t.Errorf("items changed to %+v", items)
+	}
+}
+
+// arrows returns a stack of n arrows.
+func arrows(n int) Item {
+	return Item{Name: "Arrow", Stackable: true, Quantity: n}
+}
+
+func TestAddItemMergesStacks(t *testing.T) {
+	dagger := Item{Name: "Dagger"}
+	items := AddItem(nil, arrows(5))
+	items = AddItem(items, dagger)
+	items = AddItem(items, dagger)
+	items = AddItem(items, arrows(3))
+
+	if len(items) != 3 || items[0].Quantity != 8 {
+		t.Errorf("got %v", items)
+	}
+
+	// Stacks of different kinds stay apart.
+	bolts := Item{Name: "Bolt", Stackable: true, Quantity: 2}
+	if items = AddItem(items, bolts); len(items) != 4 {
+		t.Errorf("got %v", items)
+	}
+
+	// The items added to are left alone.
+	before := []Item{arrows(1)}
+	AddItem(before, arrows(1))
+	if before[0].Quantity != 1 {
+		t.Errorf("merging changed the stack to %v", before[0])
+	}
+}
+
+func TestTakeItemFromStack(t *testing.T) {
+	items := []Item{{Name: "Dagger"}, arrows(5)}
+
+	rest, taken := TakeItem(items, 1, 2)
+	if taken.Count() != 2 || len(rest) != 2 || rest[1].Count() != 3 {
+		t.Errorf("took %v, left %v", taken, rest)
+	}
+	if items[1].Count() != 5 {
+		t.Errorf("items changed to %v", items)
+	}
+
+	// Taking more than there is takes the whole stack.
+	rest, taken = TakeItem(items, 1, 9)
+	if taken.Count() != 5 || len(rest) != 1 {
+		t.Errorf("took %v, left %v", taken, rest)
+	}
+}
+
+func TestTakeItems(t *testing.T) {
+	items := []Item{{Name: "Dagger"}, arrows(5), {Name: "Rope"}, {Name: "Dagger"}}
+
+	tests := []struct {
+		name  string
+		count int
+		taken string
+		left  string
+	}{
+		{name: "arrows", count: 3, taken: "3 Arrows", left: "Dagger,2 Arrows,Rope,Dagger"},
+		{name: "arrow", count: 1, taken: "Arrow", left: "Dagger,4 Arrows,Rope,Dagger"},
+		{name: "arrows", count: -1, taken: "5 Arrows", left: "Dagger,Rope,Dagger"},
+		// Items that are not stackable are taken one by one.
+		{name: "dagger", count: 2, taken: "Dagger,Dagger", left: "5 Arrows,Rope"},
+		{name: "dagger", count: 5, taken: "Dagger,Dagger", left: "5 Arrows,Rope"},
+		{name: "daggers", count: -1, taken: "Dagger,Dagger", left: "5 Arrows,Rope"},
+		{name: "", count: -1, taken: "Dagger,5 Arrows,Rope,Dagger", left: ""},
+		{name: "sword", count: 1, taken: "", left: "Dagger,5 Arrows,Rope,Dagger"},
+	}
+	for _, test := range tests {
+		left, taken := TakeItems(items, test.name, test.count)
+		if got := names(taken); got != test.taken {
+			t.Errorf("%d %q: took %s, want %s", test.count, test.name, got, test.taken)
+		}
+		if got := names(left); got != test.left {
+			t.Errorf("%d %q: left %s, want %s", test.count, test.name, got, test.left)
+		}
+	}
+}
+
+func TestRemoveItems(t *testing.T) {
+	dagger := Item{Name: "Dagger"}
+	items := []Item{dagger, arrows(5), {Name: "Rope"}, dagger}
+
+	tests := []struct {
+		remove []Item
+		ok     bool
+		left   string
+	}{
+		{remove: []Item{arrows(2)}, ok: true, left: "Dagger,3 Arrows,Rope,Dagger"},
+		{remove: []Item{dagger, dagger, arrows(5)}, ok: true, left: "Rope"},
+		// Nothing is removed unless all of it can be.
+		{remove: []Item{dagger, arrows(6)}, ok: false, left: "Dagger,5 Arrows,Rope,Dagger"},
+		{remove: []Item{dagger, dagger, dagger}, ok: false, left: "Dagger,5 Arrows,Rope,Dagger"},
+		{remove: []Item{{Name: "Dagger", Damage: 4}}, ok: false, left: "Dagger,5 Arrows,Rope,Dagger"},
+		{remove: nil, ok: true, left: "Dagger,5 Arrows,Rope,Dagger"},
+	}
+	for _, test := range tests {
+		left, ok := RemoveItems(items, test.remove)
+		if ok != test.ok || names(left) != test.left {
+			t.Errorf("removing %s: got %s, %t, want %s, %t", names(test.remove), names(left), ok, test.left, test.ok)
+		}
+	}
+	if names(items) != "Dagger,5 Arrows,Rope,Dagger" {
+		t.Errorf("changed the items to %s", names(items))
+	}
+}
+
+// names describes the items, separated by commas.
+func names(items []Item) string {
+	var s []string
+	for _, item := range items {
+		s = append(s, item.String())
+	}
+	return strings.Join(s, ",")
+}
@@ -0,0 +1,51 @@
+package game
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Rand is a source of random numbers that is safe to use from several
+// goroutines. Two of them created with the same seed produce the same
+// numbers, which makes anything rolled with them reproducible.
+type Rand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// NewRand creates a Rand seeded with the given seed.
+func NewRand(seed int64) *Rand {
+	return &Rand{r: rand.New(rand.NewSource(seed))}
+}
+
+// NewTimeRand creates a Rand seeded with the current time.
+func NewTimeRand() *Rand {
+	return NewRand(time.Now().UnixNano())
+}
+
+// Intn returns a number from 0 up to, but not including, n.
+func (r *Rand) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Intn(n)
+}
+
+// Int returns a non-negative number.
+func (r *Rand) Int() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Int()
+}
+
+// Float64 returns a number from 0 up to, but not including, 1.
+func (r *Rand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Float64()
+}
+
+// Roll returns a number from min to max, both included.
+func (r *Rand) Roll(min, max int) int {
+	return r.Intn(max-min+1) + min
+}
@@ -0,0 +1,46 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fightWith creates two characters with r and returns the outcomes of the
+// attacks they make against each other.
+func fightWith(r *Rand) []string {
+	a, b := NewPC(r), NewPC(r)
+	b.Skills = map[string]int{SkillDodge: 50}
+
+	var outcomes []string
+	for i := 0; i < 50; i++ {
+		damage, avoided := Attack(r, a, b)
+		outcomes = append(outcomes, fmt.Sprintf("%d %s", damage, avoided))
+		damage, avoided = Attack(r, b, a)
+		outcomes = append(outcomes, fmt.Sprintf("%d %s", damage, avoided))
+	}
+	return outcomes
+}
+
+func TestSameSeedSameFight(t *testing.T) {
+	first, second := fightWith(NewRand(42)), fightWith(NewRand(42))
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("attack %d: got %q and %q with the same seed", i, first[i], second[i])
+		}
+	}
+}
+
+func TestRandRoll(t *testing.T) {
+	r := NewRand(1)
+	seen := map[int]bool{}
+	for i := 0; i < 1000; i++ {
+		n := r.Roll(3, 6)
+		if n < 3 || n > 6 {
+			t.Fatalf("rolled %d", n)
+		}
+		seen[n] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("rolled only %v", seen)
+	}
+}
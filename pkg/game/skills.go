@@ -0,0 +1,33 @@
+package game
+
+const (
+	// MinSkillChance and MaxSkillChance bound the chance of a skill check
+	// to succeed, so that nothing is ever certain.
+	MinSkillChance = 5
+	MaxSkillChance = 95
+)
+
+// SkillChance returns the percent chance of succeeding in a skill check for
+// the given proficiency against the given difficulty, both from 0 to 100.
+func SkillChance(proficiency, difficulty int) int {
+	chance := proficiency - difficulty + 50
+	if chance < MinSkillChance {
+		return MinSkillChance
+	}
+	if chance > MaxSkillChance {
+		return MaxSkillChance
+	}
+	return chance
+}
+
+// SkillCheck returns true if a roll of a percentile die, from 1 to 100,
+// succeeds in a skill check for the given proficiency against the given
+// difficulty.
+func SkillCheck(proficiency, difficulty, roll int) bool {
+	return roll <= SkillChance(proficiency, difficulty)
+}
+
+// RollSkillCheck rolls a percentile die with r for a skill check.
+func RollSkillCheck(r *Rand, proficiency, difficulty int) bool {
+	return SkillCheck(proficiency, difficulty, r.Roll(1, 100))
+}
@@ -0,0 +1,90 @@
+package game
+
+import "testing"
+
+func TestSkillChance(t *testing.T) {
+	tests := []struct {
+		proficiency, difficulty, want int
+	}{
+		{proficiency: 50, difficulty: 50, want: 50},
+		{proficiency: 70, difficulty: 50, want: 70},
+		{proficiency: 30, difficulty: 60, want: 20},
+		{proficiency: 0, difficulty: 100, want: MinSkillChance},
+		{proficiency: 100, difficulty: 0, want: MaxSkillChance},
+	}
+	for _, test := range tests {
+		if got := SkillChance(test.proficiency, test.difficulty); got != test.want {
+			t.Errorf("%d against %d: got %d, want %d", test.proficiency, test.difficulty, got, test.want)
+		}
+	}
+}
+
+func TestSkillCheck(t *testing.T) {
+	tests := []struct {
+		proficiency, difficulty, roll int
+		want                          bool
+	}{
+		{proficiency: 50, difficulty: 50, roll: 50, want: true},
+		{proficiency: 50, difficulty: 50, roll: 51, want: false},
+		{proficiency: 0, difficulty: 100, roll: MinSkillChance, want: true},
+		{proficiency: 100, difficulty: 0, roll: MaxSkillChance + 1, want: false},
+	}
+	for _, test := range tests {
+		if got := SkillCheck(test.proficiency, test.difficulty, test.roll); got != test.want {
+			t.Errorf("%d against %d rolling %d: got %t", test.proficiency, test.difficulty, test.roll, got)
+		}
+	}
+}
+
+func TestAvoidSkills(t *testing.T) {
+	pc := &PC{Weapon: "fist"}
+	if got := pc.avoidSkills(); len(got) != 0 {
+		t.Errorf("without skills: got %v", got)
+	}
+
+	pc.Skills = map[string]int{SkillDodge: 10, SkillParry: 0}
+	if got := pc.avoidSkills(); len(got) != 1 || got[0] != SkillDodge {
+		t.Errorf("parrying with fists: got %v", got)
+	}
+
+	pc.Wield("dagger")
+	if got := pc.avoidSkills(); len(got) != 2 || got[0] != SkillDodge || got[1] != SkillParry {
+		t.Errorf("with a dagger: got %v", got)
+	}
+}
+
+func TestAttackAvoided(t *testing.T) {
+	r := NewRand(1)
+	attacker := &PC{BAB: 30, STR: 10, Weapondie: 6}
+	defender := &PC{AC: 10, Weapon: "fist", Skills: map[string]int{SkillDodge: 100}}
+
+	dodged := 0
+	for i := 0; i < 1000; i++ {
+		damage, avoided := Attack(r, attacker, defender)
+		switch avoided {
+		case SkillDodge:
+			dodged++
+			if damage != 0 {
+				t.Fatalf("dodged attack dealt %d", damage)
+			}
+		case "":
+			if damage < 1 || damage > 6 {
+				t.Fatalf("hit dealt %d", damage)
+			}
+		default:
+			t.Fatalf("avoided with %q", avoided)
+		}
+	}
+	// A proficiency of 100 dodges half of the hits.
+	if dodged < 400 || dodged > 600 {
+		t.Errorf("dodged %d of 1000 attacks", dodged)
+	}
+
+	if chance, _, _ := AttackOdds(attacker, defender); chance != 50 {
+		t.Errorf("odds: got %d, want 50", chance)
+	}
+	defender.Skills = nil
+	if chance, _, _ := AttackOdds(attacker, defender); chance != 100 {
+		t.Errorf("odds without skills: got %d, want 100", chance)
+	}
+}
@@ -0,0 +1,128 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of effects spells can have.
+const (
+	EffectDamage = "damage"
+	EffectHeal   = "heal"
+	EffectBuff   = "buff"
+	EffectLight  = "light"
+)
+
+// Spell is an ability characters can cast by spending mana.
+type Spell struct {
+	// Name is the name players cast the spell by.
+	Name string `toml:"-"`
+	// Cost is the mana spent to cast the spell.
+	Cost int `toml:"cost"`
+	// Effect is either damage, heal, buff or light.
+	Effect string `toml:"effect"`
+	// Magnitude is the hit points damaged or healed, or the armor class
+	// added by a buff.
+	Magnitude int `toml:"magnitude"`
+	// Cooldown is the number of seconds before the spell can be cast again.
+	Cooldown int `toml:"cooldown"`
+	// Duration is the number of seconds a buff lasts.
+	Duration int `toml:"duration"`
+}
+
+// Validate returns an error if the spell cannot be cast as it is.
+func (sp Spell) Validate() error {
+	switch sp.Effect {
+	case EffectDamage, EffectHeal, EffectBuff, EffectLight:
+	default:
+		return fmt.Errorf("spell %q: unknown effect %q", sp.Name, sp.Effect)
+	}
+	if sp.Cost < 0 || sp.Magnitude < 0 || sp.Cooldown < 0 || sp.Duration < 0 {
+		return fmt.Errorf("spell %q: cost, magnitude, cooldown and duration may not be negative", sp.Name)
+	}
+	if (sp.Effect == EffectBuff || sp.Effect == EffectLight) && sp.Duration == 0 {
+		return fmt.Errorf("spell %q: buffs and lights need a duration", sp.Name)
+	}
+	return nil
+}
+
+// Buff is a temporary bonus to the armor class of a character, or a light
+// the character carries.
+type Buff struct {
+	Spell   string    `toml:"spell"`
+	AC      int       `toml:"ac"`
+	Light   bool      `toml:"light"`
+	Expires time.Time `toml:"expires"`
+}
+
+// HasLight returns true if the character carries a light.
+func (pc *PC) HasLight() bool {
+	for _, b := range pc.Buffs {
+		if b.Light {
+			return true
+		}
+	}
+	return false
+}
+
+// SpellEffect is the change a spell makes to its target.
+type SpellEffect struct {
+	// HP is the change in hit points.
+	HP int
+	// AC is the change in armor class.
+	AC int
+	// Light is true if the target gets a light.
+	Light bool
+}
+
+// ResolveSpell returns the effect the spell has on the target. Hit points
+// never drop below zero or rise above the maximum hit points of the target.
+func ResolveSpell(sp Spell, target PC) SpellEffect {
+	switch sp.Effect {
+	case EffectDamage:
+		damage := sp.Magnitude
+		if damage > target.HP {
+			damage = target.HP
+		}
+		return SpellEffect{HP: -damage}
+	case EffectHeal:
+		heal := sp.Magnitude
+		if target.HP+heal > target.MaxHP {
+			heal = target.MaxHP - target.HP
+		}
+		if heal < 0 {
+			heal = 0
+		}
+		return SpellEffect{HP: heal}
+	case EffectBuff:
+		return SpellEffect{AC: sp.Magnitude}
+	case EffectLight:
+		return SpellEffect{Light: true}
+	}
+	return SpellEffect{}
+}
+
+// ExpireBuffs removes the buffs of the character that expired by now,
+// taking their bonus away, and returns them.
+func (pc *PC) ExpireBuffs(now time.Time) []Buff {
+	var expired, active []Buff
+	for _, b := range pc.Buffs {
+		if now.Before(b.Expires) {
+			active = append(active, b)
+			continue
+		}
+		pc.AC -= b.AC
+		expired = append(expired, b)
+	}
+	pc.Buffs = active
+	return expired
+}
+
+// maxMana returns the mana a character with the given intelligence can have.
+func maxMana(intelligence int) int {
+	mana := 10 + 2*attrModifier(intelligence)
+	if mana < 1 {
+		return 1
+	}
+	return mana
+}
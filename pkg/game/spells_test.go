@@ -0,0 +1,90 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpellValidate(t *testing.T) {
+	tests := []struct {
+		spell Spell
+		ok    bool
+	}{
+		{spell: Spell{Name: "heal", Effect: EffectHeal, Cost: 4, Magnitude: 5}, ok: true},
+		{spell: Spell{Name: "shield", Effect: EffectBuff, Magnitude: 2, Duration: 30}, ok: true},
+		{spell: Spell{Name: "light", Effect: EffectLight, Duration: 120}, ok: true},
+		{spell: Spell{Name: "blink", Effect: "teleport"}, ok: false},
+		{spell: Spell{Name: "spark", Effect: EffectDamage, Cost: -1}, ok: false},
+		{spell: Spell{Name: "shield", Effect: EffectBuff, Magnitude: 2}, ok: false},
+		{spell: Spell{Name: "light", Effect: EffectLight}, ok: false},
+	}
+	for _, test := range tests {
+		if err := test.spell.Validate(); (err == nil) != test.ok {
+			t.Errorf("%+v: got %v", test.spell, err)
+		}
+	}
+}
+
+func TestResolveSpell(t *testing.T) {
+	target := PC{HP: 8, MaxHP: 10}
+	tests := []struct {
+		spell Spell
+		want  SpellEffect
+	}{
+		{spell: Spell{Effect: EffectDamage, Magnitude: 3}, want: SpellEffect{HP: -3}},
+		// Hit points never drop below zero.
+		{spell: Spell{Effect: EffectDamage, Magnitude: 20}, want: SpellEffect{HP: -8}},
+		// Nor rise above the maximum.
+		{spell: Spell{Effect: EffectHeal, Magnitude: 5}, want: SpellEffect{HP: 2}},
+		{spell: Spell{Effect: EffectBuff, Magnitude: 2}, want: SpellEffect{AC: 2}},
+		{spell: Spell{Effect: EffectLight}, want: SpellEffect{Light: true}},
+	}
+	for _, test := range tests {
+		if got := ResolveSpell(test.spell, target); got != test.want {
+			t.Errorf("%+v: got %+v, want %+v", test.spell, got, test.want)
+		}
+	}
+
+	overHealed := PC{HP: 12, MaxHP: 10}
+	if got := ResolveSpell(Spell{Effect: EffectHeal, Magnitude: 5}, overHealed); got.HP != 0 {
+		t.Errorf("healing above the maximum: got %+v", got)
+	}
+}
+
+func TestExpireBuffs(t *testing.T) {
+	now := time.Now()
+	pc := PC{AC: 15, Buffs: []Buff{
+		{Spell: "shield", AC: 2, Expires: now},
+		{Spell: "light", Light: true, Expires: now.Add(time.Minute)},
+		{Spell: "armor", AC: 3, Expires: now.Add(-time.Second)},
+	}}
+
+	expired := pc.ExpireBuffs(now)
+	if len(expired) != 2 || expired[0].Spell != "shield" || expired[1].Spell != "armor" {
+		t.Errorf("expired %+v", expired)
+	}
+	if pc.AC != 10 || len(pc.Buffs) != 1 || !pc.HasLight() {
+		t.Errorf("got AC %d and buffs %+v", pc.AC, pc.Buffs)
+	}
+
+	pc.ExpireBuffs(now.Add(time.Minute))
+	if pc.HasLight() {
+		t.Error("the light did not expire")
+	}
+}
+
+func TestMaxMana(t *testing.T) {
+	tests := []struct {
+		intelligence, want int
+	}{
+		{intelligence: 10, want: 10},
+		{intelligence: 18, want: 18},
+		{intelligence: 8, want: 8},
+		{intelligence: -20, want: 1},
+	}
+	for _, test := range tests {
+		if got := maxMana(test.intelligence); got != test.want {
+			t.Errorf("INT %d: got %d, want %d", test.intelligence, got, test.want)
+		}
+	}
+}
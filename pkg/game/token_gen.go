@@ -2,7 +2,6 @@ package game
 
 import (
 	"fmt"
-	"math/rand"
 	"time"
 )
 
@@ -24,8 +23,9 @@ import (
 // ------------Standard values-----------
 
 func create_character() {
+	r := NewTimeRand()
 	tokens := []int{3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 6, 6, 6, 6, 0, 0} // Τα κουπόνια με τις τιμές τους
-	tokens[16] = random(1, 6)                                             // Το πρώτο άδειο κουπόνι, παίρνει τιμή από 1 ως 6
+	tokens[16] = r.Roll(1, 6)                                             // Το πρώτο άδειο κουπόνι, παίρνει τιμή από 1 ως 6
 	tokens[17] = 6 - tokens[16]                                           // Το δεύτερο άδειο κουπόνι, παίρνει ότι περισσέψει
 
 	player := &PC{
@@ -60,7 +60,7 @@ func create_character() {
 		}
 
 		for i := 0; i < 3; i++ { // Αυτή η for, διαλέγει ένα κουπόνι τυχαία από το slice, το αποθηκεύει στο χαρακτηριστικο
-			numb := rand.Intn(len(tokens))     // και μετά το σμπρώχνει στο τέλος του slice. Μετά, επαναπροσδιορίζουμε όλο το
+			numb := r.Intn(len(tokens))        // και μετά το σμπρώχνει στο τέλος του slice. Μετά, επαναπροσδιορίζουμε όλο το
 			time.Sleep(100 * time.Millisecond) // slice χωρίς το τελικό στοιχείο.
 			*attribute += tokens[numb]
 			tokens[numb] = tokens[len(tokens)-1]
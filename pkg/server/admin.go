@@ -0,0 +1,193 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// commandPermissions holds the permission level needed to cause the events
+// not every player is allowed to cause. Builders look after the content of
+// the game and admins after the players.
+var commandPermissions = map[string]int{
+	"goto":      area.PermissionBuilder,
+	"where":     area.PermissionBuilder,
+	"stat":      area.PermissionBuilder,
+	"reload":    area.PermissionBuilder,
+	"flags":     area.PermissionBuilder,
+	"slay":      area.PermissionBuilder,
+	"dig":       area.PermissionBuilder,
+	"setdesc":   area.PermissionBuilder,
+	"savearea":  area.PermissionBuilder,
+	"undo":      area.PermissionBuilder,
+	"redo":      area.PermissionBuilder,
+	"summon":    area.PermissionAdmin,
+	"gag":       area.PermissionAdmin,
+	"note":      area.PermissionAdmin,
+	"notes":     area.PermissionAdmin,
+	"ungag":     area.PermissionAdmin,
+	"ban":       area.PermissionAdmin,
+	"unban":     area.PermissionAdmin,
+	"banlist":   area.PermissionAdmin,
+	"setflag":   area.PermissionAdmin,
+	"clearflag": area.PermissionAdmin,
+	"promote":   area.PermissionAdmin,
+	"demote":    area.PermissionAdmin,
+	"try":       area.PermissionAdmin,
+}
+
+// commandPermission returns the permission level needed to cause the event,
+// as configured or else as it is by default.
+func (s *Server) commandPermission(etype string) int {
+	if level, ok := s.Config.CommandPermissions[etype]; ok {
+		return level
+	}
+	return commandPermissions[etype]
+}
+
+// auditBufferSize is the number of audit records that can be queued before
+// recording admin commands starts blocking.
+const auditBufferSize = 100
+
+// openAuditLog starts recording admin commands in the configured audit log.
+// Records are written in the background so that slow disks do not hold up
+// the game.
+func (s *Server) openAuditLog() error {
+	if s.Config.AuditLog == "" {
+		return nil
+	}
+
+	path := s.StaticPath(s.Config.AuditLog)
+	h, err := log.FileHandler(path, log.LogfmtFormat())
+	if err != nil {
+		return err
+	}
+	s.audit.SetHandler(log.BufferedHandler(auditBufferSize, h))
+	log.Info(fmt.Sprintf("Recording admin commands in %s", path))
+
+	return nil
+}
+
+// planGoto returns the online player given in args the admin would go to,
+// along with the free cube next to the player the admin would end up at,
+// without moving the admin.
+func planGoto(s *Server, c client.Client, args []string) (client.Client, moveTarget, bool, string) {
+	if len(args) != 1 {
+		return client.Client{}, moveTarget{}, false, "Usage: goto <nick>"
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return client.Client{}, moveTarget{}, false, fmt.Sprintf("%s is not online.", args[0])
+	}
+	if target.Player.Nickname == c.Player.Nickname {
+		return client.Client{}, moveTarget{}, false, "You are already there."
+	}
+
+	t := target.Player
+	cube, ok := s.freeCubeNextTo(c, t.Area, t.Room, t.Position)
+	if !ok {
+		return client.Client{}, moveTarget{}, false, fmt.Sprintf("There is no room next to %s.", t.Nickname)
+	}
+	return target, moveTarget{area: t.Area, room: t.Room, position: cube}, true, ""
+}
+
+// doGoto moves the admin next to where the online player given in args is.
+func doGoto(s *Server, c client.Client, args []string) (bool, string) {
+	target, to, ok, msg := planGoto(s, c, args)
+	if !ok {
+		return false, msg
+	}
+
+	s.movePlayer(c.Player, to.area, to.room, to.position)
+	return true, fmt.Sprintf("You go to %s.", target.Player.Nickname)
+}
+
+// planSummon returns the online player given in args the admin would
+// summon, along with the free cube next to the admin the player would end
+// up at, without moving the player.
+func planSummon(s *Server, c client.Client, args []string) (client.Client, moveTarget, bool, string) {
+	if len(args) != 1 {
+		return client.Client{}, moveTarget{}, false, "Usage: summon <nick>"
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return client.Client{}, moveTarget{}, false, fmt.Sprintf("%s is not online.", args[0])
+	}
+	if target.Player.Nickname == c.Player.Nickname {
+		return client.Client{}, moveTarget{}, false, "You cannot summon yourself."
+	}
+
+	p := c.Player
+	cube, ok := s.freeCubeNextTo(target, p.Area, p.Room, p.Position)
+	if !ok {
+		return client.Client{}, moveTarget{}, false, "There is no room next to you."
+	}
+	return target, moveTarget{area: p.Area, room: p.Room, position: cube}, true, ""
+}
+
+// doSummon moves the online player given in args next to where the admin
+// is. It returns the summoned player if the summon succeeded.
+func doSummon(s *Server, c client.Client, args []string) (*client.Client, string) {
+	target, to, ok, msg := planSummon(s, c, args)
+	if !ok {
+		return nil, msg
+	}
+
+	s.movePlayer(target.Player, to.area, to.room, to.position)
+	log.Info(fmt.Sprintf("%s summoned %s to %s/%s/%s", c.Player.Nickname, target.Player.Nickname, to.area, to.room, to.position))
+	return &target, fmt.Sprintf("You summon %s.", target.Player.Nickname)
+}
+
+// doWhere tells the admin where the online player given in args is. Without
+// args it lists all online players grouped by the room they are in.
+func doWhere(s *Server, args []string) string {
+	if len(args) > 1 {
+		return "Usage: where [nick]"
+	}
+
+	if len(args) == 1 {
+		target, ok := s.OnlineClientByNick(args[0])
+		if !ok {
+			return fmt.Sprintf("%s is not online.", args[0])
+		}
+		p := target.Player
+		return fmt.Sprintf("%s is in %s/%s at %s.", p.Nickname, p.Area, p.Room, p.Position)
+	}
+
+	return formatWhere(s.OnlineClients())
+}
+
+// formatWhere lists the given players grouped by the room they are in, one
+// room per line, with the position of every player.
+func formatWhere(online []client.Client) string {
+	if len(online) == 0 {
+		return "Nobody is online."
+	}
+
+	byRoom := make(map[string][]string)
+	for _, c := range online {
+		p := c.Player
+		room := p.Area + "/" + p.Room
+		byRoom[room] = append(byRoom[room], fmt.Sprintf("%s (%s)", p.Nickname, p.Position))
+	}
+
+	rooms := make([]string, 0, len(byRoom))
+	for room := range byRoom {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+
+	lines := make([]string, len(rooms))
+	for i, room := range rooms {
+		sort.Strings(byRoom[room])
+		lines[i] = fmt.Sprintf("%s: %s", room, strings.Join(byRoom[room], ", "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestGoto(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Inn", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+
+	moved, msg := doGoto(s, admin, []string{"bob"})
+	if !moved {
+		t.Fatalf("admin did not move: %s", msg)
+	}
+	// Cube 2 is east of Bob, the first direction tried.
+	if got := place(admin.Player); got != "Town/Square/2" {
+		t.Errorf("admin is at %s, want Town/Square/2", got)
+	}
+}
+
+func TestGotoNoRoom(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Inn", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+	addTestPlayer(t, s, "Carol", "Town", "Square", "2")
+	addTestPlayer(t, s, "Dave", "Town", "Square", "4")
+
+	moved, msg := doGoto(s, admin, []string{"Bob"})
+	if moved {
+		t.Fatal("admin moved with no room next to Bob")
+	}
+	if msg != "There is no room next to Bob." {
+		t.Errorf("got message %q", msg)
+	}
+	if got := place(admin.Player); got != "Town/Inn/1" {
+		t.Errorf("admin is at %s, want Town/Inn/1", got)
+	}
+}
+
+func TestGotoOffline(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Inn", "1")
+
+	if moved, msg := doGoto(s, admin, []string{"Nobody"}); moved || msg != "Nobody is not online." {
+		t.Errorf("got %v, %q", moved, msg)
+	}
+}
+
+func TestSummon(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Inn", "1")
+
+	target, msg := doSummon(s, admin, []string{"bob"})
+	if target == nil {
+		t.Fatalf("Bob was not summoned: %s", msg)
+	}
+	if got := place(bob.Player); got != "Town/Square/2" {
+		t.Errorf("Bob is at %s, want Town/Square/2", got)
+	}
+	if got := s.OnlineClientsGetByRoom("Town", "Square"); len(got) != 2 {
+		t.Errorf("got %d players in the square, want 2", len(got))
+	}
+}
+
+func TestSummonNoRoom(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Inn", "1")
+	addTestPlayer(t, s, "Carol", "Town", "Square", "2")
+	addTestPlayer(t, s, "Dave", "Town", "Square", "4")
+
+	target, msg := doSummon(s, admin, []string{"Bob"})
+	if target != nil {
+		t.Fatal("Bob was summoned with no room next to the admin")
+	}
+	if msg != "There is no room next to you." {
+		t.Errorf("got message %q", msg)
+	}
+	if got := place(bob.Player); got != "Town/Inn/1" {
+		t.Errorf("Bob is at %s, want Town/Inn/1", got)
+	}
+}
+
+func TestSummonSelf(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+
+	if target, msg := doSummon(s, admin, []string{"admin"}); target != nil || msg != "You cannot summon yourself." {
+		t.Errorf("got %v, %q", target, msg)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.AuditLog = "audit.log"
+	if err := s.openAuditLog(); err != nil {
+		t.Fatal(err)
+	}
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	admin.Player.Permission = area.PermissionAdmin
+	player := addTestPlayer(t, s, "Bob", "Town", "Inn", "1")
+
+	s.HandleCommand(player, "look")
+	s.HandleCommand(admin, "summon Bob")
+
+	// Audit records are written in the background.
+	var record string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		content, _ := ioutil.ReadFile(s.StaticPath("audit.log"))
+		if record = string(content); strings.Contains(record, "summon") {
+			break
+		}
+	}
+	for _, want := range []string{"summon", "Admin", "Bob"} {
+		if !strings.Contains(record, want) {
+			t.Errorf("audit log %q does not mention %q", record, want)
+		}
+	}
+	if strings.Contains(record, "look") {
+		t.Errorf("audit log %q records a player command", record)
+	}
+}
+
+func TestWhere(t *testing.T) {
+	s := newLoadedTestServer(t)
+	if got := doWhere(s, nil); got != "Nobody is online." {
+		t.Errorf("got %q", got)
+	}
+
+	addTestPlayer(t, s, "Dave", "Town", "Square", "4")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+	addTestPlayer(t, s, "Carol", "Town", "Inn", "3")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: "Town/Inn: Carol (3)\nTown/Square: Bob (1), Dave (4)"},
+		{args: []string{"carol"}, want: "Carol is in Town/Inn at 3."},
+		{args: []string{"Erin"}, want: "Erin is not online."},
+		{args: []string{"Bob", "Carol"}, want: "Usage: where [nick]"},
+	}
+	for _, test := range tests {
+		if got := doWhere(s, test.args); got != test.want {
+			t.Errorf("where %v: got %q, want %q", test.args, got, test.want)
+		}
+	}
+}
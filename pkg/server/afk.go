@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// doAFK marks the player as away from the keyboard, with the message given
+// in args if any. The mark is cleared by the next command of the player.
+func doAFK(c client.Client, args []string) string {
+	c.Player.AFK = true
+	c.Player.AFKMessage = strings.Join(args, " ")
+	if c.Player.AFKMessage != "" {
+		return fmt.Sprintf("You are now marked as away: %s", c.Player.AFKMessage)
+	}
+	return "You are now marked as away."
+}
+
+// afkNotice returns what players sending a tell to the player are told while
+// the player is away, or nothing if the player is not.
+func afkNotice(c client.Client) string {
+	if !c.Player.AFK {
+		return ""
+	}
+	if c.Player.AFKMessage != "" {
+		return fmt.Sprintf("%s is away: %s", c.Player.Nickname, c.Player.AFKMessage)
+	}
+	return fmt.Sprintf("%s is away.", c.Player.Nickname)
+}
+
+// backMessage tells players whose away mark was cleared.
+const backMessage = "You are no longer marked as away."
+
+// markActive records that the player gave a command at now, clearing the
+// away mark unless the command marks the player as away again. The player is
+// told along with the reply to the command. It returns true if the mark was
+// cleared.
+func markActive(c client.Client, etype string, now time.Time) bool {
+	c.Player.LastActive = now
+	if !c.Player.AFK || etype == "afk" {
+		return false
+	}
+	c.Player.AFK = false
+	c.Player.AFKMessage = ""
+	c.Player.Back = true
+	return true
+}
+
+// markIdleAFK marks the online players who have been idle for the
+// configured number of minutes at now as away.
+func (s *Server) markIdleAFK(now time.Time) {
+	if s.Config.AFKIdleMinutes <= 0 {
+		return
+	}
+	idle := time.Duration(s.Config.AFKIdleMinutes) * time.Minute
+	for _, c := range s.OnlineClients() {
+		if !c.Player.AFK && !c.Player.LastActive.IsZero() && now.Sub(c.Player.LastActive) >= idle {
+			log.Info(fmt.Sprintf("Player %q is idle and marked as away", c.Player.Nickname))
+			c.Player.AFK = true
+		}
+	}
+}
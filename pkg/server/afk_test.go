@@ -0,0 +1,75 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+func TestAFK(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+
+	if got := doAFK(alice, []string{"back", "soon"}); got != "You are now marked as away: back soon" {
+		t.Errorf("afk: got %q", got)
+	}
+	_, msg, _ := doTell(s, bob, []string{"Alice", "hi"})
+	if !strings.HasSuffix(msg, "\nAlice is away: back soon") {
+		t.Errorf("tell: got %q", msg)
+	}
+
+	// Nothing is told once Alice is back.
+	markActive(alice, "look", time.Now())
+	if _, msg, _ := doTell(s, bob, []string{"Alice", "hi"}); strings.Contains(msg, "away") {
+		t.Errorf("tell after coming back: got %q", msg)
+	}
+	if got := doAFK(alice, nil); got != "You are now marked as away." {
+		t.Errorf("afk without a message: got %q", got)
+	}
+	if got := afkNotice(alice); got != "Alice is away." {
+		t.Errorf("got notice %q", got)
+	}
+}
+
+func TestBackOnInput(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.AFKIdleMinutes = 5
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	alice.Player.LastActive = time.Now().Add(-10 * time.Minute)
+	s.markIdleAFK(time.Now())
+	if !alice.Player.AFK {
+		t.Fatal("Alice was not marked as away")
+	}
+
+	// Marking the player as away again keeps the mark.
+	handle(t, s, client.Event{Client: &alice, Etype: "afk", Cmd: "afk"}, alice)
+	if !alice.Player.AFK {
+		t.Fatal("afk cleared the away mark")
+	}
+
+	// Events not typed by the player leave the mark alone.
+	handle(t, s, client.Event{Client: &alice, Etype: "look"}, alice)
+	if !alice.Player.AFK {
+		t.Fatal("an event without a command cleared the away mark")
+	}
+
+	// The next command clears it, and the player is told along with the
+	// reply to the command.
+	reply := handle(t, s, client.Event{Client: &alice, Etype: "who", Cmd: "who"}, alice)[0]
+	if alice.Player.AFK || time.Since(alice.Player.LastActive) > time.Minute {
+		t.Errorf("got away %v, last active at %v", alice.Player.AFK, alice.Player.LastActive)
+	}
+	if !strings.Contains(reply.Events, "1 player online.") || !strings.HasSuffix(reply.Events, "\n"+s.paint(theme.System, backMessage)) {
+		t.Errorf("got reply %q", reply.Events)
+	}
+
+	// Only once.
+	reply = handle(t, s, client.Event{Client: &alice, Etype: "who", Cmd: "who"}, alice)[0]
+	if strings.Contains(reply.Events, backMessage) {
+		t.Errorf("told again: %q", reply.Events)
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// pickAmbient returns the ambient message at pick modulo the number of
+// messages when roll is less than chance, otherwise an empty string.
+func pickAmbient(messages []string, chance, roll float64, pick int) string {
+	if len(messages) == 0 || roll >= chance {
+		return ""
+	}
+	return messages[pick%len(messages)]
+}
+
+// ambientMessages returns the ambient messages of the given room, along with
+// those of its area.
+func (s *Server) ambientMessages(areaName, roomName string) []string {
+	a := s.Areas[areaName]
+	return append(append([]string(nil), a.Ambient...), a.Rooms[roomName].Ambient...)
+}
+
+// godPrintAmbient lets the players in every room with ambient messages read
+// one of them every now and then, depending on the elapsed time. Players who
+// turned ambient messages off are left out.
+func godPrintAmbient(
+	s *Server,
+	elapsed time.Duration,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	chance := changeChance(s.Config.AmbientChance, elapsed)
+	if chance <= 0 {
+		return
+	}
+
+	byRoom := onlineClientsByRoom(s)
+	keys := make([]string, 0, len(byRoom))
+	for key := range byRoom {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var listeners []client.Client
+		for _, c := range byRoom[key] {
+			if c.Player.Settings.Ambient {
+				listeners = append(listeners, c)
+			}
+		}
+		if len(listeners) == 0 {
+			continue
+		}
+
+		p := listeners[0].Player
+		msg := pickAmbient(s.ambientMessages(p.Area, p.Room), chance, s.rand.Float64(), s.rand.Int())
+		if msg == "" {
+			continue
+		}
+		msg = s.paint(theme.System, msg)
+		wg.Add(1)
+		godPrintRoom(s, listeners[0], listeners, wg, quit, roomsMap, msg, msg)
+	}
+}
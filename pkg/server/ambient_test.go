@@ -0,0 +1,80 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPickAmbient(t *testing.T) {
+	messages := []string{"A cold wind blows.", "A dog barks.", "Bells ring."}
+
+	tests := []struct {
+		messages []string
+		roll     float64
+		pick     int
+		want     string
+	}{
+		{messages: nil, roll: 0, want: ""},
+		// Nothing is read unless the roll is under the chance.
+		{messages: messages, roll: 0.5, want: ""},
+		{messages: messages, roll: 0.9, want: ""},
+		// Any of the messages may be picked.
+		{messages: messages, roll: 0.1, pick: 0, want: "A cold wind blows."},
+		{messages: messages, roll: 0.1, pick: 2, want: "Bells ring."},
+		{messages: messages, roll: 0.1, pick: 4, want: "A dog barks."},
+	}
+	for _, test := range tests {
+		if got := pickAmbient(test.messages, 0.5, test.roll, test.pick); got != test.want {
+			t.Errorf("pickAmbient(%v, roll %v, pick %d) = %q, want %q", test.messages, test.roll, test.pick, got, test.want)
+		}
+	}
+}
+
+func TestGodPrintAmbient(t *testing.T) {
+	s := newLoadedTestServer(t)
+	town := s.Areas["Town"]
+	town.Ambient = []string{"A cold wind blows."}
+	square := town.Rooms["Square"]
+	square.Ambient = []string{"A dog barks."}
+	town.Rooms["Square"] = square
+	s.Areas["Town"] = town
+	if got := strings.Join(s.ambientMessages("Town", "Square"), ","); got != "A cold wind blows.,A dog barks." {
+		t.Errorf("got ambient messages %s", got)
+	}
+
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	doAmbient(bob, []string{"off"})
+	roomsMap := createRoomsMap(s)
+
+	// Ambient messages are off unless a chance is configured.
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer close(quit)
+	godPrintAmbient(s, time.Second, &wg, quit, roomsMap)
+	wg.Wait()
+
+	// Only players who did not turn them off read them.
+	s.Config.AmbientChance = 1
+	done := make(chan struct{})
+	go func() {
+		godPrintAmbient(s, time.Second, &wg, quit, roomsMap)
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case reply := <-alice.Reply:
+		if !strings.Contains(reply.Events, "A cold wind blows.") && !strings.Contains(reply.Events, "A dog barks.") {
+			t.Errorf("Alice read %q", reply.Events)
+		}
+	case <-time.After(scriptTimeout):
+		t.Fatal("Alice read nothing")
+	}
+	select {
+	case <-done:
+	case <-time.After(scriptTimeout):
+		t.Fatal("Bob read an ambient message")
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Archive holds the files of many players in one place, for backups and for
+// moving players from one server to another.
+type Archive struct {
+	// Exported is when the archive was made.
+	Exported time.Time `json:"exported"`
+	// Players maps the nicknames of the players to the contents of their
+	// player files.
+	Players map[string]string `json:"players"`
+}
+
+// ExportPlayers writes all the player files found in the static directory to
+// an archive at path. It returns the nicknames exported, sorted, and the
+// players that were skipped because their files could not be read.
+func (s *Server) ExportPlayers(path string) ([]string, []error, error) {
+	files, err := ioutil.ReadDir(s.playerDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, &ReadError{Path: s.playerDir(), Err: err}
+	}
+
+	archive := Archive{Exported: time.Now(), Players: make(map[string]string)}
+	var exported []string
+	var skipped []error
+	// Files written before nicks were looked up regardless of case may
+	// differ from the file of the same player only in case.
+	read := make(map[string]bool)
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), ".toml")
+		if f.IsDir() || filepath.Ext(f.Name()) != ".toml" || !IsValidUsername(name) || read[nickKey(name)] {
+			continue
+		}
+		read[nickKey(name)] = true
+		player, exists, err := s.readPlayer(name)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("player %q skipped: %v", name, err))
+			continue
+		}
+		if !exists {
+			skipped = append(skipped, fmt.Errorf("player %q skipped: player file disappeared", name))
+			continue
+		}
+		data, err := encodePlayer(player)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("player %q skipped: %v", name, err))
+			continue
+		}
+		archive.Players[player.Nickname] = string(data)
+		exported = append(exported, player.Nickname)
+	}
+
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return nil, nil, err
+	}
+
+	sort.Strings(exported)
+	return exported, skipped, nil
+}
+
+// ImportPlayers writes the players in the archive at path to the static
+// directory. Players that are not valid or that already exist are left out.
+// It returns the nicknames imported, sorted, and why the others were not.
+func (s *Server) ImportPlayers(path string) ([]string, []error, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, readFileError(path, err)
+	}
+	var archive Archive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, nil, &ParseError{Path: path, Err: err}
+	}
+
+	nicks := make([]string, 0, len(archive.Players))
+	for nick := range archive.Players {
+		nicks = append(nicks, nick)
+	}
+	sort.Strings(nicks)
+
+	var imported []string
+	var skipped []error
+	seen := make(map[string]bool)
+	for _, nick := range nicks {
+		if problem := s.importProblem(path, nick, archive.Players[nick], seen); problem != nil {
+			skipped = append(skipped, problem)
+			continue
+		}
+		player, _ := decodePlayer(path, []byte(archive.Players[nick]))
+		if !s.savePlayer(player) {
+			skipped = append(skipped, fmt.Errorf("player %q skipped: player file cannot be written", nick))
+			continue
+		}
+		imported = append(imported, nick)
+	}
+
+	return imported, skipped, nil
+}
+
+// importProblem returns why the player with the given nickname and player
+// file contents, from the archive at path, cannot be imported, if it cannot.
+// Nicknames already seen in the same archive, whatever their case, are
+// conflicts too.
+func (s *Server) importProblem(path, nick, content string, seen map[string]bool) error {
+	if !IsValidUsername(nick) {
+		return fmt.Errorf("player %q skipped: not a valid nickname", nick)
+	}
+	if problem := s.nickProblem(nick); problem != "" {
+		return fmt.Errorf("player %q skipped: %s", nick, problem)
+	}
+	if seen[nickKey(nick)] {
+		return fmt.Errorf("player %q skipped: another player in the archive has the same nickname", nick)
+	}
+	seen[nickKey(nick)] = true
+
+	player, err := decodePlayer(path, []byte(content))
+	if err != nil {
+		return fmt.Errorf("player %q skipped: %v", nick, err)
+	}
+	if player.Nickname != nick {
+		return fmt.Errorf("player %q skipped: player file is for %q", nick, player.Nickname)
+	}
+	if _, exists, _ := s.readPlayer(nick); exists {
+		return fmt.Errorf("player %q skipped: already exists", nick)
+	}
+	return nil
+}
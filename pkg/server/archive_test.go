@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// readPlayers returns the players with the given nicknames as read from the
+// player files of the server.
+func readPlayers(t *testing.T, s *Server, nicks []string) []area.Player {
+	t.Helper()
+
+	var players []area.Player
+	for _, nick := range nicks {
+		p, exists, err := s.readPlayer(nick)
+		if !exists || err != nil {
+			t.Fatalf("player %q: exists %v, %v", nick, exists, err)
+		}
+		players = append(players, p)
+	}
+	return players
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	files := map[string]string{"server.toml": testConfig, "areas/town.toml": testArea}
+	from := newTestServer(t, files)
+	if err := from.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	nicks := []string{"Alice", "Bob", "Carol"}
+	for i, nick := range nicks {
+		p := area.Player{
+			Nickname: nick,
+			PC:       *game.NewPC(from.rand),
+			Area:     "Town",
+			Room:     "Inn",
+			Position: "1",
+			Settings: area.DefaultSettings(),
+		}
+		p.Level = i + 1
+		p.Settings.Color = i%2 == 0
+		if !from.savePlayer(p) {
+			t.Fatalf("%s could not be saved", nick)
+		}
+	}
+	want := readPlayers(t, from, nicks)
+
+	archive := filepath.Join(newStaticDir(t, nil), "players.json")
+	exported, _, err := from.ExportPlayers(archive)
+	if err != nil || !reflect.DeepEqual(exported, nicks) {
+		t.Fatalf("exported %v, %v", exported, err)
+	}
+
+	to := newTestServer(t, files)
+	if err := to.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	imported, skipped, err := to.ImportPlayers(archive)
+	if err != nil || len(skipped) != 0 || !reflect.DeepEqual(imported, nicks) {
+		t.Fatalf("imported %v, skipped %v, %v", imported, skipped, err)
+	}
+	if got := readPlayers(t, to, nicks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Importing again changes nothing.
+	imported, skipped, err = to.ImportPlayers(archive)
+	if err != nil || len(imported) != 0 || len(skipped) != len(nicks) {
+		t.Errorf("imported %v, skipped %v, %v", imported, skipped, err)
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":      testConfig + "reservedNicks = [\"Admin\"]\n",
+		"player/dave.toml": "Nickname = \"Dave\"\n",
+	})
+	playerFile := func(nick string) string {
+		data, err := encodePlayer(area.Player{Nickname: nick, Settings: area.DefaultSettings()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	data, err := json.Marshal(Archive{Players: map[string]string{
+		"Admin":    playerFile("Admin"),
+		"Alice":    playerFile("Alice"),
+		"alice":    playerFile("alice"),
+		"Bob":      playerFile("Carol"),
+		"Dave":     playerFile("Dave"),
+		"Eve":      "Nickname = ",
+		"bad nick": playerFile("bad nick"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(newStaticDir(t, nil), "players.json")
+	if err := ioutil.WriteFile(archive, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	imported, skipped, err := s.ImportPlayers(archive)
+	if err != nil || !reflect.DeepEqual(imported, []string{"Alice"}) {
+		t.Fatalf("imported %v, %v", imported, err)
+	}
+	var reasons []string
+	for _, problem := range skipped {
+		reasons = append(reasons, problem.Error())
+	}
+	for _, want := range []string{
+		`player "Admin" skipped: Nick Admin is reserved.`,
+		`player "alice" skipped: another player in the archive has the same nickname`,
+		`player "Bob" skipped: player file is for "Carol"`,
+		`player "Dave" skipped: already exists`,
+		`player "Eve" skipped: `,
+		`player "bad nick" skipped: not a valid nickname`,
+	} {
+		found := false
+		for _, reason := range reasons {
+			found = found || strings.HasPrefix(reason, want)
+		}
+		if !found {
+			t.Errorf("no %q in %q", want, reasons)
+		}
+	}
+	if len(skipped) != 6 {
+		t.Errorf("skipped %d players, want 6", len(skipped))
+	}
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// gridPos is the place of a room on the map of an area.
+type gridPos struct {
+	x, y int
+}
+
+// doAreaMap draws how the rooms of the area the player is in connect to
+// each other.
+func doAreaMap(s *Server, c client.Client) string {
+	a, ok := s.Areas[c.Player.Area]
+	if !ok {
+		return "There is no map of this place."
+	}
+	return formatAreaMap(a, c.Player.Area, c.Player.Room)
+}
+
+// exitStep returns the step on the area map taken through an exit on the
+// given cube, judging from which side of the room the cube is on. Cubes in
+// the middle of the room lead east.
+func exitStep(room area.Room, cube area.Cube) gridPos {
+	maxX, maxY := 0, 0
+	for _, c := range room.Cubes {
+		x, _ := strconv.Atoi(c.POSX)
+		y, _ := strconv.Atoi(c.POSY)
+		if x > maxX {
+			maxX = x
+		}
+		if y > maxY {
+			maxY = y
+		}
+	}
+
+	x, _ := strconv.Atoi(cube.POSX)
+	y, _ := strconv.Atoi(cube.POSY)
+	switch {
+	case y == 0 && maxY > 0:
+		return gridPos{0, -1}
+	case y == maxY && maxY > 0:
+		return gridPos{0, 1}
+	case x == 0 && maxX > 0:
+		return gridPos{-1, 0}
+	}
+	return gridPos{1, 0}
+}
+
+// roomLinks returns the rooms of the same area the room has exits to, along
+// with the step taken through each of them, sorted by room name.
+func roomLinks(areaName string, room area.Room) ([]string, map[string]gridPos) {
+	steps := map[string]gridPos{}
+	for _, cube := range room.Cubes {
+		for _, exit := range cube.Exits {
+			if exit.ToArea != areaName {
+				continue
+			}
+			if _, ok := steps[exit.ToRoom]; !ok {
+				steps[exit.ToRoom] = exitStep(room, cube)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(steps))
+	for name := range steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, steps
+}
+
+// layoutArea places the rooms of the area on a grid, starting from the given
+// room and following the exits. Rooms whose place is already taken, because
+// the exits do not form a clean grid, go to the nearest free place. Rooms
+// that cannot be reached from the start are placed next to the rest.
+func layoutArea(a area.Area, areaName, start string) map[string]gridPos {
+	places := map[string]gridPos{}
+	taken := map[gridPos]bool{}
+	place := func(name string, want gridPos) {
+		pos := nearestFree(taken, want)
+		places[name] = pos
+		taken[pos] = true
+	}
+
+	starts := []string{start}
+	starts = append(starts, sortedRoomNames(a.Rooms)...)
+	for _, first := range starts {
+		if _, ok := a.Rooms[first]; !ok {
+			continue
+		}
+		if _, ok := places[first]; ok {
+			continue
+		}
+		if len(places) == 0 {
+			place(first, gridPos{0, 0})
+		} else {
+			place(first, gridPos{maxPos(places).x + 2, 0})
+		}
+
+		queue := []string{first}
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			names, steps := roomLinks(areaName, a.Rooms[name])
+			for _, next := range names {
+				if _, ok := a.Rooms[next]; !ok {
+					continue
+				}
+				if _, ok := places[next]; ok {
+					continue
+				}
+				from, step := places[name], steps[next]
+				place(next, gridPos{from.x + step.x, from.y + step.y})
+				queue = append(queue, next)
+			}
+		}
+	}
+	return places
+}
+
+// nearestFree returns want if it is free, otherwise the closest free place
+// around it.
+func nearestFree(taken map[gridPos]bool, want gridPos) gridPos {
+	for r := 0; ; r++ {
+		for dy := -r; dy <= r; dy++ {
+			for dx := -r; dx <= r; dx++ {
+				if abs(dx) != r && abs(dy) != r {
+					continue
+				}
+				pos := gridPos{want.x + dx, want.y + dy}
+				if !taken[pos] {
+					return pos
+				}
+			}
+		}
+	}
+}
+
+// maxPos returns the bottom right corner of the given places.
+func maxPos(places map[string]gridPos) gridPos {
+	first := true
+	var max gridPos
+	for _, pos := range places {
+		if first || pos.x > max.x {
+			max.x = pos.x
+		}
+		if first || pos.y > max.y {
+			max.y = pos.y
+		}
+		first = false
+	}
+	return max
+}
+
+// minPos returns the top left corner of the given places.
+func minPos(places map[string]gridPos) gridPos {
+	first := true
+	var min gridPos
+	for _, pos := range places {
+		if first || pos.x < min.x {
+			min.x = pos.x
+		}
+		if first || pos.y < min.y {
+			min.y = pos.y
+		}
+		first = false
+	}
+	return min
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// formatAreaMap draws the rooms of the area on a grid, joining rooms next to
+// each other that are connected with - and |. The current room is marked
+// with *. Connections between rooms that are not next to each other on the
+// grid, and exits to other areas, are listed below the map.
+func formatAreaMap(a area.Area, areaName, current string) string {
+	places := layoutArea(a, areaName, current)
+	if len(places) == 0 {
+		return "There is no map of this place."
+	}
+
+	at := map[gridPos]string{}
+	width := 0
+	for name, pos := range places {
+		at[pos] = name
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	width += 2
+
+	linked := func(from, to string) bool {
+		names, _ := roomLinks(areaName, a.Rooms[from])
+		for _, name := range names {
+			if name == to {
+				return true
+			}
+		}
+		return false
+	}
+	connected := func(x, y string) bool {
+		return x != "" && y != "" && (linked(x, y) || linked(y, x))
+	}
+
+	min, max := minPos(places), maxPos(places)
+	var lines []string
+	for y := min.y; y <= max.y; y++ {
+		var row, below strings.Builder
+		for x := min.x; x <= max.x; x++ {
+			name := at[gridPos{x, y}]
+			cell := ""
+			switch {
+			case name == current:
+				cell = "*" + name + "*"
+			case name != "":
+				cell = "[" + name + "]"
+			}
+			row.WriteString(fmt.Sprintf("%-*s", width, cell))
+			if connected(name, at[gridPos{x + 1, y}]) {
+				row.WriteString(" - ")
+			} else {
+				row.WriteString("   ")
+			}
+
+			link := ""
+			if connected(name, at[gridPos{x, y + 1}]) {
+				link = strings.Repeat(" ", width/2) + "|"
+			}
+			below.WriteString(fmt.Sprintf("%-*s   ", width, link))
+		}
+		lines = append(lines, strings.TrimRight(row.String(), " "))
+		if y < max.y {
+			lines = append(lines, strings.TrimRight(below.String(), " "))
+		}
+	}
+
+	var others []string
+	for _, name := range sortedRoomNames(a.Rooms) {
+		names, _ := roomLinks(areaName, a.Rooms[name])
+		for _, next := range names {
+			from, to := places[name], places[next]
+			if name < next && abs(from.x-to.x)+abs(from.y-to.y) > 1 {
+				others = append(others, fmt.Sprintf("%s - %s", name, next))
+			}
+		}
+		for _, cube := range a.Rooms[name].Cubes {
+			for _, exit := range cube.Exits {
+				if exit.ToArea != areaName {
+					others = append(others, fmt.Sprintf("%s -> %s/%s", name, exit.ToArea, exit.ToRoom))
+				}
+			}
+		}
+	}
+	if len(others) > 0 {
+		lines = append(lines, "", "Also: "+strings.Join(others, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
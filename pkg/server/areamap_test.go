@@ -0,0 +1,101 @@
+package server
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// mapRoom returns a room of three by three cubes with a door on the given
+// side, or in the middle, to each of the given rooms of the area Keep.
+func mapRoom(name string, doors map[string]string) area.Room {
+	sides := map[string][2]int{"north": {1, 0}, "south": {1, 2}, "west": {0, 1}, "east": {2, 1}, "middle": {1, 1}}
+	room := area.Room{Name: name}
+	id := 0
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			id++
+			cube := area.Cube{ID: strconv.Itoa(id), POSX: strconv.Itoa(x), POSY: strconv.Itoa(y)}
+			for side, to := range doors {
+				if sides[side] == [2]int{x, y} {
+					cube.Type = "door"
+					cube.Exits = []area.Exit{{ToArea: "Keep", ToRoom: to, ToCubeID: "5"}}
+				}
+			}
+			room.Cubes = append(room.Cubes, cube)
+		}
+	}
+	return room
+}
+
+func TestLayoutArea(t *testing.T) {
+	tests := []struct {
+		name  string
+		rooms []area.Room
+		want  map[string]gridPos
+	}{
+		{
+			name: "clean grid",
+			rooms: []area.Room{
+				mapRoom("Hall", map[string]string{"north": "Garden", "south": "Cellar", "east": "Kitchen"}),
+				mapRoom("Garden", map[string]string{"south": "Hall"}),
+				mapRoom("Cellar", map[string]string{"north": "Hall"}),
+				mapRoom("Kitchen", map[string]string{"west": "Hall"}),
+			},
+			want: map[string]gridPos{"Hall": {0, 0}, "Garden": {0, -1}, "Cellar": {0, 1}, "Kitchen": {1, 0}},
+		},
+		{
+			// Doors in the middle of a room lead east, so both the Kitchen
+			// and the Pantry are east of the Hall.
+			name: "rooms on the same place",
+			rooms: []area.Room{
+				mapRoom("Hall", map[string]string{"east": "Kitchen", "middle": "Pantry"}),
+				mapRoom("Kitchen", map[string]string{"west": "Hall"}),
+				mapRoom("Pantry", nil),
+			},
+			want: map[string]gridPos{"Hall": {0, 0}, "Kitchen": {1, 0}, "Pantry": {0, -1}},
+		},
+		{
+			name: "unreachable room",
+			rooms: []area.Room{
+				mapRoom("Hall", map[string]string{"east": "Kitchen"}),
+				mapRoom("Kitchen", map[string]string{"west": "Hall"}),
+				mapRoom("Tower", nil),
+			},
+			want: map[string]gridPos{"Hall": {0, 0}, "Kitchen": {1, 0}, "Tower": {3, 0}},
+		},
+	}
+
+	for _, test := range tests {
+		a := area.Area{Name: "Keep", Rooms: map[string]area.Room{}}
+		for _, room := range test.rooms {
+			a.Rooms[room.Name] = room
+		}
+		if got := layoutArea(a, "Keep", "Hall"); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFormatAreaMap(t *testing.T) {
+	a := area.Area{Name: "Keep", Rooms: map[string]area.Room{
+		"Hall":    mapRoom("Hall", map[string]string{"south": "Cellar", "east": "Kitchen"}),
+		"Cellar":  mapRoom("Cellar", map[string]string{"north": "Hall"}),
+		"Kitchen": mapRoom("Kitchen", map[string]string{"west": "Hall"}),
+	}}
+	a.Rooms["Kitchen"].Cubes[0].Exits = []area.Exit{{ToArea: "Town", ToRoom: "Square", ToCubeID: "1"}}
+
+	want := strings.Join([]string{
+		"*Hall*    - [Kitchen]",
+		"    |",
+		"[Cellar]",
+		"",
+		"Also: Kitchen -> Town/Square",
+	}, "\n")
+	if got := formatAreaMap(a, "Keep", "Hall"); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
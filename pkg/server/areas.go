@@ -0,0 +1,52 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// doAreas lists the areas the player can discover, with the number of rooms
+// and the level needed to enter each of them. Hidden areas are only listed
+// to admins. Areas the player cannot enter are marked as locked, or left out
+// if the server is configured to hide them.
+func doAreas(s *Server, c client.Client) string {
+	table := theme.NewTable("Area", "Rooms", "Level", "Access")
+	table.Theme = s.Config.Theme
+	listed := 0
+	for _, name := range sortedAreaNames(s.Areas) {
+		a := s.Areas[name]
+		access, ok := areaAccess(a, c.Player)
+		if !ok || (access == "locked" && s.Config.HideLockedAreas) {
+			continue
+		}
+
+		level := "-"
+		if a.Access.MinLevel > 0 {
+			level = strconv.Itoa(a.Access.MinLevel) + "+"
+		}
+		table.AddRow(name, strconv.Itoa(len(a.Rooms)), level, access)
+		listed++
+	}
+
+	if listed == 0 {
+		return "There are no areas to explore."
+	}
+	return fmt.Sprintf("%s\n%s to explore.", table.Render(c.Player.Settings.Color), plural(listed, "area"))
+}
+
+// areaAccess returns whether the player can enter the area, which is "open",
+// "locked" or, for admins, "hidden". It returns false if the area is hidden
+// from the player.
+func areaAccess(a area.Area, p *area.Player) (string, bool) {
+	if a.Hidden {
+		return "hidden", p.IsAdmin()
+	}
+	if ok, _ := a.Access.Allows(p); !ok {
+		return "locked", true
+	}
+	return "open", true
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestAreaAccess(t *testing.T) {
+	crypt := area.Area{Name: "Crypt", Access: area.Access{MinLevel: 5}}
+	temple := area.Area{Name: "Temple", Access: area.Access{Requires: map[string]string{"blessed": "yes"}}}
+	vault := area.Area{Name: "Vault", Hidden: true}
+
+	novice := &area.Player{Nickname: "Alice"}
+	novice.Level = 1
+	veteran := &area.Player{Nickname: "Bob", Flags: map[string]string{"blessed": "yes"}}
+	veteran.Level = 5
+	admin := &area.Player{Nickname: "Carol", Admin: true}
+	admin.Level = 1
+
+	tests := []struct {
+		a      area.Area
+		p      *area.Player
+		access string
+		listed bool
+	}{
+		{a: crypt, p: novice, access: "locked", listed: true},
+		{a: crypt, p: veteran, access: "open", listed: true},
+		{a: temple, p: novice, access: "locked", listed: true},
+		{a: temple, p: veteran, access: "open", listed: true},
+		{a: vault, p: veteran, access: "hidden", listed: false},
+		{a: vault, p: admin, access: "hidden", listed: true},
+	}
+	for _, test := range tests {
+		access, listed := areaAccess(test.a, test.p)
+		if access != test.access || listed != test.listed {
+			t.Errorf("%s for %s: got %s, %v, want %s, %v", test.a.Name, test.p.Nickname, access, listed, test.access, test.listed)
+		}
+	}
+}
+
+func TestDoAreas(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Areas["Crypt"] = area.Area{Name: "Crypt", Access: area.Access{MinLevel: 5}}
+	s.Areas["Vault"] = area.Area{Name: "Vault", Hidden: true}
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	got := doAreas(s, c)
+	for _, want := range []string{"Town", "open", "Crypt", "5+", "locked", "2 areas to explore."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got\n%s\nwant it to hold %q", got, want)
+		}
+	}
+	if strings.Contains(got, "Vault") {
+		t.Errorf("got\n%s\nwhich lists a hidden area", got)
+	}
+
+	s.Config.HideLockedAreas = true
+	got = doAreas(s, c)
+	if strings.Contains(got, "Crypt") || !strings.Contains(got, "1 area to explore.") {
+		t.Errorf("with locked areas hidden got\n%s", got)
+	}
+
+	c.Player.Admin = true
+	got = doAreas(s, c)
+	if !strings.Contains(got, "Vault") || !strings.Contains(got, "hidden") {
+		t.Errorf("admins got\n%s", got)
+	}
+
+	s.Areas = map[string]area.Area{"Crypt": s.Areas["Crypt"]}
+	c.Player.Admin = false
+	if got := doAreas(s, c); got != "There are no areas to explore." {
+		t.Errorf("with every area locked and hidden got %q", got)
+	}
+}
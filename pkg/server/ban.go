@@ -0,0 +1,227 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gothyra/toml"
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// Ban keeps a player or an IP address out of the server.
+type Ban struct {
+	// Target is either a nickname or an IP address.
+	Target string `toml:"target"`
+	// Until is when the ban expires. Bans with a zero Until never expire.
+	Until time.Time `toml:"until"`
+}
+
+// banFile is the layout of banlist.toml.
+type banFile struct {
+	Bans []Ban `toml:"bans"`
+}
+
+// banList holds all the bans of the server. It is safe for concurrent use.
+type banList struct {
+	sync.RWMutex
+	bans map[string]Ban
+	path string
+}
+
+func newBanList(path string) *banList {
+	return &banList{
+		bans: make(map[string]Ban),
+		path: path,
+	}
+}
+
+// isBanned returns true if the given nickname or IP address is banned at the
+// given time.
+func (b *banList) isBanned(target string, now time.Time) bool {
+	b.RLock()
+	defer b.RUnlock()
+
+	ban, ok := b.bans[nickKey(target)]
+	return ok && !ban.expired(now)
+}
+
+func (ban Ban) expired(now time.Time) bool {
+	return !ban.Until.IsZero() && !now.Before(ban.Until)
+}
+
+// add bans the target and saves the ban list.
+func (b *banList) add(ban Ban) error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.bans[nickKey(ban.Target)] = ban
+	return b.save()
+}
+
+// remove lifts the ban on target and saves the ban list. It returns false
+// if target was not banned.
+func (b *banList) remove(target string) (bool, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if _, ok := b.bans[nickKey(target)]; !ok {
+		return false, nil
+	}
+	delete(b.bans, nickKey(target))
+	return true, b.save()
+}
+
+// list returns all the bans that have not expired yet, sorted by target.
+func (b *banList) list(now time.Time) []Ban {
+	b.RLock()
+	defer b.RUnlock()
+
+	bans := []Ban{}
+	for _, ban := range b.bans {
+		if !ban.expired(now) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Target < bans[j].Target })
+	return bans
+}
+
+// load reads the ban list from disk. A missing file means nobody is banned.
+// Bans that have already expired are dropped.
+func (b *banList) load() error {
+	b.Lock()
+	defer b.Unlock()
+
+	fileContent, err := ioutil.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return &ReadError{Path: b.path, Err: err}
+	}
+
+	file := banFile{}
+	if _, err := toml.Decode(string(fileContent), &file); err != nil {
+		return &ParseError{Path: b.path, Err: err}
+	}
+
+	now := time.Now()
+	for _, ban := range file.Bans {
+		if !ban.expired(now) {
+			b.bans[nickKey(ban.Target)] = ban
+		}
+	}
+	return nil
+}
+
+// save writes the ban list to disk. The caller must hold the lock.
+func (b *banList) save() error {
+	file := banFile{}
+	for _, ban := range b.bans {
+		file.Bans = append(file.Bans, ban)
+	}
+
+	data := &bytes.Buffer{}
+	if err := toml.NewEncoder(data).Encode(file); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(b.path, data.Bytes(), 0644)
+}
+
+// loadBans loads the ban list kept in the static directory.
+func (s *Server) loadBans() error {
+	s.bans = newBanList(s.resolvePath(s.Config.Paths.Bans, defaultPaths.Bans))
+	return s.bans.load()
+}
+
+// remoteIP returns the IP address the given connection comes from.
+func remoteIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
+
+// doBan bans the nickname or IP address given in args, optionally for a
+// limited time. It returns the online players affected by the ban.
+func doBan(s *Server, c client.Client, args []string) ([]client.Client, string) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, "Usage: ban <nick|ip> [duration]"
+	}
+
+	ban := Ban{Target: args[0]}
+	if net.ParseIP(ban.Target) == nil && !IsValidUsername(ban.Target) {
+		return nil, fmt.Sprintf("%s is neither a nickname nor an IP address.", ban.Target)
+	}
+	if len(args) == 2 {
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d <= 0 {
+			return nil, fmt.Sprintf("Invalid duration %q, try something like 30m or 24h.", args[1])
+		}
+		ban.Until = time.Now().Add(d)
+	}
+
+	if err := s.bans.add(ban); err != nil {
+		log.Error(fmt.Sprintf("Ban list could not be saved: %v", err))
+		return nil, "The ban could not be saved."
+	}
+	log.Info(fmt.Sprintf("%s banned %s", c.Player.Nickname, ban.Target))
+
+	var banned []client.Client
+	for _, online := range s.OnlineClients() {
+		if strings.EqualFold(online.Player.Nickname, ban.Target) || remoteIP(online.Conn) == ban.Target {
+			banned = append(banned, online)
+		}
+	}
+
+	if ban.Until.IsZero() {
+		return banned, fmt.Sprintf("%s is banned.", ban.Target)
+	}
+	return banned, fmt.Sprintf("%s is banned until %s.", ban.Target, ban.Until.Format("2006-01-02 15:04"))
+}
+
+// doUnban lifts the ban on the nickname or IP address given in args.
+func doUnban(s *Server, c client.Client, args []string) string {
+	if len(args) != 1 {
+		return "Usage: unban <nick|ip>"
+	}
+
+	ok, err := s.bans.remove(args[0])
+	if err != nil {
+		log.Error(fmt.Sprintf("Ban list could not be saved: %v", err))
+		return "The ban list could not be saved."
+	}
+	if !ok {
+		return fmt.Sprintf("%s is not banned.", args[0])
+	}
+	log.Info(fmt.Sprintf("%s unbanned %s", c.Player.Nickname, args[0]))
+	return fmt.Sprintf("%s is no longer banned.", args[0])
+}
+
+// doBanList describes all the bans in effect.
+func doBanList(s *Server) string {
+	bans := s.bans.list(time.Now())
+	if len(bans) == 0 {
+		return "Nobody is banned."
+	}
+
+	lines := []string{"Banned:"}
+	for _, ban := range bans {
+		if ban.Until.IsZero() {
+			lines = append(lines, fmt.Sprintf("  %s", ban.Target))
+		} else {
+			lines = append(lines, fmt.Sprintf("  %s until %s", ban.Target, ban.Until.Format("2006-01-02 15:04")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+func TestIsBanned(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	b := newBanList(filepath.Join(t.TempDir(), "banlist.toml"))
+	for _, ban := range []Ban{
+		{Target: "Mallory"},
+		{Target: "10.0.0.1"},
+		{Target: "Eve", Until: now.Add(time.Hour)},
+	} {
+		if err := b.add(ban); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		target string
+		at     time.Time
+		want   bool
+	}{
+		{target: "Mallory", at: now, want: true},
+		{target: "mallory", at: now, want: true},
+		{target: "10.0.0.1", at: now, want: true},
+		{target: "10.0.0.2", at: now, want: false},
+		{target: "Bob", at: now, want: false},
+		{target: "Eve", at: now, want: true},
+		{target: "Eve", at: now.Add(time.Hour - time.Nanosecond), want: true},
+		{target: "Eve", at: now.Add(time.Hour), want: false},
+	}
+	for _, test := range tests {
+		if got := b.isBanned(test.target, test.at); got != test.want {
+			t.Errorf("isBanned(%q, %v) = %v, want %v", test.target, test.at, got, test.want)
+		}
+	}
+}
+
+func TestBanListPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "banlist.toml")
+	b := newBanList(path)
+	if err := b.add(Ban{Target: "Mallory"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.add(Ban{Target: "Eve", Until: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := newBanList(path)
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.isBanned("Mallory", time.Now()) {
+		t.Error("Mallory is no longer banned")
+	}
+	if len(loaded.bans) != 1 {
+		t.Errorf("got bans %v, want the expired one dropped", loaded.bans)
+	}
+
+	if ok, err := loaded.remove("mallory"); !ok || err != nil {
+		t.Fatalf("remove = %v, %v", ok, err)
+	}
+	reloaded := newBanList(path)
+	if err := reloaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.isBanned("Mallory", time.Now()) {
+		t.Error("Mallory is still banned after the ban was lifted")
+	}
+}
+
+func TestBanDoesNotBlockGod(t *testing.T) {
+	s := newLoadedTestServer(t)
+	if err := s.loadBans(); err != nil {
+		t.Fatal(err)
+	}
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	admin.Player.Permission = area.PermissionAdmin
+
+	// Nobody reads what Mallory is sent.
+	conn, other := net.Pipe()
+	defer other.Close()
+	player := &area.Player{Nickname: "Mallory", Area: "Town", Room: "Inn", Position: "1", Settings: area.DefaultSettings()}
+	s.clientLoggedIn("Mallory", *client.NewClient(conn, player, nil))
+	mallory, _ := s.OnlineClientByNick("Mallory")
+
+	wg := &sync.WaitGroup{}
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		onBan(s, client.Event{Client: &admin, Etype: "ban", Args: []string{"Mallory"}}, wg, quit, createRoomsMap(s))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("banning a player who does not read blocked")
+	}
+	if _, ok := s.OnlineClientByNick("Mallory"); ok {
+		t.Error("Mallory is still online")
+	}
+
+	buf := make([]byte, 64)
+	n, _ := other.Read(buf)
+	if got := string(buf[:n]); got != "You have been banned from this server.\n" {
+		t.Errorf("Mallory was told %q", got)
+	}
+	select {
+	case <-mallory.Done():
+	case <-time.After(5 * time.Second):
+		t.Error("the connection of Mallory was not closed")
+	}
+	close(quit)
+	wg.Wait()
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// defaultBankSize is the number of items players can keep in the bank when
+// none is configured.
+const defaultBankSize = 50
+
+// bankerIn returns the name of the banker in the given room, if there is one.
+func (s *Server) bankerIn(areaName, roomName string) (string, bool) {
+	for _, npc := range s.Areas[areaName].Rooms[roomName].NPCs {
+		if npc.Banker {
+			return npc.Name, true
+		}
+	}
+	return "", false
+}
+
+// parseGoldArgs returns how much gold args refer to, eg. "100 gold", or all
+// of it for "gold" and "all gold", which is negative. It returns false if
+// args do not refer to gold.
+func parseGoldArgs(args []string) (int, bool, error) {
+	switch {
+	case len(args) == 1 && args[0] == "gold":
+		return -1, true, nil
+	case len(args) != 2 || args[1] != "gold":
+		return 0, false, nil
+	case args[0] == "all":
+		return -1, true, nil
+	}
+	amount, err := strconv.Atoi(args[0])
+	if err != nil || amount < 1 {
+		return 0, true, fmt.Errorf("%q is not an amount of gold", args[0])
+	}
+	return amount, true, nil
+}
+
+// bankTransfer is what depositing or withdrawing would change. Either gold
+// or items are moved.
+type bankTransfer struct {
+	banker string
+	gold   int
+	items  []game.Item
+	// inventory and bank hold what the player would carry and keep in the
+	// bank when items are moved.
+	inventory []game.Item
+	bank      []game.Item
+}
+
+// describe describes what the transfer moves.
+func (t bankTransfer) describe() string {
+	if len(t.items) == 0 {
+		return fmt.Sprintf("%d gold", t.gold)
+	}
+	return strings.Join(itemNames(t.items), ", ")
+}
+
+// planDeposit works out what depositing the gold or items given in args
+// would change, or why they cannot be deposited.
+func planDeposit(s *Server, c client.Client, args []string) (bankTransfer, bool, string) {
+	banker, ok := s.bankerIn(c.Player.Area, c.Player.Room)
+	if !ok {
+		return bankTransfer{}, false, "There is no banker here."
+	}
+
+	amount, isGold, err := parseGoldArgs(args)
+	if err != nil {
+		return bankTransfer{}, false, err.Error()
+	}
+	if isGold {
+		if amount < 0 {
+			amount = c.Player.Gold
+		}
+		if amount == 0 || amount > c.Player.Gold {
+			return bankTransfer{}, false, fmt.Sprintf("You only have %d gold.", c.Player.Gold)
+		}
+		return bankTransfer{banker: banker, gold: amount}, true, ""
+	}
+
+	name, count, ok := parseItemArgs(args)
+	if !ok || name == "" {
+		return bankTransfer{}, false, "Usage: deposit [all|<amount>] gold | deposit [all|<count>] <item>"
+	}
+	inventory, deposited := game.TakeItems(c.Player.Inventory, name, count)
+	if len(deposited) == 0 {
+		return bankTransfer{}, false, fmt.Sprintf("You are not carrying any %s.", name)
+	}
+	items := c.Player.Bank.Items
+	for _, item := range deposited {
+		items = game.AddItem(items, item)
+	}
+	if len(items) > len(c.Player.Bank.Items) && len(items) > limit(s.Config.BankSize, defaultBankSize) {
+		return bankTransfer{}, false, "There is no room left in your bank."
+	}
+	return bankTransfer{banker: banker, items: deposited, inventory: inventory, bank: items}, true, ""
+}
+
+// doDeposit leaves the gold or items given in args with the banker in the
+// room of the player, eg. "deposit 100 gold", "deposit sword" or "deposit
+// all arrows".
+func doDeposit(s *Server, c client.Client, args []string) string {
+	plan, ok, msg := planDeposit(s, c, args)
+	if !ok {
+		return msg
+	}
+
+	if len(plan.items) == 0 {
+		c.Player.Gold -= plan.gold
+		c.Player.Bank.Gold += plan.gold
+	} else {
+		c.Player.Inventory = plan.inventory
+		c.Player.Bank.Items = plan.bank
+	}
+	return fmt.Sprintf("You deposit %s with %s.", plan.describe(), plan.banker)
+}
+
+// planWithdraw works out what withdrawing the gold or items given in args
+// would change, or why they cannot be withdrawn.
+func planWithdraw(s *Server, c client.Client, args []string) (bankTransfer, bool, string) {
+	banker, ok := s.bankerIn(c.Player.Area, c.Player.Room)
+	if !ok {
+		return bankTransfer{}, false, "There is no banker here."
+	}
+
+	amount, isGold, err := parseGoldArgs(args)
+	if err != nil {
+		return bankTransfer{}, false, err.Error()
+	}
+	if isGold {
+		if amount < 0 {
+			amount = c.Player.Bank.Gold
+		}
+		if amount == 0 || amount > c.Player.Bank.Gold {
+			return bankTransfer{}, false, fmt.Sprintf("You only have %d gold in the bank.", c.Player.Bank.Gold)
+		}
+		return bankTransfer{banker: banker, gold: amount}, true, ""
+	}
+
+	name, count, ok := parseItemArgs(args)
+	if !ok || name == "" {
+		return bankTransfer{}, false, "Usage: withdraw [all|<amount>] gold | withdraw [all|<count>] <item>"
+	}
+	items, withdrawn := game.TakeItems(c.Player.Bank.Items, name, count)
+	if len(withdrawn) == 0 {
+		return bankTransfer{}, false, fmt.Sprintf("You have no %s in the bank.", name)
+	}
+	if !s.canCarry(c.Player, withdrawn) {
+		return bankTransfer{}, false, "You cannot carry that much."
+	}
+	inventory := c.Player.Inventory
+	for _, item := range withdrawn {
+		inventory = game.AddItem(inventory, item)
+	}
+	return bankTransfer{banker: banker, items: withdrawn, inventory: inventory, bank: items}, true, ""
+}
+
+// doWithdraw takes the gold or items given in args back from the banker in
+// the room of the player, eg. "withdraw 100 gold" or "withdraw 5 arrows".
+func doWithdraw(s *Server, c client.Client, args []string) string {
+	plan, ok, msg := planWithdraw(s, c, args)
+	if !ok {
+		return msg
+	}
+
+	if len(plan.items) == 0 {
+		c.Player.Bank.Gold -= plan.gold
+		c.Player.Gold += plan.gold
+	} else {
+		c.Player.Inventory = plan.inventory
+		c.Player.Bank.Items = plan.bank
+	}
+	return fmt.Sprintf("You withdraw %s from %s.", plan.describe(), plan.banker)
+}
+
+// doBalance shows what the player keeps in the bank.
+func doBalance(s *Server, c client.Client) string {
+	if _, ok := s.bankerIn(c.Player.Area, c.Player.Room); !ok {
+		return "There is no banker here."
+	}
+	return strings.Join([]string{
+		fmt.Sprintf("You have %d gold in the bank.", c.Player.Bank.Gold),
+		fmt.Sprintf("You keep: %s (%d/%d)", listOrNone(itemNames(c.Player.Bank.Items)), len(c.Player.Bank.Items), limit(s.Config.BankSize, defaultBankSize)),
+	}, "\n")
+}
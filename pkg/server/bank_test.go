@@ -0,0 +1,141 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+func TestParseGoldArgs(t *testing.T) {
+	tests := []struct {
+		args   []string
+		amount int
+		isGold bool
+		err    bool
+	}{
+		{args: []string{"gold"}, amount: -1, isGold: true},
+		{args: []string{"all", "gold"}, amount: -1, isGold: true},
+		{args: []string{"100", "gold"}, amount: 100, isGold: true},
+		{args: []string{"0", "gold"}, isGold: true, err: true},
+		{args: []string{"lots", "gold"}, isGold: true, err: true},
+		{args: []string{"sword"}},
+		{args: []string{"3", "arrows"}},
+	}
+	for _, test := range tests {
+		amount, isGold, err := parseGoldArgs(test.args)
+		if amount != test.amount || isGold != test.isGold || (err != nil) != test.err {
+			t.Errorf("%v: got %d, %t, %v", test.args, amount, isGold, err)
+		}
+	}
+}
+
+func TestDepositAndWithdrawGold(t *testing.T) {
+	s := newLoadedTestServer(t)
+	inn := s.Areas["Town"].Rooms["Inn"]
+	inn.NPCs = append(inn.NPCs, area.NPC{Name: "Banker", Banker: true})
+	s.Areas["Town"].Rooms["Inn"] = inn
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.Gold = 100
+
+	if got := doDeposit(s, c, []string{"10", "gold"}); got != "There is no banker here." {
+		t.Errorf("without a banker: got %q", got)
+	}
+	movePlayer(c.Player, "Town", "Inn", "1")
+
+	tests := []struct {
+		deposit    bool
+		args       []string
+		want       string
+		gold, bank int
+	}{
+		{deposit: true, args: []string{"30", "gold"}, want: "You deposit 30 gold with Banker.", gold: 70, bank: 30},
+		{deposit: true, args: []string{"80", "gold"}, want: "You only have 70 gold.", gold: 70, bank: 30},
+		{deposit: false, args: []string{"40", "gold"}, want: "You only have 30 gold in the bank.", gold: 70, bank: 30},
+		{deposit: false, args: []string{"10", "gold"}, want: "You withdraw 10 gold from Banker.", gold: 80, bank: 20},
+		{deposit: true, args: []string{"all", "gold"}, want: "You deposit 80 gold with Banker.", gold: 0, bank: 100},
+		{deposit: true, args: []string{"gold"}, want: "You only have 0 gold.", gold: 0, bank: 100},
+		{deposit: false, args: []string{"gold"}, want: "You withdraw 100 gold from Banker.", gold: 100, bank: 0},
+	}
+	for _, test := range tests {
+		var got string
+		if test.deposit {
+			got = doDeposit(s, c, test.args)
+		} else {
+			got = doWithdraw(s, c, test.args)
+		}
+		if got != test.want {
+			t.Errorf("%v: got %q, want %q", test.args, got, test.want)
+		}
+		if c.Player.Gold != test.gold || c.Player.Bank.Gold != test.bank {
+			t.Errorf("%v: carrying %d and banked %d gold, want %d and %d", test.args, c.Player.Gold, c.Player.Bank.Gold, test.gold, test.bank)
+		}
+	}
+}
+
+func TestDepositAndWithdrawItems(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.BankSize = 2
+	square := s.Areas["Town"].Rooms["Square"]
+	square.NPCs = append(square.NPCs, area.NPC{Name: "Banker", Banker: true})
+	s.Areas["Town"].Rooms["Square"] = square
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.Inventory = []game.Item{testSword, arrowStack(10), {Name: "Rope"}}
+
+	if got := doDeposit(s, c, []string{"sword"}); got != "You deposit Short Sword with Banker." {
+		t.Errorf("got %q", got)
+	}
+	if got := doDeposit(s, c, []string{"4", "arrows"}); got != "You deposit 4 Arrows with Banker." {
+		t.Errorf("got %q", got)
+	}
+	// Stacks already in the bank take no more room.
+	if got := doDeposit(s, c, []string{"2", "arrows"}); got != "You deposit 2 Arrows with Banker." {
+		t.Errorf("got %q", got)
+	}
+	if got := doDeposit(s, c, []string{"rope"}); got != "There is no room left in your bank." {
+		t.Errorf("full bank: got %q", got)
+	}
+	if got := strings.Join(itemNames(c.Player.Inventory), ","); got != "4 Arrows,Rope" {
+		t.Errorf("carrying %s", got)
+	}
+	if got := strings.Join(itemNames(c.Player.Bank.Items), ","); got != "Short Sword,6 Arrows" {
+		t.Errorf("banked %s", got)
+	}
+
+	if got := doWithdraw(s, c, []string{"all", "arrows"}); got != "You withdraw 6 Arrows from Banker." {
+		t.Errorf("got %q", got)
+	}
+	if got := doWithdraw(s, c, []string{"arrows"}); got != "You have no arrows in the bank." {
+		t.Errorf("got %q", got)
+	}
+	if got := strings.Join(itemNames(c.Player.Inventory), ","); got != "10 Arrows,Rope" {
+		t.Errorf("carrying %s", got)
+	}
+
+	s.Config.MaxInventory = 2
+	if got := doWithdraw(s, c, []string{"sword"}); got != "You cannot carry that much." {
+		t.Errorf("full inventory: got %q", got)
+	}
+	if len(c.Player.Bank.Items) != 1 {
+		t.Errorf("banked %v", itemNames(c.Player.Bank.Items))
+	}
+}
+
+func TestBankPersists(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.Bank = area.Bank{Gold: 42, Items: []game.Item{arrowStack(3)}}
+
+	if !s.savePlayer(*c.Player) {
+		t.Fatal("cannot save the player")
+	}
+	if exists, err := s.loadPlayer("Alice"); err != nil || !exists {
+		t.Fatalf("cannot load the player: %v", err)
+	}
+	p, _ := s.GetPlayerByNick("Alice")
+	bank := p.Bank
+	if bank.Gold != 42 || strings.Join(itemNames(bank.Items), ",") != "3 Arrows" {
+		t.Errorf("got %+v", bank)
+	}
+}
@@ -0,0 +1,348 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gothyra/toml"
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// digSteps holds the steps taken on the grid of a room in every direction,
+// in the order of directionNames.
+var digSteps = [][2]int{{1, 0}, {-1, 0}, {0, -1}, {0, 1}}
+
+// directionIndex returns the index in directionNames of the direction, which
+// may be given by its first letter.
+func directionIndex(dir string) (int, bool) {
+	for i, name := range directionNames {
+		if dir == name || dir == name[:1] {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// cubeAt returns the cube of the room at the given position.
+func cubeAt(room area.Room, x, y int) (area.Cube, bool) {
+	for _, cube := range room.Cubes {
+		if cube.POSX == strconv.Itoa(x) && cube.POSY == strconv.Itoa(y) {
+			return cube, true
+		}
+	}
+	return area.Cube{}, false
+}
+
+// nextCubeID returns an ID no cube of the room has.
+func nextCubeID(room area.Room) string {
+	max := 0
+	for _, cube := range room.Cubes {
+		if id, err := strconv.Atoi(cube.ID); err == nil && id > max {
+			max = id
+		}
+	}
+	return strconv.Itoa(max + 1)
+}
+
+// digRoom adds a room named newRoom to the area, next to the given cube of
+// the given room in the given direction. A door is put next to the cube
+// leading to the new room, and the new room has a door leading back to the
+// cube. The new room holds a single cube besides its door, which the door
+// to it leads to. If the room cannot be dug, a message telling the builder
+// why not is returned.
+func digRoom(a area.Area, roomName, cubeID string, dir int, newRoom string) (bool, string) {
+	if _, ok := a.Rooms[newRoom]; ok {
+		return false, fmt.Sprintf("There is already a room called %s here.", newRoom)
+	}
+	room, ok := a.Rooms[roomName]
+	if !ok {
+		return false, "There is no room to dig from here."
+	}
+
+	var from area.Cube
+	for _, cube := range room.Cubes {
+		if cube.ID == cubeID {
+			from = cube
+		}
+	}
+	x, errX := strconv.Atoi(from.POSX)
+	y, errY := strconv.Atoi(from.POSY)
+	if errX != nil || errY != nil {
+		return false, "You cannot dig from here."
+	}
+	step := digSteps[dir]
+	doorX, doorY := x+step[0], y+step[1]
+	if doorX < 0 || doorY < 0 {
+		return false, fmt.Sprintf("You cannot dig %s from the edge of the room.", directionNames[dir])
+	}
+	if _, ok := cubeAt(room, doorX, doorY); ok {
+		return false, fmt.Sprintf("There is no room to dig %s.", directionNames[dir])
+	}
+
+	// The new room runs in the direction dug, with its door back behind
+	// its first cube.
+	first, back := [2]int{0, 0}, [2]int{0, 0}
+	if step[0]+step[1] > 0 {
+		first = [2]int{step[0], step[1]}
+	} else {
+		back = [2]int{-step[0], -step[1]}
+	}
+
+	room.Cubes = append(room.Cubes, area.Cube{
+		ID:    nextCubeID(room),
+		POSX:  strconv.Itoa(doorX),
+		POSY:  strconv.Itoa(doorY),
+		Type:  "door",
+		Exits: []area.Exit{{ToArea: a.Name, ToRoom: newRoom, ToCubeID: "1"}},
+	})
+	a.Rooms[roomName] = room
+
+	a.Rooms[newRoom] = area.Room{
+		Name: newRoom,
+		Cubes: []area.Cube{
+			{ID: "1", POSX: strconv.Itoa(first[0]), POSY: strconv.Itoa(first[1])},
+			{
+				ID:    "2",
+				POSX:  strconv.Itoa(back[0]),
+				POSY:  strconv.Itoa(back[1]),
+				Type:  "door",
+				Exits: []area.Exit{{ToArea: a.Name, ToRoom: roomName, ToCubeID: cubeID}},
+			},
+		},
+	}
+	return true, ""
+}
+
+// claimArea marks the builder as building the area. It returns who else is
+// building the area if somebody is.
+func (s *Server) claimArea(areaName, nick string) (string, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	if editor, ok := s.editors[areaName]; ok && nickKey(editor) != nickKey(nick) {
+		return editor, false
+	}
+	s.editors[areaName] = nick
+	return "", true
+}
+
+// isBuilding returns true if the builder has changes not saved yet or edits
+// to undo.
+func (s *Server) isBuilding(nick string) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	for _, editor := range s.editors {
+		if nickKey(editor) == nickKey(nick) {
+			return true
+		}
+	}
+	_, ok := s.buildHistories[nickKey(nick)]
+	return ok
+}
+
+// releaseArea lets others build the area, forgetting the edits the builder
+// can undo in it.
+func (s *Server) releaseArea(areaName, nick string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if editor, ok := s.editors[areaName]; ok && nickKey(editor) == nickKey(nick) {
+		delete(s.editors, areaName)
+	}
+	if h, ok := s.buildHistories[nickKey(nick)]; ok {
+		h.forget(areaName)
+	}
+}
+
+// releaseAreas lets others build the areas the builder was building, and
+// forgets all the edits of the builder. Changes not saved are kept in the
+// game until the server stops or areas reload.
+func (s *Server) releaseAreas(nick string) {
+	s.Lock()
+	defer s.Unlock()
+
+	for areaName, editor := range s.editors {
+		if nickKey(editor) == nickKey(nick) {
+			delete(s.editors, areaName)
+		}
+	}
+	delete(s.buildHistories, nickKey(nick))
+}
+
+// doDig adds a room next to the player in the direction given in args, with
+// doors between the two rooms.
+func doDig(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+	if len(args) != 2 {
+		return "Usage: dig <east|west|north|south> <roomname>"
+	}
+	dir, ok := directionIndex(args[0])
+	if !ok {
+		return fmt.Sprintf("%s is not a direction.", args[0])
+	}
+
+	p := c.Player
+	if editor, ok := s.claimArea(p.Area, p.Nickname); !ok {
+		return fmt.Sprintf("%s is building this area.", editor)
+	}
+	before := copyCubes(s.Areas[p.Area].Rooms[p.Room].Cubes)
+	s.Lock()
+	ok, msg := digRoom(s.Areas[p.Area], p.Room, p.Position, dir, args[1])
+	s.Unlock()
+	if !ok {
+		return msg
+	}
+	roomsMap[p.Area][p.Room] = s.CreateRoom(p.Area, p.Room)
+	roomsMap[p.Area][args[1]] = s.CreateRoom(p.Area, args[1])
+
+	dug := s.Areas[p.Area].Rooms[args[1]]
+	s.recordEdit(p.Nickname, buildEdit{
+		{area: p.Area, room: p.Room, field: fieldCubes, before: before, after: copyCubes(s.Areas[p.Area].Rooms[p.Room].Cubes)},
+		{area: p.Area, room: args[1], field: fieldRoom, before: (*area.Room)(nil), after: &dug},
+	})
+
+	log.Info(fmt.Sprintf("%s dug %s from %s/%s to %s", p.Nickname, directionNames[dir], p.Area, p.Room, args[1]))
+	return fmt.Sprintf("You dig %s to %s.", directionNames[dir], args[1])
+}
+
+// doSetDesc changes the description of the room the player is in to the
+// text given in args.
+func doSetDesc(s *Server, c client.Client, args []string) string {
+	if len(args) == 0 {
+		return "Usage: setdesc <text>"
+	}
+
+	p := c.Player
+	if editor, ok := s.claimArea(p.Area, p.Nickname); !ok {
+		return fmt.Sprintf("%s is building this area.", editor)
+	}
+	room := s.Areas[p.Area].Rooms[p.Room]
+	before := room.Description
+	room.Description = strings.Join(args, " ") + "\n"
+	s.Lock()
+	s.Areas[p.Area].Rooms[p.Room] = room
+	s.Unlock()
+	s.recordEdit(p.Nickname, buildEdit{
+		{area: p.Area, room: p.Room, field: fieldDescription, before: before, after: room.Description},
+	})
+
+	log.Info(fmt.Sprintf("%s changed the description of %s/%s", p.Nickname, p.Area, p.Room))
+	return "Description changed."
+}
+
+// areaFiles returns the files in the areas directory holding the area with
+// the given name.
+func (s *Server) areaFiles(areaName string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(s.areasDir(), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isAreaFile(path) {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return &ReadError{Path: path, Err: err}
+		}
+		var a area.Area
+		if filepath.Ext(path) == ".json" {
+			err = json.Unmarshal(content, &a)
+		} else {
+			_, err = toml.Decode(string(content), &a)
+		}
+		if err != nil {
+			return &ParseError{Path: path, Err: err}
+		}
+		if a.Name == areaName {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// areaToSave returns the area as it is in the game, ready to be written back
+// to the given area file. NPCs and items rooms refer to by ID, and NPCs
+// spawn rules put in rooms, are kept out, as they are in the file.
+func areaToSave(a area.Area, file area.Area) area.Area {
+	rooms := make(map[string]area.Room, len(a.Rooms))
+	for name, room := range a.Rooms {
+		room.NPCs = file.Rooms[name].NPCs
+		room.Items = file.Rooms[name].Items
+		rooms[name] = room
+	}
+	a.Rooms = rooms
+	return a
+}
+
+// doSaveArea writes the area the player is in back to its TOML file, as long
+// as the area is valid and held in a single file.
+func doSaveArea(s *Server, c client.Client) string {
+	p := c.Player
+	if editor, ok := s.claimArea(p.Area, p.Nickname); !ok {
+		return fmt.Sprintf("%s is building this area.", editor)
+	}
+
+	candidate := &Server{Areas: s.Areas, Config: s.Config, npcs: s.npcs, items: s.items}
+	if problems := candidate.validateAreas(); len(problems) > 0 {
+		return fmt.Sprintf("The area cannot be saved: %v", problems[0])
+	}
+
+	files, err := s.areaFiles(p.Area)
+	if err != nil {
+		log.Error(fmt.Sprintf("Area files could not be read: %v", err))
+		return "The area cannot be saved: its files could not be read."
+	}
+	path := filepath.Join(s.areasDir(), strings.ToLower(p.Area)+".toml")
+	switch {
+	case len(files) > 1:
+		return "The area cannot be saved: it is split across several files."
+	case len(files) == 1 && filepath.Ext(files[0]) != ".toml":
+		return "The area cannot be saved: only areas written in TOML can be."
+	case len(files) == 1:
+		path = files[0]
+	}
+
+	var file area.Area
+	content, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		// The area is saved to a new file.
+	case err != nil:
+		log.Error(fmt.Sprintf("Area %q could not be saved: %v", p.Area, readFileError(path, err)))
+		return "The area cannot be saved: its file could not be read."
+	default:
+		if _, err := toml.Decode(string(content), &file); err != nil {
+			log.Error(fmt.Sprintf("Area %q could not be saved: %v", p.Area, &ParseError{Path: path, Err: err}))
+			return fmt.Sprintf("The area cannot be saved: %v", err)
+		}
+	}
+	data := &bytes.Buffer{}
+	if err := toml.NewEncoder(data).Encode(areaToSave(s.Areas[p.Area], file)); err != nil {
+		log.Error(fmt.Sprintf("Area %q could not be encoded: %v", p.Area, err))
+		return "The area could not be saved."
+	}
+	s.Lock()
+	s.savedAreaFiles[filepath.Clean(path)] = data.Bytes()
+	s.Unlock()
+	if err := ioutil.WriteFile(path, data.Bytes(), 0644); err != nil {
+		log.Error(fmt.Sprintf("Area %q could not be saved: %v", p.Area, err))
+		return "The area could not be saved."
+	}
+
+	s.releaseArea(p.Area, p.Nickname)
+	log.Info(fmt.Sprintf("%s saved area %q to %s", p.Nickname, p.Area, path))
+	return fmt.Sprintf("Area %s saved.", p.Area)
+}
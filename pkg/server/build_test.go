@@ -0,0 +1,210 @@
+package server
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+func TestDigRoom(t *testing.T) {
+	s := newLoadedTestServer(t)
+	town := s.Areas["Town"]
+
+	ok, msg := digRoom(town, "Square", "4", 3, "Cellar")
+	if !ok {
+		t.Fatalf("could not dig: %s", msg)
+	}
+
+	// The square gets a door south of cube 4 leading into the cellar.
+	door, ok := cubeAt(town.Rooms["Square"], 0, 2)
+	wantDoor := area.Cube{ID: "5", POSX: "0", POSY: "2", Type: "door", Exits: []area.Exit{{ToArea: "Town", ToRoom: "Cellar", ToCubeID: "1"}}}
+	if !ok || !reflect.DeepEqual(door, wantDoor) {
+		t.Errorf("got door %+v, want %+v", door, wantDoor)
+	}
+	// The cellar runs south from its door, which leads back to cube 4.
+	wantCellar := []area.Cube{
+		{ID: "1", POSX: "0", POSY: "1"},
+		{ID: "2", POSX: "0", POSY: "0", Type: "door", Exits: []area.Exit{{ToArea: "Town", ToRoom: "Square", ToCubeID: "4"}}},
+	}
+	if got := town.Rooms["Cellar"].Cubes; !reflect.DeepEqual(got, wantCellar) {
+		t.Errorf("got cellar %+v, want %+v", got, wantCellar)
+	}
+	if problems := s.validateAreas(); len(problems) != 0 {
+		t.Errorf("the dug room is not valid: %v", problems)
+	}
+
+	// Digging west or north puts the door of the new room after its cube.
+	ok, msg = digRoom(town, "Inn", "3", 1, "Attic")
+	if !ok {
+		t.Fatalf("could not dig: %s", msg)
+	}
+	wantAttic := []area.Cube{
+		{ID: "1", POSX: "0", POSY: "0"},
+		{ID: "2", POSX: "1", POSY: "0", Type: "door", Exits: []area.Exit{{ToArea: "Town", ToRoom: "Inn", ToCubeID: "3"}}},
+	}
+	if got := town.Rooms["Attic"].Cubes; !reflect.DeepEqual(got, wantAttic) {
+		t.Errorf("got attic %+v, want %+v", got, wantAttic)
+	}
+	if door, ok := cubeAt(town.Rooms["Inn"], 0, 1); !ok || door.Exits[0].ToRoom != "Attic" {
+		t.Errorf("got door %+v in the inn", door)
+	}
+}
+
+func TestDigRoomRefused(t *testing.T) {
+	tests := []struct {
+		room, cube string
+		dir        int
+		newRoom    string
+		want       string
+	}{
+		{room: "Square", cube: "4", dir: 3, newRoom: "Inn", want: "There is already a room called Inn here."},
+		{room: "Square", cube: "1", dir: 1, newRoom: "Cellar", want: "You cannot dig west from the edge of the room."},
+		{room: "Square", cube: "2", dir: 0, newRoom: "Cellar", want: "There is no room to dig east."},
+		{room: "Square", cube: "4", dir: 2, newRoom: "Cellar", want: "There is no room to dig north."},
+		{room: "Cellar", cube: "1", dir: 0, newRoom: "Attic", want: "There is no room to dig from here."},
+	}
+	for _, test := range tests {
+		s := newLoadedTestServer(t)
+		before := len(s.Areas["Town"].Rooms)
+		ok, msg := digRoom(s.Areas["Town"], test.room, test.cube, test.dir, test.newRoom)
+		if ok || msg != test.want {
+			t.Errorf("%+v: got %v, %q", test, ok, msg)
+		}
+		if got := len(s.Areas["Town"].Rooms); got != before {
+			t.Errorf("%+v: %d rooms after refusing, want %d", test, got, before)
+		}
+	}
+}
+
+func TestDigClaimsArea(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "4")
+	alice.Player.SetPermissionLevel(area.PermissionBuilder)
+	bob := addTestPlayer(t, s, "Bob", "Town", "Inn", "1")
+	bob.Player.SetPermissionLevel(area.PermissionBuilder)
+
+	replies := handle(t, s, client.Event{Client: &alice, Etype: "dig", Cmd: "dig", Args: []string{"s", "Cellar"}}, alice)
+	if got := replies[0].Events; !strings.Contains(got, "You dig south to Cellar.") {
+		t.Errorf("Alice read %q", got)
+	}
+	replies = handle(t, s, client.Event{Client: &bob, Etype: "setdesc", Cmd: "setdesc", Args: []string{"A", "loud", "inn."}}, bob)
+	if got := replies[0].Events; !strings.Contains(got, "Alice is building this area.") {
+		t.Errorf("Bob read %q", got)
+	}
+	if got := s.Areas["Town"].Rooms["Inn"].Description; got != "A cosy inn." {
+		t.Errorf("Bob changed the inn to %q", got)
+	}
+}
+
+func TestSaveAreaPermission(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig + "\n[config.commandPermissions]\nsavearea = 100\n",
+		"areas/town.toml": testArea,
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "4")
+	alice.Player.SetPermissionLevel(area.PermissionBuilder)
+	saveArea := client.Event{Client: &alice, Etype: "save", Cmd: "save", Args: []string{"area"}}
+
+	handle(t, s, client.Event{Client: &alice, Etype: "dig", Cmd: "dig", Args: []string{"s", "Cellar"}}, alice)
+	replies := handle(t, s, saveArea, alice)
+	if got := replies[0].Events; !strings.Contains(got, "save area is not a command you can use.") {
+		t.Errorf("a builder saving the area read %q", got)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(s.areasDir(), "town.toml")); strings.Contains(string(content), "Cellar") {
+		t.Error("a builder saved the area")
+	}
+
+	alice.Player.SetPermissionLevel(area.PermissionAdmin)
+	replies = handle(t, s, saveArea, alice)
+	if got := replies[0].Events; !strings.Contains(got, "Area Town saved.") {
+		t.Errorf("an admin saving the area read %q", got)
+	}
+	areas, err := s.readAreas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := areas["Town"].Rooms["Cellar"]; !ok {
+		t.Error("the saved area has no cellar")
+	}
+}
+
+func TestSaveAreaBrokenFile(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "4")
+	alice.Player.SetPermissionLevel(area.PermissionBuilder)
+
+	// The file was broken by hand after the area was loaded.
+	path := filepath.Join(s.areasDir(), "town.toml")
+	if err := ioutil.WriteFile(path, []byte("name = "), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	replies := handle(t, s, client.Event{Client: &alice, Etype: "savearea", Cmd: "savearea"}, alice)
+	if got := replies[0].Events; !strings.Contains(got, "The area cannot be saved: ") {
+		t.Errorf("Alice read %q", got)
+	}
+	if content, _ := ioutil.ReadFile(path); string(content) != "name = " {
+		t.Errorf("the broken file was overwritten with %q", content)
+	}
+}
+
+func TestSaveAreaNotReloaded(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "4")
+	alice.Player.SetPermissionLevel(area.PermissionBuilder)
+
+	wg := &sync.WaitGroup{}
+	quit := make(chan struct{})
+	defer func() {
+		close(quit)
+		wg.Wait()
+	}()
+	wg.Add(1)
+	go watchAreas(s, wg, quit)
+
+	// Give the watcher time to start before anything changes.
+	time.Sleep(100 * time.Millisecond)
+
+	handle(t, s, client.Event{Client: &alice, Etype: "dig", Cmd: "dig", Args: []string{"s", "Cellar"}}, alice)
+	replies := handle(t, s, client.Event{Client: &alice, Etype: "savearea", Cmd: "savearea"}, alice)
+	if got := replies[0].Events; !strings.Contains(got, "Area Town saved.") {
+		t.Fatalf("Alice read %q", got)
+	}
+	select {
+	case <-s.areaUpdates:
+		t.Fatal("the areas were reloaded after saving them in the game")
+	case <-time.After(3 * reloadDelay):
+	}
+
+	// Changes made outside the game are still reloaded.
+	path := filepath.Join(s.areasDir(), "town.toml")
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(content), "A cosy inn.", "A loud inn.", 1)
+	if err := ioutil.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case areas := <-s.areaUpdates:
+		if got := areas["Town"].Rooms["Inn"].Description; got != "A loud inn." {
+			t.Errorf("reloaded the inn as %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the areas were not reloaded after editing them")
+	}
+}
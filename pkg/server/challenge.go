@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// challengeAnswer is what connections are asked to type before logging in.
+const challengeAnswer = "enter"
+
+// Bounds of how long answering the login challenge may take. Answers sent
+// faster than a person could type them come from bots.
+const (
+	minChallengeDelay = 500 * time.Millisecond
+	maxChallengeDelay = time.Minute
+)
+
+// passChallenge asks the connection to type the challenge answer before it
+// can log in, when the login challenge is on. It returns false for
+// connections sending anything else, answering too fast, or sending more
+// right after the answer, as simple bots flooding the server do. Trusted
+// addresses are never challenged.
+func (s *Server) passChallenge(conn net.Conn, bufc *bufio.Reader) bool {
+	if !s.Config.LoginChallenge || s.isTrusted(remoteIP(conn)) {
+		return true
+	}
+
+	if err := client.WriteAll(conn, fmt.Sprintf("Type '%s' to continue.\n", challengeAnswer)); err != nil {
+		return false
+	}
+	asked := time.Now()
+	conn.SetReadDeadline(asked.Add(maxChallengeDelay))
+	defer conn.SetReadDeadline(time.Time{})
+
+	answer, tooLong, err := client.ReadLine(bufc, len(challengeAnswer)+2)
+	passed := err == nil && !tooLong &&
+		strings.EqualFold(answer, challengeAnswer) &&
+		time.Since(asked) >= minChallengeDelay &&
+		bufc.Buffered() == 0
+	if !passed {
+		log.Info(fmt.Sprintf("Connection from %v failed the login challenge", conn.RemoteAddr()))
+	}
+	return passed
+}
+
+// isTrusted returns true if the given IP address is one of the configured
+// trusted addresses or networks.
+func (s *Server) isTrusted(ip string) bool {
+	addr := net.ParseIP(ip)
+	for _, trusted := range s.Config.TrustedAddresses {
+		if trusted == ip {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(trusted); err == nil && addr != nil && network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
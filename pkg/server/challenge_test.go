@@ -0,0 +1,65 @@
+package server
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+// challengeConfig turns the login challenge on.
+const challengeConfig = testConfig + "loginChallenge = true\n"
+
+func TestLoginChallenge(t *testing.T) {
+	tests := []struct {
+		name   string
+		wait   time.Duration
+		typed  string
+		passes bool
+	}{
+		{name: "answer", wait: minChallengeDelay, typed: "enter\n", passes: true},
+		{name: "answer in capitals", wait: minChallengeDelay, typed: "ENTER\n", passes: true},
+		{name: "wrong answer", wait: minChallengeDelay, typed: "hello\n"},
+		{name: "too fast", typed: "enter\n"},
+		{name: "flood", wait: minChallengeDelay, typed: "enter\nalice\ny\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := startHarness(t, map[string]string{"server.toml": challengeConfig, "areas/town.toml": testArea})
+			c := h.connect()
+			c.expect("Type 'enter' to continue.")
+
+			time.Sleep(test.wait)
+			if _, err := io.WriteString(c.conn, test.typed); err != nil {
+				t.Fatal(err)
+			}
+			if test.passes {
+				c.expect("Whats your Nick?")
+				return
+			}
+			c.expect("See you")
+			c.expectClosed()
+		})
+	}
+}
+
+func TestIsTrusted(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.Config.TrustedAddresses = []string{"192.0.2.1", "10.0.0.0/8", "::1"}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "192.0.2.1", want: true},
+		{ip: "192.0.2.2", want: false},
+		{ip: "10.1.2.3", want: true},
+		{ip: "11.1.2.3", want: false},
+		{ip: "::1", want: true},
+		{ip: "pipe", want: false},
+	}
+	for _, test := range tests {
+		if got := s.isTrusted(test.ip); got != test.want {
+			t.Errorf("isTrusted(%s) = %t, want %t", test.ip, got, test.want)
+		}
+	}
+}
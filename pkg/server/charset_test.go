@@ -0,0 +1,55 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+)
+
+// nonASCII returns the first byte of out that is not ASCII, and false if
+// there is none.
+func nonASCII(out []byte) (byte, bool) {
+	for _, b := range out {
+		if b >= 0x80 {
+			return b, true
+		}
+	}
+	return 0, false
+}
+
+func TestASCIIOutput(t *testing.T) {
+	h := startHarness(t, map[string]string{
+		"server.toml":     testConfig + "charset = \"ascii\"\n",
+		"areas/town.toml": testArea,
+	})
+	alice := h.connect()
+	alice.login("alice")
+	alice.send("say It’s “busy” — come in")
+	alice.expect(`It's "busy" - come in`)
+
+	if b, ok := nonASCII(alice.received()); ok {
+		t.Errorf("the server sent byte %#x to a player using ascii", b)
+	}
+}
+
+func TestCharsetSetting(t *testing.T) {
+	h := startHarness(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": testArea,
+	})
+	alice := h.connect()
+	alice.login("alice")
+	alice.send("say “busy”")
+	alice.expect("“busy”")
+	if !bytes.ContainsRune(alice.received(), '│') {
+		t.Error("the box of the prompt is not drawn with box-drawing characters")
+	}
+
+	alice.send("charset ascii")
+	alice.expect("Charset is ascii.")
+	mark := len(alice.received())
+	alice.send("say “quiet”")
+	alice.expect(`"quiet"`)
+	if b, ok := nonASCII(alice.received()[mark:]); ok {
+		t.Errorf("the server sent byte %#x after the player chose ascii", b)
+	}
+}
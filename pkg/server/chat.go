@@ -0,0 +1,286 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// Chat channels players can talk on.
+const (
+	channelSay   = "say"
+	channelEmote = "emote"
+	channelOOC   = "ooc"
+	channelTell  = "tell"
+)
+
+// channels holds all the chat channels.
+var channels = []string{channelSay, channelEmote, channelOOC, channelTell}
+
+// isChannel returns true if name is a chat channel.
+func isChannel(name string) bool {
+	for _, channel := range channels {
+		if channel == name {
+			return true
+		}
+	}
+	return false
+}
+
+// listens returns true if the player has not muted the given channel.
+func listens(p *area.Player, channel string) bool {
+	return !p.Settings.Muted[channel]
+}
+
+// ignores returns true if the player ignores the player with the given nick.
+func ignores(p *area.Player, nick string) bool {
+	for _, ignored := range p.Settings.Ignored {
+		if strings.EqualFold(ignored, nick) {
+			return true
+		}
+	}
+	return false
+}
+
+// chatRecipients returns the clients that listen to the given channel and do
+// not ignore the sender.
+func chatRecipients(clients []client.Client, channel string, sender *area.Player) []client.Client {
+	var recipients []client.Client
+	for _, c := range clients {
+		if listens(c.Player, channel) && !ignores(c.Player, sender.Nickname) {
+			recipients = append(recipients, c)
+		}
+	}
+	return recipients
+}
+
+// doSay returns what the player and the players in the same room listening to
+// say get to see when the player says something.
+func doSay(c client.Client, args []string, now time.Time) CommandResult {
+	if isGagged(c.Player, now) {
+		return CommandResult{Actor: gaggedMessage}
+	}
+	if len(args) == 0 {
+		return CommandResult{Actor: "Say what?"}
+	}
+
+	text := strings.Join(args, " ")
+	return CommandResult{
+		Actor:   fmt.Sprintf("You say: %s", text),
+		Room:    fmt.Sprintf("%s says: %s", c.Player.Nickname, text),
+		Channel: channelSay,
+	}
+}
+
+// doEmote returns what the player and the players in the same room listening
+// to emotes get to see when the player emotes.
+func doEmote(s *Server, c client.Client, args []string) ([]client.Client, string, string) {
+	if isGagged(c.Player, time.Now()) {
+		return nil, gaggedMessage, ""
+	}
+	if len(args) == 0 {
+		return nil, "Emote what?", ""
+	}
+
+	emote := fmt.Sprintf("%s %s", c.Player.Nickname, strings.Join(args, " "))
+	recipients := chatRecipients(s.OnlineClientsGetByRoom(c.Player.Area, c.Player.Room), channelEmote, c.Player)
+	return recipients, emote, emote
+}
+
+// doOOC returns what the player and all the online players listening to ooc
+// get to see when the player talks out of character.
+func doOOC(s *Server, c client.Client, args []string) ([]client.Client, string, string) {
+	if isGagged(c.Player, time.Now()) {
+		return nil, gaggedMessage, ""
+	}
+	if len(args) == 0 {
+		return nil, "Say what?", ""
+	}
+
+	text := strings.Join(args, " ")
+	recipients := chatRecipients(s.OnlineClients(), channelOOC, c.Player)
+	return recipients, fmt.Sprintf("[OOC] You: %s", text), fmt.Sprintf("[OOC] %s: %s", c.Player.Nickname, text)
+}
+
+// doTell returns what the player and the player told get to see when the
+// player tells something to another player. Players who muted tells only
+// learn that somebody tried to tell them something, if the server is
+// configured so. Players who ignore the sender get nothing, without the
+// sender knowing.
+func doTell(s *Server, c client.Client, args []string) ([]client.Client, string, string) {
+	if isGagged(c.Player, time.Now()) {
+		return nil, gaggedMessage, ""
+	}
+	if len(args) < 2 {
+		return nil, "Usage: tell <nick> <message>", ""
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return nil, fmt.Sprintf("%s is not online.", args[0]), ""
+	}
+	if target.Player.Nickname == c.Player.Nickname {
+		return nil, "You mumble to yourself.", ""
+	}
+
+	text := strings.Join(args[1:], " ")
+	msg := fmt.Sprintf("You tell %s: %s", target.Player.Nickname, text)
+	if ignores(target.Player, c.Player.Nickname) {
+		return nil, msg, ""
+	}
+	if !listens(target.Player, channelTell) {
+		msg := fmt.Sprintf("%s is not listening to tells.", target.Player.Nickname)
+		if !s.Config.NotifyMutedTells {
+			return nil, msg, ""
+		}
+		return []client.Client{target}, msg, fmt.Sprintf("%s tried to tell you something.", c.Player.Nickname)
+	}
+
+	if notice := afkNotice(target); notice != "" {
+		msg += "\n" + notice
+	}
+	return []client.Client{target}, msg, fmt.Sprintf("%s tells you: %s", c.Player.Nickname, text)
+}
+
+// doChannel turns the channel given in args on or off for the player. Without
+// args it shows the state of every channel.
+func doChannel(c client.Client, args []string) string {
+	if len(args) == 0 {
+		lines := []string{"Channels:"}
+		for _, channel := range channels {
+			state := "on"
+			if !listens(c.Player, channel) {
+				state = "off"
+			}
+			lines = append(lines, fmt.Sprintf("  %-5s %s", channel, state))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if len(args) != 2 || !isChannel(args[0]) || (args[1] != "on" && args[1] != "off") {
+		return fmt.Sprintf("Usage: channel <%s> <on|off>", strings.Join(channels, "|"))
+	}
+
+	setMuted(c.Player, args[0], args[1] == "off")
+	return fmt.Sprintf("Channel %s is %s.", args[0], args[1])
+}
+
+// doQuiet mutes every channel, or unmutes them all if they are muted already.
+func doQuiet(c client.Client) string {
+	quiet := false
+	for _, channel := range channels {
+		if listens(c.Player, channel) {
+			quiet = true
+		}
+	}
+
+	for _, channel := range channels {
+		setMuted(c.Player, channel, quiet)
+	}
+	if quiet {
+		return "You stop listening to all channels."
+	}
+	return "You listen to all channels again."
+}
+
+// doIgnore adds the player with the nick given in args to the ignore list of
+// the player. Without args it shows the ignore list.
+func doIgnore(s *Server, c client.Client, args []string) string {
+	if len(args) == 0 {
+		if len(c.Player.Settings.Ignored) == 0 {
+			return "You are not ignoring anybody."
+		}
+		return fmt.Sprintf("You are ignoring: %s", strings.Join(c.Player.Settings.Ignored, ", "))
+	}
+	if len(args) != 1 {
+		return "Usage: ignore <nick>"
+	}
+
+	nick := args[0]
+	switch {
+	case strings.EqualFold(nick, c.Player.Nickname):
+		return "You cannot ignore yourself."
+	case ignores(c.Player, nick):
+		return fmt.Sprintf("You are already ignoring %s.", nick)
+	case !s.nickTaken(nick):
+		return fmt.Sprintf("%s does not exist.", nick)
+	}
+
+	c.Player.Settings.Ignored = append(c.Player.Settings.Ignored, nick)
+	return fmt.Sprintf("You are now ignoring %s.", nick)
+}
+
+// doUnignore removes the player with the nick given in args from the ignore
+// list of the player.
+func doUnignore(c client.Client, args []string) string {
+	if len(args) != 1 {
+		return "Usage: unignore <nick>"
+	}
+
+	nick := args[0]
+	for i, ignored := range c.Player.Settings.Ignored {
+		if strings.EqualFold(ignored, nick) {
+			c.Player.Settings.Ignored = append(c.Player.Settings.Ignored[:i], c.Player.Settings.Ignored[i+1:]...)
+			return fmt.Sprintf("You are no longer ignoring %s.", nick)
+		}
+	}
+	return fmt.Sprintf("You are not ignoring %s.", nick)
+}
+
+func setMuted(p *area.Player, channel string, muted bool) {
+	if !muted {
+		delete(p.Settings.Muted, channel)
+		return
+	}
+	if p.Settings.Muted == nil {
+		p.Settings.Muted = make(map[string]bool)
+	}
+	p.Settings.Muted[channel] = true
+}
+
+// godPrintChat shows msg to the sender and chatMsg to the recipients,
+// refreshing the view of each of them.
+func godPrintChat(
+	s *Server,
+	sender client.Client,
+	recipients []client.Client,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+	msg string,
+	chatMsg string,
+) {
+	// Recipients are grouped by room and by whether they filter profanity,
+	// since every group sees its own version of the message.
+	byRoom := map[string][]client.Client{}
+	chatKey := func(c client.Client) string {
+		return fmt.Sprintf("%s/%s/%t", c.Player.Area, c.Player.Room, c.Player.Settings.Filter)
+	}
+	byRoom[chatKey(sender)] = []client.Client{sender}
+	for _, r := range recipients {
+		if r.Player.Nickname == sender.Player.Nickname {
+			continue
+		}
+		key := chatKey(r)
+		byRoom[key] = append(byRoom[key], r)
+	}
+
+	keys := make([]string, 0, len(byRoom))
+	for key := range byRoom {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		wg.Add(1)
+		group := byRoom[key]
+		groupMsg := s.censor(group[0], chatMsg)
+		godPrintRoom(s, sender, group, wg, quit, roomsMap, s.paint(theme.Chat, s.censor(sender, msg)), s.paint(theme.Chat, groupMsg))
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// nicks returns the sorted nicknames of the players of the given clients.
+func nicks(clients []client.Client) string {
+	var names []string
+	for _, c := range clients {
+		names = append(names, c.Player.Nickname)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestMutedRecipients(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	addTestPlayer(t, s, "Carol", "Town", "Square", "4")
+
+	setMuted(bob.Player, channelOOC, true)
+	setMuted(bob.Player, channelEmote, true)
+
+	recipients, _, _ := doOOC(s, alice, []string{"hello"})
+	if got := nicks(recipients); got != "Alice,Carol" {
+		t.Errorf("ooc reached %s", got)
+	}
+	recipients, _, _ = doEmote(s, alice, []string{"waves"})
+	if got := nicks(recipients); got != "Alice,Carol" {
+		t.Errorf("emote reached %s", got)
+	}
+	others := s.OnlineClientsGetByRoom("Town", "Square")
+	if got := nicks(chatRecipients(others, channelSay, alice.Player)); got != "Alice,Bob,Carol" {
+		t.Errorf("say reached %s", got)
+	}
+
+	// Unmuting brings the channel back.
+	setMuted(bob.Player, channelOOC, false)
+	recipients, _, _ = doOOC(s, alice, []string{"hello"})
+	if got := nicks(recipients); got != "Alice,Bob,Carol" {
+		t.Errorf("ooc reached %s after unmuting", got)
+	}
+}
+
+func TestMutedTells(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	setMuted(bob.Player, channelTell, true)
+
+	recipients, msg, _ := doTell(s, alice, []string{"Bob", "psst"})
+	if len(recipients) != 0 {
+		t.Errorf("tell reached %s", nicks(recipients))
+	}
+	if msg != "Bob is not listening to tells." {
+		t.Errorf("got %q", msg)
+	}
+
+	s.Config.NotifyMutedTells = true
+	recipients, _, told := doTell(s, alice, []string{"Bob", "psst"})
+	if nicks(recipients) != "Bob" || told != "Alice tried to tell you something." {
+		t.Errorf("got %q for %s", told, nicks(recipients))
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	doQuiet(c)
+	for _, channel := range channels {
+		if listens(c.Player, channel) {
+			t.Errorf("still listening to %s", channel)
+		}
+	}
+	doQuiet(c)
+	for _, channel := range channels {
+		if !listens(c.Player, channel) {
+			t.Errorf("not listening to %s", channel)
+		}
+	}
+}
+
+func TestIgnoredSenders(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	addTestPlayer(t, s, "Carol", "Town", "Square", "4")
+
+	if got := doIgnore(s, bob, []string{"alice"}); got != "You are now ignoring alice." {
+		t.Fatalf("got %q", got)
+	}
+
+	others := s.OnlineClientsGetByRoom("Town", "Square")
+	if got := nicks(chatRecipients(others, channelSay, alice.Player)); got != "Alice,Carol" {
+		t.Errorf("say reached %s", got)
+	}
+	recipients, _, _ := doEmote(s, alice, []string{"waves"})
+	if got := nicks(recipients); got != "Alice,Carol" {
+		t.Errorf("emote reached %s", got)
+	}
+	recipients, _, _ = doOOC(s, alice, []string{"hello"})
+	if got := nicks(recipients); got != "Alice,Carol" {
+		t.Errorf("ooc reached %s", got)
+	}
+
+	// The sender of an ignored tell is not told about it.
+	recipients, msg, _ := doTell(s, alice, []string{"Bob", "psst"})
+	if len(recipients) != 0 {
+		t.Errorf("tell reached %s", nicks(recipients))
+	}
+	if msg != "You tell Bob: psst" {
+		t.Errorf("got %q", msg)
+	}
+
+	// Others still reach the player.
+	carol, _ := s.OnlineClientByNick("Carol")
+	recipients, _, _ = doOOC(s, carol, []string{"hi"})
+	if got := nicks(recipients); got != "Alice,Bob,Carol" {
+		t.Errorf("ooc from Carol reached %s", got)
+	}
+
+	doUnignore(bob, []string{"Alice"})
+	recipients, _, _ = doOOC(s, alice, []string{"hello"})
+	if got := nicks(recipients); got != "Alice,Bob,Carol" {
+		t.Errorf("ooc reached %s after unignoring", got)
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+
+	tests := []struct {
+		nick string
+		want string
+	}{
+		{nick: "Alice", want: "You cannot ignore yourself."},
+		{nick: "Nobody", want: "Nobody does not exist."},
+		{nick: "Bob", want: "You are now ignoring Bob."},
+		{nick: "BOB", want: "You are already ignoring BOB."},
+	}
+	for _, test := range tests {
+		if got := doIgnore(s, alice, []string{test.nick}); got != test.want {
+			t.Errorf("ignore %s: got %q, want %q", test.nick, got, test.want)
+		}
+	}
+}
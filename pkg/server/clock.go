@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultTickInterval is how often God advances the state of the world
+	// when no tick interval is configured.
+	defaultTickInterval = time.Second
+	// minTickInterval is the shortest tick interval allowed, so that God
+	// does not spend all its time ticking.
+	minTickInterval = 100 * time.Millisecond
+
+	// gameDay is the length of a day in game time.
+	gameDay = 24 * time.Hour
+	// Daytime lasts from dawnHour until duskHour in game time.
+	dawnHour = 6
+	duskHour = 18
+)
+
+// advanceGameTime returns the game time after real time has passed, given
+// that game time runs multiplier times faster than real time.
+func advanceGameTime(gameTime, real time.Duration, multiplier float64) time.Duration {
+	return gameTime + time.Duration(float64(real)*multiplier)
+}
+
+// isDaytime returns true if it is day at the given game time.
+func isDaytime(gameTime time.Duration) bool {
+	hour := int((gameTime % gameDay) / time.Hour)
+	return hour >= dawnHour && hour < duskHour
+}
+
+// formatGameTime returns the game time as the time of day and the day it is,
+// eg. "It is 14:05 on day 3, during the day."
+func formatGameTime(gameTime time.Duration) string {
+	day := int(gameTime/gameDay) + 1
+	hour := int((gameTime % gameDay) / time.Hour)
+	minute := int((gameTime % time.Hour) / time.Minute)
+
+	period := "night"
+	if isDaytime(gameTime) {
+		period = "day"
+	}
+	return fmt.Sprintf("It is %02d:%02d on day %d, during the %s.", hour, minute, day, period)
+}
+
+// formatDuration returns d in a human-friendly way, eg. "2 hours, 5 minutes".
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "less than a minute"
+	}
+
+	days := int(d / gameDay)
+	hours := int((d % gameDay) / time.Hour)
+	minutes := int((d % time.Hour) / time.Minute)
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, plural(days, "day"))
+	}
+	if hours > 0 {
+		parts = append(parts, plural(hours, "hour"))
+	}
+	if minutes > 0 {
+		parts = append(parts, plural(minutes, "minute"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseTickInterval parses the configured tick interval. An empty interval
+// means the default one, while intervals shorter than minTickInterval are
+// raised to it.
+func parseTickInterval(interval string) (time.Duration, error) {
+	if interval == "" {
+		return defaultTickInterval, nil
+	}
+
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tick interval %q: %v", interval, err)
+	}
+	return clampTickInterval(d), nil
+}
+
+// clampTickInterval returns the default tick interval for intervals that are
+// not positive and minTickInterval for intervals shorter than that.
+func clampTickInterval(d time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultTickInterval
+	}
+	if d < minTickInterval {
+		return minTickInterval
+	}
+	return d
+}
+
+// tickClock advances the game clock by the real time passed since the last
+// tick.
+func (s *Server) tickClock(elapsed time.Duration) {
+	if s.Config.GameClockMultiplier > 0 {
+		s.gameTime = advanceGameTime(s.gameTime, elapsed, s.Config.GameClockMultiplier)
+	}
+}
+
+// doTime returns the server time, the uptime of the server and, if the game
+// clock is enabled, the game time.
+func doTime(s *Server, now time.Time) string {
+	lines := []string{
+		fmt.Sprintf("Server time: %s", now.Format("2006-01-02 15:04:05 MST")),
+		fmt.Sprintf("Uptime: %s", formatDuration(now.Sub(s.started))),
+	}
+	if s.Config.GameClockMultiplier > 0 {
+		lines = append(lines, formatGameTime(s.gameTime))
+	}
+	return strings.Join(lines, "\n")
+}
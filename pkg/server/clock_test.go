@@ -0,0 +1,129 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdvanceGameTime(t *testing.T) {
+	tests := []struct {
+		gameTime   time.Duration
+		real       time.Duration
+		multiplier float64
+		want       time.Duration
+	}{
+		{gameTime: 0, real: time.Second, multiplier: 1, want: time.Second},
+		{gameTime: 0, real: time.Second, multiplier: 60, want: time.Minute},
+		{gameTime: time.Hour, real: time.Minute, multiplier: 60, want: 2 * time.Hour},
+		{gameTime: time.Hour, real: 2 * time.Second, multiplier: 0.5, want: time.Hour + time.Second},
+		{gameTime: time.Hour, real: 0, multiplier: 60, want: time.Hour},
+	}
+	for _, test := range tests {
+		if got := advanceGameTime(test.gameTime, test.real, test.multiplier); got != test.want {
+			t.Errorf("advanceGameTime(%v, %v, %v) = %v, want %v", test.gameTime, test.real, test.multiplier, got, test.want)
+		}
+	}
+}
+
+func TestTickClock(t *testing.T) {
+	s := newLoadedTestServer(t)
+
+	// The clock stands still unless enabled.
+	s.tickClock(time.Hour)
+	if s.gameTime != 0 {
+		t.Errorf("game time is %v with the clock disabled", s.gameTime)
+	}
+
+	// A real minute is a game day.
+	s.Config.GameClockMultiplier = 24 * 60
+	for i := 0; i < 60; i++ {
+		s.tickClock(time.Second)
+	}
+	if s.gameTime != gameDay {
+		t.Errorf("game time is %v, want %v", s.gameTime, gameDay)
+	}
+}
+
+func TestFormatGameTime(t *testing.T) {
+	tests := []struct {
+		gameTime time.Duration
+		want     string
+	}{
+		{gameTime: 0, want: "It is 00:00 on day 1, during the night."},
+		{gameTime: 6 * time.Hour, want: "It is 06:00 on day 1, during the day."},
+		{gameTime: 17*time.Hour + 59*time.Minute, want: "It is 17:59 on day 1, during the day."},
+		{gameTime: 18 * time.Hour, want: "It is 18:00 on day 1, during the night."},
+		{gameTime: 2*gameDay + 14*time.Hour + 5*time.Minute, want: "It is 14:05 on day 3, during the day."},
+	}
+	for _, test := range tests {
+		if got := formatGameTime(test.gameTime); got != test.want {
+			t.Errorf("formatGameTime(%v) = %q, want %q", test.gameTime, got, test.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 30 * time.Second, want: "less than a minute"},
+		{d: time.Minute, want: "1 minute"},
+		{d: 2*time.Hour + 5*time.Minute, want: "2 hours, 5 minutes"},
+		{d: 25 * time.Hour, want: "1 day, 1 hour"},
+	}
+	for _, test := range tests {
+		if got := formatDuration(test.d); got != test.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", test.d, got, test.want)
+		}
+	}
+}
+
+func TestParseTickInterval(t *testing.T) {
+	tests := []struct {
+		interval string
+		want     time.Duration
+		err      bool
+	}{
+		{interval: "", want: defaultTickInterval},
+		{interval: "2s", want: 2 * time.Second},
+		{interval: "1ms", want: minTickInterval},
+		{interval: "-1s", want: defaultTickInterval},
+		{interval: "soon", err: true},
+	}
+	for _, test := range tests {
+		got, err := parseTickInterval(test.interval)
+		if (err != nil) != test.err {
+			t.Errorf("parseTickInterval(%q): unexpected error %v", test.interval, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("parseTickInterval(%q) = %v, want %v", test.interval, got, test.want)
+		}
+	}
+}
+
+func TestLoadConfigTickInterval(t *testing.T) {
+	tests := []struct {
+		config string
+		want   time.Duration
+		err    bool
+	}{
+		{config: testConfig, want: defaultTickInterval},
+		{config: testConfig + "tickInterval = \"250ms\"\n", want: 250 * time.Millisecond},
+		{config: testConfig + "tickInterval = \"0s\"\n", want: defaultTickInterval},
+		{config: testConfig + "tickInterval = \"10ms\"\n", want: minTickInterval},
+		{config: testConfig + "tickInterval = \"fast\"\n", err: true},
+	}
+	for _, test := range tests {
+		s := newTestServer(t, map[string]string{"server.toml": test.config})
+		err := s.loadConfig()
+		if (err != nil) != test.err {
+			t.Errorf("%q: unexpected error %v", test.config, err)
+			continue
+		}
+		if !test.err && s.tickInterval != test.want {
+			t.Errorf("%q: got a tick interval of %v, want %v", test.config, s.tickInterval, test.want)
+		}
+	}
+}
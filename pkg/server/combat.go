@@ -0,0 +1,253 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// skillFlee is the skill of fleeing from combat.
+const skillFlee = "flee"
+
+// Combat settings used when none are configured.
+const (
+	defaultCombatRoundSeconds = 3
+	defaultFleeChance         = 50
+)
+
+// combatRound returns how long a round of combat lasts.
+func (s *Server) combatRound() time.Duration {
+	return time.Duration(limit(s.Config.CombatRoundSeconds, defaultCombatRoundSeconds)) * time.Second
+}
+
+// isFighting returns true if the player with the given nickname is in a
+// combat.
+func (s *Server) isFighting(nick string) bool {
+	for key := range s.combats {
+		if key.from == nick || key.to == nick {
+			return true
+		}
+	}
+	return false
+}
+
+// endCombats ends all the combats the player with the given nickname is in.
+func (s *Server) endCombats(nick string) {
+	for key := range s.combats {
+		if key.from == nick || key.to == nick {
+			delete(s.combats, key)
+		}
+	}
+}
+
+// planAttack returns the player given in args the player would attack at
+// now, without starting a combat.
+func planAttack(s *Server, c client.Client, args []string, now time.Time) (client.Client, bool, string) {
+	if len(args) != 1 {
+		return client.Client{}, false, "Usage: attack <nick>"
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok || target.Player.Area != c.Player.Area || target.Player.Room != c.Player.Room {
+		return client.Client{}, false, fmt.Sprintf("%s is not here.", args[0])
+	}
+	me, them := c.Player.Nickname, target.Player.Nickname
+	if me == them {
+		return client.Client{}, false, "You cannot attack yourself."
+	}
+	if _, ok := s.combats[duelBetween(me, them)]; ok {
+		return client.Client{}, false, fmt.Sprintf("You are already fighting %s.", them)
+	}
+	if ok, msg := s.canFight(c.Player, target.Player, now); !ok {
+		return client.Client{}, false, msg
+	}
+	return target, true, ""
+}
+
+// doAttack starts a combat with the player given in args. Rounds of the
+// combat are then fought on their own every combat round.
+func doAttack(s *Server, c client.Client, args []string, now time.Time) CommandResult {
+	target, ok, msg := planAttack(s, c, args, now)
+	if !ok {
+		return CommandResult{Actor: msg}
+	}
+	me, them := c.Player.Nickname, target.Player.Nickname
+
+	s.combats[duelBetween(me, them)] = now
+	return CommandResult{
+		Actor:   fmt.Sprintf("You attack %s!", them),
+		Room:    fmt.Sprintf("%s attacks %s!", me, them),
+		Targets: []Target{{Client: target, Msg: fmt.Sprintf("%s attacks you!", me)}},
+		Role:    theme.Combat,
+	}
+}
+
+// combatOutcome is what happened in a round of combat.
+type combatOutcome struct {
+	// msg describes the round to both fighters and everybody around them.
+	msg string
+	// loser is the fighter who was defeated in the round, if any.
+	loser *client.Client
+}
+
+// fightRound fights a round of the combat between the two players, each of
+// them attacking the other in turn. The combat ends when either of them is
+// defeated.
+func (s *Server) fightRound(key duelKey, a, b client.Client, now time.Time) combatOutcome {
+	var lines []string
+	for _, turn := range [][2]client.Client{{a, b}, {b, a}} {
+		attacker, defender := turn[0], turn[1]
+		if s.isLinkDead(attacker.Player.Nickname) {
+			lines = append(lines, fmt.Sprintf("%s stands there, defenseless.", attacker.Player.Nickname))
+			continue
+		}
+		damage, avoided := game.Attack(s.rand, &attacker.Player.PC, &defender.Player.PC)
+		attacker.Player.LastCombat = now
+		defender.Player.LastCombat = now
+		switch {
+		case avoided == game.SkillDodge:
+			lines = append(lines, fmt.Sprintf("%s dodges the attack of %s.", defender.Player.Nickname, attacker.Player.Nickname))
+			continue
+		case avoided == game.SkillParry:
+			lines = append(lines, fmt.Sprintf("%s parries the attack of %s.", defender.Player.Nickname, attacker.Player.Nickname))
+			continue
+		case damage == 0:
+			lines = append(lines, fmt.Sprintf("%s misses %s.", attacker.Player.Nickname, defender.Player.Nickname))
+			continue
+		}
+
+		defender.Player.HP -= damage
+		lines = append(lines, fmt.Sprintf("%s hits %s for %d HP.", attacker.Player.Nickname, defender.Player.Nickname, damage))
+		if defender.Player.HP <= 0 {
+			lines = append(lines, fmt.Sprintf("%s is defeated by %s!", defender.Player.Nickname, attacker.Player.Nickname))
+			delete(s.combats, key)
+			return combatOutcome{msg: strings.Join(lines, "\n"), loser: &defender}
+		}
+	}
+	s.combats[key] = now.Add(s.combatRound())
+	return combatOutcome{msg: strings.Join(lines, "\n")}
+}
+
+// defeat sends a player defeated at now back to the start location, healed,
+// after the death penalty, and describes the penalty.
+func (s *Server) defeat(c client.Client, now time.Time) string {
+	s.endCombats(c.Player.Nickname)
+	penalty := s.penalize(c, now)
+	c.Player.HP = c.Player.MaxHP
+	a, room, pos := s.startLocation()
+	s.movePlayer(c.Player, a, room, pos)
+	return penalty
+}
+
+// godPrintCombat fights the rounds of combat that are due at now and shows
+// them to everybody in the rooms of the fighters. Combats end when either
+// fighter is no longer online, no longer in the same room as the other, or
+// no longer allowed to fight the other.
+func godPrintCombat(
+	s *Server,
+	now time.Time,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	keys := make([]duelKey, 0, len(s.combats))
+	for key := range s.combats {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].from != keys[j].from {
+			return keys[i].from < keys[j].from
+		}
+		return keys[i].to < keys[j].to
+	})
+
+	for _, key := range keys {
+		next, ok := s.combats[key]
+		if !ok || now.Before(next) {
+			continue
+		}
+		a, okA := s.OnlineClientByNick(key.from)
+		b, okB := s.OnlineClientByNick(key.to)
+		if !okA || !okB || a.Player.Area != b.Player.Area || a.Player.Room != b.Player.Room {
+			delete(s.combats, key)
+			continue
+		}
+		if ok, _ := s.canFight(a.Player, b.Player, now); !ok {
+			delete(s.combats, key)
+			continue
+		}
+
+		outcome := s.fightRound(key, a, b, now)
+		msg := s.paint(theme.Combat, outcome.msg)
+		clients := s.OnlineClientsGetByRoom(a.Player.Area, a.Player.Room)
+		if outcome.loser == nil {
+			wg.Add(1)
+			godPrintRoom(s, a, clients, wg, quit, roomsMap, msg, msg)
+			continue
+		}
+
+		loserMsg := msg + "\n" + s.paint(theme.Combat, "You come to your senses somewhere safe.")
+		if penalty := s.defeat(*outcome.loser, now); penalty != "" {
+			loserMsg += "\n" + s.paint(theme.Combat, penalty)
+		}
+		wg.Add(1)
+		godPrintRoom(s, *outcome.loser, []client.Client{*outcome.loser}, wg, quit, roomsMap, loserMsg, "")
+		godPrintMove(s, *outcome.loser, wg, quit, roomsMap, "")
+		if others := s.OnlineClientsGetByRoom(a.Player.Area, a.Player.Room); len(others) > 0 {
+			winner := a
+			if winner.Player.Nickname == outcome.loser.Player.Nickname {
+				winner = b
+			}
+			wg.Add(1)
+			godPrintRoom(s, winner, others, wg, quit, roomsMap, msg, msg)
+		}
+	}
+}
+
+// fleeChance returns the percent chance of the player fleeing from combat.
+// Players who learned to flee do better or worse than the configured chance
+// the more or less proficient they are, as the configured chance is that of
+// a proficiency of 50.
+func (s *Server) fleeChance(p *area.Player) int {
+	chance := limit(s.Config.FleeChance, defaultFleeChance)
+	if proficiency, ok := p.Skills[skillFlee]; ok {
+		return game.SkillChance(proficiency, 100-chance)
+	}
+	return chance
+}
+
+// doFlee tries to escape every combat the player is in, moving the player
+// through a random way out on success.
+func doFlee(s *Server, c client.Client, roomsMap map[string]map[string][][]area.Cube) (bool, string) {
+	var ways []int
+	mapArray := roomsMap[c.Player.Area][c.Player.Room]
+	exits := area.FindExits(mapArray, c.Player.Area, c.Player.Room, c.Player.Position)
+	for direction, exit := range exits {
+		pos, _ := strconv.Atoi(exit[1])
+		if ok, _ := isCubeAvailable(s, c, exit[0], exit[2], pos); !ok {
+			continue
+		}
+		if ok, _ := s.canEnter(c.Player, exit[0], exit[2]); ok {
+			ways = append(ways, direction)
+		}
+	}
+	if len(ways) == 0 {
+		return false, "There is nowhere to flee to!"
+	}
+	if s.rand.Intn(100) >= s.fleeChance(c.Player) {
+		return false, "You try to flee but fail!"
+	}
+
+	s.endCombats(c.Player.Nickname)
+	direction := ways[s.rand.Intn(len(ways))]
+	doMove(s, c, roomsMap, direction)
+	return true, fmt.Sprintf("You flee %s!", directionNames[direction])
+}
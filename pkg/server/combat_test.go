@@ -0,0 +1,179 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+func TestFleeChance(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	tests := []struct {
+		configured int
+		skills     map[string]int
+		want       int
+	}{
+		{configured: 0, skills: nil, want: defaultFleeChance},
+		{configured: 30, skills: nil, want: 30},
+		{configured: 30, skills: map[string]int{skillFlee: 50}, want: 30},
+		{configured: 30, skills: map[string]int{skillFlee: 80}, want: 60},
+		{configured: 50, skills: map[string]int{skillFlee: 10}, want: 10},
+		{configured: 50, skills: map[string]int{skillFlee: 0}, want: 5},
+		{configured: 50, skills: map[string]int{skillFlee: 100}, want: 95},
+	}
+	for _, test := range tests {
+		s.Config.FleeChance = test.configured
+		c.Player.Skills = test.skills
+		if got := s.fleeChance(c.Player); got != test.want {
+			t.Errorf("%d%% with %v: got %d, want %d", test.configured, test.skills, got, test.want)
+		}
+	}
+}
+
+// seededFight fights rounds of combat on a server seeded with the given seed
+// until either fighter is defeated, and returns what happened.
+func seededFight(t *testing.T, seed int64) []string {
+	s := newLoadedTestServer(t)
+	s.seedRand(seed)
+	a := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	b := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	key := duelBetween("Alice", "Bob")
+	var rounds []string
+	for i := 0; i < 100; i++ {
+		outcome := s.fightRound(key, a, b, now)
+		rounds = append(rounds, outcome.msg)
+		if outcome.loser != nil {
+			break
+		}
+	}
+	return rounds
+}
+
+func TestSameSeedSameCombat(t *testing.T) {
+	first, second := seededFight(t, 7), seededFight(t, 7)
+	if len(first) != len(second) {
+		t.Fatalf("got %d and %d rounds with the same seed", len(first), len(second))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("round %d: got %q and %q with the same seed", i, first[i], second[i])
+		}
+	}
+}
+
+// fightCombats fights the rounds of combat due at now and returns what each
+// of the given clients reads.
+func fightCombats(t *testing.T, s *Server, now time.Time, clients ...client.Client) []client.Reply {
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer close(quit)
+
+	done := make(chan struct{})
+	go func() {
+		godPrintCombat(s, now, &wg, quit, createRoomsMap(s))
+		wg.Wait()
+		close(done)
+	}()
+	replies := make([]client.Reply, len(clients))
+	for i, c := range clients {
+		select {
+		case replies[i] = <-c.Reply:
+		case <-time.After(scriptTimeout):
+			t.Fatalf("%s read nothing", c.Player.Nickname)
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(scriptTimeout):
+		t.Fatal("the combat round did not finish")
+	}
+	return replies
+}
+
+func TestCombatRounds(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.pvp = pvpOn
+	s.Config.CombatRoundSeconds = 3
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	for _, c := range []client.Client{alice, bob} {
+		c.Player.HP, c.Player.MaxHP = 1000, 1000
+	}
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	key := duelBetween("Alice", "Bob")
+
+	if got := doAttack(s, alice, []string{"Bob"}, now).Actor; got != "You attack Bob!" {
+		t.Fatalf("attack: got %q", got)
+	}
+	if next, ok := s.combats[key]; !ok || !next.Equal(now) {
+		t.Fatalf("first round at %v, %v, want %v", next, ok, now)
+	}
+
+	// Nothing happens before the round is due.
+	fightCombats(t, s, now.Add(-time.Second))
+	if next := s.combats[key]; !next.Equal(now) {
+		t.Errorf("round fought early, next at %v", next)
+	}
+
+	// Both fighters read the round, and the next one is a round later.
+	for _, reply := range fightCombats(t, s, now, alice, bob) {
+		if !strings.Contains(reply.Events, "Alice") || !strings.Contains(reply.Events, "Bob") {
+			t.Errorf("got round %q", reply.Events)
+		}
+	}
+	if next, want := s.combats[key], now.Add(3*time.Second); !next.Equal(want) {
+		t.Errorf("next round at %v, want %v", next, want)
+	}
+
+	// The combat ends when the fighters are no longer in the same room.
+	s.movePlayer(bob.Player, "Town", "Inn", "1")
+	fightCombats(t, s, now.Add(3*time.Second))
+	if _, ok := s.combats[key]; ok {
+		t.Error("the combat went on after Bob left")
+	}
+}
+
+func TestFlee(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.seedRand(1)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	roomsMap := createRoomsMap(s)
+	key := duelBetween("Alice", "Bob")
+
+	// With a chance of 1% the seeded source makes Alice fail.
+	s.Config.FleeChance = 1
+	s.combats[key] = time.Now()
+	if ok, msg := doFlee(s, alice, roomsMap); ok || msg != "You try to flee but fail!" {
+		t.Errorf("unlikely flee: got %v, %q", ok, msg)
+	}
+	if _, ok := s.combats[key]; !ok {
+		t.Error("the combat ended after a failed flee")
+	}
+
+	// Bob stands east of Alice, so she can only flee south.
+	s.Config.FleeChance = 100
+	if ok, msg := doFlee(s, alice, roomsMap); !ok || msg != "You flee south!" {
+		t.Errorf("flee: got %v, %q", ok, msg)
+	}
+	if got := place(alice.Player); got != "Town/Square/4" {
+		t.Errorf("fled to %s", got)
+	}
+	if _, ok := s.combats[key]; ok {
+		t.Error("the combat went on after Alice fled")
+	}
+
+	// In the corner of the Inn, with Carol in the way, there is nowhere to go.
+	s.movePlayer(alice.Player, "Town", "Inn", "3")
+	addTestPlayer(t, s, "Carol", "Town", "Inn", "1")
+	if ok, msg := doFlee(s, alice, roomsMap); ok || msg != "There is nowhere to flee to!" {
+		t.Errorf("cornered: got %v, %q", ok, msg)
+	}
+}
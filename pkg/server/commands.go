@@ -0,0 +1,256 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// commands maps every command players can type to the event it causes.
+var commands = map[string]string{
+	"l":         "look",
+	"look":      "look",
+	"map":       "look",
+	"clear":     "clear",
+	"cls":       "clear",
+	"redraw":    "clear",
+	"e":         "move_east",
+	"east":      "move_east",
+	"w":         "move_west",
+	"west":      "move_west",
+	"n":         "move_north",
+	"north":     "move_north",
+	"s":         "move_south",
+	"south":     "move_south",
+	"quit":      "quit",
+	"exit":      "quit",
+	"goto":      "goto",
+	"summon":    "summon",
+	"where":     "where",
+	"stat":      "stat",
+	"reload":    "reload",
+	"gag":       "gag",
+	"note":      "note",
+	"notes":     "notes",
+	"ungag":     "ungag",
+	"filter":    "filter",
+	"locate":    "where",
+	"ban":       "ban",
+	"unban":     "unban",
+	"banlist":   "banlist",
+	"rename":    "rename",
+	"prompt":    "prompt",
+	"say":       "say",
+	"emote":     "emote",
+	"ooc":       "ooc",
+	"tell":      "tell",
+	"channel":   "channel",
+	"quiet":     "quiet",
+	"deaf":      "quiet",
+	"ignore":    "ignore",
+	"unignore":  "unignore",
+	"finger":    "finger",
+	"time":      "time",
+	"uptime":    "time",
+	"i":         "inventory",
+	"inv":       "inventory",
+	"inventory": "inventory",
+	"get":       "get",
+	"take":      "get",
+	"drop":      "drop",
+	"wear":      "wear",
+	"wield":     "wield",
+	"remove":    "remove",
+	"score":     "score",
+	"list":      "list",
+	"buy":       "buy",
+	"sell":      "sell",
+	"deposit":   "deposit",
+	"withdraw":  "withdraw",
+	"balance":   "balance",
+	"trade":     "trade",
+	"skills":    "skills",
+	"practice":  "practice",
+	"cast":      "cast",
+	"cooldowns": "cooldowns",
+	"flags":     "flags",
+	"setflag":   "setflag",
+	"clearflag": "clearflag",
+	"slay":      "slay",
+	"talk":      "talk",
+	"recall":    "recall",
+	"home":      "recall",
+	"save":      "save",
+	"exits":     "exits",
+	"areamap":   "areamap",
+	"areas":     "areas",
+	"compass":   "compass",
+	"charset":   "charset",
+	"autolook":  "autolook",
+	"ambient":   "ambient",
+	"brief":     "brief",
+	"settings":  "settings",
+	"pager":     "pager",
+	"who":       "who",
+	"afk":       "afk",
+	"duel":      "duel",
+	"attack":    "attack",
+	"kill":      "attack",
+	"flee":      "flee",
+	"news":      "news",
+	"mail":      "mail",
+	"color":     "color",
+	"colour":    "color",
+	"promote":   "promote",
+	"dig":       "dig",
+	"setdesc":   "setdesc",
+	"savearea":  "savearea",
+	"undo":      "undo",
+	"redo":      "redo",
+	"try":       "try",
+	"demote":    "demote",
+
+	"leaderboard": "leaderboard",
+	"top":         "leaderboard",
+}
+
+// maxSuggestionDistance is how many typos an unknown command may have for a
+// known command to still be suggested instead.
+const maxSuggestionDistance = 2
+
+// canUse returns true if the given player is allowed to cause the event.
+func (s *Server) canUse(c client.Client, etype string) bool {
+	return c.Player.PermissionLevel() >= s.commandPermission(etype)
+}
+
+// commandNames returns the commands the given player can use, sorted.
+func (s *Server) commandNames(c client.Client) []string {
+	names := []string{}
+	for name, etype := range commands {
+		if s.canUse(c, etype) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchCommand returns the event caused by cmd for the given player. Besides
+// the command names themselves, unique prefixes of them are accepted, with
+// exact names always winning. If cmd is a prefix of commands causing
+// different events, no event is returned but the matching commands are.
+func (s *Server) matchCommand(c client.Client, cmd string) (string, []string) {
+	if etype, ok := commands[cmd]; ok && s.canUse(c, etype) {
+		return etype, nil
+	}
+
+	var candidates []string
+	etypes := map[string]bool{}
+	for _, name := range s.commandNames(c) {
+		if strings.HasPrefix(name, cmd) {
+			candidates = append(candidates, name)
+			etypes[commands[name]] = true
+		}
+	}
+
+	if len(etypes) == 1 {
+		return commands[candidates[0]], nil
+	}
+	return "", candidates
+}
+
+// ambiguousCommand returns the reply to a player typing a prefix of several
+// commands.
+func (s *Server) ambiguousCommand(c client.Client, cmd string) string {
+	_, candidates := s.matchCommand(c, cmd)
+	return fmt.Sprintf("'%s' could be any of: %s.", cmd, strings.Join(candidates, ", "))
+}
+
+// unknownCommand returns the reply to a player typing an unknown command,
+// suggesting the closest command the player could have meant.
+func (s *Server) unknownCommand(c client.Client, cmd string) string {
+	if suggestion, ok := suggestCommand(cmd, s.commandNames(c)); ok {
+		return fmt.Sprintf("Unknown command '%s'. Did you mean '%s'?", cmd, suggestion)
+	}
+	return "Huh?"
+}
+
+// suggestCommand returns the name closest to cmd, as long as it is close
+// enough to be a typo of it. Single-letter shortcuts are never suggested.
+func suggestCommand(cmd string, names []string) (string, bool) {
+	best, bestDistance := "", maxSuggestionDistance+1
+	for _, name := range names {
+		if len(name) < 2 {
+			continue
+		}
+		if d := levenshtein(cmd, name); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best, bestDistance <= maxSuggestionDistance && bestDistance < len(cmd)
+}
+
+// levenshtein returns the number of single character insertions, deletions
+// or substitutions needed to turn a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// isNumber returns true if cmd is made of digits only.
+func isNumber(cmd string) bool {
+	if cmd == "" {
+		return false
+	}
+	for _, r := range cmd {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// allowCommand returns true if the player is within the configured command
+// rate at now.
+func (s *Server) allowCommand(c client.Client, now time.Time) bool {
+	if s.Config.CommandsPerSecond <= 0 || c.Player.IsAdmin() {
+		return true
+	}
+	burst := s.Config.CommandBurst
+	if burst < 1 {
+		burst = 1
+	}
+	return c.Throttle.Allow(now, s.Config.CommandsPerSecond, burst)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"look", "look", 0},
+		{"lok", "look", 1},
+		{"loko", "look", 2},
+		{"", "say", 3},
+		{"kitten", "sitting", 3},
+		{"émote", "emote", 1},
+	}
+	for _, test := range tests {
+		if got := levenshtein(test.a, test.b); got != test.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := levenshtein(test.b, test.a); got != test.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+func TestSuggestCommand(t *testing.T) {
+	names := []string{"e", "emote", "look", "quit", "say", "who"}
+
+	tests := []struct {
+		cmd  string
+		want string
+		ok   bool
+	}{
+		{cmd: "lok", want: "look", ok: true},
+		{cmd: "loook", want: "look", ok: true},
+		{cmd: "qiut", want: "quit", ok: true},
+		{cmd: "emot", want: "emote", ok: true},
+		// Too far from anything.
+		{cmd: "dance", ok: false},
+		// As many typos as letters is no typo.
+		{cmd: "xy", ok: false},
+		// Single-letter shortcuts are never suggested.
+		{cmd: "x", ok: false},
+	}
+	for _, test := range tests {
+		got, ok := suggestCommand(test.cmd, names)
+		if ok != test.ok || (ok && got != test.want) {
+			t.Errorf("suggestCommand(%q) = %q, %v, want %q, %v", test.cmd, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+
+	if got, want := s.unknownCommand(c, "lok"), "Unknown command 'lok'. Did you mean 'look'?"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := s.unknownCommand(c, "xyzzy"); got != "Huh?" {
+		t.Errorf("got %q, want Huh?", got)
+	}
+	// Commands the player cannot use are never suggested.
+	if got := s.unknownCommand(c, "summo"); got != "Huh?" {
+		t.Errorf("got %q for an admin command", got)
+	}
+}
+
+func TestMatchCommand(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+
+	tests := []struct {
+		cmd        string
+		etype      string
+		candidates []string
+	}{
+		// Exact names win, even when they prefix other commands.
+		{cmd: "e", etype: "move_east"},
+		{cmd: "s", etype: "move_south"},
+		// Unique prefixes resolve.
+		{cmd: "cool", etype: "cooldowns"},
+		{cmd: "ea", etype: "move_east"},
+		// Several names for the same event are still unique.
+		{cmd: "col", etype: "color"},
+		// Ambiguous prefixes list what they could be.
+		{cmd: "cha", candidates: []string{"channel", "charset"}},
+		// Admin commands are not matched for players.
+		{cmd: "setd"},
+		{cmd: "summon"},
+	}
+	for _, test := range tests {
+		etype, candidates := s.matchCommand(c, test.cmd)
+		if etype != test.etype {
+			t.Errorf("matchCommand(%q) = %q, want %q", test.cmd, etype, test.etype)
+		}
+		if strings.Join(candidates, ",") != strings.Join(test.candidates, ",") {
+			t.Errorf("matchCommand(%q) candidates = %v, want %v", test.cmd, candidates, test.candidates)
+		}
+	}
+
+	if got, want := s.ambiguousCommand(c, "cha"), "'cha' could be any of: channel, charset."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHandleCommandPager(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+	c.Pager.Page("1\n2\n3", 1)
+
+	// The pager answers the next line while output is pending.
+	s.HandleCommand(c, "")
+	if ev := <-s.Events; ev.Etype != "more" || ev.Cmd != "" {
+		t.Errorf("got %s event %q, want more", ev.Etype, ev.Cmd)
+	}
+	s.HandleCommand(c, "q")
+	if ev := <-s.Events; ev.Etype != "more" || ev.Cmd != "q" {
+		t.Errorf("got %s event %q, want more q", ev.Etype, ev.Cmd)
+	}
+
+	// Any other command stops paging and runs as usual.
+	s.HandleCommand(c, "look")
+	if ev := <-s.Events; ev.Etype != "look" {
+		t.Errorf("got %s event, want look", ev.Etype)
+	}
+	if c.Pager.Pending() {
+		t.Error("output is still pending")
+	}
+}
+
+func TestAllowCommand(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.CommandsPerSecond, s.Config.CommandBurst = 1, 1
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	admin := addTestPlayer(t, s, "Root", "Town", "Square", "2")
+	admin.Player.Admin = true
+	now := time.Now()
+
+	if !s.allowCommand(alice, now) || s.allowCommand(alice, now) {
+		t.Error("Alice is not throttled after a burst of one command")
+	}
+	for i := 0; i < 3; i++ {
+		if !s.allowCommand(admin, now) {
+			t.Fatal("admins are throttled")
+		}
+	}
+
+	s.Config.CommandsPerSecond = 0
+	if !s.allowCommand(alice, now) {
+		t.Error("Alice is throttled with throttling turned off")
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// doCooldowns shows the actions the player cannot use yet and how long
+// before the player can use them again.
+func doCooldowns(c client.Client, now time.Time) string {
+	var names []string
+	for name := range c.Player.Cooldowns {
+		if c.Player.CooldownLeft(name, now) > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "You have nothing on cooldown."
+	}
+	sort.Strings(names)
+
+	lines := []string{"Cooldowns:"}
+	for _, name := range names {
+		seconds := int((c.Player.CooldownLeft(name, now) + time.Second - 1) / time.Second)
+		lines = append(lines, fmt.Sprintf("  %-15s %s", name, plural(seconds, "second")))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDoCooldowns(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	now := time.Now()
+
+	if got := doCooldowns(c, now); got != "You have nothing on cooldown." {
+		t.Errorf("got %q", got)
+	}
+
+	c.Player.Cooldowns = map[string]time.Time{
+		"spark":  now.Add(1500 * time.Millisecond),
+		"heal":   now.Add(time.Second),
+		"recall": now.Add(-time.Second),
+	}
+	want := "Cooldowns:\n  heal            1 second\n  spark           2 seconds"
+	if got := doCooldowns(c, now); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
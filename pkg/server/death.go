@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// corpse holds what a player carried when defeated, left where the player
+// fell until it decays.
+type corpse struct {
+	owner  string
+	items  []game.Item
+	decays time.Time
+}
+
+// xpLoss returns the experience a character with the given experience loses
+// when defeated, given the percent of it the death penalty takes.
+func xpLoss(xp, percent int) int {
+	if xp <= 0 || percent <= 0 {
+		return 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	return xp * percent / 100
+}
+
+// penalize applies the death penalty of the server to the player defeated at
+// now, before the player is moved away, and describes it. The player loses
+// DeathXPPercent of their experience and leaves a corpse holding what they
+// carry, unless CorpseSeconds is zero.
+func (s *Server) penalize(c client.Client, now time.Time) string {
+	var lines []string
+	if loss := xpLoss(c.Player.XP, s.Config.DeathXPPercent); loss > 0 {
+		c.Player.XP -= loss
+		lines = append(lines, fmt.Sprintf("You lose %d XP.", loss))
+	}
+
+	if s.Config.CorpseSeconds > 0 && len(c.Player.Inventory) > 0 {
+		key := roomKey(c.Player.Area, c.Player.Room)
+		s.corpses[key] = append(s.corpses[key], corpse{
+			owner:  c.Player.Nickname,
+			items:  c.Player.Inventory,
+			decays: now.Add(time.Duration(s.Config.CorpseSeconds) * time.Second),
+		})
+		c.Player.Inventory = nil
+		lines = append(lines, fmt.Sprintf("Your belongings are left on your corpse in %s. Go back and get corpse before it decays.", c.Player.Room))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// corpsesIn returns the corpses in the given room.
+func (s *Server) corpsesIn(areaName, roomName string) []corpse {
+	return s.corpses[roomKey(areaName, roomName)]
+}
+
+// decayCorpses does away with the corpses that decay at now, along with
+// everything left on them.
+func (s *Server) decayCorpses(now time.Time) {
+	for key, corpses := range s.corpses {
+		var kept []corpse
+		for _, cp := range corpses {
+			if now.Before(cp.decays) {
+				kept = append(kept, cp)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.corpses, key)
+			continue
+		}
+		s.corpses[key] = kept
+	}
+}
+
+// doGetCorpse takes everything left on the corpses of the player in the room
+// of the player. Nobody else can take anything from them.
+func doGetCorpse(s *Server, c client.Client) CommandResult {
+	key := roomKey(c.Player.Area, c.Player.Room)
+	var mine []game.Item
+	var others []corpse
+	for _, cp := range s.corpses[key] {
+		if cp.owner != c.Player.Nickname {
+			others = append(others, cp)
+			continue
+		}
+		for _, item := range cp.items {
+			mine = game.AddItem(mine, item)
+		}
+	}
+	if len(others) == len(s.corpses[key]) {
+		if len(others) > 0 {
+			return CommandResult{Actor: "You cannot take anything from the corpse of somebody else."}
+		}
+		return CommandResult{Actor: "There is no corpse here."}
+	}
+	if !s.canCarry(c.Player, mine) {
+		return CommandResult{Actor: "You cannot carry that much."}
+	}
+
+	if len(others) == 0 {
+		delete(s.corpses, key)
+	} else {
+		s.corpses[key] = others
+	}
+	for _, item := range mine {
+		c.Player.Inventory = game.AddItem(c.Player.Inventory, item)
+	}
+	return CommandResult{
+		Actor: fmt.Sprintf("You take %s from your corpse.", strings.Join(itemNames(mine), ", ")),
+		Room:  fmt.Sprintf("%s takes back their belongings from their corpse.", c.Player.Nickname),
+	}
+}
+
+// corpseNames describes the corpses.
+func corpseNames(corpses []corpse) []string {
+	names := make([]string, 0, len(corpses))
+	for _, cp := range corpses {
+		names = append(names, fmt.Sprintf("the corpse of %s", cp.owner))
+	}
+	return names
+}
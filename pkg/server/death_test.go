@@ -0,0 +1,156 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// testRope is an item to hand out in tests that cannot be worn.
+var testRope = game.Item{Name: "Rope"}
+
+func TestXPLoss(t *testing.T) {
+	tests := []struct {
+		xp, percent, want int
+	}{
+		{xp: 1000, percent: 10, want: 100},
+		{xp: 999, percent: 10, want: 99},
+		{xp: 1000, percent: 0, want: 0},
+		{xp: 1000, percent: 100, want: 1000},
+		{xp: 1000, percent: 150, want: 1000},
+		{xp: 1000, percent: -5, want: 0},
+		{xp: 0, percent: 10, want: 0},
+		{xp: 5, percent: 10, want: 0},
+	}
+	for _, test := range tests {
+		if got := xpLoss(test.xp, test.percent); got != test.want {
+			t.Errorf("%d%% of %d: got %d, want %d", test.percent, test.xp, got, test.want)
+		}
+	}
+}
+
+func TestPenalizeLeavesCorpse(t *testing.T) {
+	s := newTestServer(t, nil)
+	s.Config.DeathXPPercent = 10
+	s.Config.CorpseSeconds = 60
+	c := addTestPlayer(t, s, "Alice", "Town", "Inn", "1")
+	c.Player.XP = 250
+	c.Player.Inventory = []game.Item{testSword, testRope}
+	now := time.Now()
+
+	penalty := s.penalize(c, now)
+	if want := "You lose 25 XP.\nYour belongings are left on your corpse in Inn. Go back and get corpse before it decays."; penalty != want {
+		t.Errorf("penalty: got %q, want %q", penalty, want)
+	}
+	if c.Player.XP != 225 || len(c.Player.Inventory) != 0 {
+		t.Errorf("got %d XP and %v", c.Player.XP, itemNames(c.Player.Inventory))
+	}
+
+	corpses := s.corpsesIn("Town", "Inn")
+	if len(corpses) != 1 || corpses[0].owner != "Alice" || !corpses[0].decays.Equal(now.Add(time.Minute)) {
+		t.Fatalf("got %+v", corpses)
+	}
+	if got := strings.Join(itemNames(corpses[0].items), ","); got != "Short Sword,Rope" {
+		t.Errorf("corpse holds %s", got)
+	}
+}
+
+func TestDefeatPenalizes(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.DeathXPPercent = 10
+	s.Config.CorpseSeconds = 60
+	c := addTestPlayer(t, s, "Alice", "Town", "Inn", "1")
+	c.Player.XP = 100
+	c.Player.HP = 0
+	c.Player.Inventory = []game.Item{testRope}
+
+	penalty := s.defeat(c, time.Now())
+	if !strings.HasPrefix(penalty, "You lose 10 XP.") {
+		t.Errorf("penalty: got %q", penalty)
+	}
+	if len(s.corpsesIn("Town", "Inn")) != 1 {
+		t.Errorf("no corpse was left where Alice fell")
+	}
+	if c.Player.Room != "Square" || c.Player.HP != c.Player.MaxHP {
+		t.Errorf("woke up in %s with %d/%d HP", c.Player.Room, c.Player.HP, c.Player.MaxHP)
+	}
+}
+
+func TestPenalizeWithoutPenalty(t *testing.T) {
+	s := newTestServer(t, nil)
+	c := addTestPlayer(t, s, "Alice", "Town", "Inn", "1")
+	c.Player.XP = 250
+	c.Player.Inventory = []game.Item{testSword}
+
+	if penalty := s.penalize(c, time.Now()); penalty != "" {
+		t.Errorf("penalty: got %q", penalty)
+	}
+	if c.Player.XP != 250 || len(c.Player.Inventory) != 1 || len(s.corpses) != 0 {
+		t.Errorf("got %d XP, %v and corpses %+v", c.Player.XP, itemNames(c.Player.Inventory), s.corpses)
+	}
+}
+
+func TestDecayCorpses(t *testing.T) {
+	s := newTestServer(t, nil)
+	now := time.Now()
+	s.corpses[roomKey("Town", "Inn")] = []corpse{
+		{owner: "Alice", items: []game.Item{testSword}, decays: now.Add(time.Minute)},
+		{owner: "Bob", items: []game.Item{testSword}, decays: now.Add(2 * time.Minute)},
+	}
+	s.corpses[roomKey("Town", "Square")] = []corpse{
+		{owner: "Carol", items: []game.Item{testSword}, decays: now.Add(time.Minute)},
+	}
+
+	s.decayCorpses(now.Add(59 * time.Second))
+	if len(s.corpsesIn("Town", "Inn")) != 2 || len(s.corpsesIn("Town", "Square")) != 1 {
+		t.Errorf("decayed too soon: %+v", s.corpses)
+	}
+
+	s.decayCorpses(now.Add(time.Minute))
+	if got := corpseNames(s.corpsesIn("Town", "Inn")); strings.Join(got, ",") != "the corpse of Bob" {
+		t.Errorf("left %v in the inn", got)
+	}
+	if _, ok := s.corpses[roomKey("Town", "Square")]; ok {
+		t.Error("the square still holds corpses")
+	}
+
+	s.decayCorpses(now.Add(2 * time.Minute))
+	if len(s.corpses) != 0 {
+		t.Errorf("left %+v", s.corpses)
+	}
+}
+
+func TestGetCorpse(t *testing.T) {
+	s := newTestServer(t, nil)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Inn", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Inn", "2")
+	decays := time.Now().Add(time.Minute)
+
+	if got := doGet(s, alice, []string{"corpse"}).Actor; got != "There is no corpse here." {
+		t.Errorf("got %q", got)
+	}
+
+	s.corpses[roomKey("Town", "Inn")] = []corpse{
+		{owner: "Alice", items: []game.Item{testSword, arrowStack(5)}, decays: decays},
+		{owner: "Alice", items: []game.Item{arrowStack(3)}, decays: decays},
+	}
+	if got := doLook(s, alice).Actor; got != "Bob is here.\nYou see the corpse of Alice, the corpse of Alice." {
+		t.Errorf("look: got %q", got)
+	}
+	if got := doGet(s, bob, []string{"corpse"}).Actor; got != "You cannot take anything from the corpse of somebody else." {
+		t.Errorf("Bob: got %q", got)
+	}
+
+	got := doGet(s, alice, []string{"corpse"})
+	if got.Actor != "You take Short Sword, 8 Arrows from your corpse." || got.Room != "Alice takes back their belongings from their corpse." {
+		t.Errorf("got %+v", got)
+	}
+	if got := strings.Join(itemNames(alice.Player.Inventory), ","); got != "Short Sword,8 Arrows" {
+		t.Errorf("carrying %s", got)
+	}
+	if len(s.corpses) != 0 {
+		t.Errorf("left %+v", s.corpses)
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// PvP modes a server can be in.
+const (
+	// pvpOn lets players fight each other freely.
+	pvpOn = "on"
+	// pvpOff keeps players from fighting each other.
+	pvpOff = "off"
+	// pvpConsent lets players fight each other only during a duel both
+	// agreed to.
+	pvpConsent = "consent"
+)
+
+// Durations of duels when none are configured.
+const (
+	defaultDuelChallengeSeconds = 60
+	defaultDuelSeconds          = 600
+)
+
+// parsePvP checks the configured PvP mode. No mode means consent.
+func parsePvP(mode string) (string, error) {
+	switch mode {
+	case "":
+		return pvpConsent, nil
+	case pvpOn, pvpOff, pvpConsent:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid pvp mode %q, use on, off or consent", mode)
+}
+
+// duelKey identifies a challenge from one player to another, or a duel
+// between two players when the nicknames are sorted.
+type duelKey struct {
+	from, to string
+}
+
+// duelBetween returns the key of the duel between the two players.
+func duelBetween(a, b string) duelKey {
+	if a > b {
+		a, b = b, a
+	}
+	return duelKey{a, b}
+}
+
+// inDuel returns true if the player with the given nickname is dueling or
+// has challenged or been challenged to a duel.
+func (s *Server) inDuel(nick string) bool {
+	for _, keys := range []map[duelKey]time.Time{s.duels, s.challenges} {
+		for key := range keys {
+			if key.from == nick || key.to == nick {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canFight returns true if the attacker may harm the defender at now,
+// otherwise a message telling the attacker why not.
+func (s *Server) canFight(attacker, defender *area.Player, now time.Time) (bool, string) {
+	switch s.pvp {
+	case pvpOn:
+		return true, ""
+	case pvpOff:
+		return false, "Players cannot fight each other here."
+	}
+
+	if until, ok := s.duels[duelBetween(attacker.Nickname, defender.Nickname)]; ok && now.Before(until) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("%s is not flagged for PvP with you. Challenge them with duel %s.", defender.Nickname, defender.Nickname)
+}
+
+// expireDuels forgets the challenges and duels that are over at now.
+func (s *Server) expireDuels(now time.Time) {
+	for key, until := range s.challenges {
+		if !now.Before(until) {
+			delete(s.challenges, key)
+		}
+	}
+	for key, until := range s.duels {
+		if !now.Before(until) {
+			delete(s.duels, key)
+		}
+	}
+}
+
+// doDuel challenges the player given in args to a duel, or accepts the
+// challenge of that player. Challenges and duels only last for a while.
+func doDuel(s *Server, c client.Client, args []string, now time.Time) CommandResult {
+	if s.pvp != pvpConsent {
+		return CommandResult{Actor: "There are no duels here."}
+	}
+	if len(args) != 1 {
+		return CommandResult{Actor: "Usage: duel <nick>"}
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return CommandResult{Actor: fmt.Sprintf("%s is not online.", args[0])}
+	}
+	me, them := c.Player.Nickname, target.Player.Nickname
+	if me == them {
+		return CommandResult{Actor: "You cannot duel yourself."}
+	}
+
+	if until, ok := s.duels[duelBetween(me, them)]; ok && now.Before(until) {
+		return CommandResult{Actor: fmt.Sprintf("You are already dueling %s.", them)}
+	}
+
+	if until, ok := s.challenges[duelKey{them, me}]; ok && now.Before(until) {
+		delete(s.challenges, duelKey{them, me})
+		seconds := limit(s.Config.DuelSeconds, defaultDuelSeconds)
+		s.duels[duelBetween(me, them)] = now.Add(time.Duration(seconds) * time.Second)
+		return CommandResult{
+			Actor:   fmt.Sprintf("You accept the duel with %s.", them),
+			Room:    fmt.Sprintf("%s and %s start a duel.", me, them),
+			Targets: []Target{{Client: target, Msg: fmt.Sprintf("%s accepts your duel.", me)}},
+			Role:    theme.Combat,
+		}
+	}
+
+	seconds := limit(s.Config.DuelChallengeSeconds, defaultDuelChallengeSeconds)
+	s.challenges[duelKey{me, them}] = now.Add(time.Duration(seconds) * time.Second)
+	return CommandResult{
+		Actor: fmt.Sprintf("You challenge %s to a duel.", them),
+		Targets: []Target{{
+			Client: target,
+			Msg:    fmt.Sprintf("%s challenges you to a duel. Type duel %s within %s to accept.", me, me, plural(seconds, "second")),
+		}},
+		Role: theme.Combat,
+	}
+}
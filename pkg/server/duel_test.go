@@ -0,0 +1,89 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttackNeedsConsent(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	now := time.Now()
+
+	refused := "Bob is not flagged for PvP with you. Challenge them with duel Bob."
+	if got := doAttack(s, alice, []string{"Bob"}, now).Actor; got != refused {
+		t.Fatalf("attack without a duel: got %q", got)
+	}
+
+	// A challenge alone is not consent.
+	if got := doDuel(s, alice, []string{"Bob"}, now).Actor; got != "You challenge Bob to a duel." {
+		t.Fatalf("challenge: got %q", got)
+	}
+	if got := doAttack(s, alice, []string{"Bob"}, now).Actor; got != refused {
+		t.Errorf("attack after a challenge: got %q", got)
+	}
+
+	if got := doDuel(s, bob, []string{"Alice"}, now).Actor; got != "You accept the duel with Alice." {
+		t.Fatalf("accept: got %q", got)
+	}
+	if got := doAttack(s, alice, []string{"Bob"}, now).Actor; got != "You attack Bob!" {
+		t.Errorf("attack during the duel: got %q", got)
+	}
+	s.endCombats("Alice")
+
+	// Duels are over after a while.
+	over := now.Add(defaultDuelSeconds * time.Second)
+	if got := doAttack(s, alice, []string{"Bob"}, over).Actor; got != refused {
+		t.Errorf("attack after the duel: got %q", got)
+	}
+}
+
+func TestDuelChallengeExpires(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	now := time.Now()
+
+	doDuel(s, alice, []string{"Bob"}, now)
+	late := now.Add(defaultDuelChallengeSeconds * time.Second)
+	s.expireDuels(late)
+	if got := doDuel(s, bob, []string{"Alice"}, late).Actor; got != "You challenge Alice to a duel." {
+		t.Errorf("late accept: got %q", got)
+	}
+}
+
+func TestAttackPvPModes(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: pvpOff, want: "Players cannot fight each other here."},
+		{mode: pvpOn, want: "You attack Bob!"},
+	}
+	for _, test := range tests {
+		s.pvp = test.mode
+		if got := doAttack(s, alice, []string{"Bob"}, time.Now()).Actor; got != test.want {
+			t.Errorf("pvp %s: got %q, want %q", test.mode, got, test.want)
+		}
+		s.endCombats("Alice")
+	}
+	if got := doDuel(s, alice, []string{"Bob"}, time.Now()).Actor; got != "There are no duels here." {
+		t.Errorf("duel with pvp on: got %q", got)
+	}
+}
+
+func TestParsePvP(t *testing.T) {
+	for mode, want := range map[string]string{"": pvpConsent, "on": pvpOn, "off": pvpOff, "consent": pvpConsent} {
+		if got, err := parsePvP(mode); err != nil || got != want {
+			t.Errorf("%q: got %q, %v, want %q", mode, got, err, want)
+		}
+	}
+	if _, err := parsePvP("sometimes"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"os"
+)
+
+// NotFoundError is returned when a file that must exist, such as the config
+// file, does not.
+type NotFoundError struct {
+	Path string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s does not exist", e.Path)
+}
+
+// ReadError is returned when a file exists but cannot be read.
+type ReadError struct {
+	Path string
+	Err  error
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("%s could not be loaded: %v", e.Path, e.Err)
+}
+
+func (e *ReadError) Unwrap() error { return e.Err }
+
+// ParseError is returned when a file cannot be decoded.
+type ParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s could not be unmarshaled: %v", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// ValidationError is returned when a file decodes fine but what it holds
+// does not make sense, such as an unknown weapon or an exit to nowhere. Path
+// is empty for problems spanning several files.
+type ValidationError struct {
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// readFileError returns the error to report for a file that could not be
+// read, telling missing files apart.
+func readFileError(path string, err error) error {
+	if os.IsNotExist(err) {
+		return &NotFoundError{Path: path}
+	}
+	return &ReadError{Path: path, Err: err}
+}
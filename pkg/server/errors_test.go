@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  interface{}
+	}{
+		{name: "missing", files: nil, want: &NotFoundError{}},
+		{name: "undecodable", files: map[string]string{"server.toml": "[config\n"}, want: &ParseError{}},
+		{name: "invalid", files: map[string]string{"server.toml": testConfig + "charset = \"ebcdic\"\n"}, want: &ValidationError{}},
+	}
+	for _, test := range tests {
+		s := newTestServer(t, test.files)
+		err := s.loadConfig()
+		if !sameErrorType(err, test.want) {
+			t.Errorf("%s config: got %T %v, want a %T", test.name, err, err, test.want)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  interface{}
+	}{
+		{
+			name:  "undecodable area",
+			files: map[string]string{"areas/bad.toml": "name = \"Bad\"\nrooms = ["},
+			want:  &ParseError{},
+		},
+		{
+			name:  "exit to nowhere",
+			files: map[string]string{"areas/keep.toml": "name = \"Keep\"\n[rooms.Hall]\nname = \"Hall\"\ncubes = [ { id = \"1\", posx = \"0\", posy = \"0\", type = \"door\", exits = [ { toArea = \"Keep\", toRoom = \"Cellar\", toCubeId = \"1\" } ] } ]\n"},
+			want:  &ValidationError{},
+		},
+		{
+			name:  "undecodable ban list",
+			files: map[string]string{"banlist.toml": "bans = ["},
+			want:  &ParseError{},
+		},
+	}
+	for _, test := range tests {
+		files := map[string]string{"server.toml": testConfig, "areas/town.toml": testArea}
+		for name, content := range test.files {
+			files[name] = content
+		}
+		s := newTestServer(t, files)
+		if err := s.loadConfig(); err != nil {
+			t.Fatal(err)
+		}
+		err := s.Load()
+		if !sameErrorType(err, test.want) {
+			t.Errorf("%s: got %T %v, want a %T", test.name, err, err, test.want)
+		}
+	}
+
+	s := newTestServer(t, map[string]string{"server.toml": testConfig, "areas/town.toml": testArea})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(); err != nil {
+		t.Errorf("valid content: got %v", err)
+	}
+}
+
+func TestReadPlayerErrors(t *testing.T) {
+	s := newLoadedTestServer(t)
+	if err := s.ensurePlayerDir(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(s.playerDir(), "alice.toml"), []byte("nickname = "), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exists, err := s.loadPlayer("Alice")
+	if !exists || !sameErrorType(err, &ParseError{}) {
+		t.Errorf("got %v, %T %v, want a *ParseError", exists, err, err)
+	}
+}
+
+func TestReadFileError(t *testing.T) {
+	_, notExist := os.Open(filepath.Join(os.TempDir(), "thyra-missing", "server.toml"))
+	if err := readFileError("server.toml", notExist); !sameErrorType(err, &NotFoundError{}) {
+		t.Errorf("missing file: got %T", err)
+	}
+
+	denied := errors.New("permission denied")
+	err := readFileError("server.toml", denied)
+	if !sameErrorType(err, &ReadError{}) || !errors.Is(err, denied) {
+		t.Errorf("unreadable file: got %T %v", err, err)
+	}
+}
+
+// sameErrorType returns true if err is, or wraps, an error of the same type
+// as want, which is one of the error types of loading static content.
+func sameErrorType(err error, want interface{}) bool {
+	switch want.(type) {
+	case *NotFoundError:
+		var target *NotFoundError
+		return errors.As(err, &target)
+	case *ReadError:
+		var target *ReadError
+		return errors.As(err, &target)
+	case *ParseError:
+		var target *ParseError
+		return errors.As(err, &target)
+	case *ValidationError:
+		var target *ValidationError
+		return errors.As(err, &target)
+	}
+	return false
+}
@@ -0,0 +1,716 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// eventHandler handles an event in God, replying to the players involved.
+// Replies are tracked by wg and given up on once quit is closed.
+type eventHandler func(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+)
+
+// replyWith returns the handler replying to the player who caused the event
+// with what reply returns.
+func replyWith(reply func(s *Server, cl client.Client, ev client.Event) string) eventHandler {
+	return func(
+		s *Server,
+		ev client.Event,
+		wg *sync.WaitGroup,
+		quit <-chan struct{},
+		roomsMap map[string]map[string][][]area.Cube,
+	) {
+		cl := ev.Client
+		wg.Add(1)
+		godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, reply(s, *cl, ev), "")
+	}
+}
+
+// deliverWith returns the handler delivering the result run returns.
+func deliverWith(run func(s *Server, cl client.Client, ev client.Event) CommandResult) eventHandler {
+	return func(
+		s *Server,
+		ev client.Event,
+		wg *sync.WaitGroup,
+		quit <-chan struct{},
+		roomsMap map[string]map[string][][]area.Cube,
+	) {
+		deliver(s, *ev.Client, run(s, *ev.Client, ev), wg, quit, roomsMap)
+	}
+}
+
+// eventHandlers maps every event to the handler God runs when it happens.
+var eventHandlers = map[string]eventHandler{
+	"look": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doLook(s, cl)
+	}),
+	"clear": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doClear(cl)
+	}),
+	"move_east":  onMove(0),
+	"move_west":  onMove(1),
+	"move_north": onMove(2),
+	"move_south": onMove(3),
+	"enter_door": onEnterDoor,
+	"goto":       onGoto,
+	"recall":     onRecall,
+	"save": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		if len(ev.Args) == 1 && ev.Args[0] == "area" {
+			if !s.canUse(cl, "savearea") {
+				return "save area is not a command you can use."
+			}
+			return doSaveArea(s, cl)
+		}
+		return doSave(s, cl, time.Now())
+	}),
+	"summon": onSummon,
+	"gag":    onGag,
+	"ungag":  onUngag,
+	"note": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doNote(s, cl, ev.Args)
+	}),
+	"notes": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doNotes(s, ev.Args)
+	}),
+	"stat": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doStat(s, cl, ev.Args)
+	}),
+	"reload": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doReload(s, cl)
+	}),
+	"filter": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doFilter(cl, ev.Args)
+	}),
+	"where": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doWhere(s, ev.Args)
+	}),
+	"ban": onBan,
+	"unban": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doUnban(s, cl, ev.Args)
+	}),
+	"banlist": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doBanList(s)
+	}),
+	"rename": onRename,
+	"prompt": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doPrompt(cl, ev.Args)
+	}),
+	"say":   onSay,
+	"emote": onEmote,
+	"ooc":   onOoc,
+	"tell":  onTell,
+	"channel": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doChannel(cl, ev.Args)
+	}),
+	"quiet": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doQuiet(cl)
+	}),
+	"ignore": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doIgnore(s, cl, ev.Args)
+	}),
+	"unignore": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doUnignore(cl, ev.Args)
+	}),
+	"finger": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doFinger(s, ev.Args, time.Now())
+	}),
+	"time": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doTime(s, time.Now())
+	}),
+	"inventory": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doInventory(cl)
+	}),
+	"get": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doGet(s, cl, ev.Args)
+	}),
+	"drop": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doDrop(s, cl, ev.Args)
+	}),
+	"wear": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doWear(cl, ev.Args, false)
+	}),
+	"wield": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doWear(cl, ev.Args, true)
+	}),
+	"remove": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doRemove(cl, ev.Args)
+	}),
+	"score": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doScore(cl)
+	}),
+	"list": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doList(s, cl)
+	}),
+	"buy": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doBuy(s, cl, ev.Args)
+	}),
+	"sell": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doSell(s, cl, ev.Args)
+	}),
+	"deposit": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doDeposit(s, cl, ev.Args)
+	}),
+	"withdraw": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doWithdraw(s, cl, ev.Args)
+	}),
+	"balance": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doBalance(s, cl)
+	}),
+	"trade": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doTrade(s, cl, ev.Args)
+	}),
+	"skills": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doSkills(s, cl)
+	}),
+	"practice": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doPractice(s, cl, ev.Args)
+	}),
+	"cast": onCast,
+	"cooldowns": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doCooldowns(cl, time.Now())
+	}),
+	"flags": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doFlags(s, ev.Args)
+	}),
+	"setflag": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doSetFlag(s, cl, ev.Args)
+	}),
+	"clearflag": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doClearFlag(s, cl, ev.Args)
+	}),
+	"slay": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doSlay(s, cl, ev.Args, time.Now())
+	}),
+	"promote": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doPromote(s, cl, ev.Args, true)
+	}),
+	"demote": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doPromote(s, cl, ev.Args, false)
+	}),
+	"dig":     onDig,
+	"setdesc": onSetDesc,
+	"undo":    onUndo,
+	"redo":    onUndo,
+	"try":     onTry,
+	"savearea": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doSaveArea(s, cl)
+	}),
+	"talk": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doTalk(s, cl, ev.Args)
+	}),
+	"respond": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doRespond(s, cl, ev.Cmd)
+	}),
+	"too_long": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return s.paint(theme.Error, "That was too long, so it was ignored.")
+	}),
+	"notice": onNotice,
+	"exits":  onExits,
+	"areamap": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doAreaMap(s, cl)
+	}),
+	"areas": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doAreas(s, cl)
+	}),
+	"charset": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doCharset(s, cl, ev.Args)
+	}),
+	"compass": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doCompass(cl, ev.Args)
+	}),
+	"autolook": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doAutoLook(cl, ev.Args)
+	}),
+	"ambient": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doAmbient(cl, ev.Args)
+	}),
+	"brief": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doBrief(cl, ev.Args)
+	}),
+	"news": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doNews(s, cl, ev.Args)
+	}),
+	"leaderboard": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doLeaderboard(s, cl, time.Now())
+	}),
+	"mail": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doMail(s, cl, ev.Args, time.Now())
+	}),
+	"duel": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doDuel(s, cl, ev.Args, time.Now())
+	}),
+	"attack": deliverWith(func(s *Server, cl client.Client, ev client.Event) CommandResult {
+		return doAttack(s, cl, ev.Args, time.Now())
+	}),
+	"flee": onFlee,
+	"afk": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doAFK(cl, ev.Args)
+	}),
+	"who": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doWho(s, cl, ev.Args)
+	}),
+	"more": onMore,
+	"pager": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doPager(cl, ev.Args)
+	}),
+	"settings": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doSettings(s, cl)
+	}),
+	"color": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return doColor(cl, ev.Args)
+	}),
+	"quit":       onQuit,
+	"disconnect": onDisconnect,
+	"throttled": replyWith(func(s *Server, cl client.Client, ev client.Event) string {
+		return s.paint(theme.Error, "Slow down.")
+	}),
+	"ambiguous": onAmbiguous,
+	"unknown":   onUnknown,
+}
+
+// onMove returns the handler moving the player in the given direction.
+func onMove(direction int) eventHandler {
+	return func(
+		s *Server,
+		ev client.Event,
+		wg *sync.WaitGroup,
+		quit <-chan struct{},
+		roomsMap map[string]map[string][][]area.Cube,
+	) {
+		cl := ev.Client
+		c := s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room)
+		if s.isFighting(cl.Player.Nickname) {
+			wg.Add(1)
+			godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, "You are fighting! Try to flee instead.", "")
+			return
+		}
+		msg := doMove(s, *cl, roomsMap, direction)
+		wg.Add(1)
+		godPrintRoom(s, *cl, c, wg, quit, roomsMap, msg, "")
+	}
+}
+
+func onEnterDoor(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	currentroom := s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room)
+	wg.Add(1)
+	godPrintRoom(s, *cl, currentroom, wg, quit, roomsMap, "", fmt.Sprintf("%s enter the room.", cl.Player.Nickname))
+
+	previousroom := s.OnlineClientsGetByRoom(cl.Player.PreviousArea, cl.Player.PreviousRoom)
+	if previousroom != nil {
+		wg.Add(1)
+		godPrintRoom(s, *cl, previousroom, wg, quit, roomsMap, "", fmt.Sprintf("%s left the room.", cl.Player.Nickname))
+	}
+}
+
+// onDig digs a room next to the builder, showing the new door to everybody
+// in the room.
+func onDig(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	msg := doDig(s, *cl, ev.Args, roomsMap)
+	wg.Add(1)
+	godPrintRoom(s, *cl, s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room), wg, quit, roomsMap, msg, "")
+}
+
+// onSetDesc changes the description of the room of the builder, showing it
+// to everybody in the room.
+func onSetDesc(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	msg := doSetDesc(s, *cl, ev.Args)
+	wg.Add(1)
+	godPrintRoom(s, *cl, s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room), wg, quit, roomsMap, msg, "")
+}
+
+// onUndo undoes or redoes an edit of the builder, showing the rooms changed
+// to everybody in them.
+func onUndo(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	edit, msg := doUndo(s, *cl, ev.Etype == "redo", roomsMap)
+
+	refreshed := map[string]bool{roomKey(cl.Player.Area, cl.Player.Room): true}
+	wg.Add(1)
+	godPrintRoom(s, *cl, s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room), wg, quit, roomsMap, msg, "")
+	for _, change := range edit {
+		others := s.OnlineClientsGetByRoom(change.area, change.room)
+		if refreshed[roomKey(change.area, change.room)] || len(others) == 0 {
+			continue
+		}
+		refreshed[roomKey(change.area, change.room)] = true
+		wg.Add(1)
+		godPrintRoom(s, others[0], others, wg, quit, roomsMap, "", "")
+	}
+}
+
+// onTry shows the admin what a command would change, and nobody else.
+func onTry(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	wg.Add(1)
+	godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, doTry(s, *cl, ev.Args, roomsMap), "")
+}
+
+func onGoto(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	c := s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room)
+	moved, msg := doGoto(s, *cl, ev.Args)
+	if moved {
+		godPrintMove(s, *cl, wg, quit, roomsMap, msg)
+	} else {
+		wg.Add(1)
+		godPrintRoom(s, *cl, c, wg, quit, roomsMap, msg, "")
+	}
+}
+
+func onRecall(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	moved, msg := doRecall(s, *cl, time.Now())
+	if moved {
+		godPrintMove(s, *cl, wg, quit, roomsMap, msg)
+	} else {
+		wg.Add(1)
+		godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, msg, "")
+	}
+}
+
+func onFlee(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	if !s.isFighting(cl.Player.Nickname) {
+		wg.Add(1)
+		godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, "You are not fighting anybody.", "")
+		return
+	}
+	fled, msg := doFlee(s, *cl, roomsMap)
+	msg = s.paint(theme.Combat, msg)
+	if fled {
+		godPrintMove(s, *cl, wg, quit, roomsMap, msg)
+	} else {
+		wg.Add(1)
+		godPrintRoom(s, *cl, s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room), wg, quit, roomsMap,
+			msg, s.paint(theme.Combat, fmt.Sprintf("%s tries to flee but fails.", cl.Player.Nickname)))
+	}
+}
+
+func onSummon(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	target, msg := doSummon(s, *cl, ev.Args)
+	if target != nil {
+		godPrintMove(s, *target, wg, quit, roomsMap, fmt.Sprintf("You have been summoned by %s.", cl.Player.Nickname))
+	}
+	wg.Add(1)
+	godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, msg, "")
+}
+
+func onGag(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	target, msg := doGag(s, *cl, ev.Args)
+	if target != nil {
+		wg.Add(1)
+		godPrintRoom(s, *target, []client.Client{*target}, wg, quit, roomsMap, s.paint(theme.System, "You have been gagged."), "")
+	}
+	wg.Add(1)
+	godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, msg, "")
+}
+
+func onUngag(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	target, msg := doUngag(s, *cl, ev.Args)
+	if target != nil {
+		wg.Add(1)
+		godPrintRoom(s, *target, []client.Client{*target}, wg, quit, roomsMap, s.paint(theme.System, "You are no longer gagged."), "")
+	}
+	wg.Add(1)
+	godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, msg, "")
+}
+
+func onBan(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	banned, msg := doBan(s, *cl, ev.Args)
+	bannedSelf := false
+	for _, b := range banned {
+		bannedSelf = bannedSelf || b.Player.Nickname == cl.Player.Nickname
+		s.OnExit(b)
+		// Writing to a player who stopped reading blocks, so God leaves
+		// it to a goroutine of its own.
+		wg.Add(1)
+		go func(b client.Client) {
+			defer wg.Done()
+			b.Send("You have been banned from this server.\n")
+			b.Close()
+		}(b)
+	}
+	if !bannedSelf {
+		wg.Add(1)
+		godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, msg, "")
+	}
+}
+
+func onRename(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	renamed, msg := doRename(s, *cl, ev.Args)
+	if renamed != nil && renamed.Player.Nickname != cl.Player.Nickname {
+		wg.Add(1)
+		godPrintRoom(s, *renamed, []client.Client{*renamed}, wg, quit, roomsMap, fmt.Sprintf("You are now known as %s.", renamed.Player.Nickname), "")
+	}
+	wg.Add(1)
+	godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, msg, "")
+}
+
+func onSay(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	endConversation(*cl)
+	deliver(s, *cl, doSay(*cl, ev.Args, time.Now()), wg, quit, roomsMap)
+}
+
+func onEmote(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	recipients, msg, chatMsg := doEmote(s, *cl, ev.Args)
+	godPrintChat(s, *cl, recipients, wg, quit, roomsMap, msg, chatMsg)
+}
+
+func onOoc(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	recipients, msg, chatMsg := doOOC(s, *cl, ev.Args)
+	godPrintChat(s, *cl, recipients, wg, quit, roomsMap, msg, chatMsg)
+}
+
+func onTell(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	recipients, msg, chatMsg := doTell(s, *cl, ev.Args)
+	godPrintChat(s, *cl, recipients, wg, quit, roomsMap, msg, chatMsg)
+}
+
+func onCast(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	godPrintCast(s, *cl, wg, quit, roomsMap, doCast(s, *cl, ev.Args, time.Now()))
+}
+
+func onNotice(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	msg := s.paint(theme.System, cl.Player.Notice)
+	cl.Player.Notice = ""
+	wg.Add(1)
+	godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, msg, "")
+}
+
+func onMore(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	msg := ""
+	if ev.Cmd == "q" {
+		cl.Pager.Stop()
+	} else {
+		msg = cl.Pager.Rest()
+	}
+	wg.Add(1)
+	godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, msg, "")
+}
+
+func onExits(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	wg.Add(1)
+	godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, doExits(*cl, roomsMap), "")
+}
+
+func onQuit(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	if s.isFighting(cl.Player.Nickname) {
+		wg.Add(1)
+		godPrintRoom(s, *cl, []client.Client{*cl}, wg, quit, roomsMap, "You cannot quit in the middle of a fight!", "")
+		return
+	}
+	//TODO :
+	//godPrint(s, c, wg, quit, roomsMap, fmt.Sprintf("%s has quit.", c.Player.Nickname))
+	//clients := s.OnlineClientsGetByRoom(c.Player.Area, c.Player.Room)
+	//for i := range clients {
+	//	log.Info(fmt.Sprintf("Clients same room : %s", clients[i].Player.Nickname))
+	//}
+	s.OnExit(*cl)
+	cl.Close()
+}
+
+func onDisconnect(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	// Only log out players whose connection broke, not those who
+	// already quit or logged in again since.
+	if online, ok := s.OnlineClientByNick(cl.Player.Nickname); ok && online.Conn == cl.Conn {
+		log.Info(fmt.Sprintf("Player %q lost the connection", cl.Player.Nickname))
+		if s.holdLinkDead(*cl, time.Now()) {
+			return
+		}
+		s.suspendSession(*cl, time.Now())
+	}
+}
+
+func onAmbiguous(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	c := s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room)
+	wg.Add(1)
+	godPrintRoom(s, *cl, c, wg, quit, roomsMap, s.paint(theme.Error, s.ambiguousCommand(*cl, ev.Cmd)), "")
+}
+
+func onUnknown(
+	s *Server,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	cl := ev.Client
+	c := s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room)
+	wg.Add(1)
+	godPrintRoom(s, *cl, c, wg, quit, roomsMap, s.paint(theme.Error, s.unknownCommand(*cl, ev.Cmd)), "")
+}
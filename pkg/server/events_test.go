@@ -0,0 +1,140 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// handle runs the handler of the event the way God does and returns what
+// each of the given clients is sent, in the same order.
+func handle(t *testing.T, s *Server, ev client.Event, clients ...client.Client) []client.Reply {
+	t.Helper()
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer close(quit)
+
+	replies := make([]client.Reply, len(clients))
+	var read sync.WaitGroup
+	for i, c := range clients {
+		read.Add(1)
+		go func(i int, c client.Client) {
+			defer read.Done()
+			select {
+			case replies[i] = <-c.Reply:
+			case <-time.After(scriptTimeout):
+			}
+		}(i, c)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handleEvent(s, eventHandlers[ev.Etype], ev, &wg, quit, createRoomsMap(s))
+	}()
+	done := make(chan struct{})
+	go func() {
+		read.Wait()
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * scriptTimeout):
+		t.Fatalf("the %s handler did not finish", ev.Etype)
+	}
+	return replies
+}
+
+func TestEventHandlersCoverCommands(t *testing.T) {
+	for cmd, etype := range commands {
+		if _, ok := eventHandlers[etype]; !ok {
+			t.Errorf("command %s has no handler for event %q", cmd, etype)
+		}
+	}
+}
+
+func TestLookHandler(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "4")
+
+	replies := handle(t, s, client.Event{Client: &alice, Etype: "look", Cmd: "look"}, alice)
+	if got := replies[0].Events; !strings.Contains(got, "Bob is here.") {
+		t.Errorf("Alice read %q", got)
+	}
+	if got := string(replies[0].Intro); !strings.Contains(got, "The town square.") {
+		t.Errorf("Alice saw %q", got)
+	}
+}
+
+func TestClearHandler(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	alice.Player.HideIntro = true
+
+	replies := handle(t, s, client.Event{Client: &alice, Etype: "clear", Cmd: "clear"}, alice)
+	if !replies[0].Clear {
+		t.Error("the screen of Alice was not cleared")
+	}
+	if got := string(replies[0].Intro); !strings.Contains(got, "The town square.") {
+		t.Errorf("Alice saw %q", got)
+	}
+
+	replies = handle(t, s, client.Event{Client: &alice, Etype: "look", Cmd: "look"}, alice)
+	if replies[0].Clear {
+		t.Error("the screen of Alice was cleared again")
+	}
+}
+
+func TestMoveHandler(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "4")
+
+	replies := handle(t, s, client.Event{Client: &alice, Etype: "move_east", Cmd: "e"}, alice, bob)
+	if got := place(alice.Player); got != "Town/Square/2" {
+		t.Errorf("Alice is at %s", got)
+	}
+	if got := replies[0].Exits; !strings.Contains(got, "East(Inn)") {
+		t.Errorf("Alice sees exits %q", got)
+	}
+	// Everybody in the room sees Alice move.
+	if len(replies[1].World) == 0 {
+		t.Error("the room was not drawn again for Bob")
+	}
+}
+
+func TestQuitHandler(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "4")
+
+	// Nobody quits in the middle of a fight.
+	s.combats[duelBetween("Alice", "Bob")] = time.Now()
+	replies := handle(t, s, client.Event{Client: &alice, Etype: "quit", Cmd: "quit"}, alice)
+	if got := replies[0].Events; got != "You cannot quit in the middle of a fight!" {
+		t.Errorf("got %q", got)
+	}
+	s.endCombats("Alice")
+
+	alice.Player.Position = "2"
+	handle(t, s, client.Event{Client: &alice, Etype: "quit", Cmd: "quit"})
+	if _, ok := s.OnlineClientByNick("Alice"); ok {
+		t.Error("Alice is still online")
+	}
+	select {
+	case <-alice.Done():
+	default:
+		t.Error("the connection of Alice is still open")
+	}
+	if p, ok, err := s.readPlayer("Alice"); !ok || err != nil || p.Position != "2" {
+		t.Errorf("Alice was saved at %s: %t, %v", p.Position, ok, err)
+	}
+	if _, ok := s.OnlineClientByNick(bob.Player.Nickname); !ok {
+		t.Error("Bob was logged out too")
+	}
+}
@@ -0,0 +1,19 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// doExits lists the directions the player can go to from where the player
+// stands, and where the doors among them lead to.
+func doExits(c client.Client, roomsMap map[string]map[string][][]area.Cube) string {
+	p := c.Player
+	exits := area.ListExits(area.FindExits(roomsMap[p.Area][p.Room], p.Area, p.Room, p.Position))
+	if len(exits) == 0 {
+		return "There are no exits here."
+	}
+	return "Exits:\n  " + strings.Join(exits, "\n  ")
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+func TestExits(t *testing.T) {
+	s := newLoadedTestServer(t)
+	roomsMap := createRoomsMap(s)
+	roomsMap["Town"]["Cell"] = [][]area.Cube{{{ID: "1"}}}
+
+	tests := []struct {
+		room, position string
+		want           string
+	}{
+		{room: "Square", position: "2", want: "Exits:\n  East  - door to Inn in Town\n  West"},
+		{room: "Square", position: "4", want: "Exits:\n  North"},
+		{room: "Inn", position: "1", want: "Exits:\n  West  - door to Square in Town\n  South"},
+		{room: "Cell", position: "1", want: "There are no exits here."},
+	}
+	for _, test := range tests {
+		c := client.Client{Player: &area.Player{Area: "Town", Room: test.room, Position: test.position}}
+		if got := doExits(c, roomsMap); got != test.want {
+			t.Errorf("%s/%s: got %q, want %q", test.room, test.position, got, test.want)
+		}
+	}
+}
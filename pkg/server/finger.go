@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// doFinger returns what is known about the player with the nick given in
+// args, whether online or not.
+func doFinger(s *Server, args []string, now time.Time) string {
+	if len(args) != 1 {
+		return "Usage: finger <nick>"
+	}
+
+	nick := args[0]
+	var player area.Player
+	online, isOnline := s.OnlineClientByNick(nick)
+	if isOnline {
+		player = *online.Player
+	} else {
+		p, exists, err := s.readPlayer(nick)
+		if err != nil {
+			return fmt.Sprintf("%s could not be looked up.", nick)
+		}
+		if !exists {
+			return fmt.Sprintf("%s does not exist.", nick)
+		}
+		player = p
+	}
+
+	status := "offline"
+	if isOnline {
+		status = "online"
+	}
+
+	lines := []string{
+		fmt.Sprintf("%s, level %d, is %s.", player.Nickname, player.Level, status),
+		fmt.Sprintf("Last login:  %s", timeAgo(player.LastLogin, now)),
+	}
+	if !isOnline {
+		lines = append(lines, fmt.Sprintf("Last logout: %s", timeAgo(player.LastLogout, now)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// timeAgo returns how long before now t was in a human-friendly way, eg.
+// "2 hours ago".
+func timeAgo(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + " ago"
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day") + " ago"
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month") + " ago"
+	}
+	return plural(int(d/(365*24*time.Hour)), "year") + " ago"
+}
+
+// plural returns n followed by the given unit, pluralized when n is not 1.
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+func TestTimeAgo(t *testing.T) {
+	now := time.Date(2016, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{ago: 0, want: "just now"},
+		{ago: 59 * time.Second, want: "just now"},
+		{ago: time.Minute, want: "1 minute ago"},
+		{ago: 45 * time.Minute, want: "45 minutes ago"},
+		{ago: 90 * time.Minute, want: "1 hour ago"},
+		{ago: 2 * time.Hour, want: "2 hours ago"},
+		{ago: 24 * time.Hour, want: "1 day ago"},
+		{ago: 29 * 24 * time.Hour, want: "29 days ago"},
+		{ago: 60 * 24 * time.Hour, want: "2 months ago"},
+		{ago: 365 * 24 * time.Hour, want: "1 year ago"},
+		{ago: 3 * 365 * 24 * time.Hour, want: "3 years ago"},
+	}
+	for _, test := range tests {
+		if got := timeAgo(now.Add(-test.ago), now); got != test.want {
+			t.Errorf("timeAgo(%v) = %q, want %q", test.ago, got, test.want)
+		}
+	}
+
+	if got := timeAgo(time.Time{}, now); got != "never" {
+		t.Errorf("timeAgo(zero) = %q, want never", got)
+	}
+}
+
+func TestFinger(t *testing.T) {
+	s := newLoadedTestServer(t)
+	now := time.Now()
+
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.LastLogin = now.Add(-2 * time.Hour)
+	if got, want := doFinger(s, []string{"alice"}, now), "Alice, level 1, is online.\nLast login:  2 hours ago"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	offline := area.Player{
+		Nickname:   "Bob",
+		PC:         *game.NewPC(s.rand),
+		LastLogin:  now.Add(-3 * 24 * time.Hour),
+		LastLogout: now.Add(-50 * time.Minute),
+	}
+	if !s.savePlayer(offline) {
+		t.Fatal("cannot save Bob")
+	}
+	if got, want := doFinger(s, []string{"bob"}, now), "Bob, level 1, is offline.\nLast login:  3 days ago\nLast logout: 50 minutes ago"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if got, want := doFinger(s, []string{"Nobody"}, now), "Nobody does not exist."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// setFlag sets the flag with the given key on the player.
+func setFlag(p *area.Player, key, value string) {
+	if p.Flags == nil {
+		p.Flags = make(map[string]string)
+	}
+	p.Flags[key] = value
+}
+
+// doFlags shows the flags of the online player given in args.
+func doFlags(s *Server, args []string) string {
+	if len(args) != 1 {
+		return "Usage: flags <nick>"
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return fmt.Sprintf("%s is not online.", args[0])
+	}
+	if len(target.Player.Flags) == 0 {
+		return fmt.Sprintf("%s has no flags.", target.Player.Nickname)
+	}
+
+	var keys []string
+	for key := range target.Player.Flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := []string{fmt.Sprintf("Flags of %s:", target.Player.Nickname)}
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("  %s = %s", key, target.Player.Flags[key]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// doSetFlag sets a flag on the online player given in args. The value of the
+// flag is "true" unless given.
+func doSetFlag(s *Server, c client.Client, args []string) string {
+	if len(args) < 2 || len(args) > 3 {
+		return "Usage: setflag <nick> <key> [value]"
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return fmt.Sprintf("%s is not online.", args[0])
+	}
+
+	key, value := args[1], "true"
+	if len(args) == 3 {
+		value = args[2]
+	}
+	setFlag(target.Player, key, value)
+
+	log.Info(fmt.Sprintf("%s set flag %s=%s on %s", c.Player.Nickname, key, value, target.Player.Nickname))
+	return fmt.Sprintf("Set %s = %s on %s.", key, value, target.Player.Nickname)
+}
+
+// doClearFlag clears a flag of the online player given in args.
+func doClearFlag(s *Server, c client.Client, args []string) string {
+	if len(args) != 2 {
+		return "Usage: clearflag <nick> <key>"
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return fmt.Sprintf("%s is not online.", args[0])
+	}
+
+	key := args[1]
+	if _, ok := target.Player.Flags[key]; !ok {
+		return fmt.Sprintf("%s has no flag %s.", target.Player.Nickname, key)
+	}
+	delete(target.Player.Flags, key)
+
+	log.Info(fmt.Sprintf("%s cleared flag %s on %s", c.Player.Nickname, key, target.Player.Nickname))
+	return fmt.Sprintf("Cleared %s on %s.", key, target.Player.Nickname)
+}
@@ -0,0 +1,50 @@
+package server
+
+import "testing"
+
+func TestFlags(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "2")
+
+	steps := []struct {
+		run  func() string
+		want string
+	}{
+		{run: func() string { return doFlags(s, []string{"alice"}) }, want: "Alice has no flags."},
+		{run: func() string { return doFlags(s, []string{"Bob"}) }, want: "Bob is not online."},
+		{run: func() string { return doFlags(s, nil) }, want: "Usage: flags <nick>"},
+		{run: func() string { return doSetFlag(s, admin, []string{"Alice", "quest.rats"}) }, want: "Set quest.rats = true on Alice."},
+		{run: func() string { return doSetFlag(s, admin, []string{"Alice", "guild", "thieves"}) }, want: "Set guild = thieves on Alice."},
+		{run: func() string { return doSetFlag(s, admin, []string{"Alice"}) }, want: "Usage: setflag <nick> <key> [value]"},
+		{run: func() string { return doFlags(s, []string{"Alice"}) }, want: "Flags of Alice:\n  guild = thieves\n  quest.rats = true"},
+		{run: func() string { return doClearFlag(s, admin, []string{"Alice", "quest.rats"}) }, want: "Cleared quest.rats on Alice."},
+		{run: func() string { return doClearFlag(s, admin, []string{"Alice", "quest.rats"}) }, want: "Alice has no flag quest.rats."},
+		{run: func() string { return doClearFlag(s, admin, []string{"Bob", "guild"}) }, want: "Bob is not online."},
+	}
+	for i, step := range steps {
+		if got := step.run(); got != step.want {
+			t.Errorf("step %d: got %q, want %q", i, got, step.want)
+		}
+	}
+	if len(alice.Player.Flags) != 1 || alice.Player.Flags["guild"] != "thieves" {
+		t.Errorf("Alice has flags %v", alice.Player.Flags)
+	}
+}
+
+func TestFlagsPersist(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	setFlag(c.Player, "quest.rats", "done")
+
+	if !s.savePlayer(*c.Player) {
+		t.Fatal("cannot save the player")
+	}
+	p, ok, err := s.readPlayer("Alice")
+	if err != nil || !ok {
+		t.Fatalf("cannot read the player: %v", err)
+	}
+	if p.Flags["quest.rats"] != "done" {
+		t.Errorf("got flags %v", p.Flags)
+	}
+}
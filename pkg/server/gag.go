@@ -0,0 +1,83 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// gaggedMessage tells gagged players they cannot chat.
+const gaggedMessage = "You are gagged."
+
+// isGagged returns true if the player may not chat at the given time. Gags
+// that have expired are lifted.
+func isGagged(p *area.Player, now time.Time) bool {
+	if !p.Gagged {
+		return false
+	}
+	if !p.GaggedUntil.IsZero() && !now.Before(p.GaggedUntil) {
+		p.Gagged = false
+		p.GaggedUntil = time.Time{}
+		return false
+	}
+	return true
+}
+
+// doGag keeps the online player given in args from chatting, optionally for
+// a number of minutes. It returns the gagged player if the gag succeeded.
+func doGag(s *Server, c client.Client, args []string) (*client.Client, string) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, "Usage: gag <nick> [minutes]"
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return nil, fmt.Sprintf("%s is not online.", args[0])
+	}
+	if target.Player.Nickname == c.Player.Nickname {
+		return nil, "You cannot gag yourself."
+	}
+
+	until := time.Time{}
+	if len(args) == 2 {
+		minutes, err := strconv.Atoi(args[1])
+		if err != nil || minutes <= 0 {
+			return nil, fmt.Sprintf("Invalid number of minutes %q.", args[1])
+		}
+		until = time.Now().Add(time.Duration(minutes) * time.Minute)
+	}
+
+	target.Player.Gagged = true
+	target.Player.GaggedUntil = until
+	log.Info(fmt.Sprintf("%s gagged %s", c.Player.Nickname, target.Player.Nickname))
+
+	if until.IsZero() {
+		return &target, fmt.Sprintf("%s is gagged.", target.Player.Nickname)
+	}
+	return &target, fmt.Sprintf("%s is gagged until %s.", target.Player.Nickname, until.Format("2006-01-02 15:04"))
+}
+
+// doUngag lets the online player given in args chat again.
+func doUngag(s *Server, c client.Client, args []string) (*client.Client, string) {
+	if len(args) != 1 {
+		return nil, "Usage: ungag <nick>"
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return nil, fmt.Sprintf("%s is not online.", args[0])
+	}
+	if !isGagged(target.Player, time.Now()) {
+		return nil, fmt.Sprintf("%s is not gagged.", target.Player.Nickname)
+	}
+
+	target.Player.Gagged = false
+	target.Player.GaggedUntil = time.Time{}
+	log.Info(fmt.Sprintf("%s ungagged %s", c.Player.Nickname, target.Player.Nickname))
+	return &target, fmt.Sprintf("%s is no longer gagged.", target.Player.Nickname)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestGagEnforced(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	admin.Player.Permission = area.PermissionAdmin
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+
+	if target, got := doGag(s, admin, []string{"bob"}); target == nil || got != "Bob is gagged." {
+		t.Fatalf("got %q", got)
+	}
+
+	// Every chat channel answers the gagged player only.
+	if got := doSay(bob, []string{"hi"}, time.Now()); got.Actor != gaggedMessage || got.Room != "" {
+		t.Errorf("say: got %+v", got)
+	}
+	if recipients, actor, others := doEmote(s, bob, []string{"waves"}); len(recipients) != 0 || actor != gaggedMessage || others != "" {
+		t.Errorf("emote: got %d recipients, %q, %q", len(recipients), actor, others)
+	}
+	if recipients, actor, others := doOOC(s, bob, []string{"hi"}); len(recipients) != 0 || actor != gaggedMessage || others != "" {
+		t.Errorf("ooc: got %d recipients, %q, %q", len(recipients), actor, others)
+	}
+	if recipients, actor, others := doTell(s, bob, []string{"Admin", "hi"}); len(recipients) != 0 || actor != gaggedMessage || others != "" {
+		t.Errorf("tell: got %d recipients, %q, %q", len(recipients), actor, others)
+	}
+
+	if _, got := doUngag(s, admin, []string{"Bob"}); got != "Bob is no longer gagged." {
+		t.Errorf("got %q", got)
+	}
+	if got := doSay(bob, []string{"hi"}, time.Now()); got.Actor != "You say: hi" {
+		t.Errorf("say after the ungag: got %+v", got)
+	}
+	if _, got := doUngag(s, admin, []string{"Bob"}); got != "Bob is not gagged." {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestGagExpires(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+
+	if _, got := doGag(s, admin, []string{"Bob", "5"}); got == "" || !bob.Player.Gagged {
+		t.Fatalf("got %q", got)
+	}
+	until := bob.Player.GaggedUntil
+	if !isGagged(bob.Player, until.Add(-time.Second)) {
+		t.Error("the gag was lifted before it expired")
+	}
+	if isGagged(bob.Player, until) {
+		t.Error("the gag did not expire")
+	}
+	if bob.Player.Gagged || !bob.Player.GaggedUntil.IsZero() {
+		t.Errorf("the expired gag was kept: %t until %v", bob.Player.Gagged, bob.Player.GaggedUntil)
+	}
+}
+
+func TestGagPersists(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	doGag(s, admin, []string{"Bob", "30"})
+
+	if !s.savePlayer(*bob.Player) {
+		t.Fatal("player was not saved")
+	}
+	p, _, err := s.readPlayer("Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isGagged(&p, time.Now()) || !p.GaggedUntil.Equal(bob.Player.GaggedUntil) {
+		t.Errorf("got gagged %t until %v, want until %v", p.Gagged, p.GaggedUntil, bob.Player.GaggedUntil)
+	}
+}
+
+func TestGagUsage(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: "Usage: gag <nick> [minutes]"},
+		{args: []string{"Erin"}, want: "Erin is not online."},
+		{args: []string{"Admin"}, want: "You cannot gag yourself."},
+		{args: []string{"Bob", "soon"}, want: `Invalid number of minutes "soon".`},
+		{args: []string{"Bob", "0"}, want: `Invalid number of minutes "0".`},
+	}
+	for _, test := range tests {
+		if target, got := doGag(s, admin, test.args); target != nil || got != test.want {
+			t.Errorf("gag %v: got %q, want %q", test.args, got, test.want)
+		}
+	}
+}
@@ -4,87 +4,155 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	log "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/gothyra/thyra/pkg/area"
 	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
 )
 
+// God runs the game until quit is closed. A panic while running the game is
+// logged and the game starts over instead of bringing the server down.
 func God(s *Server, wg *sync.WaitGroup, quit <-chan struct{}) {
 	log.Info("god started")
 	defer wg.Done()
 
-	roomsMap := make(map[string]map[string][][]area.Cube)
-	for _, a := range s.Areas {
-		roomsMap[a.Name] = make(map[string][][]area.Cube)
-		for _, room := range a.Rooms {
-			roomsMap[a.Name][room.Name] = s.CreateRoom(a.Name, room.Name)
-		}
+	workers := newRoomWorkers(s.Config.GodWorkers, wg, quit)
+	for !runGod(s, wg, quit, workers) {
+		log.Warn("God restarted")
 	}
+}
+
+// runGod handles the events of the game and advances the world. It returns
+// true once quit is closed, and false if it panicked. Room events are left
+// to the workers if there are any, and everything else waits for the
+// workers to be done first.
+func runGod(s *Server, wg *sync.WaitGroup, quit <-chan struct{}, workers *roomWorkers) (stopped bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic("God", r)
+			stopped = false
+		}
+	}()
+
+	roomsMap := createRoomsMap(s)
+
+	log.Info(fmt.Sprintf("God ticks every %v", s.tickInterval))
+	tick := time.NewTicker(s.tickInterval)
+	defer tick.Stop()
 
 	for {
 		select {
 		case <-quit:
 			log.Warn("God quit")
-			return
-
-		case ev := <-s.Events:
-			cl := ev.Client
-			c := s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room)
-
-			switch ev.Etype {
-			case "look":
-				wg.Add(1)
-				godPrintRoom(s, *cl, c, wg, quit, roomsMap, "", "")
-
-			case "move_east":
-				msg := doMove(s, *cl, roomsMap, 0)
-				wg.Add(1)
-				godPrintRoom(s, *cl, c, wg, quit, roomsMap, msg, "")
-
-			case "move_west":
-				msg := doMove(s, *cl, roomsMap, 1)
+			return true
+
+		case now := <-tick.C:
+			workers.wait()
+			elapsed := now.Sub(s.lastTick)
+			s.lastTick = now
+
+			s.tickClock(elapsed)
+			s.decayCorpses(now)
+			s.respawnNPCs(now)
+			godCancelTrades(s, wg, quit, roomsMap)
+			s.expireSessions(now)
+			s.expireDuels(now)
+			s.tickSpells(now, elapsed)
+			for _, areaName := range s.tickWeather(elapsed) {
+				godPrintWeather(s, areaName, wg, quit, roomsMap)
+			}
+			godPrintAmbient(s, elapsed, wg, quit, roomsMap)
+			godPrintCombat(s, now, wg, quit, roomsMap)
+			godReleaseLinkDead(s, now, wg, quit, roomsMap)
+			s.markIdleAFK(now)
+
+		case areas := <-s.areaUpdates:
+			workers.wait()
+			s.Lock()
+			s.Areas = areas
+			s.populate()
+			s.Unlock()
+			roomsMap = createRoomsMap(s)
+			s.forgetBuilding()
+			log.Info("Areas reloaded.")
+
+			for _, clients := range onlineClientsByRoom(s) {
 				wg.Add(1)
-				godPrintRoom(s, *cl, c, wg, quit, roomsMap, msg, "")
+				godPrintRoom(s, clients[0], clients, wg, quit, roomsMap, "", "")
+			}
 
-			case "move_north":
-				msg := doMove(s, *cl, roomsMap, 2)
-				wg.Add(1)
-				godPrintRoom(s, *cl, c, wg, quit, roomsMap, msg, "")
+		case ev := <-s.Events:
+			handler, ok := eventHandlers[ev.Etype]
+			if !ok {
+				log.Warn(fmt.Sprintf("No handler for event %q", ev.Etype))
+				continue
+			}
+			if workers != nil && roomEvents[ev.Etype] {
+				roomsMap := roomsMap
+				workers.run(roomKey(ev.Client.Player.Area, ev.Client.Player.Room), func() {
+					handleEvent(s, handler, ev, wg, quit, roomsMap)
+				})
+				continue
+			}
+			workers.wait()
+			handleEvent(s, handler, ev, wg, quit, roomsMap)
+		}
+	}
+}
 
-			case "move_south":
-				msg := doMove(s, *cl, roomsMap, 3)
-				wg.Add(1)
-				godPrintRoom(s, *cl, c, wg, quit, roomsMap, msg, "")
-			case "enter_door":
-				currentroom := s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room)
-				wg.Add(1)
-				godPrintRoom(s, *cl, currentroom, wg, quit, roomsMap, "", fmt.Sprintf("%s enter the room.", cl.Player.Nickname))
+// handleEvent runs the handler of the event, once the player typing the
+// command behind it is marked as active. A panic in the handler is logged
+// and closes the connection of the player the event came from, who then
+// gets saved and logged out as if the connection broke, while the game goes
+// on for everybody else.
+func handleEvent(
+	s *Server,
+	handler eventHandler,
+	ev client.Event,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic(fmt.Sprintf("the %s event of %q", ev.Etype, ev.Client.Player.Nickname), r)
+			ev.Client.Close()
+		}
+	}()
+	// Only events caused by the player typing a command carry it.
+	if ev.Cmd != "" {
+		markActive(*ev.Client, ev.Etype, time.Now())
+	}
+	handler(s, ev, wg, quit, roomsMap)
+}
 
-				previousroom := s.OnlineClientsGetByRoom(cl.Player.PreviousArea, cl.Player.PreviousRoom)
-				if previousroom != nil {
-					wg.Add(1)
-					godPrintRoom(s, *cl, previousroom, wg, quit, roomsMap, "", fmt.Sprintf("%s left the room.", cl.Player.Nickname))
-				}
+// createRoomsMap creates the cube grids of all the rooms in all the areas.
+func createRoomsMap(s *Server) map[string]map[string][][]area.Cube {
+	roomsMap := make(map[string]map[string][][]area.Cube)
+	for _, a := range s.Areas {
+		roomsMap[a.Name] = make(map[string][][]area.Cube)
+		for _, room := range a.Rooms {
+			roomsMap[a.Name][room.Name] = s.CreateRoom(a.Name, room.Name)
+		}
+	}
+	return roomsMap
+}
 
-			case "quit":
-				//TODO :
-				//godPrint(s, c, wg, quit, roomsMap, fmt.Sprintf("%s has quit.", c.Player.Nickname))
-				//clients := s.OnlineClientsGetByRoom(c.Player.Area, c.Player.Room)
-				//for i := range clients {
-				//	log.Info(fmt.Sprintf("Clients same room : %s", clients[i].Player.Nickname))
-				//}
-				s.OnExit(*cl)
-				cl.Conn.Close()
-
-			case "unknown":
-				wg.Add(1)
-				godPrintRoom(s, *cl, c, wg, quit, roomsMap, "Huh?", "")
+// onlineClientsByRoom groups all the online players by the room they are in.
+func onlineClientsByRoom(s *Server) map[string][]client.Client {
+	s.RLock()
+	defer s.RUnlock()
 
-			}
+	byRoom := make(map[string][]client.Client)
+	for key, occupants := range s.rooms {
+		for _, c := range occupants {
+			byRoom[key] = append(byRoom[key], *c)
 		}
 	}
+	return byRoom
 }
 
 func godPrintRoom(
@@ -113,24 +181,55 @@ func godPrintRoom(
 
 		posToCurr := copyMapWithNewPos(positionToCurrent, c.Player.Position)
 
-		buffintro := area.PrintIntro(s.Areas[c.Player.Area].Rooms[c.Player.Room].Description)
+		description := s.roomDescription(c.Player.Area, c.Player.Room)
+		if !s.playerCanSee(p) {
+			// In the dark nobody else can be made out.
+			description = tooDarkMessage
+			posToCurr = map[string]bool{p.Position: true}
+		}
+		if p.Glance {
+			description = s.roomName(c.Player.Area, c.Player.Room) + "\n"
+		}
+		if p.HideIntro {
+			description = ""
+		}
+		buffintro := area.PrintIntro(description)
 		bufmap := area.PrintMap(p, posToCurr, mapArray)
-		bufexits := area.PrintExits(area.FindExits(mapArray, c.Player.Area, c.Player.Room, c.Player.Position))
+		exits := area.FindExits(mapArray, c.Player.Area, c.Player.Room, c.Player.Position)
+		bufexits := area.PrintExits(exits)
 
 		reply := client.Reply{
 			World: bufmap.Bytes(),
 			Intro: buffintro.Bytes(),
 			Exits: bufexits.String(),
 		}
+		if p.Glance {
+			reply.World = nil
+		}
+		if p.ClearScreen {
+			reply.Clear = true
+			p.ClearScreen = false
+		}
+		if p.Settings.Compass {
+			reply.Compass = area.PrintCompass(exits)
+		}
 
 		if cl.Player.Nickname == p.Nickname {
-			reply.Events = msg
+			if p.Back {
+				if msg != "" {
+					msg += "\n"
+				}
+				msg += s.paint(theme.System, backMessage)
+				p.Back = false
+			}
+			reply.Events = c.Pager.Page(msg, p.Settings.PageLength)
 		} else {
 			reply.Events = globalMsg
 		}
 
 		select {
 		case c.Reply <- reply:
+		case <-c.Done():
 		case <-quit:
 			return
 		}
@@ -138,6 +237,33 @@ func godPrintRoom(
 
 }
 
+// godPrintMove refreshes the rooms involved after the given player moved from
+// one place to another, letting everybody there know who came and who left.
+func godPrintMove(
+	s *Server,
+	cl client.Client,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+	msg string,
+) {
+	p := cl.Player
+	if p.Area == p.PreviousArea && p.Room == p.PreviousRoom {
+		wg.Add(1)
+		godPrintRoom(s, cl, s.OnlineClientsGetByRoom(p.Area, p.Room), wg, quit, roomsMap, msg, "")
+		return
+	}
+
+	wg.Add(1)
+	godPrintRoom(s, cl, s.OnlineClientsGetByRoom(p.Area, p.Room), wg, quit, roomsMap, msg, fmt.Sprintf("%s enter the room.", p.Nickname))
+
+	previousroom := s.OnlineClientsGetByRoom(p.PreviousArea, p.PreviousRoom)
+	if len(previousroom) > 0 {
+		wg.Add(1)
+		godPrintRoom(s, cl, previousroom, wg, quit, roomsMap, "", fmt.Sprintf("%s left the room.", p.Nickname))
+	}
+}
+
 func copyMapWithNewPos(m map[string]bool, currentPos string) map[string]bool {
 	copied := map[string]bool{}
 	for k, v := range m {
@@ -149,36 +275,107 @@ func copyMapWithNewPos(m map[string]bool, currentPos string) map[string]bool {
 	return copied
 }
 
+// moveTarget is where a player moving ends up.
+type moveTarget struct {
+	area, room, position string
+	// door is true if the player goes through a door.
+	door bool
+}
+
+// planMove returns where the player would end up moving in the given
+// direction, without moving the player. If the player cannot move that way,
+// a message telling the player why not is returned.
+func planMove(s *Server, c client.Client, roomsMap map[string]map[string][][]area.Cube, direction int) (moveTarget, bool, string) {
+	mapArray := roomsMap[c.Player.Area][c.Player.Room]
+	posarray := area.FindExits(mapArray, c.Player.Area, c.Player.Room, c.Player.Position)
+
+	newarea := posarray[direction][0]
+	newroom := posarray[direction][2]
+	newpos, _ := strconv.Atoi(posarray[direction][1])
+	if newpos > 0 {
+		if ok, msg := s.canEnter(c.Player, newarea, newroom); !ok {
+			return moveTarget{}, false, msg
+		}
+	}
+
+	if isAvailable, info := isCubeAvailable(s, c, newarea, newroom, newpos); !isAvailable {
+		return moveTarget{}, false, info
+	}
+	return moveTarget{
+		area:     newarea,
+		room:     newroom,
+		position: strconv.Itoa(newpos),
+		door:     posarray[direction][3] == "door",
+	}, true, ""
+}
+
 func doMove(s *Server, c client.Client, roomsMap map[string]map[string][][]area.Cube, direction int) string {
 	event := client.Event{
 		Client: &c,
 	}
 
-	mapArray := roomsMap[c.Player.Area][c.Player.Room]
-	posarray := area.FindExits(mapArray, c.Player.Area, c.Player.Room, c.Player.Position)
+	target, ok, info := planMove(s, c, roomsMap, direction)
+	if !ok {
+		return info
+	}
 
-	newPosType := posarray[direction][3]
-	if newPosType == "door" {
+	if target.door {
 		event.Etype = "enter_door"
 		s.Events <- event
 	}
+	s.movePlayer(c.Player, target.area, target.room, target.position)
+	if !c.Player.Settings.AutoLook {
+		c.Player.HideIntro = true
+		return fmt.Sprintf("You move %s.", directionNames[direction])
+	}
+	return ""
+}
 
-	newarea := posarray[direction][0]
-	newroom := posarray[direction][2]
-	newpos, _ := strconv.Atoi(posarray[direction][1])
-	isAvailable, info := isCubeAvailable(s, c, newarea, newroom, newpos)
+// directionNames names the directions doMove takes, in order.
+var directionNames = []string{"east", "west", "north", "south"}
+
+// movePlayer places the player at the given position, remembering the room
+// the player was in before.
+func movePlayer(p *area.Player, toArea, toRoom, toPosition string) {
+	p.PreviousArea = p.Area
+	p.PreviousRoom = p.Room
+	p.Area = toArea
+	p.Room = toRoom
+	p.Position = toPosition
+	glance(p, p.PreviousArea, p.PreviousRoom)
+}
 
-	if isAvailable {
-		c.Player.PreviousArea = c.Player.Area
-		c.Player.PreviousRoom = c.Player.Room
-		c.Player.Position = strconv.Itoa(newpos)
-		c.Player.Area = newarea
-		c.Player.Room = newroom
-		return ""
+// canEnter returns true if the player may enter the given room, otherwise a
+// message telling the player why not. Admins may enter anywhere and players
+// already in the room can move around it.
+func (s *Server) canEnter(p *area.Player, areaName, roomName string) (bool, string) {
+	if p.IsAdmin() || (p.Area == areaName && p.Room == roomName) {
+		return true, ""
 	}
 
-	return info
+	a := s.Areas[areaName]
+	if p.Area != areaName {
+		if ok, msg := a.Access.Allows(p); !ok {
+			return false, msg
+		}
+	}
+	if ok, msg := a.Rooms[roomName].Access.Allows(p); !ok {
+		return false, msg
+	}
+	if s.isRoomFull(areaName, roomName) {
+		return false, roomFullMessage
+	}
+	return true, ""
+}
+
+// roomFullMessage tells players they cannot enter a full room.
+const roomFullMessage = "It's too crowded in there."
 
+// isRoomFull returns true if the given room already holds as many players as
+// it can.
+func (s *Server) isRoomFull(areaName, roomName string) bool {
+	max := s.Areas[areaName].Rooms[roomName].MaxOccupants
+	return max > 0 && len(s.OnlineClientsGetByRoom(areaName, roomName)) >= max
 }
 
 // isCubeAvailable returns if the given cube is available, otherwise includes info about what or who is
@@ -189,14 +386,47 @@ func isCubeAvailable(s *Server, client client.Client, area string, room string,
 		return false, "You can't go that way"
 	}
 
-	online := s.OnlineClients()
-	for i := range online {
-		c := online[i]
-
-		if c.Player.Area == area && c.Player.Room == room && c.Player.Position == strconv.Itoa(cube) && client.Player.Nickname != c.Player.Nickname {
+	for _, c := range s.OnlineClientsGetByRoom(area, room) {
+		if c.Player.Position == strconv.Itoa(cube) && client.Player.Nickname != c.Player.Nickname {
 			return false, c.Player.Nickname + " is blocking the way"
 		}
 	}
 
 	return true, ""
 }
+
+// freeCubeNextTo returns a cube of the given room next to the given cube
+// that is not a door and that nobody but the given player stands on, so that
+// players brought to somebody do not end up on top of them.
+func (s *Server) freeCubeNextTo(c client.Client, areaName, roomName, cubeID string) (string, bool) {
+	room := s.Areas[areaName].Rooms[roomName]
+
+	var x, y int
+	found := false
+	for _, cube := range room.Cubes {
+		if cube.ID == cubeID {
+			x, _ = strconv.Atoi(cube.POSX)
+			y, _ = strconv.Atoi(cube.POSY)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	for _, step := range digSteps {
+		cube, ok := cubeAt(room, x+step[0], y+step[1])
+		if !ok || cube.Type == "door" {
+			continue
+		}
+		id, err := strconv.Atoi(cube.ID)
+		if err != nil {
+			continue
+		}
+		if free, _ := isCubeAvailable(s, c, areaName, roomName, id); free {
+			return cube.ID, true
+		}
+	}
+	return "", false
+}
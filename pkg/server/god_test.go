@@ -0,0 +1,83 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestRoomCapacity(t *testing.T) {
+	s := newLoadedTestServer(t)
+	inn := s.Areas["Town"].Rooms["Inn"]
+	inn.MaxOccupants = 2
+	s.Areas["Town"].Rooms["Inn"] = inn
+
+	alice := addTestPlayer(t, s, "Alice", "Town", "Inn", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+	carol := addTestPlayer(t, s, "Carol", "Town", "Square", "2")
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "4")
+	admin.Player.Permission = area.PermissionAdmin
+
+	if ok, msg := s.canEnter(bob.Player, "Town", "Inn"); !ok {
+		t.Fatalf("Bob cannot enter the inn holding one player: %s", msg)
+	}
+	s.movePlayer(bob.Player, "Town", "Inn", "3")
+
+	// The third player is refused, unless an admin.
+	if ok, msg := s.canEnter(carol.Player, "Town", "Inn"); ok || msg != roomFullMessage {
+		t.Errorf("Carol entered the full inn: %t, %q", ok, msg)
+	}
+	if ok, _ := s.canEnter(admin.Player, "Town", "Inn"); !ok {
+		t.Error("the admin cannot enter the full inn")
+	}
+	// Players already in a full room can move around in it.
+	if ok, _ := s.canEnter(alice.Player, "Town", "Inn"); !ok {
+		t.Error("Alice cannot move within the full inn")
+	}
+	// Rooms without a capacity are never full.
+	if ok, _ := s.canEnter(alice.Player, "Town", "Square"); !ok {
+		t.Error("Alice cannot enter the square")
+	}
+
+	s.movePlayer(bob.Player, "Town", "Square", "1")
+	if ok, msg := s.canEnter(carol.Player, "Town", "Inn"); !ok {
+		t.Errorf("Carol cannot enter the inn once Bob left: %s", msg)
+	}
+}
+
+func TestRoomAccess(t *testing.T) {
+	s := newLoadedTestServer(t)
+	inn := s.Areas["Town"].Rooms["Inn"]
+	inn.Access = area.Access{MinLevel: 3}
+	s.Areas["Town"].Rooms["Inn"] = inn
+	square := s.Areas["Town"].Rooms["Square"]
+	square.Access = area.Access{Requires: map[string]string{"quest.gate": "open"}, Denied: "The gate is shut."}
+	s.Areas["Town"].Rooms["Square"] = square
+
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Inn", "1")
+	admin := addTestPlayer(t, s, "Admin", "Town", "Inn", "3")
+	admin.Player.Permission = area.PermissionAdmin
+
+	// A level-gated room.
+	if ok, msg := s.canEnter(alice.Player, "Town", "Inn"); ok || msg != "You need to be level 3 to go there." {
+		t.Errorf("level 1: got %t, %q", ok, msg)
+	}
+	alice.Player.Level = 3
+	if ok, msg := s.canEnter(alice.Player, "Town", "Inn"); !ok {
+		t.Errorf("level 3: %s", msg)
+	}
+
+	// A flag-gated room.
+	if ok, msg := s.canEnter(bob.Player, "Town", "Square"); ok || msg != "The gate is shut." {
+		t.Errorf("without the flag: got %t, %q", ok, msg)
+	}
+	setFlag(bob.Player, "quest.gate", "open")
+	if ok, msg := s.canEnter(bob.Player, "Town", "Square"); !ok {
+		t.Errorf("with the flag: %s", msg)
+	}
+
+	if ok, _ := s.canEnter(admin.Player, "Town", "Square"); !ok {
+		t.Error("the admin cannot enter the gated square")
+	}
+}
@@ -0,0 +1,336 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// scriptTimeout is how long scripted clients wait for the output they expect.
+const scriptTimeout = 5 * time.Second
+
+// testHarness runs a server the way Start does, without listening on a port.
+// Scripted clients connect to it through in-memory connections.
+type testHarness struct {
+	t             *testing.T
+	s             *Server
+	wg            *sync.WaitGroup
+	quit          chan struct{}
+	clientRequest chan client.Request
+	regRequest    chan client.LoginRequest
+}
+
+// startHarness loads a server from a fresh static directory holding the given
+// files and runs it until the test is over.
+func startHarness(t *testing.T, files map[string]string) *testHarness {
+	// Players get a screen only on terminals the server knows.
+	withEnv(t, "TERM", "xterm")
+
+	s := newTestServer(t, files)
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &testHarness{
+		t:             t,
+		s:             s,
+		wg:            &sync.WaitGroup{},
+		quit:          make(chan struct{}),
+		clientRequest: make(chan client.Request, 1000),
+		regRequest:    make(chan client.LoginRequest, limit(s.Config.RegistrationQueue, defaultRegistrationQueue)),
+	}
+	s.registrations = h.regRequest
+
+	h.wg.Add(2)
+	go handleRegistrations(s, h.wg, h.quit, h.regRequest)
+	go broadcast(s, h.wg, h.quit, h.clientRequest)
+
+	t.Cleanup(func() {
+		close(h.quit)
+		done := make(chan struct{})
+		go func() {
+			h.wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(scriptTimeout):
+			t.Error("the server did not stop")
+		}
+	})
+	return h
+}
+
+// withEnv sets the environment variable for the rest of the test.
+func withEnv(t *testing.T, key, value string) {
+	old, had := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if had {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+// connect opens a connection to the server for a scripted client.
+func (h *testHarness) connect() *scriptedClient {
+	server, conn := net.Pipe()
+	sc := &scriptedClient{t: h.t, conn: conn, screen: newScreen(), closed: make(chan struct{})}
+	h.t.Cleanup(func() { conn.Close() })
+
+	h.wg.Add(1)
+	go handleConnection(server, h.s, h.wg, h.quit, h.clientRequest, h.regRequest)
+	go sc.read()
+	return sc
+}
+
+// eventually waits until cond is true, failing the test with what it waited
+// for if it is not in time.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(scriptTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// scriptedClient is a player typing scripted lines. Everything the server
+// sends it is played on a virtual screen, which the test makes assertions
+// about.
+type scriptedClient struct {
+	t    *testing.T
+	conn net.Conn
+
+	mu     sync.Mutex
+	screen *screen
+	// output holds every byte the server sent.
+	output []byte
+	closed chan struct{}
+}
+
+// read plays the output of the server on the screen until the connection is
+// closed.
+func (sc *scriptedClient) read() {
+	defer close(sc.closed)
+	buf := make([]byte, 4096)
+	for {
+		n, err := sc.conn.Read(buf)
+		sc.mu.Lock()
+		sc.screen.write(buf[:n])
+		sc.output = append(sc.output, buf[:n]...)
+		sc.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// received returns every byte the server sent so far.
+func (sc *scriptedClient) received() []byte {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return append([]byte(nil), sc.output...)
+}
+
+// send types the given line.
+func (sc *scriptedClient) send(line string) {
+	sc.t.Helper()
+	sc.conn.SetWriteDeadline(time.Now().Add(scriptTimeout))
+	if _, err := io.WriteString(sc.conn, line+"\n"); err != nil {
+		sc.t.Fatalf("sending %q: %v", line, err)
+	}
+}
+
+// expect waits until the screen shows all the given texts, failing the test
+// if it does not in time.
+func (sc *scriptedClient) expect(texts ...string) {
+	sc.t.Helper()
+	deadline := time.Now().Add(scriptTimeout)
+	for {
+		sc.mu.Lock()
+		shown := sc.screen.String()
+		sc.mu.Unlock()
+
+		missing := ""
+		for _, text := range texts {
+			if !strings.Contains(shown, text) {
+				missing = text
+				break
+			}
+		}
+		if missing == "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			sc.t.Fatalf("the screen does not show %q:\n%s", missing, shown)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// expectAny waits until the screen shows any of the given texts and returns
+// it, failing the test if it does not in time.
+func (sc *scriptedClient) expectAny(texts ...string) string {
+	sc.t.Helper()
+	deadline := time.Now().Add(scriptTimeout)
+	for {
+		sc.mu.Lock()
+		shown := sc.screen.String()
+		sc.mu.Unlock()
+
+		for _, text := range texts {
+			if strings.Contains(shown, text) {
+				return text
+			}
+		}
+		if time.Now().After(deadline) {
+			sc.t.Fatalf("the screen shows none of %q:\n%s", texts, shown)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// expectClosed waits until the server closes the connection.
+func (sc *scriptedClient) expectClosed() {
+	sc.t.Helper()
+	select {
+	case <-sc.closed:
+	case <-time.After(scriptTimeout):
+		sc.t.Fatal("the connection is still open")
+	}
+}
+
+// login creates the given player and logs in as them.
+func (sc *scriptedClient) login(nick string) {
+	sc.t.Helper()
+	sc.expect("Whats your Nick?")
+	sc.send(nick)
+	sc.expect("Do you want to create that user? [y|n]")
+	sc.send("y")
+}
+
+// screen is a terminal good enough to play what the server sends players:
+// text, line breaks, cursor moves and clearing the screen. Other escape
+// sequences are dropped.
+type screen struct {
+	lines    map[int][]rune
+	row, col int
+	// pending holds the start of an escape sequence or a character split
+	// between writes.
+	pending []byte
+}
+
+func newScreen() *screen {
+	return &screen{lines: make(map[int][]rune)}
+}
+
+// write plays the given output.
+func (sc *screen) write(p []byte) {
+	b := append(sc.pending, p...)
+	sc.pending = nil
+
+	for len(b) > 0 {
+		switch b[0] {
+		case '\033':
+			n, ok := sc.escape(b)
+			if !ok {
+				sc.pending = append([]byte(nil), b...)
+				return
+			}
+			b = b[n:]
+		case '\n':
+			sc.row++
+			sc.col = 0
+			b = b[1:]
+		case '\r':
+			sc.col = 0
+			b = b[1:]
+		default:
+			if !utf8.FullRune(b) {
+				sc.pending = append([]byte(nil), b...)
+				return
+			}
+			r, n := utf8.DecodeRune(b)
+			sc.put(r)
+			b = b[n:]
+		}
+	}
+}
+
+// escape plays the escape sequence at the start of b and returns its
+// length, or false if b holds only part of it.
+func (sc *screen) escape(b []byte) (int, bool) {
+	if len(b) < 2 {
+		return 0, false
+	}
+	if b[1] != '[' {
+		return 2, true
+	}
+
+	for i := 2; i < len(b); i++ {
+		if b[i] < 0x40 || b[i] > 0x7e {
+			continue
+		}
+		params := strings.TrimPrefix(string(b[2:i]), "?")
+		switch b[i] {
+		case 'H':
+			sc.row, sc.col = 0, 0
+			if parts := strings.Split(params, ";"); len(parts) == 2 {
+				row, _ := strconv.Atoi(parts[0])
+				col, _ := strconv.Atoi(parts[1])
+				sc.row, sc.col = row-1, col-1
+			}
+		case 'J':
+			if params == "2" {
+				sc.lines = make(map[int][]rune)
+			}
+		}
+		return i + 1, true
+	}
+	return 0, false
+}
+
+// put writes r at the cursor and moves the cursor past it.
+func (sc *screen) put(r rune) {
+	line := sc.lines[sc.row]
+	for len(line) <= sc.col {
+		line = append(line, ' ')
+	}
+	line[sc.col] = r
+	sc.lines[sc.row] = line
+	sc.col++
+}
+
+// String returns the lines of the screen, without trailing spaces.
+func (sc *screen) String() string {
+	last := -1
+	for row := range sc.lines {
+		if row > last {
+			last = row
+		}
+	}
+
+	var buf bytes.Buffer
+	for row := 0; row <= last; row++ {
+		buf.WriteString(strings.TrimRight(string(sc.lines[row]), " "))
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
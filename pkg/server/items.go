@@ -0,0 +1,372 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gothyra/toml"
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// itemFile is the layout of the files in the items directory.
+type itemFile struct {
+	// Items holds item definitions by ID.
+	Items map[string]game.Item `toml:"items"`
+}
+
+// itemsDir returns the directory holding the item definition files.
+func (s *Server) itemsDir() string {
+	return filepath.Join(s.staticDir, "items")
+}
+
+// loadItems reads the item definitions shared by all areas. A missing items
+// directory means there are none. IDs must be unique across all files.
+func (s *Server) loadItems() error {
+	items := make(map[string]game.Item)
+
+	paths, err := filepath.Glob(filepath.Join(s.itemsDir(), "*.toml"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fileContent, err := ioutil.ReadFile(path)
+		if err != nil {
+			return &ReadError{Path: path, Err: err}
+		}
+
+		file := itemFile{}
+		if _, err := toml.Decode(string(fileContent), &file); err != nil {
+			return &ParseError{Path: path, Err: err}
+		}
+		for id, item := range file.Items {
+			if _, ok := items[id]; ok {
+				return &ValidationError{Path: path, Err: fmt.Errorf("item %q is defined more than once", id)}
+			}
+			if err := item.Validate(); err != nil {
+				return &ValidationError{Path: path, Err: fmt.Errorf("item %q: %v", id, err)}
+			}
+			item.ID = id
+			items[id] = item
+		}
+		log.Info(fmt.Sprintf("Loaded %d items from %s", len(file.Items), filepath.Base(path)))
+	}
+
+	s.items = items
+	return nil
+}
+
+// resolveItems adds the items the rooms of the given areas refer to by ID to
+// the rooms. Unknown IDs are left for validation to report.
+func (s *Server) resolveItems(areas map[string]area.Area) {
+	for _, a := range areas {
+		for roomName, room := range a.Rooms {
+			for _, id := range room.ItemIDs {
+				if item, ok := s.items[id]; ok {
+					room.Items = append(room.Items, item)
+				}
+			}
+			a.Rooms[roomName] = room
+		}
+	}
+}
+
+// danglingItems returns a problem for every reference to an item that is
+// not defined.
+func (s *Server) danglingItems() []error {
+	var problems []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			for _, id := range a.Rooms[roomName].ItemIDs {
+				if _, ok := s.items[id]; !ok {
+					problems = append(problems, fmt.Errorf("area %q room %q: item %q is not defined", areaName, roomName, id))
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// danglingKitItems returns a problem for every item of the starting kit that
+// is not defined.
+func (s *Server) danglingKitItems() []error {
+	var problems []error
+	for _, id := range s.Config.StartingKit.Items {
+		if _, ok := s.items[id]; !ok {
+			problems = append(problems, fmt.Errorf("starting kit: item %q is not defined", id))
+		}
+	}
+	return problems
+}
+
+// itemsIn returns the items lying in the given room. Rooms nobody picked
+// anything up from or dropped anything in yet hold the items they were
+// defined with.
+func (s *Server) itemsIn(areaName, roomName string) []game.Item {
+	if items, ok := s.ground[roomKey(areaName, roomName)]; ok {
+		return items
+	}
+	return s.Areas[areaName].Rooms[roomName].Items
+}
+
+// setItemsIn changes the items lying in the given room. It is only called
+// by God.
+func (s *Server) setItemsIn(areaName, roomName string, items []game.Item) {
+	s.ground[roomKey(areaName, roomName)] = items
+}
+
+// itemNames describes each of the items, along with how many of it there
+// are.
+func itemNames(items []game.Item) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.String())
+	}
+	return names
+}
+
+// parseItemArgs returns the name of the items args refer to and how many of
+// them, which is negative for all of them. "all" alone refers to every item.
+func parseItemArgs(args []string) (string, int, bool) {
+	if len(args) == 0 {
+		return "", 0, false
+	}
+	if args[0] == "all" {
+		return strings.Join(args[1:], " "), -1, true
+	}
+	if count, err := strconv.Atoi(args[0]); err == nil {
+		if count < 1 || len(args) == 1 {
+			return "", 0, false
+		}
+		return strings.Join(args[1:], " "), count, true
+	}
+	return strings.Join(args, " "), 1, true
+}
+
+// listOrNone joins the items, or says there are none.
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
+
+// doInventory lists what the player carries and wears.
+func doInventory(c client.Client) string {
+	return strings.Join([]string{
+		fmt.Sprintf("You are carrying: %s", listOrNone(itemNames(c.Player.Inventory))),
+		fmt.Sprintf("You are wearing: %s", listOrNone(wornNames(&c.Player.PC))),
+		fmt.Sprintf("You have %d gold.", c.Player.Gold),
+	}, "\n")
+}
+
+// wornNames returns the names of the items the player wears, with the slot
+// each of them is worn in.
+func wornNames(p *game.PC) []string {
+	var names []string
+	for _, slot := range game.Slots {
+		if item, ok := p.Equipment[slot]; ok {
+			names = append(names, fmt.Sprintf("%s (%s)", item.Name, slot))
+		}
+	}
+	return names
+}
+
+// pickup is what getting items from the ground would change.
+type pickup struct {
+	// ground holds what would be left lying in the room.
+	ground []game.Item
+	taken  []game.Item
+}
+
+// planGet works out what getting the items given in args would change, or
+// why they cannot be got.
+func planGet(s *Server, c client.Client, args []string) (pickup, bool, string) {
+	name, count, ok := parseItemArgs(args)
+	if !ok {
+		return pickup{}, false, "Usage: get [all|<count>] <item> | get all"
+	}
+
+	ground, taken := game.TakeItems(s.itemsIn(c.Player.Area, c.Player.Room), name, count)
+	if len(taken) == 0 {
+		if name == "" {
+			return pickup{}, false, "There is nothing here."
+		}
+		return pickup{}, false, fmt.Sprintf("There is no %s here.", name)
+	}
+	if !s.canCarry(c.Player, taken) {
+		return pickup{}, false, "You cannot carry that much."
+	}
+	return pickup{ground: ground, taken: taken}, true, ""
+}
+
+// doGet picks up the items given in args from the room of the player, eg.
+// "get sword", "get 3 arrows", "get all arrows" or "get all". "get corpse"
+// takes back what the player left on their corpse.
+func doGet(s *Server, c client.Client, args []string) CommandResult {
+	if len(args) == 1 && args[0] == "corpse" {
+		return doGetCorpse(s, c)
+	}
+	plan, ok, msg := planGet(s, c, args)
+	if !ok {
+		return CommandResult{Actor: msg}
+	}
+
+	s.setItemsIn(c.Player.Area, c.Player.Room, plan.ground)
+	for _, item := range plan.taken {
+		c.Player.Inventory = game.AddItem(c.Player.Inventory, item)
+	}
+	what := strings.Join(itemNames(plan.taken), ", ")
+	return CommandResult{
+		Actor: fmt.Sprintf("You pick up %s.", what),
+		Room:  fmt.Sprintf("%s picks up %s.", c.Player.Nickname, what),
+	}
+}
+
+// drop is what dropping items would change.
+type drop struct {
+	// inventory holds what the player would be left carrying.
+	inventory []game.Item
+	dropped   []game.Item
+}
+
+// planDrop works out what dropping the items given in args would change, or
+// why they cannot be dropped.
+func planDrop(c client.Client, args []string) (drop, bool, string) {
+	name, count, ok := parseItemArgs(args)
+	if !ok {
+		return drop{}, false, "Usage: drop [all|<count>] <item> | drop all"
+	}
+
+	inventory, dropped := game.TakeItems(c.Player.Inventory, name, count)
+	if len(dropped) == 0 {
+		if name == "" {
+			return drop{}, false, "You are not carrying anything."
+		}
+		return drop{}, false, fmt.Sprintf("You are not carrying any %s.", name)
+	}
+	return drop{inventory: inventory, dropped: dropped}, true, ""
+}
+
+// doDrop drops the items given in args in the room of the player, eg. "drop
+// sword", "drop 3 arrows", "drop all arrows" or "drop all".
+func doDrop(s *Server, c client.Client, args []string) CommandResult {
+	plan, ok, msg := planDrop(c, args)
+	if !ok {
+		return CommandResult{Actor: msg}
+	}
+
+	c.Player.Inventory = plan.inventory
+	ground := s.itemsIn(c.Player.Area, c.Player.Room)
+	for _, item := range plan.dropped {
+		ground = game.AddItem(ground, item)
+	}
+	s.setItemsIn(c.Player.Area, c.Player.Room, ground)
+	what := strings.Join(itemNames(plan.dropped), ", ")
+	return CommandResult{
+		Actor: fmt.Sprintf("You drop %s.", what),
+		Room:  fmt.Sprintf("%s drops %s.", c.Player.Nickname, what),
+	}
+}
+
+// doWear wears the item given in args in the given slot. Wielding is
+// wearing in the weapon slot, while wearing is wearing anywhere else.
+func doWear(c client.Client, args []string, wield bool) string {
+	verb := "wear"
+	if wield {
+		verb = "wield"
+	}
+	if len(args) == 0 {
+		return fmt.Sprintf("What do you want to %s?", verb)
+	}
+
+	name := strings.Join(args, " ")
+	i, ok := game.FindItem(c.Player.Inventory, name)
+	if !ok {
+		return fmt.Sprintf("You are not carrying any %s.", name)
+	}
+	item := c.Player.Inventory[i]
+	switch {
+	case item.Slot == "":
+		return fmt.Sprintf("You cannot %s %s.", verb, item.Name)
+	case wield && item.Slot != game.SlotWeapon:
+		return fmt.Sprintf("You cannot wield %s, try wearing it.", item.Name)
+	case !wield && item.Slot == game.SlotWeapon:
+		return fmt.Sprintf("You cannot wear %s, try wielding it.", item.Name)
+	}
+
+	previous, hadPrevious := c.Player.Equipment[item.Slot]
+	c.Player.Wear(i)
+	if hadPrevious {
+		return fmt.Sprintf("You put away %s and %s %s.", previous.Name, verb, item.Name)
+	}
+	return fmt.Sprintf("You %s %s.", verb, item.Name)
+}
+
+// doRemove stops wearing the item given in args, carrying it instead.
+func doRemove(c client.Client, args []string) string {
+	if len(args) == 0 {
+		return "Remove what?"
+	}
+
+	name := strings.Join(args, " ")
+	for _, slot := range game.Slots {
+		item, ok := c.Player.Equipment[slot]
+		if ok && (item.Matches(name) || slot == name) {
+			c.Player.Remove(slot)
+			return fmt.Sprintf("You remove %s.", item.Name)
+		}
+	}
+	return fmt.Sprintf("You are not wearing any %s.", name)
+}
+
+// doScore shows the stats of the player along with what the player wears.
+func doScore(c client.Client) string {
+	p := c.Player
+	armor := p.Armor
+	if armor == "" {
+		armor = "none"
+	}
+	return strings.Join([]string{
+		fmt.Sprintf("%s, level %d %s", p.Nickname, p.Level, p.Class),
+		fmt.Sprintf("HP     : %d/%d", p.HP, p.MaxHP),
+		fmt.Sprintf("Mana   : %d/%d", p.Mana, p.MaxMana),
+		fmt.Sprintf("STR %d DEX %d CON %d INT %d WIS %d CHA %d", p.STR, p.DEX, p.CON, p.INT, p.WIS, p.CHA),
+		fmt.Sprintf("XP     : %d", p.XP),
+		fmt.Sprintf("AC     : %d", p.AC),
+		fmt.Sprintf("Weapon : %s (1d%d)", p.Weapon, p.Weapondie),
+		fmt.Sprintf("Armor  : %s", armor),
+		fmt.Sprintf("Gold   : %d", p.Gold),
+		fmt.Sprintf("Wearing: %s", listOrNone(wornNames(&p.PC))),
+	}, "\n")
+}
+
+// invalidItems returns a problem for every item lying in a room that
+// characters cannot have.
+func (s *Server) invalidItems() []error {
+	var problems []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			room := a.Rooms[roomName]
+			for _, item := range room.Items {
+				if err := item.Validate(); err != nil {
+					problems = append(problems, fmt.Errorf("area %q room %q: %v", areaName, roomName, err))
+				}
+			}
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,342 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// testSword is a weapon to hand out in tests.
+var testSword = game.Item{Name: "Short Sword", Slot: game.SlotWeapon, Damage: 6}
+
+func TestGetAndDrop(t *testing.T) {
+	s := newLoadedTestServer(t)
+	square := s.Areas["Town"].Rooms["Square"]
+	square.Items = []game.Item{testSword, testRope}
+	s.Areas["Town"].Rooms["Square"] = square
+
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	if got := doLook(s, c).Actor; got != "You are alone here.\nYou see Short Sword, Rope lying here." {
+		t.Errorf("look: got %q", got)
+	}
+
+	got := doGet(s, c, []string{"sword"})
+	if got.Actor != "You pick up Short Sword." || got.Room != "Alice picks up Short Sword." {
+		t.Errorf("get: got %+v", got)
+	}
+	if names := itemNames(s.itemsIn("Town", "Square")); strings.Join(names, ",") != "Rope" {
+		t.Errorf("left %v in the room", names)
+	}
+	// The definition of the room is left alone.
+	if len(s.Areas["Town"].Rooms["Square"].Items) != 2 {
+		t.Error("picking up changed the room definition")
+	}
+	if got := doGet(s, c, []string{"sword"}).Actor; got != "There is no sword here." {
+		t.Errorf("get again: got %q", got)
+	}
+
+	// Dropped items can be picked up in the room they were dropped in.
+	movePlayer(c.Player, "Town", "Inn", "3")
+	if got := doDrop(s, c, []string{"sword"}).Actor; got != "You drop Short Sword." {
+		t.Errorf("drop: got %q", got)
+	}
+	if len(c.Player.Inventory) != 0 {
+		t.Errorf("still carrying %+v", c.Player.Inventory)
+	}
+	if names := itemNames(s.itemsIn("Town", "Inn")); strings.Join(names, ",") != "Short Sword" {
+		t.Errorf("left %v in the inn", names)
+	}
+	if got := doDrop(s, c, []string{"sword"}).Actor; got != "You are not carrying any sword." {
+		t.Errorf("drop again: got %q", got)
+	}
+}
+
+func TestWearAndRemove(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.DEX = 10
+	c.Player.Inventory = []game.Item{
+		testSword,
+		{Name: "Leather Armor", Slot: game.SlotArmor, ArmorBonus: 2},
+		testRope,
+	}
+
+	tests := []struct {
+		args  []string
+		wield bool
+		want  string
+	}{
+		{args: []string{"rope"}, want: "You cannot wear Rope."},
+		{args: []string{"sword"}, want: "You cannot wear Short Sword, try wielding it."},
+		{args: []string{"armor"}, wield: true, want: "You cannot wield Leather Armor, try wearing it."},
+		{args: []string{"hat"}, want: "You are not carrying any hat."},
+		{args: []string{"sword"}, wield: true, want: "You wield Short Sword."},
+		{args: []string{"leather", "armor"}, want: "You wear Leather Armor."},
+	}
+	for _, test := range tests {
+		if got := doWear(c, test.args, test.wield); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.args, got, test.want)
+		}
+	}
+
+	if c.Player.Weapondie != 6 || c.Player.AC != 12 {
+		t.Errorf("1d%d, AC %d, want 1d6, AC 12", c.Player.Weapondie, c.Player.AC)
+	}
+	if got, want := doInventory(c), "You are carrying: Rope\nYou are wearing: Short Sword (weapon), Leather Armor (armor)\nYou have 0 gold."; got != want {
+		t.Errorf("inventory: got %q, want %q", got, want)
+	}
+	if got := doScore(c); !strings.Contains(got, "Weapon : Short Sword (1d6)") || !strings.Contains(got, "AC     : 12") {
+		t.Errorf("score: got %q", got)
+	}
+
+	if got := doRemove(c, []string{"armor"}); got != "You remove Leather Armor." {
+		t.Errorf("remove: got %q", got)
+	}
+	if got := doRemove(c, []string{"weapon"}); got != "You remove Short Sword." {
+		t.Errorf("remove by slot: got %q", got)
+	}
+	if got := doRemove(c, []string{"weapon"}); got != "You are not wearing any weapon." {
+		t.Errorf("remove again: got %q", got)
+	}
+	if c.Player.Weapondie != 3 || c.Player.AC != 10 || len(c.Player.Inventory) != 3 {
+		t.Errorf("1d%d, AC %d, carrying %d items after removing everything", c.Player.Weapondie, c.Player.AC, len(c.Player.Inventory))
+	}
+}
+
+func TestEquipmentPersists(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.Inventory = []game.Item{testSword, testRope, arrowStack(7)}
+	doWear(c, []string{"sword"}, true)
+
+	if !s.savePlayer(*c.Player) {
+		t.Fatal("cannot save Alice")
+	}
+	delete(s.Players, "Alice")
+	exists, err := s.loadPlayer("Alice")
+	if err != nil || !exists {
+		t.Fatalf("cannot load Alice back: %v", err)
+	}
+	p, _ := s.GetPlayerByNick("Alice")
+	if got := itemNames(p.Inventory); strings.Join(got, ",") != "Rope,7 Arrows" {
+		t.Errorf("loaded back carrying %v", got)
+	}
+	if got := p.Equipment[game.SlotWeapon]; got != testSword {
+		t.Errorf("loaded back wielding %+v", got)
+	}
+}
+
+func TestInvalidItems(t *testing.T) {
+	s := newLoadedTestServer(t)
+	square := s.Areas["Town"].Rooms["Square"]
+	square.Items = []game.Item{testSword, {Name: "Hat", Slot: "head"}}
+	s.Areas["Town"].Rooms["Square"] = square
+
+	problems := s.invalidItems()
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), `unknown slot "head"`) {
+		t.Errorf("got %v", problems)
+	}
+}
+
+// arrowStack returns a stack of count arrows.
+func arrowStack(count int) game.Item {
+	return game.Item{Name: "Arrow", Stackable: true, Quantity: count}
+}
+
+func TestDanglingKitItems(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.items = map[string]game.Item{"rope": {ID: "rope", Name: "Rope"}}
+	s.Config.StartingKit.Items = []string{"rope", "lute"}
+
+	problems := s.validateAreas()
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), `starting kit: item "lute" is not defined`) {
+		t.Errorf("got %v", problems)
+	}
+}
+
+func TestParseItemArgs(t *testing.T) {
+	tests := []struct {
+		args  string
+		name  string
+		count int
+		ok    bool
+	}{
+		{args: "short sword", name: "short sword", count: 1, ok: true},
+		{args: "3 arrows", name: "arrows", count: 3, ok: true},
+		{args: "all arrows", name: "arrows", count: -1, ok: true},
+		{args: "all", name: "", count: -1, ok: true},
+		{args: "0 arrows", ok: false},
+		{args: "-2 arrows", ok: false},
+		{args: "3", ok: false},
+		{args: "", ok: false},
+	}
+	for _, test := range tests {
+		name, count, ok := parseItemArgs(strings.Fields(test.args))
+		if ok != test.ok || (ok && (name != test.name || count != test.count)) {
+			t.Errorf("parseItemArgs(%q) = %q, %d, %v, want %q, %d, %v", test.args, name, count, ok, test.name, test.count, test.ok)
+		}
+	}
+}
+
+func TestGetAndDropStacks(t *testing.T) {
+	s := newLoadedTestServer(t)
+	square := s.Areas["Town"].Rooms["Square"]
+	square.Items = []game.Item{arrowStack(10)}
+	s.Areas["Town"].Rooms["Square"] = square
+
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.Inventory = []game.Item{arrowStack(2)}
+
+	steps := []struct {
+		get       bool
+		args      string
+		want      string
+		carrying  string
+		lyingHere string
+	}{
+		{get: true, args: "3 arrows", want: "You pick up 3 Arrows.", carrying: "5 Arrows", lyingHere: "7 Arrows"},
+		{get: false, args: "arrow", want: "You drop Arrow.", carrying: "4 Arrows", lyingHere: "8 Arrows"},
+		{get: false, args: "all arrows", want: "You drop 4 Arrows.", carrying: "", lyingHere: "12 Arrows"},
+		{get: false, args: "all", want: "You are not carrying anything.", carrying: "", lyingHere: "12 Arrows"},
+		{get: true, args: "20 arrows", want: "You pick up 12 Arrows.", carrying: "12 Arrows", lyingHere: ""},
+		{get: true, args: "all", want: "There is nothing here.", carrying: "12 Arrows", lyingHere: ""},
+	}
+	for _, step := range steps {
+		var got string
+		if step.get {
+			got = doGet(s, c, strings.Fields(step.args)).Actor
+		} else {
+			got = doDrop(s, c, strings.Fields(step.args)).Actor
+		}
+		if got != step.want {
+			t.Errorf("%s: got %q, want %q", step.args, got, step.want)
+		}
+		if got := strings.Join(itemNames(c.Player.Inventory), ","); got != step.carrying {
+			t.Errorf("%s: carrying %q, want %q", step.args, got, step.carrying)
+		}
+		if got := strings.Join(itemNames(s.itemsIn("Town", "Square")), ","); got != step.lyingHere {
+			t.Errorf("%s: %q lying here, want %q", step.args, got, step.lyingHere)
+		}
+	}
+}
+
+// itemArea is an area placing items defined in the items directory.
+const itemArea = `name = "Town"
+
+[rooms.Square]
+name = "Square"
+itemIds = ["dagger", "rope"]
+items = [ { name = "Stick" } ]
+cubes = [ { id = "1", posx = "0", posy = "0" } ]
+`
+
+func TestLoadItems(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": itemArea,
+		"items/weapons.toml": `[items.dagger]
+name = "Dagger"
+slot = "weapon"
+damage = 4
+`,
+		"items/gear.toml": "[items.rope]\nname = \"Rope\"\n",
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := game.Item{ID: "dagger", Name: "Dagger", Slot: game.SlotWeapon, Damage: 4}
+	if len(s.items) != 2 || s.items["dagger"] != want {
+		t.Errorf("loaded %+v", s.items)
+	}
+	if got := strings.Join(itemNames(s.Areas["Town"].Rooms["Square"].Items), ","); got != "Stick,Dagger,Rope" {
+		t.Errorf("the square holds %s", got)
+	}
+	if problems := s.danglingItems(); len(problems) != 0 {
+		t.Errorf("got %v", problems)
+	}
+}
+
+func TestSaveAreaKeepsItemIDs(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": itemArea,
+		"items/gear.toml": "[items.dagger]\nname = \"Dagger\"\nslot = \"weapon\"\ndamage = 4\n\n[items.rope]\nname = \"Rope\"\n",
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	if got := doSaveArea(s, c); got != "Area Town saved." {
+		t.Fatalf("got %q", got)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(itemNames(s.Areas["Town"].Rooms["Square"].Items), ","); got != "Stick,Dagger,Rope" {
+		t.Errorf("the square holds %s after saving", got)
+	}
+}
+
+func TestLoadItemsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "duplicate ID",
+			files: map[string]string{
+				"items/a.toml": "[items.rope]\nname = \"Rope\"\n",
+				"items/b.toml": "[items.rope]\nname = \"Long Rope\"\n",
+			},
+			want: `item "rope" is defined more than once`,
+		},
+		{
+			name:  "invalid item",
+			files: map[string]string{"items/a.toml": "[items.stick]\nname = \"Stick\"\nslot = \"weapon\"\n"},
+			want:  `item "stick": weapon "Stick" needs a damage die`,
+		},
+	}
+	for _, test := range tests {
+		test.files["server.toml"] = testConfig
+		test.files["areas/town.toml"] = itemArea
+		s := newTestServer(t, test.files)
+		if err := s.loadConfig(); err != nil {
+			t.Fatal(err)
+		}
+
+		err := s.loadAreas()
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+	}
+}
+
+func TestDanglingItems(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": itemArea,
+		"items/gear.toml": "[items.rope]\nname = \"Rope\"\n",
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := s.validateAreas()
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), `area "Town" room "Square": item "dagger" is not defined`) {
+		t.Errorf("got %v", problems)
+	}
+}
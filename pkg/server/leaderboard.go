@@ -0,0 +1,114 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// Leaderboard settings used when none are configured.
+const (
+	defaultLeaderboardSize           = 10
+	defaultLeaderboardRefreshSeconds = 300
+)
+
+// leaderboard caches the ranking of all players, so that the player files are
+// not all read every time somebody looks at it. It is only accessed by God.
+type leaderboard struct {
+	ranked    []area.Player
+	refreshed time.Time
+}
+
+// rankPlayers sorts the players from the highest level down, by nickname
+// among players of the same level.
+func rankPlayers(players []area.Player) {
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].Level != players[j].Level {
+			return players[i].Level > players[j].Level
+		}
+		return players[i].Nickname < players[j].Nickname
+	})
+}
+
+// readAllPlayers reads the files of all players, online or not. Players whose
+// file cannot be read are skipped. Online players are taken from memory since
+// their files may be out of date. Players are matched to their files by
+// nickKey, as files are named in lower case.
+func (s *Server) readAllPlayers() []area.Player {
+	online := make(map[string]area.Player)
+	for _, c := range s.OnlineClients() {
+		online[nickKey(c.Player.Nickname)] = *c.Player
+	}
+	read := make(map[string]bool)
+
+	files, err := ioutil.ReadDir(s.playerDir())
+	if err != nil {
+		log.Warn(fmt.Sprintf("Player files could not be listed: %v", err))
+	}
+
+	var players []area.Player
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), ".toml")
+		if f.IsDir() || filepath.Ext(f.Name()) != ".toml" || !IsValidUsername(name) {
+			continue
+		}
+		key := nickKey(name)
+		if read[key] {
+			continue
+		}
+		read[key] = true
+		if p, ok := online[key]; ok {
+			players = append(players, p)
+			delete(online, key)
+			continue
+		}
+		p, exists, err := s.readPlayer(name)
+		if !exists || err != nil {
+			log.Warn(fmt.Sprintf("Skipping player %q in the leaderboard", name))
+			continue
+		}
+		players = append(players, p)
+	}
+	// Players who never saved yet.
+	for _, p := range online {
+		players = append(players, p)
+	}
+	return players
+}
+
+// doLeaderboard lists the highest ranking players, refreshing the ranking if
+// it is older than the configured refresh interval.
+func doLeaderboard(s *Server, c client.Client, now time.Time) string {
+	refresh := time.Duration(limit(s.Config.LeaderboardRefreshSeconds, defaultLeaderboardRefreshSeconds)) * time.Second
+	if s.leaderboard.refreshed.IsZero() || now.Sub(s.leaderboard.refreshed) >= refresh {
+		players := s.readAllPlayers()
+		rankPlayers(players)
+		s.leaderboard.ranked = players
+		s.leaderboard.refreshed = now
+	}
+
+	ranked := s.leaderboard.ranked
+	if size := limit(s.Config.LeaderboardSize, defaultLeaderboardSize); len(ranked) > size {
+		ranked = ranked[:size]
+	}
+	if len(ranked) == 0 {
+		return "Nobody is ranked yet."
+	}
+
+	table := theme.NewTable("#", "Name", "Level", "Class")
+	table.Theme = s.Config.Theme
+	for i, p := range ranked {
+		table.AddRow(strconv.Itoa(i+1), p.Nickname, strconv.Itoa(p.Level), p.Class)
+	}
+	return table.Render(c.Player.Settings.Color)
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// nicknames returns the nicknames of the players.
+func nicknames(players []area.Player) string {
+	var nicks []string
+	for _, p := range players {
+		nicks = append(nicks, p.Nickname)
+	}
+	return strings.Join(nicks, ",")
+}
+
+func TestRankPlayers(t *testing.T) {
+	players := []area.Player{
+		{Nickname: "Carol", PC: game.PC{Level: 2}},
+		{Nickname: "Bob", PC: game.PC{Level: 5}},
+		{Nickname: "Dave", PC: game.PC{Level: 1}},
+		{Nickname: "Alice", PC: game.PC{Level: 2}},
+	}
+	rankPlayers(players)
+	if got, want := nicknames(players), "Bob,Alice,Carol,Dave"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.LeaderboardSize = 2
+	now := time.Now()
+
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	alice.Player.Level = 3
+	for _, p := range []area.Player{
+		{Nickname: "Bob", PC: game.PC{Level: 4}},
+		{Nickname: "Carol", PC: game.PC{Level: 1}},
+	} {
+		if !s.savePlayer(p) {
+			t.Fatalf("cannot save %s", p.Nickname)
+		}
+	}
+	// Corrupt player files are skipped.
+	if err := ioutil.WriteFile(filepath.Join(s.playerDir(), "mallory.toml"), []byte("Nickname = [["), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := doLeaderboard(s, alice, now)
+	if strings.Index(got, "Bob") > strings.Index(got, "Alice") || strings.Contains(got, "Carol") || strings.Contains(got, "Mallory") {
+		t.Errorf("got leaderboard:\n%s", got)
+	}
+	if got := nicknames(s.leaderboard.ranked); got != "Bob,Alice,Carol" {
+		t.Errorf("got ranking %s", got)
+	}
+
+	// The ranking is only read again once the refresh interval is over.
+	alice.Player.Level = 9
+	doLeaderboard(s, alice, now.Add(defaultLeaderboardRefreshSeconds*time.Second-time.Second))
+	if got := nicknames(s.leaderboard.ranked); got != "Bob,Alice,Carol" {
+		t.Errorf("got ranking %s before the refresh", got)
+	}
+	doLeaderboard(s, alice, now.Add(defaultLeaderboardRefreshSeconds*time.Second))
+	if got := nicknames(s.leaderboard.ranked); got != "Alice,Bob,Carol" {
+		t.Errorf("got ranking %s after the refresh", got)
+	}
+}
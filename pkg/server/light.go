@@ -0,0 +1,60 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// Darkness of rooms.
+const (
+	// darkAlways rooms are dark at any time of day.
+	darkAlways = "always"
+	// darkAtNight rooms are dark during the night of the game clock.
+	darkAtNight = "night"
+)
+
+// tooDarkMessage replaces the description of rooms too dark to see in.
+const tooDarkMessage = "It is too dark to see much here.\n"
+
+// isDark returns true if the room is dark, given whether it is daytime.
+func isDark(room area.Room, daytime bool) bool {
+	switch room.Darkness {
+	case darkAlways:
+		return true
+	case darkAtNight:
+		return !daytime
+	}
+	return false
+}
+
+// canSee returns true if the player can see in the room, given whether it is
+// daytime. Players carrying a light can see anywhere.
+func canSee(room area.Room, daytime bool, p *area.Player) bool {
+	return !isDark(room, daytime) || p.HasLight()
+}
+
+// playerCanSee returns true if the player can see in the room the player is
+// in right now.
+func (s *Server) playerCanSee(p *area.Player) bool {
+	return canSee(s.Areas[p.Area].Rooms[p.Room], isDaytime(s.gameTime), p)
+}
+
+// badDarkness returns a problem for every room whose darkness is not one
+// rooms can have.
+func (s *Server) badDarkness() []error {
+	var problems []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			switch darkness := a.Rooms[roomName].Darkness; darkness {
+			case "", darkAlways, darkAtNight:
+			default:
+				problems = append(problems, fmt.Errorf("area %q room %q: unknown darkness %q, use always or night", areaName, roomName, darkness))
+			}
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+func TestCanSee(t *testing.T) {
+	lit := &area.Player{}
+	lit.Buffs = []game.Buff{{Spell: "light", Light: true}}
+	shielded := &area.Player{}
+	shielded.Buffs = []game.Buff{{Spell: "shield", AC: 2}}
+
+	tests := []struct {
+		darkness string
+		daytime  bool
+		player   *area.Player
+		want     bool
+	}{
+		{darkness: "", daytime: false, player: &area.Player{}, want: true},
+		{darkness: darkAtNight, daytime: true, player: &area.Player{}, want: true},
+		{darkness: darkAtNight, daytime: false, player: &area.Player{}, want: false},
+		{darkness: darkAtNight, daytime: false, player: shielded, want: false},
+		{darkness: darkAtNight, daytime: false, player: lit, want: true},
+		{darkness: darkAlways, daytime: true, player: &area.Player{}, want: false},
+		{darkness: darkAlways, daytime: true, player: lit, want: true},
+	}
+	for _, test := range tests {
+		room := area.Room{Darkness: test.darkness}
+		if got := canSee(room, test.daytime, test.player); got != test.want {
+			t.Errorf("darkness %q, daytime %v, buffs %+v: got %v, want %v", test.darkness, test.daytime, test.player.Buffs, got, test.want)
+		}
+	}
+}
+
+func TestLookInTheDark(t *testing.T) {
+	dark := strings.Replace(testArea, `description = "The town square."`, `description = "The town square."`+"\ndarkness = \"night\"", 1)
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": dark,
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "4")
+
+	// Midnight.
+	s.gameTime = 0
+	replies := handle(t, s, client.Event{Client: &alice, Etype: "look", Cmd: "look"}, alice)
+	if got := replies[0].Events; !strings.Contains(got, "It is too dark to see who is here.") {
+		t.Errorf("Alice read %q at night", got)
+	}
+	if got := string(replies[0].Intro); got != tooDarkMessage {
+		t.Errorf("Alice saw %q at night", got)
+	}
+
+	alice.Player.Buffs = []game.Buff{{Spell: "light", Light: true, Expires: time.Now().Add(time.Minute)}}
+	replies = handle(t, s, client.Event{Client: &alice, Etype: "look", Cmd: "look"}, alice)
+	if got := replies[0].Events; !strings.Contains(got, "Bob is here.") {
+		t.Errorf("Alice read %q with a light", got)
+	}
+
+	// Noon.
+	alice.Player.Buffs = nil
+	s.gameTime = 12 * time.Hour
+	replies = handle(t, s, client.Event{Client: &alice, Etype: "look", Cmd: "look"}, alice)
+	if got := string(replies[0].Intro); !strings.Contains(got, "The town square.") {
+		t.Errorf("Alice saw %q by day", got)
+	}
+}
+
+func TestBadDarkness(t *testing.T) {
+	s := newLoadedTestServer(t)
+	if problems := s.badDarkness(); len(problems) != 0 {
+		t.Errorf("got %v", problems)
+	}
+
+	inn := s.Areas["Town"].Rooms["Inn"]
+	inn.Darkness = "dusk"
+	s.Areas["Town"].Rooms["Inn"] = inn
+	problems := s.badDarkness()
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), `unknown darkness "dusk"`) {
+		t.Errorf("got %v", problems)
+	}
+}
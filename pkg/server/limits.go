@@ -0,0 +1,76 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync/atomic"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// Limits on the content loaded when none are configured.
+const (
+	defaultMaxAreas        = 1000
+	defaultMaxRoomsPerArea = 1000
+	defaultMaxRoomSize     = 100
+)
+
+// defaultRegistrationQueue is the number of logins that can wait to be
+// handled when none is configured.
+const defaultRegistrationQueue = 1000
+
+// limit returns the configured limit, or the default one when none is
+// configured.
+func limit(configured, def int) int {
+	if configured <= 0 {
+		return def
+	}
+	return configured
+}
+
+// checkAreaLimits makes sure that the loaded areas stay within the
+// configured limits after the area with the given name was loaded, so that
+// runaway content is refused before it is all read into memory.
+func (s *Server) checkAreaLimits(areas map[string]area.Area, name string) error {
+	if max := limit(s.Config.MaxAreas, defaultMaxAreas); len(areas) > max {
+		return fmt.Errorf("more than %d areas", max)
+	}
+
+	a := areas[name]
+	if max := limit(s.Config.MaxRoomsPerArea, defaultMaxRoomsPerArea); len(a.Rooms) > max {
+		return fmt.Errorf("area %q has more than %d rooms", name, max)
+	}
+
+	max := limit(s.Config.MaxRoomSize, defaultMaxRoomSize)
+	for _, roomName := range sortedRoomNames(a.Rooms) {
+		for _, cube := range a.Rooms[roomName].Cubes {
+			x, _ := strconv.Atoi(cube.POSX)
+			y, _ := strconv.Atoi(cube.POSY)
+			if x >= max || y >= max {
+				return fmt.Errorf("area %q room %q: cube %s at (%s,%s) is outside the maximum room size of %d",
+					name, roomName, cube.ID, cube.POSX, cube.POSY, max)
+			}
+		}
+	}
+
+	return nil
+}
+
+// refuseRegistration tells the connection that the server is too busy to
+// log it in and counts it as refused.
+func (s *Server) refuseRegistration(conn net.Conn) {
+	refused := atomic.AddUint64(&s.refusedRegistrations, 1)
+	log.Warn(fmt.Sprintf("Refused login from %s, %d logins are waiting (%d refused so far)",
+		conn.RemoteAddr(), len(s.registrations), refused))
+	client.WriteAll(conn, "The server is busy, please try again in a moment.\n")
+}
+
+// formatRegistrationStat describes how busy logging in is.
+func (s *Server) formatRegistrationStat() string {
+	return fmt.Sprintf("Logins waiting: %d of %d, refused: %d",
+		len(s.registrations), cap(s.registrations), atomic.LoadUint64(&s.refusedRegistrations))
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+func TestAreaLimits(t *testing.T) {
+	otherArea := strings.Replace(testArea, `name = "Town"`, `name = "Village"`, 1)
+
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{name: "within the limits", config: "maxAreas = 2\nmaxRoomsPerArea = 2\nmaxRoomSize = 3\n"},
+		{name: "too many areas", config: "maxAreas = 1\n", want: "more than 1 areas"},
+		{name: "too many rooms", config: "maxRoomsPerArea = 1\n", want: "has more than 1 rooms"},
+		{name: "room too large", config: "maxRoomSize = 2\n", want: `room "Square": cube 3 at (2,0) is outside the maximum room size of 2`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(t, map[string]string{
+				"server.toml":        testConfig + test.config,
+				"areas/town.toml":    testArea,
+				"areas/village.toml": otherArea,
+			})
+			if err := s.loadConfig(); err != nil {
+				t.Fatal(err)
+			}
+
+			err := s.loadAreas()
+			switch {
+			case test.want == "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)):
+				t.Errorf("got %v, want an error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRefuseRegistration(t *testing.T) {
+	h := startHarness(t, map[string]string{"server.toml": testConfig, "areas/town.toml": testArea})
+	// Nothing handles the logins queued here.
+	h.regRequest = make(chan client.LoginRequest, 1)
+	h.regRequest <- client.LoginRequest{Username: "waiting"}
+
+	c := h.connect()
+	c.expect("Whats your Nick?")
+	c.send("alice")
+	c.expect("The server is busy, please try again in a moment.")
+	c.expectClosed()
+	if got := h.s.formatRegistrationStat(); got != "Logins waiting: 0 of 1000, refused: 1" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRegistrationBurst(t *testing.T) {
+	h := startHarness(t, map[string]string{
+		"server.toml":     testConfig + "registrationQueue = 1\n",
+		"areas/town.toml": testArea,
+	})
+
+	const burst = 30
+	clients := make([]*scriptedClient, burst)
+	for i := range clients {
+		clients[i] = h.connect()
+	}
+	var typed sync.WaitGroup
+	for i, c := range clients {
+		c.expect("Whats your Nick?")
+		typed.Add(1)
+		go func(i int, c *scriptedClient) {
+			defer typed.Done()
+			io.WriteString(c.conn, fmt.Sprintf("player%d\n", i))
+		}(i, c)
+	}
+	typed.Wait()
+
+	// Every login is either handled or refused, none is left hanging.
+	refused := 0
+	for _, c := range clients {
+		if c.expectAny("Do you want to create that user?", "The server is busy") == "The server is busy" {
+			c.expectClosed()
+			refused++
+		}
+	}
+	if got, want := h.s.formatRegistrationStat(), fmt.Sprintf("Logins waiting: 0 of 1, refused: %d", refused); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// linkDeadBody is a player held in the world without a connection.
+type linkDeadBody struct {
+	nick string
+	// until is when the body is let go of.
+	until time.Time
+}
+
+// holdLinkDead keeps the player whose connection broke in the middle of a
+// fight in the world, so that disconnecting is no way out of a fight. The
+// body stays until the fights are over or the configured time passes,
+// unless the player reconnects first. It returns false if bodies are not
+// kept, in which case the player simply leaves the fights.
+func (s *Server) holdLinkDead(c client.Client, now time.Time) bool {
+	if s.Config.LinkDeadSeconds <= 0 || !s.isFighting(c.Player.Nickname) {
+		return false
+	}
+
+	s.Lock()
+	s.linkDead[nickKey(c.Player.Nickname)] = linkDeadBody{
+		nick:  c.Player.Nickname,
+		until: now.Add(time.Duration(s.Config.LinkDeadSeconds) * time.Second),
+	}
+	s.Unlock()
+	log.Info(fmt.Sprintf("Player %q went link-dead in the middle of a fight", c.Player.Nickname))
+	return true
+}
+
+// isLinkDead returns true if the body of the player with the given nickname
+// is held in the world without a connection.
+func (s *Server) isLinkDead(nick string) bool {
+	s.RLock()
+	defer s.RUnlock()
+	_, ok := s.linkDead[nickKey(nick)]
+	return ok
+}
+
+// takeOverLinkDead returns the player of the body held for the given
+// nickname, if there is one, so that the player can carry on from where the
+// connection broke.
+func (s *Server) takeOverLinkDead(nick string) (*area.Player, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.linkDead[nickKey(nick)]; !ok {
+		return nil, false
+	}
+	delete(s.linkDead, nickKey(nick))
+	body, ok := s.onlineClients[nickKey(nick)]
+	if !ok {
+		return nil, false
+	}
+	return body.Player, true
+}
+
+// godReleaseLinkDead lets go of the bodies whose fights are over or that were
+// held long enough at now, logging out their players as if their connection
+// had just broken.
+func godReleaseLinkDead(
+	s *Server,
+	now time.Time,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	s.Lock()
+	var released []string
+	for key, body := range s.linkDead {
+		if !now.Before(body.until) || !s.isFighting(body.nick) {
+			released = append(released, body.nick)
+			delete(s.linkDead, key)
+		}
+	}
+	s.Unlock()
+
+	for _, nick := range released {
+		c, ok := s.OnlineClientByNick(nick)
+		if !ok {
+			continue
+		}
+		log.Info(fmt.Sprintf("Released the link-dead body of %q", c.Player.Nickname))
+		s.endCombats(c.Player.Nickname)
+		s.suspendSession(c, now)
+
+		if others := s.OnlineClientsGetByRoom(c.Player.Area, c.Player.Room); len(others) > 0 {
+			msg := s.paint(theme.Combat, fmt.Sprintf("The body of %s fades away.", c.Player.Nickname))
+			wg.Add(1)
+			godPrintRoom(s, others[0], others, wg, quit, roomsMap, msg, msg)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// releaseLinkDead lets go of the link-dead bodies due at now and returns what
+// each of the given clients reads.
+func releaseLinkDead(t *testing.T, s *Server, now time.Time, clients ...client.Client) []client.Reply {
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer close(quit)
+
+	done := make(chan struct{})
+	go func() {
+		godReleaseLinkDead(s, now, &wg, quit, createRoomsMap(s))
+		wg.Wait()
+		close(done)
+	}()
+	replies := make([]client.Reply, len(clients))
+	for i, c := range clients {
+		select {
+		case replies[i] = <-c.Reply:
+		case <-time.After(scriptTimeout):
+			t.Fatalf("%s read nothing", c.Player.Nickname)
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(scriptTimeout):
+		t.Fatal("releasing the link-dead bodies did not finish")
+	}
+	return replies
+}
+
+func TestHoldLinkDead(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	now := time.Now()
+
+	// Bodies are not held unless configured, nor when not fighting.
+	s.combats[duelBetween("Alice", "Bob")] = now
+	if s.holdLinkDead(alice, now) {
+		t.Error("held a body with linkDeadSeconds unset")
+	}
+	s.Config.LinkDeadSeconds = 30
+	s.endCombats("Alice")
+	if s.holdLinkDead(alice, now) {
+		t.Error("held the body of a player not fighting")
+	}
+
+	s.combats[duelBetween("Alice", "Bob")] = now
+	if !s.holdLinkDead(alice, now) || !s.isLinkDead("alice") {
+		t.Fatal("the body of a fighting player was not held")
+	}
+
+	// Reconnecting takes the body over, only once.
+	p, ok := s.takeOverLinkDead("ALICE")
+	if !ok || p != alice.Player {
+		t.Fatalf("took over %v, %v", p, ok)
+	}
+	if s.isLinkDead("Alice") {
+		t.Error("the body is still held after being taken over")
+	}
+	if _, ok := s.takeOverLinkDead("Alice"); ok {
+		t.Error("took over the body twice")
+	}
+}
+
+func TestLinkDeadDefenseless(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.LinkDeadSeconds = 30
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	alice.Player.HP, alice.Player.MaxHP = 1000, 1000
+	now := time.Now()
+	key := duelBetween("Bob", "Alice")
+	s.combats[key] = now
+	s.holdLinkDead(alice, now)
+
+	outcome := s.fightRound(key, bob, alice, now)
+	if !strings.HasSuffix(outcome.msg, "Alice stands there, defenseless.") {
+		t.Errorf("got round %q", outcome.msg)
+	}
+	if bob.Player.HP != bob.Player.MaxHP {
+		t.Errorf("the link-dead body hit Bob down to %d HP", bob.Player.HP)
+	}
+}
+
+func TestReleaseLinkDead(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.LinkDeadSeconds = 30
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	now := time.Now()
+	s.combats[duelBetween("Bob", "Alice")] = now
+	s.holdLinkDead(alice, now)
+
+	// The body stays while the fight goes on and the time is not up.
+	releaseLinkDead(t, s, now.Add(29*time.Second))
+	if !s.isLinkDead("Alice") {
+		t.Fatal("the body was let go of early")
+	}
+
+	replies := releaseLinkDead(t, s, now.Add(30*time.Second), bob)
+	if !strings.Contains(replies[0].Events, "The body of Alice fades away.") {
+		t.Errorf("Bob read %q", replies[0].Events)
+	}
+	if s.isLinkDead("Alice") || s.isFighting("Alice") {
+		t.Error("the body is still held or fighting after the time was up")
+	}
+	if _, ok := s.OnlineClientByNick("Alice"); ok {
+		t.Error("Alice is still online")
+	}
+}
+
+func TestReleaseLinkDeadAfterFight(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.LinkDeadSeconds = 30
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Inn", "1")
+	now := time.Now()
+	s.combats[duelBetween("Bob", "Alice")] = now
+	s.holdLinkDead(alice, now)
+
+	// Once the fight is over there is no reason to keep the body.
+	s.endCombats("Bob")
+	releaseLinkDead(t, s, now.Add(time.Second))
+	if s.isLinkDead("Alice") {
+		t.Error("the body was held after the fight was over")
+	}
+	if _, ok := s.OnlineClientByNick("Alice"); ok {
+		t.Error("Alice is still online")
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// doClear clears the terminal of the player and shows the room again in
+// full, tidying up whatever cluttered it.
+func doClear(c client.Client) string {
+	c.Player.ClearScreen = true
+	c.Player.HideIntro = false
+	c.Player.Glance = false
+	return ""
+}
+
+// doLook tells the player who else is in the room and what lies in it. The
+// room itself is drawn along with every result, and looking brings back its
+// description if moving left it out.
+func doLook(s *Server, c client.Client) CommandResult {
+	c.Player.HideIntro = false
+	c.Player.Glance = false
+	if !s.playerCanSee(c.Player) {
+		return CommandResult{Actor: "It is too dark to see who is here."}
+	}
+
+	var others []string
+	for _, o := range s.OnlineClientsGetByRoom(c.Player.Area, c.Player.Room) {
+		if o.Player.Nickname != c.Player.Nickname {
+			others = append(others, o.Player.Nickname)
+		}
+	}
+	sort.Strings(others)
+
+	msg := ""
+	switch len(others) {
+	case 0:
+		msg = "You are alone here."
+	case 1:
+		msg = fmt.Sprintf("%s is here.", others[0])
+	default:
+		last := len(others) - 1
+		msg = fmt.Sprintf("%s and %s are here.", strings.Join(others[:last], ", "), others[last])
+	}
+	if items := s.itemsIn(c.Player.Area, c.Player.Room); len(items) > 0 {
+		msg += fmt.Sprintf("\nYou see %s lying here.", strings.Join(itemNames(items), ", "))
+	}
+	if corpses := s.corpsesIn(c.Player.Area, c.Player.Room); len(corpses) > 0 {
+		msg += fmt.Sprintf("\nYou see %s.", strings.Join(corpseNames(corpses), ", "))
+	}
+	return CommandResult{Actor: msg}
+}
+
+// glance decides whether the player sees only the name and the exits of the
+// room just entered from the given one. That is the case in brief mode
+// for rooms the player has already been to since logging in.
+func glance(p *area.Player, fromArea, fromRoom string) {
+	if p.Area == fromArea && p.Room == fromRoom {
+		return
+	}
+	if p.Seen == nil {
+		p.Seen = map[string]bool{}
+	}
+	p.Seen[fromArea+"/"+fromRoom] = true
+	p.Glance = p.Settings.Brief && p.Seen[p.Area+"/"+p.Room]
+}
+
+// roomName returns the name of the given room, falling back to its key for
+// rooms without one.
+func (s *Server) roomName(areaName, roomName string) string {
+	if name := s.Areas[areaName].Rooms[roomName].Name; name != "" {
+		return name
+	}
+	return roomName
+}
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gothyra/toml"
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// defaultMailboxSize is the number of mails a mailbox holds when no size is
+// configured.
+const defaultMailboxSize = 50
+
+// Mail is a message a player sent to another, online or not.
+type Mail struct {
+	Sent    time.Time `toml:"sent"`
+	From    string    `toml:"from"`
+	Subject string    `toml:"subject"`
+	Body    string    `toml:"body"`
+	Read    bool      `toml:"read"`
+}
+
+// mailboxFile is the layout of the mailbox files.
+type mailboxFile struct {
+	Mail []Mail `toml:"mail"`
+}
+
+// mailboxPath returns the path of the mailbox of the given player.
+func (s *Server) mailboxPath(nick string) string {
+	return foldedPath(s.mailDir(), nick+".toml")
+}
+
+// readMailbox returns the mail of the given player, oldest first. A missing
+// mailbox is empty.
+func (s *Server) readMailbox(nick string) ([]Mail, error) {
+	s.mailLock.Lock()
+	defer s.mailLock.Unlock()
+
+	return s.readMailboxLocked(nick)
+}
+
+func (s *Server) readMailboxLocked(nick string) ([]Mail, error) {
+	fileContent, err := ioutil.ReadFile(s.mailboxPath(nick))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := mailboxFile{}
+	if _, err := toml.Decode(string(fileContent), &file); err != nil {
+		return nil, err
+	}
+	return file.Mail, nil
+}
+
+// updateMailbox changes the mailbox of the given player with update and
+// saves it.
+func (s *Server) updateMailbox(nick string, update func([]Mail) ([]Mail, error)) error {
+	s.mailLock.Lock()
+	defer s.mailLock.Unlock()
+
+	mail, err := s.readMailboxLocked(nick)
+	if err != nil {
+		return err
+	}
+	if mail, err = update(mail); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.mailDir(), 0755); err != nil {
+		return err
+	}
+	data := &bytes.Buffer{}
+	if err := toml.NewEncoder(data).Encode(mailboxFile{Mail: mail}); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.mailboxPath(nick), data.Bytes(), 0644)
+}
+
+// unreadMail returns how many mails the given player has not read.
+func (s *Server) unreadMail(nick string) int {
+	mail, err := s.readMailbox(nick)
+	if err != nil {
+		log.Warn(fmt.Sprintf("Mailbox of %q could not be read: %v", nick, err))
+		return 0
+	}
+
+	unread := 0
+	for _, m := range mail {
+		if !m.Read {
+			unread++
+		}
+	}
+	return unread
+}
+
+// errMailboxFull is returned when mail is sent to a full mailbox.
+var errMailboxFull = fmt.Errorf("mailbox is full")
+
+// doMail sends mail to another player, or lists, reads and deletes the mail
+// of the player.
+func doMail(s *Server, c client.Client, args []string, now time.Time) CommandResult {
+	nick := c.Player.Nickname
+	if len(args) == 0 {
+		return CommandResult{Actor: listMail(s, nick)}
+	}
+
+	switch args[0] {
+	case "read":
+		number, ok := mailNumber(args)
+		if !ok {
+			return CommandResult{Actor: "Usage: mail read <number>"}
+		}
+		var read Mail
+		err := s.updateMailbox(nick, func(mail []Mail) ([]Mail, error) {
+			if number > len(mail) {
+				return nil, fmt.Errorf("there is no mail number %d", number)
+			}
+			mail[number-1].Read = true
+			read = mail[number-1]
+			return mail, nil
+		})
+		if err != nil {
+			return CommandResult{Actor: fmt.Sprintf("You have no mail number %d.", number)}
+		}
+		return CommandResult{Actor: fmt.Sprintf("From: %s\nDate: %s\nSubject: %s\n\n%s",
+			read.From, read.Sent.Format("2006-01-02 15:04"), read.Subject, read.Body)}
+
+	case "del":
+		number, ok := mailNumber(args)
+		if !ok {
+			return CommandResult{Actor: "Usage: mail del <number>"}
+		}
+		err := s.updateMailbox(nick, func(mail []Mail) ([]Mail, error) {
+			if number > len(mail) {
+				return nil, fmt.Errorf("there is no mail number %d", number)
+			}
+			return append(mail[:number-1], mail[number:]...), nil
+		})
+		if err != nil {
+			return CommandResult{Actor: fmt.Sprintf("You have no mail number %d.", number)}
+		}
+		return CommandResult{Actor: "Mail deleted."}
+	}
+
+	return sendMail(s, c, args, now)
+}
+
+// sendMail sends the mail given in args. The subject is the first word, or
+// everything up to a | when there is one.
+func sendMail(s *Server, c client.Client, args []string, now time.Time) CommandResult {
+	usage := CommandResult{Actor: "Usage: mail <nick> <subject> <body>, or mail <nick> <subject> | <body>\n" +
+		"       mail [read <number>|del <number>]"}
+	if len(args) < 3 {
+		return usage
+	}
+
+	to := args[0]
+	if strings.EqualFold(to, c.Player.Nickname) {
+		return CommandResult{Actor: "You cannot mail yourself."}
+	}
+	if !IsValidUsername(to) || !s.playerExists(to) {
+		return CommandResult{Actor: fmt.Sprintf("There is no player called %s.", to)}
+	}
+
+	text := strings.Join(args[1:], " ")
+	subject, body := args[1], strings.Join(args[2:], " ")
+	if i := strings.Index(text, "|"); i >= 0 {
+		subject, body = strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+	}
+	if subject == "" || body == "" {
+		return usage
+	}
+
+	size := limit(s.Config.MailboxSize, defaultMailboxSize)
+	err := s.updateMailbox(to, func(mail []Mail) ([]Mail, error) {
+		if len(mail) >= size {
+			return nil, errMailboxFull
+		}
+		return append(mail, Mail{Sent: now, From: c.Player.Nickname, Subject: subject, Body: body}), nil
+	})
+	if err == errMailboxFull {
+		return CommandResult{Actor: fmt.Sprintf("The mailbox of %s is full.", to)}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("Mail to %q could not be saved: %v", to, err))
+		return CommandResult{Actor: "Your mail could not be sent."}
+	}
+
+	result := CommandResult{Actor: fmt.Sprintf("Mail sent to %s.", to)}
+	if target, ok := s.OnlineClientByNick(to); ok {
+		result.Targets = []Target{{
+			Client: target,
+			Msg:    s.paint(theme.System, fmt.Sprintf("You have new mail from %s.", c.Player.Nickname)),
+		}}
+	}
+	return result
+}
+
+// mailNumber parses the mail number of the read and del subcommands.
+func mailNumber(args []string) (int, bool) {
+	if len(args) != 2 {
+		return 0, false
+	}
+	number, err := strconv.Atoi(args[1])
+	return number, err == nil && number > 0
+}
+
+// listMail describes the mail of the given player, marking unread mail with
+// a star.
+func listMail(s *Server, nick string) string {
+	mail, err := s.readMailbox(nick)
+	if err != nil {
+		log.Error(fmt.Sprintf("Mailbox of %q could not be read: %v", nick, err))
+		return "Your mailbox could not be read."
+	}
+	if len(mail) == 0 {
+		return "You have no mail."
+	}
+
+	lines := make([]string, len(mail))
+	for i, m := range mail {
+		mark := " "
+		if !m.Read {
+			mark = "*"
+		}
+		lines[i] = fmt.Sprintf("%s%2d. %s %-12s %s", mark, i+1, m.Sent.Format("2006-01-02"), m.From, m.Subject)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mailNotice tells the player about unread mail, or returns an empty string
+// if there is none.
+func (s *Server) mailNotice(nick string) string {
+	unread := s.unreadMail(nick)
+	if unread == 0 {
+		return ""
+	}
+	return fmt.Sprintf("You have %s. Type mail to read it.", plural(unread, "unread message"))
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// saveOfflinePlayer saves a player with the given nickname who is not
+// online.
+func saveOfflinePlayer(t *testing.T, s *Server, nick string) {
+	p := area.Player{Nickname: nick, PC: *game.NewPC(s.rand), Area: "Town", Room: "Square", Position: "1", Settings: area.DefaultSettings()}
+	if !s.savePlayer(p) {
+		t.Fatalf("cannot save %s", nick)
+	}
+}
+
+func TestMailbox(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	saveOfflinePlayer(t, s, "Bob")
+	bob := client.Client{Player: &area.Player{Nickname: "Bob"}}
+	now := time.Date(2016, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, args := range [][]string{
+		{"bob", "Hello", "see", "you", "at", "the", "inn"},
+		{"Bob", "Two", "words", "|", "meet", "me", "there"},
+	} {
+		if got := doMail(s, alice, args, now).Actor; got != "Mail sent to "+args[0]+"." {
+			t.Fatalf("mail %v: got %q", args, got)
+		}
+	}
+
+	// The mail is kept on disk until Bob deletes it.
+	if got, want := listMail(s, "Bob"), "* 1. 2016-05-01 Alice        Hello\n* 2. 2016-05-01 Alice        Two words"; got != want {
+		t.Errorf("got list:\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := doMail(s, bob, []string{"read", "2"}, now).Actor, "From: Alice\nDate: 2016-05-01 12:00\nSubject: Two words\n\nmeet me there"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := s.unreadMail("Bob"); got != 1 {
+		t.Errorf("Bob has %d unread mails, want 1", got)
+	}
+	if got := doMail(s, bob, []string{"del", "1"}, now).Actor; got != "Mail deleted." {
+		t.Errorf("got %q", got)
+	}
+	mail, err := s.readMailbox("Bob")
+	if err != nil || len(mail) != 1 || mail[0].Subject != "Two words" || !mail[0].Read {
+		t.Errorf("got mailbox %+v, %v", mail, err)
+	}
+
+	for _, args := range [][]string{{"read", "2"}, {"del", "2"}} {
+		if got := doMail(s, bob, args, now).Actor; got != "You have no mail number 2." {
+			t.Errorf("mail %v: got %q", args, got)
+		}
+	}
+}
+
+func TestSendMailRefused(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.MailboxSize = 1
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	saveOfflinePlayer(t, s, "Bob")
+	now := time.Now()
+
+	doMail(s, alice, []string{"Bob", "Hello", "there"}, now)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"Bob", "Again", "hello"}, want: "The mailbox of Bob is full."},
+		{args: []string{"Nobody", "Hello", "there"}, want: "There is no player called Nobody."},
+		{args: []string{"../bob", "Hello", "there"}, want: "There is no player called ../bob."},
+		{args: []string{"alice", "Hello", "there"}, want: "You cannot mail yourself."},
+		{args: []string{"Bob", "Hello"}, want: "Usage: mail <nick> <subject> <body>, or mail <nick> <subject> | <body>\n       mail [read <number>|del <number>]"},
+	}
+	for _, test := range tests {
+		if got := doMail(s, alice, test.args, now).Actor; got != test.want {
+			t.Errorf("mail %v: got %q, want %q", test.args, got, test.want)
+		}
+	}
+}
+
+func TestMailNoticeOnLogin(t *testing.T) {
+	h := startHarness(t, map[string]string{"server.toml": testConfig, "areas/town.toml": testArea})
+	saveOfflinePlayer(t, h.s, "Bob")
+	alice := client.Client{Player: &area.Player{Nickname: "Alice"}}
+	for i := 0; i < 2; i++ {
+		doMail(h.s, alice, []string{"Bob", "Hello", "there"}, time.Now())
+	}
+
+	bob := h.connect()
+	bob.expect("Whats your Nick?")
+	bob.send("Bob")
+	bob.expect("You have 2 unread messages. Type mail to read it.")
+
+	if got := h.s.mailNotice("Alice"); got != "" {
+		t.Errorf("Alice without mail got %q", got)
+	}
+	if !strings.Contains(listMail(h.s, "Bob"), "*") {
+		t.Error("the mail was marked as read by logging in")
+	}
+}
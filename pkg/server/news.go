@@ -0,0 +1,195 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gothyra/toml"
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// News is an announcement admins post for players.
+type News struct {
+	Posted time.Time `toml:"posted"`
+	Author string    `toml:"author"`
+	Text   string    `toml:"text"`
+}
+
+// newsFile is the layout of news.toml.
+type newsFile struct {
+	News []News `toml:"news"`
+}
+
+// newsBoard holds all the news of the server, oldest first. It is safe for
+// concurrent use.
+type newsBoard struct {
+	sync.RWMutex
+	news []News
+	path string
+}
+
+func newNewsBoard(path string) *newsBoard {
+	return &newsBoard{path: path}
+}
+
+// add posts the news and saves the news board.
+func (b *newsBoard) add(news News) error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.news = append(b.news, news)
+	return b.save()
+}
+
+// remove deletes the news with the given number, counting from 1, and saves
+// the news board. It returns false if there is no such news.
+func (b *newsBoard) remove(number int) (bool, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if number < 1 || number > len(b.news) {
+		return false, nil
+	}
+	b.news = append(b.news[:number-1], b.news[number:]...)
+	return true, b.save()
+}
+
+// list returns all the news, oldest first.
+func (b *newsBoard) list() []News {
+	b.RLock()
+	defer b.RUnlock()
+
+	return append([]News(nil), b.news...)
+}
+
+// since returns the news posted after the given time, oldest first.
+func (b *newsBoard) since(t time.Time) []News {
+	b.RLock()
+	defer b.RUnlock()
+
+	var news []News
+	for _, n := range b.news {
+		if n.Posted.After(t) {
+			news = append(news, n)
+		}
+	}
+	return news
+}
+
+// load reads the news board from disk. A missing file means there is no
+// news.
+func (b *newsBoard) load() error {
+	b.Lock()
+	defer b.Unlock()
+
+	fileContent, err := ioutil.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return &ReadError{Path: b.path, Err: err}
+	}
+
+	file := newsFile{}
+	if _, err := toml.Decode(string(fileContent), &file); err != nil {
+		return &ParseError{Path: b.path, Err: err}
+	}
+	b.news = file.News
+	return nil
+}
+
+// save writes the news board to disk. The caller must hold the lock.
+func (b *newsBoard) save() error {
+	data := &bytes.Buffer{}
+	if err := toml.NewEncoder(data).Encode(newsFile{News: b.news}); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(b.path, data.Bytes(), 0644)
+}
+
+// loadNews loads the news board kept in the static directory.
+func (s *Server) loadNews() error {
+	s.news = newNewsBoard(s.resolvePath(s.Config.Paths.News, defaultPaths.News))
+	return s.news.load()
+}
+
+// formatNews describes the given news, one per line.
+func formatNews(news []News) string {
+	lines := make([]string, len(news))
+	for i, n := range news {
+		lines[i] = fmt.Sprintf("%s %s: %s", n.Posted.Format("2006-01-02"), n.Author, n.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newsDigest describes the news posted since the given time, or returns an
+// empty string if there is none.
+func (s *Server) newsDigest(since time.Time) string {
+	news := s.news.since(since)
+	if len(news) == 0 {
+		return ""
+	}
+	return "News since you last logged in:\n" + formatNews(news)
+}
+
+// doNews lists the news. Admins can also post and delete news.
+func doNews(s *Server, c client.Client, args []string) string {
+	if len(args) == 0 || args[0] == "list" {
+		news := s.news.list()
+		if len(news) == 0 {
+			return "There is no news."
+		}
+		lines := make([]string, len(news))
+		for i, line := range strings.Split(formatNews(news), "\n") {
+			lines[i] = fmt.Sprintf("%2d. %s", i+1, line)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if !c.Player.IsAdmin() {
+		return "Usage: news [list]"
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 2 {
+			return "Usage: news add <text>"
+		}
+		news := News{Posted: time.Now(), Author: c.Player.Nickname, Text: strings.Join(args[1:], " ")}
+		if err := s.news.add(news); err != nil {
+			log.Error(fmt.Sprintf("News could not be saved: %v", err))
+			return "The news could not be saved."
+		}
+		log.Info(fmt.Sprintf("%s posted news: %s", c.Player.Nickname, news.Text))
+		return "News posted."
+
+	case "del":
+		if len(args) != 2 {
+			return "Usage: news del <number>"
+		}
+		number, err := strconv.Atoi(args[1])
+		if err != nil {
+			return "Usage: news del <number>"
+		}
+		ok, err := s.news.remove(number)
+		if err != nil {
+			log.Error(fmt.Sprintf("News could not be saved: %v", err))
+			return "The news could not be saved."
+		}
+		if !ok {
+			return fmt.Sprintf("There is no news number %d.", number)
+		}
+		log.Info(fmt.Sprintf("%s deleted news number %d", c.Player.Nickname, number))
+		return "News deleted."
+	}
+
+	return "Usage: news [list|add <text>|del <number>]"
+}
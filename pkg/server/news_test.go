@@ -0,0 +1,94 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestNewsSince(t *testing.T) {
+	lastLogin := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+	b := newNewsBoard(filepath.Join(newStaticDir(t, nil), "news.toml"))
+	for _, n := range []News{
+		{Posted: lastLogin.Add(-time.Hour), Author: "Admin", Text: "Old news."},
+		{Posted: lastLogin, Author: "Admin", Text: "Posted as the player logged in."},
+		{Posted: lastLogin.Add(time.Second), Author: "Admin", Text: "Fresh news."},
+		{Posted: lastLogin.Add(24 * time.Hour), Author: "Mod", Text: "Fresher news."},
+	} {
+		if err := b.add(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := b.since(lastLogin)
+	if len(got) != 2 || got[0].Text != "Fresh news." || got[1].Text != "Fresher news." {
+		t.Errorf("got %+v", got)
+	}
+	if got := b.since(lastLogin.Add(48 * time.Hour)); len(got) != 0 {
+		t.Errorf("got %+v after the latest news", got)
+	}
+	if got := b.since(time.Time{}); len(got) != 4 {
+		t.Errorf("got %d news for players who never logged in, want 4", len(got))
+	}
+}
+
+func TestNewsDigest(t *testing.T) {
+	s := newLoadedTestServer(t)
+	if err := s.loadNews(); err != nil {
+		t.Fatal(err)
+	}
+	posted := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+	s.news.add(News{Posted: posted, Author: "Admin", Text: "The inn is open."})
+
+	if got := s.newsDigest(posted); got != "" {
+		t.Errorf("got %q, want no digest", got)
+	}
+	want := "News since you last logged in:\n2020-05-10 Admin: The inn is open."
+	if got := s.newsDigest(posted.Add(-time.Minute)); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewsCommand(t *testing.T) {
+	s := newLoadedTestServer(t)
+	if err := s.loadNews(); err != nil {
+		t.Fatal(err)
+	}
+	admin := addTestPlayer(t, s, "Admin", "Town", "Square", "1")
+	admin.Player.Permission = area.PermissionAdmin
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+
+	steps := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "Bob", args: nil, want: "There is no news."},
+		{name: "Bob", args: []string{"add", "hi"}, want: "Usage: news [list]"},
+		{name: "Admin", args: []string{"add", "The", "inn", "is", "open."}, want: "News posted."},
+		{name: "Admin", args: []string{"add", "Bank", "holiday."}, want: "News posted."},
+		{name: "Admin", args: []string{"del", "3"}, want: "There is no news number 3."},
+		{name: "Admin", args: []string{"del", "1"}, want: "News deleted."},
+	}
+	for _, step := range steps {
+		c := bob
+		if step.name == "Admin" {
+			c = admin
+		}
+		if got := doNews(s, c, step.args); got != step.want {
+			t.Errorf("%s news %v: got %q, want %q", step.name, step.args, got, step.want)
+		}
+	}
+
+	// The news board is saved as it changes.
+	if err := s.loadNews(); err != nil {
+		t.Fatal(err)
+	}
+	got := doNews(s, bob, []string{"list"})
+	if !strings.HasPrefix(got, " 1. ") || !strings.HasSuffix(got, " Admin: Bank holiday.") || strings.Contains(got, "\n") {
+		t.Errorf("got %q", got)
+	}
+}
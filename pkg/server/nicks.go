@@ -0,0 +1,88 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Bounds of the length of nicks when none are configured.
+const (
+	defaultMinNickLength = 1
+	defaultMaxNickLength = 40
+)
+
+// maxNickLength is the longest nick IsValidUsername accepts.
+const maxNickLength = 64
+
+// checkNickLengths makes sure that the configured bounds of the length of
+// nicks make sense.
+func checkNickLengths(min, max int) error {
+	if max > maxNickLength {
+		return fmt.Errorf("maxNickLength cannot be more than %d", maxNickLength)
+	}
+	if min > limit(max, defaultMaxNickLength) {
+		return fmt.Errorf("minNickLength %d is more than maxNickLength", min)
+	}
+	return nil
+}
+
+// nickProblem returns why a new player cannot take the given nick, or an
+// empty string if the nick is fine.
+func (s *Server) nickProblem(nick string) string {
+	min := limit(s.Config.MinNickLength, defaultMinNickLength)
+	max := limit(s.Config.MaxNickLength, defaultMaxNickLength)
+
+	switch {
+	case len(nick) < min:
+		return fmt.Sprintf("Nick %s is too short, nicks are %d to %d characters long.", nick, min, max)
+	case len(nick) > max:
+		return fmt.Sprintf("Nick %s is too long, nicks are %d to %d characters long.", nick, min, max)
+	case !IsValidUsername(nick):
+		return fmt.Sprintf("Nick %s is not valid, nicks are made of letters, digits, _ and - (0-9a-z_-).", nick)
+	case s.isReservedNick(nick):
+		return fmt.Sprintf("Nick %s is reserved.", nick)
+	}
+	return ""
+}
+
+// isReservedNick returns true if nobody can take the given nick.
+func (s *Server) isReservedNick(nick string) bool {
+	for _, reserved := range s.Config.ReservedNicks {
+		if strings.EqualFold(nick, reserved) {
+			return true
+		}
+	}
+	return false
+}
+
+// nickKey returns the form of the given nick players are looked up by, so
+// that nicks differing only in case belong to the same player. The nick
+// players are shown keeps the case it was registered with.
+func nickKey(nick string) string {
+	return strings.ToLower(nick)
+}
+
+// foldedPath returns the path of the file with the given name in dir,
+// whatever the case of the name. Files are named in lower case, but files
+// written before nicks were looked up regardless of case keep the case they
+// were written with.
+func foldedPath(dir, name string) string {
+	path := filepath.Join(dir, strings.ToLower(name))
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return path
+	}
+	for _, f := range files {
+		if strings.EqualFold(f.Name(), name) {
+			return filepath.Join(dir, f.Name())
+		}
+	}
+	return path
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+func TestNickProblem(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.ReservedNicks = []string{"admin", "God"}
+
+	tests := []struct {
+		min, max int
+		nick     string
+		want     string
+	}{
+		{nick: "a", want: ""},
+		{nick: strings.Repeat("a", 40), want: ""},
+		{nick: "", want: "Nick  is too short, nicks are 1 to 40 characters long."},
+		{nick: strings.Repeat("a", 41), want: "Nick " + strings.Repeat("a", 41) + " is too long, nicks are 1 to 40 characters long."},
+		{min: 3, max: 5, nick: "ab", want: "Nick ab is too short, nicks are 3 to 5 characters long."},
+		{min: 3, max: 5, nick: "abc", want: ""},
+		{min: 3, max: 5, nick: "abcde", want: ""},
+		{min: 3, max: 5, nick: "abcdef", want: "Nick abcdef is too long, nicks are 3 to 5 characters long."},
+		{nick: "al ice", want: "Nick al ice is not valid, nicks are made of letters, digits, _ and - (0-9a-z_-)."},
+		{nick: "../alice", want: "Nick ../alice is not valid, nicks are made of letters, digits, _ and - (0-9a-z_-)."},
+		{nick: "Admin", want: "Nick Admin is reserved."},
+		{nick: "god", want: "Nick god is reserved."},
+		{nick: "goddess", want: ""},
+	}
+	for _, test := range tests {
+		s.Config.MinNickLength, s.Config.MaxNickLength = test.min, test.max
+		if got := s.nickProblem(test.nick); got != test.want {
+			t.Errorf("%d to %d, %q: got %q, want %q", test.min, test.max, test.nick, got, test.want)
+		}
+	}
+}
+
+func TestCheckNickLengths(t *testing.T) {
+	tests := []struct {
+		min, max int
+		ok       bool
+	}{
+		{ok: true},
+		{min: 3, max: 12, ok: true},
+		{min: 40, ok: true},
+		{min: 41, ok: false},
+		{min: 6, max: 5, ok: false},
+		{max: maxNickLength, ok: true},
+		{max: maxNickLength + 1, ok: false},
+	}
+	for _, test := range tests {
+		if err := checkNickLengths(test.min, test.max); (err == nil) != test.ok {
+			t.Errorf("%d to %d: got %v", test.min, test.max, err)
+		}
+	}
+}
+
+func TestCreateReservedNick(t *testing.T) {
+	h := startHarness(t, map[string]string{
+		"server.toml":     testConfig + "reservedNicks = [\"admin\"]\n",
+		"areas/town.toml": testArea,
+	})
+	c := h.connect()
+	c.expect("Whats your Nick?")
+	c.send("Admin")
+	c.expect("Nick Admin is reserved.")
+}
+
+func TestNickCase(t *testing.T) {
+	s := newLoadedTestServer(t)
+
+	s.CreatePlayer("Bob")
+	p, ok := s.GetPlayerByNick("bob")
+	if !ok || p.Nickname != "Bob" {
+		t.Fatalf("got %q, %t for bob", p.Nickname, ok)
+	}
+	if !s.savePlayer(p) {
+		t.Fatal("cannot save Bob")
+	}
+	if _, err := os.Stat(filepath.Join(s.playerDir(), "bob.toml")); err != nil {
+		t.Errorf("the player file is not in lower case: %v", err)
+	}
+
+	// Logging in as BOB loads Bob, who keeps the case of the nick.
+	delete(s.Players, nickKey("Bob"))
+	s.CreatePlayer("BOB")
+	if p, ok := s.GetPlayerByNick("Bob"); len(s.Players) != 1 || !ok || p.Nickname != "Bob" {
+		t.Errorf("got players %v", s.Players)
+	}
+
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	if c, ok := s.OnlineClientByNick("bOB"); !ok || c.Player.Nickname != "Bob" {
+		t.Errorf("Bob is not online as bOB")
+	}
+	if _, msg, _ := doTell(s, alice, []string{"bob", "hi"}); msg != "You tell Bob: hi" {
+		t.Errorf("got %q", msg)
+	}
+	if got := doWho(s, alice, nil); strings.Count(got, "Bob") != 1 {
+		t.Errorf("got who:\n%s", got)
+	}
+	// Online players are not ranked again from their files.
+	if got := nicknames(s.readAllPlayers()); got != "Alice,Bob" && got != "Bob,Alice" {
+		t.Errorf("got players %s", got)
+	}
+}
+
+func TestNickCaseOldFiles(t *testing.T) {
+	s := newLoadedTestServer(t)
+	if err := s.ensurePlayerDir(); err != nil {
+		t.Fatal(err)
+	}
+	// Files written before nicks were looked up regardless of case keep the
+	// case they were written with, and may be left next to newer ones.
+	for name, nick := range map[string]string{"Carol.toml": "Carol", "Dave.toml": "Dave", "dave.toml": "Dave"} {
+		data, err := encodePlayer(area.Player{Nickname: nick, PC: *game.NewPC(s.rand)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(s.playerDir(), name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if p, exists, err := s.readPlayer("carol"); !exists || err != nil || p.Nickname != "Carol" {
+		t.Errorf("Carol.toml is not read for carol: %t, %v", exists, err)
+	}
+	if got := nicknames(s.readAllPlayers()); got != "Carol,Dave" {
+		t.Errorf("got players %s", got)
+	}
+	exported, skipped, err := s.ExportPlayers(filepath.Join(s.staticDir, "players.json"))
+	if err != nil || len(skipped) != 0 || strings.Join(exported, ",") != "Carol,Dave" {
+		t.Errorf("exported %v, skipped %v: %v", exported, skipped, err)
+	}
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gothyra/toml"
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// Note is a moderation note admins keep about a player. Players never see
+// the notes about them.
+type Note struct {
+	Written time.Time `toml:"written"`
+	Author  string    `toml:"author"`
+	Text    string    `toml:"text"`
+}
+
+// noteFile is the layout of notes.toml.
+type noteFile struct {
+	// Notes holds the notes about every player, by nickname.
+	Notes map[string][]Note `toml:"notes"`
+}
+
+// noteBook holds the moderation notes about all players. It is safe for
+// concurrent use.
+type noteBook struct {
+	sync.RWMutex
+	notes map[string][]Note
+	path  string
+}
+
+func newNoteBook(path string) *noteBook {
+	return &noteBook{
+		notes: make(map[string][]Note),
+		path:  path,
+	}
+}
+
+// add appends the note about the player and saves the note book.
+func (b *noteBook) add(nick string, note Note) error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.notes[nickKey(nick)] = append(b.notes[nickKey(nick)], note)
+	return b.save()
+}
+
+// rename moves the notes about the player known as oldNick to newNick and
+// saves the note book.
+func (b *noteBook) rename(oldNick, newNick string) error {
+	b.Lock()
+	defer b.Unlock()
+
+	notes, ok := b.notes[nickKey(oldNick)]
+	if !ok || nickKey(oldNick) == nickKey(newNick) {
+		return nil
+	}
+	delete(b.notes, nickKey(oldNick))
+	b.notes[nickKey(newNick)] = append(b.notes[nickKey(newNick)], notes...)
+	return b.save()
+}
+
+// list returns the notes about the player, oldest first.
+func (b *noteBook) list(nick string) []Note {
+	b.RLock()
+	defer b.RUnlock()
+
+	return append([]Note(nil), b.notes[nickKey(nick)]...)
+}
+
+// load reads the note book from disk. A missing file means there are no
+// notes.
+func (b *noteBook) load() error {
+	b.Lock()
+	defer b.Unlock()
+
+	fileContent, err := ioutil.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return &ReadError{Path: b.path, Err: err}
+	}
+
+	file := noteFile{}
+	if _, err := toml.Decode(string(fileContent), &file); err != nil {
+		return &ParseError{Path: b.path, Err: err}
+	}
+	for nick, notes := range file.Notes {
+		b.notes[nickKey(nick)] = append(b.notes[nickKey(nick)], notes...)
+	}
+	return nil
+}
+
+// save writes the note book to disk. The caller must hold the lock.
+func (b *noteBook) save() error {
+	data := &bytes.Buffer{}
+	if err := toml.NewEncoder(data).Encode(noteFile{Notes: b.notes}); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(b.path, data.Bytes(), 0644)
+}
+
+// loadNotes loads the moderation notes kept in the static directory.
+func (s *Server) loadNotes() error {
+	s.notes = newNoteBook(s.resolvePath(s.Config.Paths.Notes, defaultPaths.Notes))
+	return s.notes.load()
+}
+
+// playerExists returns true if there is a player with the given nickname,
+// online or not.
+func (s *Server) playerExists(nick string) bool {
+	if _, ok := s.OnlineClientByNick(nick); ok {
+		return true
+	}
+	_, exists, _ := s.readPlayer(nick)
+	return exists
+}
+
+// doNote attaches a moderation note to the player given in args.
+func doNote(s *Server, c client.Client, args []string) string {
+	if len(args) < 2 {
+		return "Usage: note <nick> <text>"
+	}
+
+	nick := args[0]
+	if !s.playerExists(nick) {
+		return fmt.Sprintf("There is no player called %s.", nick)
+	}
+
+	note := Note{Written: time.Now(), Author: c.Player.Nickname, Text: strings.Join(args[1:], " ")}
+	if err := s.notes.add(nick, note); err != nil {
+		log.Error(fmt.Sprintf("Notes could not be saved: %v", err))
+		return "The note could not be saved."
+	}
+	return fmt.Sprintf("Note about %s saved.", nick)
+}
+
+// doNotes lists the moderation notes about the player given in args.
+func doNotes(s *Server, args []string) string {
+	if len(args) != 1 {
+		return "Usage: notes <nick>"
+	}
+
+	notes := s.notes.list(args[0])
+	if len(notes) == 0 {
+		return fmt.Sprintf("There are no notes about %s.", args[0])
+	}
+
+	lines := []string{fmt.Sprintf("Notes about %s:", args[0])}
+	for _, note := range notes {
+		lines = append(lines, fmt.Sprintf("%s %s: %s", note.Written.Format("2006-01-02 15:04"), note.Author, note.Text))
+	}
+	return strings.Join(lines, "\n")
+}
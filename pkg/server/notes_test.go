@@ -0,0 +1,74 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// newNotesTestServer returns a loaded test server keeping notes.
+func newNotesTestServer(t *testing.T) *Server {
+	s := newLoadedTestServer(t)
+	if err := s.loadNotes(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNotes(t *testing.T) {
+	s := newNotesTestServer(t)
+	admin := addTestPlayer(t, s, "Root", "Town", "Square", "1")
+	addTestPlayer(t, s, "Mallory", "Town", "Square", "2")
+
+	if got := doNotes(s, []string{"Mallory"}); got != "There are no notes about Mallory." {
+		t.Errorf("got %q", got)
+	}
+	for _, text := range []string{"spamming", "spamming again"} {
+		if got := doNote(s, admin, append([]string{"mallory"}, strings.Fields(text)...)); got != "Note about mallory saved." {
+			t.Fatalf("got %q", got)
+		}
+	}
+
+	got := doNotes(s, []string{"Mallory"})
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3 || lines[0] != "Notes about Mallory:" ||
+		!strings.HasSuffix(lines[1], " Root: spamming") || !strings.HasSuffix(lines[2], " Root: spamming again") {
+		t.Errorf("got %q", got)
+	}
+
+	// The notes are kept on disk.
+	if err := s.loadNotes(); err != nil {
+		t.Fatal(err)
+	}
+	if again := doNotes(s, []string{"Mallory"}); again != got {
+		t.Errorf("after loading the notes again got %q, want %q", again, got)
+	}
+}
+
+func TestNoteUsage(t *testing.T) {
+	s := newNotesTestServer(t)
+	admin := addTestPlayer(t, s, "Root", "Town", "Square", "1")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"Mallory"}, want: "Usage: note <nick> <text>"},
+		{args: []string{"Nobody", "spamming"}, want: "There is no player called Nobody."},
+	}
+	for _, test := range tests {
+		if got := doNote(s, admin, test.args); got != test.want {
+			t.Errorf("note %v: got %q, want %q", test.args, got, test.want)
+		}
+	}
+	if got := doNotes(s, nil); got != "Usage: notes <nick>" {
+		t.Errorf("got %q", got)
+	}
+	// Only admins can read or write notes, so players never see theirs.
+	for _, cmd := range []string{"note", "notes"} {
+		if commandPermissions[cmd] != area.PermissionAdmin {
+			t.Errorf("%s is not an admin command", cmd)
+		}
+	}
+}
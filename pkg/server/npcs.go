@@ -0,0 +1,86 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/gothyra/toml"
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// npcFile is the layout of the files in the npcs directory.
+type npcFile struct {
+	// NPCs holds NPC definitions by ID.
+	NPCs map[string]area.NPC `toml:"npcs"`
+}
+
+// loadNPCs reads the NPC definitions shared by all areas. A missing npcs
+// directory means there are none. IDs must be unique across all files.
+func (s *Server) loadNPCs() error {
+	npcs := make(map[string]area.NPC)
+
+	paths, err := filepath.Glob(filepath.Join(s.npcsDir(), "*.toml"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fileContent, err := ioutil.ReadFile(path)
+		if err != nil {
+			return &ReadError{Path: path, Err: err}
+		}
+
+		file := npcFile{}
+		if _, err := toml.Decode(string(fileContent), &file); err != nil {
+			return &ParseError{Path: path, Err: err}
+		}
+		for id, npc := range file.NPCs {
+			if _, ok := npcs[id]; ok {
+				return &ValidationError{Path: path, Err: fmt.Errorf("NPC %q is defined more than once", id)}
+			}
+			npc.ID = id
+			npcs[id] = npc
+		}
+		log.Info(fmt.Sprintf("Loaded %d NPCs from %s", len(file.NPCs), filepath.Base(path)))
+	}
+
+	s.npcs = npcs
+	return nil
+}
+
+// resolveNPCs adds the NPCs the rooms of the given areas refer to by ID to
+// the rooms. Unknown IDs are left for validation to report.
+func (s *Server) resolveNPCs(areas map[string]area.Area) {
+	for _, a := range areas {
+		for roomName, room := range a.Rooms {
+			for _, id := range room.NPCIDs {
+				if npc, ok := s.npcs[id]; ok {
+					room.NPCs = append(room.NPCs, npc)
+				}
+			}
+			a.Rooms[roomName] = room
+		}
+	}
+}
+
+// danglingNPCs returns a problem for every reference to an NPC that is not
+// defined.
+func (s *Server) danglingNPCs() []error {
+	var problems []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			for _, id := range a.Rooms[roomName].NPCIDs {
+				if _, ok := s.npcs[id]; !ok {
+					problems = append(problems, fmt.Errorf("area %q room %q: NPC %q is not defined", areaName, roomName, id))
+				}
+			}
+		}
+	}
+
+	return problems
+}
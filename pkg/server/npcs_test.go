@@ -0,0 +1,88 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+// npcArea is an area placing NPCs defined in the npcs directory.
+const npcArea = `name = "Town"
+
+[rooms.Square]
+name = "Square"
+npcIds = ["mayor", "guard"]
+npcs = [ { name = "Beggar" } ]
+cubes = [ { id = "1", posx = "0", posy = "0" } ]
+`
+
+func TestLoadNPCs(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": npcArea,
+		"npcs/town.toml": `[npcs.mayor]
+name = "Mayor"
+[npcs.mayor.dialogue.start]
+text = "Welcome."
+`,
+		"npcs/guards.toml": `[npcs.guard]
+name = "Guard"
+`,
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.npcs) != 2 || s.npcs["mayor"].Dialogue["start"].Text != "Welcome." {
+		t.Errorf("loaded %+v", s.npcs)
+	}
+
+	var names []string
+	for _, npc := range s.Areas["Town"].Rooms["Square"].NPCs {
+		names = append(names, npc.Name)
+	}
+	if got := strings.Join(names, ","); got != "Beggar,Mayor,Guard" {
+		t.Errorf("the square holds %s", got)
+	}
+	if problems := s.danglingNPCs(); len(problems) != 0 {
+		t.Errorf("got %v", problems)
+	}
+}
+
+func TestLoadNPCsDuplicateID(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": npcArea,
+		"npcs/a.toml":     "[npcs.mayor]\nname = \"Mayor\"\n",
+		"npcs/b.toml":     "[npcs.mayor]\nname = \"Other Mayor\"\n",
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.loadAreas()
+	if err == nil || !strings.Contains(err.Error(), `NPC "mayor" is defined more than once`) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestDanglingNPCs(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": npcArea,
+		"npcs/town.toml":  "[npcs.mayor]\nname = \"Mayor\"\n",
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := s.danglingNPCs()
+	if len(problems) != 1 || problems[0].Error() != `area "Town" room "Square": NPC "guard" is not defined` {
+		t.Errorf("got %v", problems)
+	}
+}
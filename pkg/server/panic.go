@@ -0,0 +1,14 @@
+package server
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+// logPanic logs the value a panic in the named part of the server was
+// recovered with, along with the stack trace of the panic.
+func logPanic(where string, r interface{}) {
+	log.Error(fmt.Sprintf("Recovered from a panic in %s: %v\n%s", where, r, debug.Stack()))
+}
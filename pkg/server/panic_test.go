@@ -0,0 +1,44 @@
+package server
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+func TestEventPanic(t *testing.T) {
+	eventHandlers["panic"] = func(*Server, client.Event, *sync.WaitGroup, <-chan struct{}, map[string]map[string][][]area.Cube) {
+		panic("boom")
+	}
+	t.Cleanup(func() { delete(eventHandlers, "panic") })
+
+	h := startHarness(t, map[string]string{"server.toml": testConfig, "areas/town.toml": testArea})
+	alice := h.connect()
+	alice.login("alice")
+	alice.send("e")
+	alice.expect("HP] >")
+
+	c, ok := h.s.OnlineClientByNick("alice")
+	if !ok {
+		t.Fatal("alice is not online")
+	}
+	h.s.Events <- client.Event{Client: &c, Etype: "panic"}
+
+	// Alice is saved and logged out.
+	alice.expectClosed()
+	eventually(t, "alice to be logged out", func() bool {
+		_, online := h.s.OnlineClientByNick("alice")
+		return !online
+	})
+	if p, ok, err := h.s.readPlayer("alice"); !ok || err != nil || p.Position != "2" {
+		t.Errorf("alice was saved at %s: %t, %v", p.Position, ok, err)
+	}
+
+	// The game goes on for everybody else.
+	bob := h.connect()
+	bob.login("bob")
+	bob.send("e")
+	bob.expect("Exits  : [ East(Inn) West ]")
+}
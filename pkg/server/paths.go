@@ -0,0 +1,71 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Paths holds where the server keeps its files and directories. Relative
+// paths are relative to the static directory, and paths left out keep their
+// default.
+type Paths struct {
+	Areas   string `toml:"areas"`
+	Players string `toml:"players"`
+	NPCs    string `toml:"npcs"`
+	Mail    string `toml:"mail"`
+	Spells  string `toml:"spells"`
+	News    string `toml:"news"`
+	Notes   string `toml:"notes"`
+	Bans    string `toml:"bans"`
+}
+
+// defaultPaths is where the server keeps its files unless configured
+// otherwise.
+var defaultPaths = Paths{
+	Areas:   "areas",
+	Players: "player",
+	NPCs:    "npcs",
+	Mail:    "mail",
+	Spells:  "spells.toml",
+	News:    "news.toml",
+	Notes:   "notes.toml",
+	Bans:    "banlist.toml",
+}
+
+// configPath returns the path of the configuration file, which is
+// server.toml in the static directory unless THYRA_CONFIG says otherwise.
+func (s *Server) configPath() string {
+	if path := os.Getenv("THYRA_CONFIG"); path != "" {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(s.staticDir, "server.toml")
+}
+
+// resolvePath returns the configured path relative to the static
+// directory, or the default one when none is configured.
+func (s *Server) resolvePath(configured, def string) string {
+	if configured == "" {
+		configured = def
+	}
+	return s.StaticPath(configured)
+}
+
+// areasDir returns the directory holding the area files.
+func (s *Server) areasDir() string {
+	return s.resolvePath(s.Config.Paths.Areas, defaultPaths.Areas)
+}
+
+// playerDir returns the directory holding the player files.
+func (s *Server) playerDir() string {
+	return s.resolvePath(s.Config.Paths.Players, defaultPaths.Players)
+}
+
+// npcsDir returns the directory holding the NPC definition files.
+func (s *Server) npcsDir() string {
+	return s.resolvePath(s.Config.Paths.NPCs, defaultPaths.NPCs)
+}
+
+// mailDir returns the directory holding the mailboxes of the players.
+func (s *Server) mailDir() string {
+	return s.resolvePath(s.Config.Paths.Mail, defaultPaths.Mail)
+}
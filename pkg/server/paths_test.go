@@ -0,0 +1,70 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePaths(t *testing.T) {
+	s := newTestServer(t, nil)
+	static := s.staticDir
+	absolute := filepath.Join(os.TempDir(), "thyra-players")
+
+	s.Config.Paths = Paths{Areas: filepath.Join("content", "areas"), Players: absolute}
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		// Paths configured relative to the static directory.
+		{name: "areas", got: s.areasDir(), want: filepath.Join(static, "content", "areas")},
+		// Absolute paths are used as they are.
+		{name: "players", got: s.playerDir(), want: absolute},
+		// Paths left out keep their default.
+		{name: "npcs", got: s.npcsDir(), want: filepath.Join(static, "npcs")},
+		{name: "items", got: s.itemsDir(), want: filepath.Join(static, "items")},
+		{name: "mail", got: s.mailDir(), want: filepath.Join(static, "mail")},
+		{name: "news", got: s.resolvePath(s.Config.Paths.News, defaultPaths.News), want: filepath.Join(static, "news.toml")},
+		{name: "config", got: s.configPath(), want: filepath.Join(static, "server.toml")},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, test.got, test.want)
+		}
+	}
+
+	config := filepath.Join(os.TempDir(), "etc", "..", "thyra.toml")
+	withEnv(t, "THYRA_CONFIG", config)
+	if got, want := s.configPath(), filepath.Join(os.TempDir(), "thyra.toml"); got != want {
+		t.Errorf("config: got %s, want %s", got, want)
+	}
+}
+
+func TestLoadFromConfiguredPaths(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml": testConfig + `
+[config.paths]
+areas = "world"
+players = "data/players"
+`,
+		"world/town.toml": testArea,
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Areas["Town"]; !ok {
+		t.Errorf("the areas were not loaded from world: %v", s.Areas)
+	}
+
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	if !s.savePlayer(*c.Player) {
+		t.Fatal("cannot save the player")
+	}
+	if _, err := os.Stat(filepath.Join(s.staticDir, "data", "players", "alice.toml")); err != nil {
+		t.Errorf("the player was not saved in data/players: %v", err)
+	}
+}
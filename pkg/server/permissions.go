@@ -0,0 +1,88 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// roles names the permission levels players are usually given.
+var roles = map[string]int{
+	"player":  area.PermissionPlayer,
+	"builder": area.PermissionBuilder,
+	"admin":   area.PermissionAdmin,
+}
+
+// roleName returns the name of the permission level, the level itself if it
+// has no name, or nothing for players.
+func roleName(level int) string {
+	switch {
+	case level == area.PermissionPlayer:
+		return ""
+	case level == area.PermissionBuilder:
+		return "builder"
+	case level >= area.PermissionAdmin:
+		return "admin"
+	}
+	return fmt.Sprintf("level %d", level)
+}
+
+// parsePermission returns the permission level named by a role or given as
+// a number.
+func parsePermission(arg string) (int, bool) {
+	if level, ok := roles[arg]; ok {
+		return level, true
+	}
+	level, err := strconv.Atoi(arg)
+	return level, err == nil && level >= area.PermissionPlayer
+}
+
+// doPromote changes the permission level of the online player given in args.
+// Promotions raise the level, to admin unless given, and demotions lower it,
+// to player unless given. Nobody can change the level of players at or above
+// their own level, or give levels above their own.
+func doPromote(s *Server, c client.Client, args []string, promote bool) CommandResult {
+	cmd, level := "demote", area.PermissionPlayer
+	if promote {
+		cmd, level = "promote", area.PermissionAdmin
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return CommandResult{Actor: fmt.Sprintf("Usage: %s <nick> [player|builder|admin|level]", cmd)}
+	}
+	if len(args) == 2 {
+		var ok bool
+		if level, ok = parsePermission(args[1]); !ok {
+			return CommandResult{Actor: fmt.Sprintf("%s is not a permission level.", args[1])}
+		}
+	}
+
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok {
+		return CommandResult{Actor: fmt.Sprintf("%s is not online.", args[0])}
+	}
+	own, current := c.Player.PermissionLevel(), target.Player.PermissionLevel()
+	switch {
+	case target.Player.Nickname == c.Player.Nickname:
+		return CommandResult{Actor: fmt.Sprintf("You cannot %s yourself.", cmd)}
+	case current >= own:
+		return CommandResult{Actor: fmt.Sprintf("You cannot %s %s.", cmd, target.Player.Nickname)}
+	case level > own:
+		return CommandResult{Actor: "You cannot give a higher permission level than your own."}
+	case promote && level <= current, !promote && level >= current:
+		return CommandResult{Actor: fmt.Sprintf("That would not %s %s, who is at permission level %d.", cmd, target.Player.Nickname, current)}
+	}
+
+	target.Player.SetPermissionLevel(level)
+	log.Info(fmt.Sprintf("%s changed the permission level of %s from %d to %d", c.Player.Nickname, target.Player.Nickname, current, level))
+	return CommandResult{
+		Actor: fmt.Sprintf("%s is now at permission level %d.", target.Player.Nickname, level),
+		Targets: []Target{{
+			Client: target,
+			Msg:    fmt.Sprintf("%s changed your permission level to %d.", c.Player.Nickname, level),
+		}},
+	}
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+func TestCanUseAtBoundaries(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+
+	tests := []struct {
+		etype string
+		level int
+		want  bool
+	}{
+		{etype: "look", level: area.PermissionPlayer, want: true},
+		{etype: "reload", level: area.PermissionPlayer, want: false},
+		{etype: "reload", level: area.PermissionBuilder - 1, want: false},
+		{etype: "reload", level: area.PermissionBuilder, want: true},
+		{etype: "ban", level: area.PermissionBuilder, want: false},
+		{etype: "ban", level: area.PermissionAdmin - 1, want: false},
+		{etype: "ban", level: area.PermissionAdmin, want: true},
+		{etype: "ban", level: area.PermissionAdmin + 1, want: true},
+	}
+	for _, test := range tests {
+		c.Player.SetPermissionLevel(test.level)
+		if got := s.canUse(c, test.etype); got != test.want {
+			t.Errorf("canUse(%q) at level %d = %v, want %v", test.etype, test.level, got, test.want)
+		}
+		etype, _ := s.matchCommand(c, test.etype)
+		if got := etype == test.etype; got != test.want {
+			t.Errorf("matchCommand(%q) at level %d = %q", test.etype, test.level, etype)
+		}
+	}
+}
+
+func TestConfiguredCommandPermissions(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig + "\n[config.commandPermissions]\nban = 50\nlook = 10\n",
+		"areas/town.toml": testArea,
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	c := addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+
+	c.Player.SetPermissionLevel(area.PermissionBuilder)
+	if !s.canUse(c, "ban") {
+		t.Error("builders cannot ban once ban needs level 50")
+	}
+	c.Player.SetPermissionLevel(9)
+	if s.canUse(c, "look") {
+		t.Error("level 9 can look when look needs level 10")
+	}
+	// Commands left alone keep their default level.
+	if s.canUse(c, "reload") {
+		t.Error("level 9 can reload")
+	}
+
+	for _, config := range []string{"bogus = 10\n", "look = -1\n"} {
+		s := newTestServer(t, map[string]string{
+			"server.toml": testConfig + "\n[config.commandPermissions]\n" + config,
+		})
+		if err := s.loadConfig(); err == nil {
+			t.Errorf("%q: loaded fine", config)
+		}
+	}
+}
+
+func TestLegacyAdmin(t *testing.T) {
+	p := &area.Player{Admin: true}
+	if got := p.PermissionLevel(); got != area.PermissionAdmin {
+		t.Errorf("got level %d for a player saved as an admin", got)
+	}
+	p.SetPermissionLevel(area.PermissionBuilder)
+	if p.Admin || p.IsAdmin() {
+		t.Error("demoting to builder left the player an admin")
+	}
+}
+
+func TestPromote(t *testing.T) {
+	s := newLoadedTestServer(t)
+	admin := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	admin.Player.SetPermissionLevel(area.PermissionAdmin)
+	builder := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	builder.Player.SetPermissionLevel(area.PermissionBuilder)
+	player := addTestPlayer(t, s, "Carol", "Town", "Square", "4")
+
+	tests := []struct {
+		by      client.Client
+		promote bool
+		args    []string
+		want    string
+		level   int
+	}{
+		{by: admin, promote: true, args: []string{"Carol", "builder"}, want: "Carol is now at permission level 50.", level: 50},
+		{by: admin, promote: true, args: []string{"Carol", "builder"}, want: "That would not promote Carol, who is at permission level 50.", level: 50},
+		{by: admin, promote: false, args: []string{"Carol"}, want: "Carol is now at permission level 0.", level: 0},
+		{by: admin, promote: true, args: []string{"Carol", "75"}, want: "Carol is now at permission level 75.", level: 75},
+		{by: admin, promote: true, args: []string{"Carol", "superuser"}, want: "superuser is not a permission level.", level: 75},
+		{by: builder, promote: false, args: []string{"Carol"}, want: "You cannot demote Carol.", level: 75},
+		{by: admin, promote: false, args: []string{"Carol", "player"}, want: "Carol is now at permission level 0.", level: 0},
+		{by: builder, promote: true, args: []string{"Carol", "admin"}, want: "You cannot give a higher permission level than your own.", level: 0},
+		{by: builder, promote: true, args: []string{"Carol", "49"}, want: "Carol is now at permission level 49.", level: 49},
+		{by: builder, promote: false, args: []string{"Bob"}, want: "You cannot demote yourself.", level: 49},
+		{by: admin, promote: true, args: []string{"Dave"}, want: "Dave is not online.", level: 49},
+	}
+	for _, test := range tests {
+		got := doPromote(s, test.by, test.args, test.promote)
+		if got.Actor != test.want {
+			t.Errorf("%s %v: got %q, want %q", test.by.Player.Nickname, test.args, got.Actor, test.want)
+		}
+		if level := player.Player.PermissionLevel(); level != test.level {
+			t.Errorf("%s %v: Carol is at level %d, want %d", test.by.Player.Nickname, test.args, level, test.level)
+		}
+	}
+}
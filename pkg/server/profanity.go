@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// wordFilter masks the words of a wordlist in chat. It is safe for
+// concurrent use.
+type wordFilter struct {
+	sync.RWMutex
+	// words holds the lowercased words to mask.
+	words map[string]bool
+	path  string
+}
+
+func newWordFilter(path string) *wordFilter {
+	return &wordFilter{path: path}
+}
+
+// load reads the wordlist from disk, one word per line. Blank lines and
+// lines starting with # are skipped. The filter masks nothing when it has no
+// file.
+func (f *wordFilter) load() error {
+	words := make(map[string]bool)
+	if f.path != "" {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return readFileError(f.path, err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if word == "" || strings.HasPrefix(word, "#") {
+				continue
+			}
+			words[word] = true
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	f.Lock()
+	f.words = words
+	f.Unlock()
+	return nil
+}
+
+// mask replaces every letter of the whole words of text found in the
+// wordlist with an asterisk. Words are runs of letters and digits, so that
+// words merely containing a listed word are left alone.
+func (f *wordFilter) mask(text string) string {
+	f.RLock()
+	defer f.RUnlock()
+	if len(f.words) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		if f.words[strings.ToLower(word)] {
+			b.WriteString(strings.Repeat("*", utf8.RuneCountInString(word)))
+		} else {
+			b.WriteString(word)
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(i)
+		}
+		b.WriteRune(r)
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+	return b.String()
+}
+
+// loadProfanityFilter loads the configured wordlist of the profanity filter.
+func (s *Server) loadProfanityFilter() error {
+	path := ""
+	if s.Config.ProfanityFilter != "" {
+		path = s.StaticPath(s.Config.ProfanityFilter)
+	}
+	s.profanity = newWordFilter(path)
+	return s.profanity.load()
+}
+
+// censor masks the profanity in text for the player, unless the player
+// turned the filter off.
+func (s *Server) censor(c client.Client, text string) string {
+	if !c.Player.Settings.Filter {
+		return text
+	}
+	return s.profanity.mask(text)
+}
+
+// doReload reads the wordlist of the profanity filter again.
+func doReload(s *Server, c client.Client) string {
+	if err := s.profanity.load(); err != nil {
+		log.Error(fmt.Sprintf("Profanity filter could not be reloaded: %v", err))
+		return "The profanity filter could not be reloaded."
+	}
+	log.Info(fmt.Sprintf("%s reloaded the profanity filter", c.Player.Nickname))
+	return "Profanity filter reloaded."
+}
+
+// doFilter turns the profanity filter on or off for the player.
+func doFilter(c client.Client, args []string) string {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		return "Usage: filter <on|off>"
+	}
+
+	c.Player.Settings.Filter = args[0] == "on"
+	return fmt.Sprintf("Profanity filter is %s.", args[0])
+}
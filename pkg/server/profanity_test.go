@@ -0,0 +1,80 @@
+package server
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// newTestWordFilter returns a filter loaded from a wordlist holding the
+// given content.
+func newTestWordFilter(t *testing.T, wordlist string) *wordFilter {
+	path := filepath.Join(newStaticDir(t, map[string]string{"profanity.txt": wordlist}), "profanity.txt")
+	f := newWordFilter(path)
+	if err := f.load(); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestWordFilterMask(t *testing.T) {
+	f := newTestWordFilter(t, "# Words to mask\ndarn\n\n  Heck \nfrak\n")
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "darn it", want: "**** it"},
+		{text: "What the HECK!", want: "What the ****!"},
+		{text: "darn,heck.frak", want: "****,****.****"},
+		// Only whole words are masked.
+		{text: "darned hecklers", want: "darned hecklers"},
+		{text: "darn2 heck_", want: "darn2 ****_"},
+		{text: "ädarn darnö", want: "ädarn darnö"},
+		{text: "", want: ""},
+		{text: "# Words to mask", want: "# Words to mask"},
+	}
+	for _, test := range tests {
+		if got := f.mask(test.text); got != test.want {
+			t.Errorf("mask(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestWordFilterReload(t *testing.T) {
+	f := newTestWordFilter(t, "darn\n")
+	if err := ioutil.WriteFile(f.path, []byte("heck\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.mask("darn heck"); got != "darn ****" {
+		t.Errorf("got %q", got)
+	}
+
+	// Without a wordlist nothing is masked.
+	f = newWordFilter("")
+	if err := f.load(); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.mask("darn heck"); got != "darn heck" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCensor(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.profanity = newTestWordFilter(t, "darn\n")
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	if got := s.censor(c, "darn it"); got != "**** it" {
+		t.Errorf("filter on: got %q", got)
+	}
+	if got := doFilter(c, []string{"off"}); got != "Profanity filter is off." {
+		t.Errorf("got %q", got)
+	}
+	if got := s.censor(c, "darn it"); got != "darn it" {
+		t.Errorf("filter off: got %q", got)
+	}
+}
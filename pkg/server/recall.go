@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// recallCooldown is the name of the cooldown of recall.
+const recallCooldown = "recall"
+
+// planRecall returns where the player would recall to at now, without
+// moving the player.
+func planRecall(s *Server, c client.Client, now time.Time) (moveTarget, bool, string) {
+	if c.Player.InCombat(now, time.Duration(s.Config.CombatLockSeconds)*time.Second) {
+		return moveTarget{}, false, "You are too busy fighting."
+	}
+	if left := c.Player.CooldownLeft(recallCooldown, now); left > 0 {
+		seconds := int((left + time.Second - 1) / time.Second)
+		return moveTarget{}, false, fmt.Sprintf("You cannot recall for another %s.", plural(seconds, "second"))
+	}
+
+	a, room, pos := s.startLocation()
+	if c.Player.Area == a && c.Player.Room == room && c.Player.Position == pos {
+		return moveTarget{}, false, "You are already there."
+	}
+	if ok, msg := s.canEnter(c.Player, a, room); !ok {
+		return moveTarget{}, false, msg
+	}
+	return moveTarget{area: a, room: room, position: pos}, true, ""
+}
+
+// doRecall moves the player back to the start location, unless the player is
+// fighting or recalled too recently. It returns true if the player moved.
+func doRecall(s *Server, c client.Client, now time.Time) (bool, string) {
+	target, ok, msg := planRecall(s, c, now)
+	if !ok {
+		return false, msg
+	}
+
+	s.movePlayer(c.Player, target.area, target.room, target.position)
+	c.Player.SetCooldown(recallCooldown, time.Duration(s.Config.RecallCooldownSeconds)*time.Second)
+	return true, "You recall to safety."
+}
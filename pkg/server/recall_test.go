@@ -0,0 +1,35 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecall(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.CombatLockSeconds = 10
+	s.Config.RecallCooldownSeconds = 60
+	c := addTestPlayer(t, s, "Alice", "Town", "Inn", "1")
+	now := time.Now()
+
+	// Players cannot recall out of a fight until the combat lock is over.
+	c.Player.LastCombat = now.Add(-9 * time.Second)
+	if moved, got := doRecall(s, c, now); moved || got != "You are too busy fighting." {
+		t.Errorf("in combat: got %t, %q", moved, got)
+	}
+	c.Player.LastCombat = now.Add(-10 * time.Second)
+	if moved, got := doRecall(s, c, now); !moved || got != "You recall to safety." {
+		t.Errorf("after the combat lock: got %t, %q", moved, got)
+	}
+	if c.Player.Area != "Town" || c.Player.Room != "Square" || c.Player.Position != "1" {
+		t.Errorf("got player at %s/%s/%s", c.Player.Area, c.Player.Room, c.Player.Position)
+	}
+
+	ready := c.Player.Cooldowns[recallCooldown]
+	if moved, got := doRecall(s, c, ready.Add(-30*time.Second)); moved || got != "You cannot recall for another 30 seconds." {
+		t.Errorf("on cooldown: got %t, %q", moved, got)
+	}
+	if moved, got := doRecall(s, c, ready); moved || got != "You are already there." {
+		t.Errorf("at the start: got %t, %q", moved, got)
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// defaultRelocateNotice tells players whose saved place is gone where they
+// are now when no notice is configured.
+const defaultRelocateNotice = "The place you were in is gone. You find yourself in {room}."
+
+// relocate returns where to put a player saved at a place that does not
+// exist anymore. Players whose room is still there stay in it, at its spawn
+// cube if it has one, or else at the cube numbered closest to the one they
+// were at, since cubes are numbered across the room. Everybody else goes to
+// the start location.
+func (s *Server) relocate(areaName, roomName, cubeID string) (string, string, string) {
+	room, ok := s.Areas[areaName].Rooms[roomName]
+	if !ok || len(room.Cubes) == 0 {
+		return s.startLocation()
+	}
+	if room.Spawn != "" && s.locationExists(areaName, roomName, room.Spawn) {
+		return areaName, roomName, room.Spawn
+	}
+	return areaName, roomName, closestCube(room, cubeID)
+}
+
+// closestCube returns the ID of the cube in the room numbered closest to the
+// given ID, preferring cubes that are not doors. The first cube of the room
+// is returned when the ID is not a number.
+func closestCube(room area.Room, cubeID string) string {
+	want, err := strconv.Atoi(cubeID)
+	if err != nil {
+		return room.Cubes[0].ID
+	}
+
+	best, bestDistance, bestDoor := room.Cubes[0].ID, -1, true
+	for _, cube := range room.Cubes {
+		id, err := strconv.Atoi(cube.ID)
+		if err != nil {
+			continue
+		}
+		distance := abs(id - want)
+		door := cube.Type == "door"
+		if bestDistance < 0 || (bestDoor && !door) || (door == bestDoor && distance < bestDistance) {
+			best, bestDistance, bestDoor = cube.ID, distance, door
+		}
+	}
+	return best
+}
+
+// relocateNotice returns what players moved out of a place that is gone are
+// told, naming the room they are in now.
+func (s *Server) relocateNotice(areaName, roomName string) string {
+	notice := s.Config.RelocateNotice
+	if notice == "" {
+		notice = defaultRelocateNotice
+	}
+	return strings.Replace(notice, "{room}", s.roomName(areaName, roomName), -1)
+}
+
+// missingSpawns returns a problem for every room whose spawn cube does not
+// exist.
+func (s *Server) missingSpawns() []error {
+	var problems []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			spawn := a.Rooms[roomName].Spawn
+			if spawn != "" && !s.locationExists(areaName, roomName, spawn) {
+				problems = append(problems, fmt.Errorf("area %q room %q: spawn cube %s does not exist", areaName, roomName, spawn))
+			}
+		}
+	}
+
+	return problems
+}
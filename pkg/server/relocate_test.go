@@ -0,0 +1,100 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// loadSavedAt saves Alice at the given place and loads her again.
+func loadSavedAt(t *testing.T, s *Server, areaName, room, position string) *area.Player {
+	delete(s.Players, nickKey("Alice"))
+	if !s.savePlayer(area.Player{Nickname: "Alice", PC: *game.NewPC(s.rand), Area: areaName, Room: room, Position: position}) {
+		t.Fatal("player was not saved")
+	}
+	if exists, err := s.loadPlayer("Alice"); !exists || err != nil {
+		t.Fatalf("player was not loaded: exists=%v err=%v", exists, err)
+	}
+	p, _ := s.GetPlayerByNick("Alice")
+	return &p
+}
+
+func TestLoadPlayerRelocatesFromMissingCube(t *testing.T) {
+	s := newLoadedTestServer(t)
+
+	// Cube 5 of the Square is a wall now, and 4 is the closest cube left.
+	p := loadSavedAt(t, s, "Town", "Square", "5")
+	if got := place(p); got != "Town/Square/4" {
+		t.Errorf("got player at %s, want Town/Square/4", got)
+	}
+	if p.Notice != "The place you were in is gone. You find yourself in Square." {
+		t.Errorf("got notice %q", p.Notice)
+	}
+
+	// Players at a cube that is still there stay, even on a door.
+	p = loadSavedAt(t, s, "Town", "Inn", "2")
+	if got := place(p); got != "Town/Inn/2" || p.Notice != "" {
+		t.Errorf("a player on a door that exists was moved to %s: %q", got, p.Notice)
+	}
+
+	// Cubes not numbered are looked for from the first one.
+	p = loadSavedAt(t, s, "Town", "Square", "3x")
+	if got := place(p); got != "Town/Square/1" {
+		t.Errorf("got player at %s, want the first cube of the Square", got)
+	}
+
+	// The spawn cube of the room comes first.
+	square := s.Areas["Town"].Rooms["Square"]
+	square.Spawn = "2"
+	s.Areas["Town"].Rooms["Square"] = square
+	s.Config.RelocateNotice = "You wake up in the {room}."
+	p = loadSavedAt(t, s, "Town", "Square", "5")
+	if got := place(p); got != "Town/Square/2" {
+		t.Errorf("got player at %s, want the spawn cube", got)
+	}
+	if p.Notice != "You wake up in the Square." {
+		t.Errorf("got notice %q", p.Notice)
+	}
+}
+
+func TestClosestCube(t *testing.T) {
+	room := area.Room{Cubes: []area.Cube{
+		{ID: "1"},
+		{ID: "3", Type: "door"},
+		{ID: "4"},
+		{ID: "9"},
+	}}
+	tests := []struct {
+		id, want string
+	}{
+		{id: "2", want: "1"},
+		{id: "3", want: "4"},
+		{id: "7", want: "9"},
+		{id: "100", want: "9"},
+		{id: "wall", want: "1"},
+	}
+	for _, test := range tests {
+		if got := closestCube(room, test.id); got != test.want {
+			t.Errorf("closest to %s: got %s, want %s", test.id, got, test.want)
+		}
+	}
+
+	doors := area.Room{Cubes: []area.Cube{{ID: "1", Type: "door"}, {ID: "5", Type: "door"}}}
+	if got := closestCube(doors, "4"); got != "5" {
+		t.Errorf("in a room of doors: got %s, want 5", got)
+	}
+}
+
+func TestMissingSpawns(t *testing.T) {
+	s := newLoadedTestServer(t)
+	inn := s.Areas["Town"].Rooms["Inn"]
+	inn.Spawn = "7"
+	s.Areas["Town"].Rooms["Inn"] = inn
+
+	problems := s.missingSpawns()
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), `room "Inn": spawn cube 7 does not exist`) {
+		t.Errorf("got problems %v", problems)
+	}
+}
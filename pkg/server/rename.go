@@ -0,0 +1,210 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// doRename renames a character. Admins can rename anybody with
+// rename <oldnick> <newnick>, while players can rename themselves with
+// rename <newnick> if the server allows it. It returns the renamed player if
+// that player is online.
+func doRename(s *Server, c client.Client, args []string) (*client.Client, string) {
+	var oldNick, newNick string
+	switch {
+	case len(args) == 2 && c.Player.IsAdmin():
+		oldNick, newNick = args[0], args[1]
+	case len(args) == 1 && s.Config.AllowSelfRename:
+		oldNick, newNick = c.Player.Nickname, args[0]
+	case c.Player.IsAdmin():
+		return nil, "Usage: rename <oldnick> <newnick>"
+	case s.Config.AllowSelfRename:
+		return nil, "Usage: rename <newnick>"
+	default:
+		return nil, "You cannot rename yourself."
+	}
+
+	if problem := s.nickProblem(newNick); problem != "" {
+		return nil, problem
+	}
+	if !strings.EqualFold(oldNick, newNick) && s.nickTaken(newNick) {
+		return nil, fmt.Sprintf("%s is already taken.", newNick)
+	}
+
+	ok, oldFileName := s.getPlayerFileName(oldNick)
+	if !ok {
+		return nil, fmt.Sprintf("Username %s is not valid (0-9a-z_-).", oldNick)
+	}
+
+	online, isOnline := s.OnlineClientByNick(oldNick)
+	if !isOnline {
+		if _, ok := s.Players[nickKey(oldNick)]; !ok {
+			if exists, err := s.loadPlayer(oldNick); err != nil || !exists {
+				return nil, fmt.Sprintf("%s does not exist.", oldNick)
+			}
+		}
+	}
+
+	// Fights and edits are keyed by the nickname exactly as it is.
+	if isOnline {
+		oldNick = online.Player.Nickname
+	} else {
+		oldNick = s.Players[nickKey(oldNick)].Nickname
+	}
+	if problem := s.renameProblem(oldNick, newNick, time.Now()); problem != "" {
+		return nil, problem
+	}
+
+	s.renamePlayer(oldNick, newNick)
+
+	player := s.Players[nickKey(newNick)]
+	if isOnline {
+		player = *online.Player
+	}
+	if !s.savePlayer(player) {
+		s.renamePlayer(newNick, oldNick)
+		return nil, fmt.Sprintf("%s could not be renamed.", oldNick)
+	}
+	// Changing only the case of the nick keeps the same file.
+	if _, newFileName := s.getPlayerFileName(newNick); newFileName != oldFileName {
+		if err := os.Remove(oldFileName); err != nil && !os.IsNotExist(err) {
+			log.Error(fmt.Sprintf("%s could not be removed: %v", oldFileName, err))
+		}
+	}
+	s.mailLock.Lock()
+	if err := os.Rename(s.mailboxPath(oldNick), s.mailboxPath(newNick)); err != nil && !os.IsNotExist(err) {
+		log.Error(fmt.Sprintf("Mailbox of %s could not be renamed: %v", oldNick, err))
+	}
+	s.mailLock.Unlock()
+	if err := s.notes.rename(oldNick, newNick); err != nil {
+		log.Error(fmt.Sprintf("Notes about %s could not be moved: %v", oldNick, err))
+	}
+	s.renameIgnoredOffline(oldNick, newNick)
+
+	log.Info(fmt.Sprintf("%s renamed %s to %s", c.Player.Nickname, oldNick, newNick))
+
+	msg := fmt.Sprintf("%s is now known as %s.", oldNick, newNick)
+	if !isOnline {
+		return nil, msg
+	}
+	return &online, msg
+}
+
+// nickTaken returns true if a player with the given nickname exists, either
+// online, in memory or on disk.
+func (s *Server) nickTaken(nick string) bool {
+	if _, ok := s.OnlineClientByNick(nick); ok {
+		return true
+	}
+	if _, ok := s.Players[nickKey(nick)]; ok {
+		return true
+	}
+	if ok, fileName := s.getPlayerFileName(nick); ok {
+		if _, err := os.Stat(fileName); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// renameProblem returns why the player known as oldNick cannot be renamed
+// to newNick at now, if it cannot. Players who are banned, fighting or
+// building keep their nickname until that is over, so that a new nickname is
+// no way out of a ban or a fight and no edits are left under the old one.
+func (s *Server) renameProblem(oldNick, newNick string, now time.Time) string {
+	switch {
+	case s.bans.isBanned(oldNick, now):
+		return fmt.Sprintf("%s is banned. Lift the ban first.", oldNick)
+	case s.bans.isBanned(newNick, now):
+		return fmt.Sprintf("%s is banned.", newNick)
+	case s.isFighting(oldNick) || s.isLinkDead(oldNick) || s.inDuel(oldNick):
+		return fmt.Sprintf("%s is in the middle of a fight.", oldNick)
+	case s.isBuilding(oldNick):
+		return fmt.Sprintf("%s is building. The area needs to be saved first.", oldNick)
+	}
+	return ""
+}
+
+// renamePlayer moves the player known as oldNick to newNick in all the
+// in-memory caches, updating the session of the player if online, and the
+// ignore lists of the players in memory.
+func (s *Server) renamePlayer(oldNick, newNick string) {
+	// TODO: Lock
+	if p, ok := s.Players[nickKey(oldNick)]; ok {
+		delete(s.Players, nickKey(oldNick))
+		p.Nickname = newNick
+		s.Players[nickKey(newNick)] = p
+	}
+	for _, p := range s.Players {
+		renameIgnored(&p, oldNick, newNick)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if c, ok := s.onlineClients[nickKey(oldNick)]; ok {
+		delete(s.onlineClients, nickKey(oldNick))
+		c.Player.Nickname = newNick
+		s.onlineClients[nickKey(newNick)] = c
+	}
+	if ss, ok := s.sessions[nickKey(oldNick)]; ok {
+		delete(s.sessions, nickKey(oldNick))
+		ss.player.Nickname = newNick
+		s.sessions[nickKey(newNick)] = ss
+	}
+	for _, c := range s.onlineClients {
+		renameIgnored(c.Player, oldNick, newNick)
+	}
+	for _, ss := range s.sessions {
+		renameIgnored(ss.player, oldNick, newNick)
+	}
+}
+
+// renameIgnored replaces oldNick by newNick in the ignore list of the player.
+// It returns true if the player was ignoring oldNick.
+func renameIgnored(p *area.Player, oldNick, newNick string) bool {
+	renamed := false
+	for i, ignored := range p.Settings.Ignored {
+		if strings.EqualFold(ignored, oldNick) {
+			p.Settings.Ignored[i] = newNick
+			renamed = true
+		}
+	}
+	return renamed
+}
+
+// renameIgnoredOffline replaces oldNick by newNick in the ignore lists of
+// the players who are not online, rewriting their player files. Online
+// players are saved with their ignore lists when they leave.
+func (s *Server) renameIgnoredOffline(oldNick, newNick string) {
+	files, err := ioutil.ReadDir(s.playerDir())
+	if err != nil {
+		log.Error(fmt.Sprintf("Ignore lists could not be updated: %v", err))
+		return
+	}
+
+	for _, f := range files {
+		nick := strings.TrimSuffix(f.Name(), ".toml")
+		if f.IsDir() || filepath.Ext(f.Name()) != ".toml" || !IsValidUsername(nick) {
+			continue
+		}
+		if _, online := s.OnlineClientByNick(nick); online {
+			continue
+		}
+		p, exists, err := s.readPlayer(nick)
+		if err != nil || !exists || !renameIgnored(&p, oldNick, newNick) {
+			continue
+		}
+		if !s.savePlayer(p) {
+			log.Error(fmt.Sprintf("Ignore list of %s could not be updated", p.Nickname))
+		}
+	}
+}
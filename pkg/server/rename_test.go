@@ -0,0 +1,164 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// newRenameTestServer returns a test server with an admin online, and the
+// ban list and notes loaded.
+func newRenameTestServer(t *testing.T) (*Server, client.Client) {
+	s := newLoadedTestServer(t)
+	if err := s.loadBans(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadNotes(); err != nil {
+		t.Fatal(err)
+	}
+	admin := addTestPlayer(t, s, "Admin", "Town", "Inn", "1")
+	admin.Player.Permission = area.PermissionAdmin
+	return s, admin
+}
+
+func TestRenameMigratesNickKeys(t *testing.T) {
+	s, admin := newRenameTestServer(t)
+	addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+	carol := addTestPlayer(t, s, "Carol", "Town", "Square", "2")
+	carol.Player.Settings.Ignored = []string{"bob"}
+	dave := area.Player{Nickname: "Dave", Settings: area.DefaultSettings()}
+	dave.Settings.Ignored = []string{"Bob"}
+	if !s.savePlayer(dave) {
+		t.Fatal("Dave was not saved")
+	}
+	if err := s.notes.add("Bob", Note{Author: "Admin", Text: "Watch out."}); err != nil {
+		t.Fatal(err)
+	}
+
+	renamed, msg := doRename(s, admin, []string{"bob", "Robert"})
+	if renamed == nil {
+		t.Fatalf("Bob was not renamed: %s", msg)
+	}
+
+	if _, ok := s.OnlineClientByNick("Bob"); ok {
+		t.Error("Bob is still online")
+	}
+	robert, ok := s.OnlineClientByNick("robert")
+	if !ok || robert.Player.Nickname != "Robert" {
+		t.Fatalf("Robert is not online: %v", ok)
+	}
+	if _, ok := s.Players[nickKey("Bob")]; ok {
+		t.Error("Bob is still in Players")
+	}
+	if p, ok := s.Players[nickKey("Robert")]; !ok || p.Nickname != "Robert" {
+		t.Errorf("Robert is not in Players: %+v", p)
+	}
+	if occupants := s.OnlineClientsGetByRoom("Town", "Square"); len(occupants) != 2 {
+		t.Errorf("got %d players in the square, want 2", len(occupants))
+	}
+
+	if !ignores(carol.Player, "Robert") || ignores(carol.Player, "Bob") {
+		t.Errorf("Carol ignores %v, want Robert", carol.Player.Settings.Ignored)
+	}
+	if p, _, _ := s.readPlayer("Dave"); !ignores(&p, "Robert") {
+		t.Errorf("Dave ignores %v, want Robert", p.Settings.Ignored)
+	}
+
+	if notes := s.notes.list("Robert"); len(notes) != 1 {
+		t.Errorf("got %d notes about Robert, want 1", len(notes))
+	}
+	if notes := s.notes.list("Bob"); len(notes) != 0 {
+		t.Errorf("got %d notes about Bob, want none", len(notes))
+	}
+
+	if _, err := os.Stat(filepath.Join(s.playerDir(), "robert.toml")); err != nil {
+		t.Errorf("player file of Robert is missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.playerDir(), "bob.toml")); !os.IsNotExist(err) {
+		t.Errorf("player file of Bob is still there: %v", err)
+	}
+}
+
+func TestRenameMigratesSession(t *testing.T) {
+	s, admin := newRenameTestServer(t)
+	bob := area.Player{Nickname: "Bob", Area: "Town", Room: "Square", Position: "1", Settings: area.DefaultSettings()}
+	if !s.savePlayer(bob) {
+		t.Fatal("Bob was not saved")
+	}
+	s.Players[nickKey("Bob")] = bob
+	s.sessions[nickKey("Bob")] = session{player: &bob, expires: time.Now().Add(time.Minute)}
+
+	if _, msg := doRename(s, admin, []string{"Bob", "Robert"}); msg != "Bob is now known as Robert." {
+		t.Fatalf("got %q", msg)
+	}
+
+	if p, ok := s.resumeSession("Bob", time.Now()); ok {
+		t.Errorf("the session can still be resumed as Bob by %s", p.Nickname)
+	}
+	p, ok := s.resumeSession("Robert", time.Now())
+	if !ok || p.Nickname != "Robert" {
+		t.Errorf("the session cannot be resumed as Robert: %v", ok)
+	}
+}
+
+func TestRenameRefused(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(s *Server)
+		want  string
+	}{
+		{
+			name:  "banned",
+			setup: func(s *Server) { s.bans.add(Ban{Target: "bob"}) },
+			want:  "Bob is banned. Lift the ban first.",
+		},
+		{
+			name:  "new nick banned",
+			setup: func(s *Server) { s.bans.add(Ban{Target: "Robert"}) },
+			want:  "Robert is banned.",
+		},
+		{
+			name:  "fighting",
+			setup: func(s *Server) { s.combats[duelKey{"Carol", "Bob"}] = time.Now() },
+			want:  "Bob is in the middle of a fight.",
+		},
+		{
+			name:  "challenged to a duel",
+			setup: func(s *Server) { s.challenges[duelKey{"Carol", "Bob"}] = time.Now().Add(time.Minute) },
+			want:  "Bob is in the middle of a fight.",
+		},
+		{
+			name:  "link-dead",
+			setup: func(s *Server) { s.linkDead[nickKey("Bob")] = linkDeadBody{nick: "Bob"} },
+			want:  "Bob is in the middle of a fight.",
+		},
+		{
+			name:  "building",
+			setup: func(s *Server) { s.claimArea("Town", "Bob") },
+			want:  "Bob is building. The area needs to be saved first.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, admin := newRenameTestServer(t)
+			bob := addTestPlayer(t, s, "Bob", "Town", "Square", "1")
+			test.setup(s)
+
+			renamed, msg := doRename(s, admin, []string{"bob", "Robert"})
+			if renamed != nil || msg != test.want {
+				t.Errorf("got %v, %q, want %q", renamed, msg, test.want)
+			}
+			if bob.Player.Nickname != "Bob" {
+				t.Errorf("Bob is now known as %s", bob.Player.Nickname)
+			}
+			if _, ok := s.OnlineClientByNick("Bob"); !ok {
+				t.Error("Bob is no longer online as Bob")
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// CommandResult is what the players involved in a command get to see.
+// Command handlers return it instead of printing themselves, and deliver
+// prints it, so that the policy of who sees what lives in one place.
+type CommandResult struct {
+	// Actor is shown to the player who gave the command.
+	Actor string
+	// Room is shown to the other players in the room of the actor.
+	Room string
+	// Channel is the chat channel Room is said on. When it is set, only the
+	// players listening to the channel and not ignoring the actor see Room,
+	// and profanity is masked for those filtering it.
+	Channel string
+	// Targets holds messages shown to particular players.
+	Targets []Target
+	// Role is the theme role Actor, Room and Targets are colored as. Chat
+	// is always colored as theme.Chat.
+	Role string
+}
+
+// Target is a message shown to a particular player.
+type Target struct {
+	Client client.Client
+	Msg    string
+}
+
+// deliver shows the result of a command given by cl to everybody involved.
+func deliver(
+	s *Server,
+	cl client.Client,
+	result CommandResult,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	var others []client.Client
+	if result.Room != "" {
+		for _, o := range s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room) {
+			if o.Player.Nickname != cl.Player.Nickname {
+				others = append(others, o)
+			}
+		}
+	}
+
+	if result.Channel != "" {
+		godPrintChat(s, cl, chatRecipients(others, result.Channel, cl.Player), wg, quit, roomsMap, result.Actor, result.Room)
+	} else {
+		wg.Add(1)
+		godPrintRoom(s, cl, append([]client.Client{cl}, others...), wg, quit, roomsMap,
+			s.paint(result.Role, result.Actor), s.paint(result.Role, result.Room))
+	}
+
+	for _, t := range result.Targets {
+		wg.Add(1)
+		godPrintRoom(s, t.Client, []client.Client{t.Client}, wg, quit, roomsMap, s.paint(result.Role, t.Msg), "")
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSayResult(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	tests := []struct {
+		args []string
+		want CommandResult
+	}{
+		{
+			args: []string{"hello", "there"},
+			want: CommandResult{Actor: "You say: hello there", Room: "Alice says: hello there", Channel: channelSay},
+		},
+		{args: nil, want: CommandResult{Actor: "Say what?"}},
+	}
+	for _, test := range tests {
+		if got := doSay(c, test.args, time.Now()); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("say %v: got %+v, want %+v", test.args, got, test.want)
+		}
+	}
+}
+
+func TestLookResult(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	alice.Player.HideIntro = true
+
+	steps := []struct {
+		join string
+		want string
+	}{
+		{want: "You are alone here."},
+		{join: "Dave", want: "Dave is here."},
+		{join: "Bob", want: "Bob and Dave are here."},
+		{join: "Carol", want: "Bob, Carol and Dave are here."},
+	}
+	positions := []string{"2", "3", "4"}
+	for i, step := range steps {
+		if step.join != "" {
+			addTestPlayer(t, s, step.join, "Town", "Square", positions[i-1])
+		}
+		want := CommandResult{Actor: step.want}
+		if got := doLook(s, alice); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+	if alice.Player.HideIntro {
+		t.Error("looking did not bring back the room description")
+	}
+
+	// Players elsewhere are not seen.
+	addTestPlayer(t, s, "Erin", "Town", "Inn", "1")
+	if got := doLook(s, alice).Actor; got != "Bob, Carol and Dave are here." {
+		t.Errorf("got %q", got)
+	}
+}
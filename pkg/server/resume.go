@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// session is the in-world state of a player whose connection broke, kept so
+// that the player can resume it by logging in again.
+type session struct {
+	player *area.Player
+	// expires is when the session can no longer be resumed.
+	expires time.Time
+}
+
+// resumable returns true if the session can still be resumed at now.
+func (ss session) resumable(now time.Time) bool {
+	return now.Before(ss.expires)
+}
+
+// suspendSession logs out the player whose connection broke, keeping the
+// session of the player around for the reconnect grace window. The player is
+// saved either way so that nothing is lost if the session expires.
+func (s *Server) suspendSession(c client.Client, now time.Time) {
+	s.OnExit(c)
+
+	grace := time.Duration(s.Config.ReconnectGraceSeconds) * time.Second
+	if grace <= 0 {
+		return
+	}
+
+	s.Lock()
+	s.sessions[nickKey(c.Player.Nickname)] = session{player: c.Player, expires: now.Add(grace)}
+	s.Unlock()
+}
+
+// resumeSession returns the player of the suspended session of the given
+// nickname, if there is one that can still be resumed at now. A session can
+// be resumed only once.
+func (s *Server) resumeSession(nick string, now time.Time) (*area.Player, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	ss, ok := s.sessions[nickKey(nick)]
+	if !ok {
+		return nil, false
+	}
+	delete(s.sessions, nickKey(nick))
+	if !ss.resumable(now) {
+		return nil, false
+	}
+	return ss.player, true
+}
+
+// expireSessions forgets the suspended sessions that can no longer be
+// resumed at now.
+func (s *Server) expireSessions(now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	for nick, ss := range s.sessions {
+		if !ss.resumable(now) {
+			log.Info(fmt.Sprintf("Session of player %q expired", nick))
+			delete(s.sessions, nick)
+		}
+	}
+}
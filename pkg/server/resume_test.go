@@ -0,0 +1,75 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResumeSession(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		after  time.Duration
+		resume bool
+	}{
+		{name: "right after the connection broke", after: 0, resume: true},
+		{name: "just before the session expires", after: time.Minute - time.Nanosecond, resume: true},
+		{name: "when the session expires", after: time.Minute, resume: false},
+		{name: "long after the session expired", after: time.Hour, resume: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newLoadedTestServer(t)
+			s.Config.ReconnectGraceSeconds = 60
+			c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+			s.suspendSession(c, now)
+
+			p, ok := s.resumeSession("alice", now.Add(test.after))
+			if ok != test.resume {
+				t.Fatalf("got resumed %t, want %t", ok, test.resume)
+			}
+			if ok && p != c.Player {
+				t.Errorf("resumed another player: %+v", p)
+			}
+			// A session is gone once resumed or found expired.
+			if _, ok := s.resumeSession("Alice", now); ok {
+				t.Error("the session was resumed twice")
+			}
+		})
+	}
+}
+
+func TestSuspendSessionWithoutGrace(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.Gold = 7
+	now := time.Now()
+
+	s.suspendSession(c, now)
+	if _, ok := s.resumeSession("Alice", now); ok {
+		t.Error("resumed a session without a grace window")
+	}
+	// The player is saved either way.
+	if p, exists, err := s.readPlayer("Alice"); !exists || err != nil || p.Gold != 7 {
+		t.Errorf("got %d gold, exists=%v err=%v", p.Gold, exists, err)
+	}
+}
+
+func TestExpireSessions(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.ReconnectGraceSeconds = 60
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	now := time.Now()
+
+	s.suspendSession(alice, now)
+	s.suspendSession(bob, now.Add(30*time.Second))
+	s.expireSessions(now.Add(time.Minute))
+	if len(s.sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(s.sessions))
+	}
+	if _, ok := s.resumeSession("Bob", now.Add(time.Minute)); !ok {
+		t.Error("Bob cannot resume the session")
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// roomKey returns the key of the given room in the rooms index, and in the
+// other maps holding something for every room.
+func roomKey(areaName, roomName string) string {
+	return areaName + "/" + roomName
+}
+
+// indexClient adds the online player to the index of the room the player is
+// in, replacing any session the player had there before. The lock must be
+// held.
+func (s *Server) indexClient(c *client.Client) {
+	key := roomKey(c.Player.Area, c.Player.Room)
+	s.unindexClient(c.Player.Nickname, c.Player.Area, c.Player.Room)
+	s.rooms[key] = append(s.rooms[key], c)
+}
+
+// unindexClient removes the player with the given nickname from the index of
+// the given room. The lock must be held.
+func (s *Server) unindexClient(nick, areaName, roomName string) {
+	key := roomKey(areaName, roomName)
+	occupants := s.rooms[key]
+	for i, c := range occupants {
+		if nickKey(c.Player.Nickname) != nickKey(nick) {
+			continue
+		}
+		occupants = append(occupants[:i:i], occupants[i+1:]...)
+		break
+	}
+	if len(occupants) == 0 {
+		delete(s.rooms, key)
+		return
+	}
+	s.rooms[key] = occupants
+}
+
+// movePlayer places the player at the given position, remembering the room
+// the player was in before and keeping the rooms index up to date.
+func (s *Server) movePlayer(p *area.Player, toArea, toRoom, toPosition string) {
+	s.Lock()
+	defer s.Unlock()
+
+	movePlayer(p, toArea, toRoom, toPosition)
+	if p.Area == p.PreviousArea && p.Room == p.PreviousRoom {
+		return
+	}
+	c, ok := s.onlineClients[nickKey(p.Nickname)]
+	if !ok {
+		return
+	}
+	s.unindexClient(p.Nickname, p.PreviousArea, p.PreviousRoom)
+	s.indexClient(c)
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// scanRoom finds the online players in the given room by going through all
+// of them, as was done before the rooms index.
+func scanRoom(s *Server, areaName, roomName string) []client.Client {
+	var inRoom []client.Client
+	for _, c := range s.OnlineClients() {
+		if c.Player.Area == areaName && c.Player.Room == roomName {
+			inRoom = append(inRoom, c)
+		}
+	}
+	return inRoom
+}
+
+// checkIndex fails the test if the rooms index does not hold exactly the
+// online players in every room.
+func checkIndex(t *testing.T, s *Server) {
+	t.Helper()
+
+	indexed := 0
+	for key, occupants := range s.rooms {
+		if len(occupants) == 0 {
+			t.Errorf("room %s is indexed without anybody in it", key)
+		}
+		for _, c := range occupants {
+			if got := roomKey(c.Player.Area, c.Player.Room); got != key {
+				t.Errorf("%s is indexed in %s but is in %s", c.Player.Nickname, key, got)
+			}
+			if online, ok := s.onlineClients[nickKey(c.Player.Nickname)]; !ok || online != c {
+				t.Errorf("%s is indexed in %s but is not online there", c.Player.Nickname, key)
+			}
+		}
+		indexed += len(occupants)
+	}
+	if indexed != len(s.onlineClients) {
+		t.Errorf("%d players are indexed, %d are online", indexed, len(s.onlineClients))
+	}
+}
+
+func TestRoomIndex(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "2")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "4")
+	addTestPlayer(t, s, "Carol", "Town", "Inn", "3")
+	checkIndex(t, s)
+
+	steps := []struct {
+		name   string
+		do     func()
+		square string
+		inn    string
+	}{
+		{
+			name:   "walking into the inn",
+			do:     func() { handle(t, s, client.Event{Client: &alice, Etype: "move_east", Cmd: "e"}, alice) },
+			square: "Bob",
+			inn:    "Alice,Carol",
+		},
+		{
+			name:   "moving within the inn",
+			do:     func() { s.movePlayer(alice.Player, "Town", "Inn", "3") },
+			square: "Bob",
+			inn:    "Alice,Carol",
+		},
+		{
+			name:   "going back to the square",
+			do:     func() { s.movePlayer(alice.Player, "Town", "Square", "1") },
+			square: "Alice,Bob",
+			inn:    "Carol",
+		},
+		{
+			name:   "logging out",
+			do:     func() { s.clientLoggedOut("Carol") },
+			square: "Alice,Bob",
+		},
+		{
+			name: "logging in again from another room",
+			do: func() {
+				p := *bob.Player
+				p.Room = "Inn"
+				p.Position = "1"
+				s.clientLoggedIn("bob", *client.NewClient(bob.Conn, &p, nil))
+			},
+			square: "Alice",
+			inn:    "Bob",
+		},
+	}
+	for _, step := range steps {
+		step.do()
+		checkIndex(t, s)
+		for room, want := range map[string]string{"Square": step.square, "Inn": step.inn} {
+			got := nicks(s.OnlineClientsGetByRoom("Town", room))
+			if got != want {
+				t.Errorf("%s: the index has %q in the %s, want %q", step.name, got, room, want)
+			}
+			if scanned := nicks(scanRoom(s, "Town", room)); got != scanned {
+				t.Errorf("%s: the index has %q in the %s, scanning finds %q", step.name, got, room, scanned)
+			}
+		}
+	}
+}
+
+// benchmarkServer returns a server with the given number of players spread
+// over 100 rooms.
+func benchmarkServer(b *testing.B, players int) *Server {
+	s := newLoadedTestServer(b)
+	for i := 0; i < players; i++ {
+		addTestPlayer(b, s, fmt.Sprintf("Player%d", i), "Town", fmt.Sprintf("Room%d", i%100), "1")
+	}
+	return s
+}
+
+func BenchmarkRoomOccupants(b *testing.B) {
+	for _, players := range []int{100, 1000, 5000} {
+		s := benchmarkServer(b, players)
+		b.Run(fmt.Sprintf("scan/%d", players), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scanRoom(s, "Town", "Room42")
+			}
+		})
+		b.Run(fmt.Sprintf("index/%d", players), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.OnlineClientsGetByRoom("Town", "Room42")
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// saveCooldown is the name of the cooldown of save.
+const saveCooldown = "save"
+
+// doSave writes the character of the player to disk, unless the player saved
+// too recently.
+func doSave(s *Server, c client.Client, now time.Time) string {
+	if left := c.Player.CooldownLeft(saveCooldown, now); left > 0 {
+		seconds := int((left + time.Second - 1) / time.Second)
+		return fmt.Sprintf("You cannot save for another %s.", plural(seconds, "second"))
+	}
+
+	if !s.savePlayer(*c.Player) {
+		return "Your character could not be saved."
+	}
+	c.Player.SetCooldown(saveCooldown, time.Duration(s.Config.SaveCooldownSeconds)*time.Second)
+	return "Character saved."
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSave(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.SaveCooldownSeconds = 30
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.Gold = 42
+
+	if got := doSave(s, c, time.Now()); got != "Character saved." {
+		t.Fatalf("got %q", got)
+	}
+	if p, exists, err := s.readPlayer("Alice"); !exists || err != nil || p.Gold != 42 {
+		t.Errorf("got %d gold, exists=%v err=%v", p.Gold, exists, err)
+	}
+
+	c.Player.Gold = 50
+	ready := c.Player.Cooldowns[saveCooldown]
+	if got := doSave(s, c, ready.Add(-10*time.Second)); got != "You cannot save for another 10 seconds." {
+		t.Errorf("on cooldown: got %q", got)
+	}
+	if p, _, _ := s.readPlayer("Alice"); p.Gold != 42 {
+		t.Errorf("saved while on cooldown: got %d gold", p.Gold)
+	}
+
+	if got := doSave(s, c, ready); got != "Character saved." {
+		t.Errorf("after the cooldown: got %q", got)
+	}
+	if p, _, _ := s.readPlayer("Alice"); p.Gold != 50 {
+		t.Errorf("got %d gold", p.Gold)
+	}
+}
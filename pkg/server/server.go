@@ -3,8 +3,8 @@ package server
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net"
 	"os"
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,12 +22,221 @@ import (
 	"github.com/gothyra/thyra/pkg/area"
 	"github.com/gothyra/thyra/pkg/client"
 	"github.com/gothyra/thyra/pkg/game"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// Location where newly created players start, unless configured otherwise.
+const (
+	startArea     = "City"
+	startRoom     = "Inn"
+	startPosition = "1"
 )
 
 // Config holds the server configuration.
 type Config struct {
-	host string
-	port int
+	Host string `toml:"host"`
+	Port int    `toml:"port"`
+
+	// WarnDuplicateCubes logs cubes sharing the same position in a room as
+	// warnings instead of refusing to load the area.
+	WarnDuplicateCubes bool `toml:"warnDuplicateCubes"`
+	// WarnOneWayExits logs exits leading to rooms that have no exit back.
+	WarnOneWayExits bool `toml:"warnOneWayExits"`
+
+	// AuditLog is the file admin commands are recorded in. Relative paths
+	// are relative to the static directory.
+	AuditLog string `toml:"auditLog"`
+	// CommandPermissions overrides the permission level needed for
+	// commands, such as reload. Aliases of a command, such as kill for
+	// attack, share its permission level.
+	CommandPermissions map[string]int `toml:"commandPermissions"`
+
+	// ProfanityFilter is the wordlist of words masked in chat, one word per
+	// line. Relative paths are relative to the static directory. Nothing
+	// is masked when it is empty.
+	ProfanityFilter string `toml:"profanityFilter"`
+
+	// LoginChallenge asks connections to type a word before they can log
+	// in, to keep out simple bots flooding the server.
+	LoginChallenge bool `toml:"loginChallenge"`
+	// TrustedAddresses holds IP addresses and networks, such as
+	// "10.0.0.0/8", that are never challenged.
+	TrustedAddresses []string `toml:"trustedAddresses"`
+
+	// MinNickLength and MaxNickLength bound the length of the nicks new
+	// players can take.
+	MinNickLength int `toml:"minNickLength"`
+	MaxNickLength int `toml:"maxNickLength"`
+	// ReservedNicks holds nicks nobody can take, whatever their case.
+	ReservedNicks []string `toml:"reservedNicks"`
+
+	// AllowSelfRename lets players rename their own character.
+	AllowSelfRename bool `toml:"allowSelfRename"`
+	// NotifyMutedTells lets players who muted tells know that somebody
+	// tried to tell them something.
+	NotifyMutedTells bool `toml:"notifyMutedTells"`
+
+	// LogLevel is the most verbose level logged: debug, info, warn, error
+	// or crit. Everything is logged when it is empty.
+	LogLevel string `toml:"logLevel"`
+	// LogFile is the file logs are written to instead of stdout. Relative
+	// paths are relative to the static directory.
+	LogFile string `toml:"logFile"`
+	// LogFormat is either text or json. Logs are written as text when it is
+	// empty.
+	LogFormat string `toml:"logFormat"`
+	// GameClockMultiplier is how many times faster than real time the game
+	// clock runs. The game clock is disabled when it is zero.
+	GameClockMultiplier float64 `toml:"gameClockMultiplier"`
+	// GameClockStartHour is the hour of the first game day the game clock
+	// starts from.
+	GameClockStartHour int `toml:"gameClockStartHour"`
+	// WeatherChangeChance is the chance, from 0 to 1, that the weather of
+	// an area changes every second.
+	WeatherChangeChance float64 `toml:"weatherChangeChance"`
+	// AmbientChance is the chance, from 0 to 1, that the players in a room
+	// read one of its ambient messages every second. Ambient messages are
+	// off when it is zero.
+	AmbientChance float64 `toml:"ambientChance"`
+	// DeathXPPercent is the percent of their experience players lose when
+	// defeated, and CorpseSeconds how long the corpse holding what they
+	// carried lasts. Players lose no experience when DeathXPPercent is zero,
+	// and keep what they carry when CorpseSeconds is zero.
+	DeathXPPercent int `toml:"deathXpPercent"`
+	CorpseSeconds  int `toml:"corpseSeconds"`
+	// MaxInventory is the number of items players can carry, counting a
+	// stack of items as one.
+	MaxInventory int `toml:"maxInventory"`
+	// SellPercent is the percent of the value of items vendors pay for
+	// them. It is 50 when left out.
+	SellPercent int `toml:"sellPercent"`
+	// BankSize is the number of items players can keep in the bank,
+	// counting a stack of items as one. It is 50 when left out.
+	BankSize int `toml:"bankSize"`
+	// RandomSeed seeds everything random in the game, from rolling new
+	// players to the weather, so that the same seed plays out the same way.
+	// The current time is used when it is zero.
+	RandomSeed int64 `toml:"randomSeed"`
+	// AFKIdleMinutes is the number of minutes after which idle players are
+	// marked as away. Idle players are never marked when it is zero.
+	AFKIdleMinutes int `toml:"afkIdleMinutes"`
+	// RelocateNotice is told to players whose saved place is gone when they
+	// log in, with {room} replaced by the room they find themselves in.
+	RelocateNotice string `toml:"relocateNotice"`
+	// HideLockedAreas leaves the areas players cannot enter out of the
+	// areas they are shown, instead of marking them as locked.
+	HideLockedAreas bool `toml:"hideLockedAreas"`
+	// StartingSkills holds the skills players can learn, along with the
+	// proficiency new players start with in each of them.
+	StartingSkills map[string]int `toml:"startingSkills"`
+	// StartingKit is the equipment new players start with. Players get
+	// random equipment for whatever it leaves empty.
+	StartingKit StartingKit `toml:"startingKit"`
+	// StartingStats holds the attributes, class and hit points new players
+	// start with. Whatever it leaves out is rolled as usual.
+	StartingStats game.Stats `toml:"startingStats"`
+	// StartingPractices is the number of practice sessions new players
+	// start with.
+	StartingPractices int `toml:"startingPractices"`
+	// SkillCap is the highest proficiency players can reach by practicing.
+	SkillCap int `toml:"skillCap"`
+	// PracticeGain is how much proficiency a practice session adds.
+	PracticeGain int `toml:"practiceGain"`
+	// ManaRegenSeconds is the number of seconds it takes online players to
+	// regenerate a point of mana. Mana does not regenerate when it is zero.
+	ManaRegenSeconds int `toml:"manaRegenSeconds"`
+	// Charset is what players who did not choose one are shown in, either
+	// utf8 or ascii. It is utf8 when it is empty.
+	Charset string `toml:"charset"`
+	// MaxLineLength is the longest line in bytes players can send. Longer
+	// lines are ignored.
+	MaxLineLength int `toml:"maxLineLength"`
+	// StartArea, StartRoom and StartPosition are where new players start and
+	// where players whose saved location no longer exists are moved to.
+	StartArea     string `toml:"startArea"`
+	StartRoom     string `toml:"startRoom"`
+	StartPosition string `toml:"startPosition"`
+	// RecallCooldownSeconds is the number of seconds players have to wait
+	// before they can recall again.
+	RecallCooldownSeconds int `toml:"recallCooldownSeconds"`
+	// MaxAreas, MaxRoomsPerArea and MaxRoomSize limit the content loaded,
+	// so that malformed or runaway area files are refused instead of
+	// exhausting memory. MaxRoomSize is the largest number of cubes along
+	// either side of a room. Defaults are used for those not set.
+	MaxAreas        int `toml:"maxAreas"`
+	MaxRoomsPerArea int `toml:"maxRoomsPerArea"`
+	MaxRoomSize     int `toml:"maxRoomSize"`
+	// MaxSpawnedNPCs is the most NPCs spawn rules can keep in rooms at
+	// once, so that runaway spawn rules cannot fill the world. A default
+	// is used when it is not set.
+	MaxSpawnedNPCs int `toml:"maxSpawnedNPCs"`
+	// PvP is whether players can fight each other: "on", "off", or
+	// "consent" for only during duels both players agreed to, which is the
+	// default.
+	PvP string `toml:"pvp"`
+	// DuelChallengeSeconds is how long players have to accept a challenge
+	// to a duel, and DuelSeconds how long duels last.
+	DuelChallengeSeconds int `toml:"duelChallengeSeconds"`
+	DuelSeconds          int `toml:"duelSeconds"`
+	// MailboxSize is the number of mails a player can keep.
+	MailboxSize int `toml:"mailboxSize"`
+	// LeaderboardSize is the number of players the leaderboard shows, and
+	// LeaderboardRefreshSeconds how often it reads the player files again.
+	LeaderboardSize           int `toml:"leaderboardSize"`
+	LeaderboardRefreshSeconds int `toml:"leaderboardRefreshSeconds"`
+	// CommandsPerSecond is how many commands players can send per second
+	// on average, and CommandBurst how many they can send at once. Commands
+	// over the limit are dropped. Admins are not limited, and neither is
+	// anybody when CommandsPerSecond is zero.
+	CommandsPerSecond float64 `toml:"commandsPerSecond"`
+	CommandBurst      int     `toml:"commandBurst"`
+	// ReconnectGraceSeconds is the number of seconds players whose
+	// connection broke can log in again to resume where they left off. Zero
+	// turns resuming off.
+	ReconnectGraceSeconds int `toml:"reconnectGraceSeconds"`
+	// SaveCooldownSeconds is the number of seconds players have to wait
+	// before they can save their character again.
+	SaveCooldownSeconds int `toml:"saveCooldownSeconds"`
+	// CombatLockSeconds is the number of seconds after dealing or taking
+	// damage during which players cannot recall.
+	CombatLockSeconds int `toml:"combatLockSeconds"`
+	// CombatRoundSeconds is the number of seconds between the rounds of a
+	// combat, and FleeChance the percent chance of fleeing from it.
+	CombatRoundSeconds int `toml:"combatRoundSeconds"`
+	FleeChance         int `toml:"fleeChance"`
+	// LinkDeadSeconds is the number of seconds players whose connection
+	// breaks in the middle of a fight stay in the world, defenseless,
+	// unless the fight ends or they reconnect sooner. They leave the fight
+	// at once when it is zero.
+	LinkDeadSeconds int `toml:"linkDeadSeconds"`
+	// RegistrationQueue is the number of logins that can wait to be
+	// handled. Connections logging in while it is full are refused.
+	RegistrationQueue int `toml:"registrationQueue"`
+	// GodWorkers is the number of goroutines handling events that involve a
+	// single room, such as looking or talking, so that events of different
+	// rooms are handled at the same time. Events of the same room are still
+	// handled one at a time and in order. God handles every event itself
+	// when it is less than two.
+	GodWorkers int `toml:"godWorkers"`
+	// Paths holds where the server keeps its files.
+	Paths Paths `toml:"paths"`
+	// TickInterval is how often the world advances, eg. "1s". It cannot be
+	// shorter than 100ms.
+	TickInterval string `toml:"tickInterval"`
+	// KeepAlivePeriod is how often TCP keepalive probes are sent on idle
+	// connections, eg. "1m", so that connections whose other end vanished
+	// without closing them are detected and their players logged out. It
+	// defaults to defaultKeepAlivePeriod and "0s" turns keepalives off.
+	// Keepalives do not log out players who are idle but still connected.
+	KeepAlivePeriod string `toml:"keepAlivePeriod"`
+	// Theme maps the roles text plays, such as chat or error, to the ANSI
+	// SGR codes coloring it. Roles not in it use the default theme.
+	Theme theme.Theme `toml:"theme"`
+}
+
+// configFile is the layout of server.toml.
+type configFile struct {
+	Config Config `toml:"config"`
 }
 
 // Server holds all the required fields for running a simple game server.
@@ -34,15 +244,210 @@ type Server struct {
 	sync.RWMutex
 	Players       map[string]area.Player
 	onlineClients map[string]*client.Client
-	Areas         map[string]area.Area
-	Events        chan client.Event
+	// Areas is only changed by God, holding the lock. Anything reading it
+	// outside God, such as logins, holds the read lock.
+	Areas  map[string]area.Area
+	Events chan client.Event
+
+	// rooms indexes the online players by the room they are in, so that
+	// nothing needs to go through all of them to find who is in a room.
+	rooms map[string][]*client.Client
 
 	staticDir string
 	Config    Config
+
+	// DevMode enables features useful while authoring content, such as
+	// reloading areas as soon as their files change.
+	DevMode bool
+	// areaUpdates hands freshly reloaded areas over to God.
+	areaUpdates chan map[string]area.Area
+	// audit records the commands used by admins.
+	audit log.Logger
+	// bans holds the nicknames and addresses that may not connect.
+	bans *banList
+	// profanity masks profanity in chat.
+	profanity *wordFilter
+	// mailLock serializes access to the mailboxes of the players.
+	mailLock sync.Mutex
+	// notes holds the moderation notes admins keep about players.
+	notes *noteBook
+	// news holds the announcements shown to players when they log in.
+	news *newsBoard
+
+	// started is when the server started.
+	started time.Time
+	// lastTick is the last time God advanced the world.
+	lastTick time.Time
+	// gameTime is the time passed in the game world since the first game
+	// day started. It is only accessed by God.
+	gameTime time.Duration
+	// weather holds the current weather of every area. It is only accessed
+	// by God.
+	weather map[string]string
+	// corpses holds the corpses of defeated players, by room key. It is
+	// only accessed by God.
+	corpses map[string][]corpse
+	// ground holds the items lying in the rooms players picked anything up
+	// from or dropped anything in, by room key. It is only accessed by God.
+	ground map[string][]game.Item
+	// trades holds the trades between players, by the nickname of either
+	// player. It is only accessed by God.
+	trades map[string]*trade
+	// spells holds the spells players can cast, by name.
+	spells map[string]game.Spell
+	// tickInterval is how often God advances the world.
+	tickInterval time.Duration
+	// keepAlivePeriod is how often keepalive probes are sent on player
+	// connections. Keepalives are off when it is zero.
+	keepAlivePeriod time.Duration
+	// sessions holds the sessions of players whose connection broke that
+	// can still be resumed, by nickname.
+	sessions map[string]session
+	// leaderboard caches the ranking of all players.
+	leaderboard leaderboard
+	// npcs holds the NPC definitions rooms can refer to, by ID.
+	npcs map[string]area.NPC
+	// items holds the item definitions rooms can refer to, by ID.
+	items map[string]game.Item
+	// spawned is the number of NPCs spawn rules keep in rooms, and
+	// respawns holds the killed ones that come back. They are only
+	// accessed by God once it started.
+	spawned  int
+	respawns []respawn
+	// pvp is the PvP mode of the server.
+	pvp string
+	// challenges holds when challenges to duels expire, and duels when
+	// duels end. They are only accessed by God.
+	challenges map[duelKey]time.Time
+	duels      map[duelKey]time.Time
+	// combats holds when the next round of every combat between two
+	// players is fought. It is only accessed by God.
+	combats map[duelKey]time.Time
+	// linkDead holds the players kept in the world after their connection
+	// broke in the middle of a fight.
+	linkDead map[string]linkDeadBody
+	// editors holds who is building every area with changes not saved
+	// yet, so that builders do not overwrite each other.
+	editors map[string]string
+	// buildHistories holds the edits every builder can undo and redo, by
+	// nickname.
+	buildHistories map[string]*buildHistory
+	// savedAreaFiles holds what save area last wrote to every area file,
+	// by path, so that watchAreas does not reload areas for it.
+	savedAreaFiles map[string][]byte
+	// manaRegenElapsed is the time passed since online players last
+	// regenerated mana. It is only accessed by God.
+	manaRegenElapsed time.Duration
+	// registrations queues the login requests of new connections, and
+	// refusedRegistrations counts the connections refused because the
+	// queue was full.
+	registrations        chan client.LoginRequest
+	refusedRegistrations uint64
+	// rand is what everything random in the game is rolled with. It is
+	// seeded with the configured seed, if any, so that the game can be
+	// replayed.
+	rand *game.Rand
+}
+
+// StartingKit is the equipment new players start with.
+type StartingKit struct {
+	Weapon string `toml:"weapon"`
+	Armor  string `toml:"armor"`
+	// Items holds the IDs of the items new players carry, defined in the
+	// items directory, and Gold the gold they carry.
+	Items []string `toml:"items"`
+	Gold  int      `toml:"gold"`
+}
+
+// validate makes sure the equipment of the kit exists. The items of the kit
+// are checked once the items are loaded, by danglingKitItems.
+func (kit StartingKit) validate() error {
+	if kit.Weapon != "" && !game.IsWeapon(kit.Weapon) {
+		return fmt.Errorf("unknown weapon %q in the starting kit", kit.Weapon)
+	}
+	if kit.Armor != "" && !game.IsArmor(kit.Armor) {
+		return fmt.Errorf("unknown armor %q in the starting kit", kit.Armor)
+	}
+	if kit.Gold < 0 {
+		return fmt.Errorf("the starting kit cannot hold negative gold")
+	}
+	return nil
+}
+
+// LoadConfig creates a Server holding the configuration found in the static
+// directory and nothing else yet, so that the configuration can be applied,
+// eg. to logging, before anything else is loaded.
+func LoadConfig() (*Server, error) {
+	s := newServer()
+	if err := s.checkDirs(s.staticDir); err != nil {
+		return nil, err
+	}
+	if err := s.loadConfig(); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
-// NewServer creates a new Server.
-func NewServer() *Server {
+// Load loads everything the game needs from the static directory, on top of
+// the configuration. It stops at the first thing that cannot be loaded and
+// returns what went wrong. Every problem found when validating the areas is
+// logged before returning.
+func (s *Server) Load() error {
+	s.seedRand(s.Config.RandomSeed)
+
+	if err := s.checkDirs(s.areasDir()); err != nil {
+		return err
+	}
+
+	if err := s.ensurePlayerDir(); err != nil {
+		return err
+	}
+
+	if err := s.openAuditLog(); err != nil {
+		return fmt.Errorf("audit log could not be opened: %w", err)
+	}
+
+	if err := s.loadBans(); err != nil {
+		return fmt.Errorf("ban list could not be loaded: %w", err)
+	}
+
+	if err := s.loadProfanityFilter(); err != nil {
+		return fmt.Errorf("profanity filter could not be loaded: %w", err)
+	}
+
+	if err := s.loadNotes(); err != nil {
+		return fmt.Errorf("notes could not be loaded: %w", err)
+	}
+
+	if err := s.loadNews(); err != nil {
+		return fmt.Errorf("news could not be loaded: %w", err)
+	}
+
+	if err := s.loadAreas(); err != nil {
+		return err
+	}
+
+	if err := s.loadSpells(); err != nil {
+		return err
+	}
+
+	if problems := s.validateAreas(); len(problems) > 0 {
+		for _, problem := range problems {
+			log.Error(problem.Error())
+		}
+		return &ValidationError{Err: fmt.Errorf("found %d problem(s) in the areas", len(problems))}
+	}
+
+	s.started = time.Now()
+	s.lastTick = s.started
+	s.gameTime = time.Duration(s.Config.GameClockStartHour) * time.Hour
+	s.tickWeather(0)
+	return nil
+}
+
+// newServer creates a Server that uses the configured static directory but
+// has no configuration or areas loaded yet.
+func newServer() *Server {
 	// Environment variables
 	staticDir := os.Getenv("THYRA_STATIC")
 	if len(staticDir) == 0 {
@@ -50,82 +455,306 @@ func NewServer() *Server {
 		staticDir = filepath.Join(pwd, "static")
 		log.Warn("Set THYRA_STATIC if you wish to configure the directory for static content")
 	}
+	// Paths are joined to the static directory, so it is kept free of
+	// trailing separators, eg. "static/" becomes "static".
+	staticDir = filepath.Clean(staticDir)
 	log.Info(fmt.Sprintf("Using %s for static content", staticDir))
 
 	s := &Server{
-		Players:       make(map[string]area.Player),
-		onlineClients: make(map[string]*client.Client),
-		Areas:         make(map[string]area.Area),
-		staticDir:     staticDir,
-		Events:        make(chan client.Event, 1000),
+		Players:        make(map[string]area.Player),
+		onlineClients:  make(map[string]*client.Client),
+		rooms:          make(map[string][]*client.Client),
+		sessions:       make(map[string]session),
+		challenges:     make(map[duelKey]time.Time),
+		duels:          make(map[duelKey]time.Time),
+		combats:        make(map[duelKey]time.Time),
+		linkDead:       make(map[string]linkDeadBody),
+		editors:        make(map[string]string),
+		savedAreaFiles: make(map[string][]byte),
+		Areas:          make(map[string]area.Area),
+		staticDir:      staticDir,
+		Events:         make(chan client.Event, 1000),
+		areaUpdates:    make(chan map[string]area.Area),
+		weather:        make(map[string]string),
+		corpses:        make(map[string][]corpse),
+		ground:         make(map[string][]game.Item),
+		trades:         make(map[string]*trade),
+		audit:          log.New(),
+		rand:           game.NewTimeRand(),
 	}
+	s.audit.SetHandler(log.DiscardHandler())
 
-	if err := s.loadConfig(); err != nil {
-		os.Exit(1)
+	return s
+}
+
+// seedRand makes the server roll everything with a source seeded with the
+// given seed, or with the current time if it is zero.
+func (s *Server) seedRand(seed int64) {
+	if seed == 0 {
+		s.rand = game.NewTimeRand()
+	} else {
+		s.rand = game.NewRand(seed)
+		log.Info(fmt.Sprintf("Random numbers seeded with %d", seed))
 	}
+}
 
-	if err := s.loadAreas(); err != nil {
-		os.Exit(1)
+// startLocation returns the configured start location, falling back to the
+// default one for anything not configured.
+func (s *Server) startLocation() (string, string, string) {
+	a, room, pos := startArea, startRoom, startPosition
+	if s.Config.StartArea != "" {
+		a = s.Config.StartArea
+	}
+	if s.Config.StartRoom != "" {
+		room = s.Config.StartRoom
+	}
+	if s.Config.StartPosition != "" {
+		pos = s.Config.StartPosition
 	}
+	return a, room, pos
+}
 
-	return s
+// paint colors text as the given role using the theme of the server.
+func (s *Server) paint(role, text string) string {
+	return s.Config.Theme.Paint(role, text)
+}
+
+// StaticPath returns the given path relative to the static directory, unless
+// it is already absolute.
+func (s *Server) StaticPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.staticDir, path)
 }
 
 // loadConfig loads in memory the server configuration from server.toml found in
-// the static directory.
+// the static directory, or from the file THYRA_CONFIG points to.
 func (s *Server) loadConfig() error {
 	log.Info("Loading config ...")
 
-	configFileName := filepath.Join(s.staticDir, "/server.toml")
+	configFileName := s.configPath()
 	fileContent, fileIoErr := ioutil.ReadFile(configFileName)
 	if fileIoErr != nil {
-
-		log.Info(fmt.Sprintf("%s could not be loaded: %v\n", configFileName, fileIoErr))
-		return fileIoErr
+		err := readFileError(configFileName, fileIoErr)
+		log.Error(err.Error())
+		return err
 	}
 
-	config := Config{}
+	config := configFile{}
 	if _, err := toml.Decode(string(fileContent), &config); err != nil {
-		log.Info(fmt.Sprintf("%s could not be unmarshaled: %v\n", configFileName, err))
+		err = &ParseError{Path: configFileName, Err: err}
+		log.Error(err.Error())
 		return err
 	}
 
-	s.Config = config
+	if err := config.Config.validate(); err != nil {
+		err = &ValidationError{Path: configFileName, Err: err}
+		log.Error(err.Error())
+		return err
+	}
+
+	// The settings were validated above, so they parse fine.
+	tickInterval, _ := parseTickInterval(config.Config.TickInterval)
+	keepAlivePeriod, _ := parseKeepAlivePeriod(config.Config.KeepAlivePeriod)
+	pvp, _ := parsePvP(config.Config.PvP)
+
+	s.Config = config.Config
+	s.tickInterval = tickInterval
+	s.keepAlivePeriod = keepAlivePeriod
+	s.pvp = pvp
 	log.Info("Config loaded.")
 	return nil
 }
 
+// validate makes sure the settings that need more than decoding make sense.
+func (c Config) validate() error {
+	if _, err := parseTickInterval(c.TickInterval); err != nil {
+		return err
+	}
+	if _, err := parseKeepAlivePeriod(c.KeepAlivePeriod); err != nil {
+		return err
+	}
+	if err := checkNickLengths(c.MinNickLength, c.MaxNickLength); err != nil {
+		return err
+	}
+	if err := c.StartingKit.validate(); err != nil {
+		return err
+	}
+	if err := c.StartingStats.Validate(); err != nil {
+		return fmt.Errorf("startingStats: %v", err)
+	}
+	if _, err := parsePvP(c.PvP); err != nil {
+		return err
+	}
+	if c.Charset != "" && !client.IsCharset(c.Charset) {
+		return fmt.Errorf("invalid charset %q, use utf8 or ascii", c.Charset)
+	}
+	for cmd, level := range c.CommandPermissions {
+		if commands[cmd] != cmd {
+			return fmt.Errorf("commandPermissions: unknown command %q", cmd)
+		}
+		if level < area.PermissionPlayer {
+			return fmt.Errorf("commandPermissions: %s needs a permission level of at least %d", cmd, area.PermissionPlayer)
+		}
+	}
+	return nil
+}
+
 // loadAreas loads all the areas from the static directory into memory.
 // TODO: Change the way we load rooms into memory. We should load rooms
-// where online players are. We should also change our schema to hold
-// rooms in separate files.
+// where online players are.
 func (s *Server) loadAreas() error {
 	log.Info("Loading areas ...")
+
+	if err := s.loadNPCs(); err != nil {
+		return err
+	}
+	if err := s.loadItems(); err != nil {
+		return err
+	}
+
+	areas, err := s.readAreas()
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.Areas = areas
+	s.populate()
+	s.Unlock()
+	return nil
+}
+
+// readAreas reads all the area files found in the areas directory. An area
+// may be split across several files that share the same area name. Files
+// that are not area files, such as editor swap files, are skipped.
+func (s *Server) readAreas() (map[string]area.Area, error) {
+	areas := make(map[string]area.Area)
+
 	areaWalker := func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isAreaFile(path) {
 			return nil
 		}
 
 		fileContent, fileIoErr := ioutil.ReadFile(path)
 		if fileIoErr != nil {
-			log.Info(fmt.Sprintf("%s could not be loaded: %v", path, fileIoErr))
-			return fileIoErr
+			err := &ReadError{Path: path, Err: fileIoErr}
+			log.Error(err.Error())
+			return err
 		}
 
 		area := area.Area{}
-		if _, err := toml.Decode(string(fileContent), &area); err != nil {
-			log.Info(fmt.Sprintf("%s could not be unmarshaled: %v", path, err))
+		switch filepath.Ext(path) {
+		case ".json":
+			err = json.Unmarshal(fileContent, &area)
+		default:
+			_, err = toml.Decode(string(fileContent), &area)
+		}
+		if err != nil {
+			err = &ParseError{Path: path, Err: err}
+			log.Error(err.Error())
 			return err
 		}
 
-		log.Info(fmt.Sprintf("Loaded area %q", area.Name))
-		// TODO: Lock
-		s.Areas[area.Name] = area
+		if problems := duplicateCubes(area); len(problems) > 0 {
+			if !s.Config.WarnDuplicateCubes {
+				return &ValidationError{Path: path, Err: problems[0]}
+			}
+			for _, problem := range problems {
+				log.Warn(problem.Error())
+			}
+		}
+
+		if err := mergeArea(areas, area); err != nil {
+			return &ValidationError{Path: path, Err: err}
+		}
+		if err := s.checkAreaLimits(areas, area.Name); err != nil {
+			return &ValidationError{Path: path, Err: err}
+		}
+		log.Info(fmt.Sprintf("Loaded area %q from %s", area.Name, filepath.Base(path)))
+
+		return nil
+	}
+
+	if err := filepath.Walk(s.areasDir(), areaWalker); err != nil {
+		return nil, err
+	}
+	s.resolveNPCs(areas)
+	s.resolveItems(areas)
+	return areas, nil
+}
+
+// checkDirs makes sure the given directories, such as the static directory
+// and the areas directory, can be read.
+func (s *Server) checkDirs(dirs ...string) error {
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		switch {
+		case os.IsNotExist(err):
+			return fmt.Errorf("%s does not exist; set THYRA_STATIC to the directory holding server.toml, areas/ and player/", dir)
+		case err != nil:
+			return fmt.Errorf("%s cannot be read: %v", dir, err)
+		case !info.IsDir():
+			return fmt.Errorf("%s is not a directory; set THYRA_STATIC to the directory holding server.toml, areas/ and player/", dir)
+		}
+
+		f, err := os.Open(dir)
+		if err != nil {
+			return fmt.Errorf("%s cannot be read: %v", dir, err)
+		}
+		f.Close()
+	}
+
+	return nil
+}
+
+// ensurePlayerDir creates the player directory if it does not exist yet, so
+// that a fresh static directory works on the first run.
+func (s *Server) ensurePlayerDir() error {
+	if err := os.MkdirAll(s.playerDir(), 0755); err != nil {
+		return fmt.Errorf("%s cannot be created: %v", s.playerDir(), err)
+	}
+	return nil
+}
+
+// isAreaFile returns true if the given file holds an area. Areas can be
+// written either in TOML or in JSON.
+func isAreaFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".toml" || ext == ".json"
+}
 
+// mergeArea adds the given area to areas. Areas split across several files
+// are merged into one, as long as no room is defined twice.
+func mergeArea(areas map[string]area.Area, a area.Area) error {
+	existing, ok := areas[a.Name]
+	if !ok {
+		areas[a.Name] = a
 		return nil
 	}
 
-	return filepath.Walk(s.staticDir+"/areas/", areaWalker)
+	for name := range a.Rooms {
+		if _, ok := existing.Rooms[name]; ok {
+			return fmt.Errorf("room %q is already defined in area %q", name, a.Name)
+		}
+	}
+
+	if existing.Rooms == nil {
+		existing.Rooms = make(map[string]area.Room)
+	}
+	for name, room := range a.Rooms {
+		existing.Rooms[name] = room
+	}
+	if existing.Intro == "" {
+		existing.Intro = a.Intro
+	}
+	areas[a.Name] = existing
+
+	return nil
 }
 
 func (s *Server) Start(port int64) {
@@ -138,7 +767,8 @@ func (s *Server) Start(port int64) {
 
 	wg := &sync.WaitGroup{}
 	quit := make(chan struct{})
-	regRequest := make(chan client.LoginRequest, 1000)
+	regRequest := make(chan client.LoginRequest, limit(s.Config.RegistrationQueue, defaultRegistrationQueue))
+	s.registrations = regRequest
 	clientRequest := make(chan client.Request, 1000)
 
 	wg.Add(1)
@@ -150,6 +780,11 @@ func (s *Server) Start(port int64) {
 	wg.Add(1)
 	go broadcast(s, wg, quit, clientRequest)
 
+	if s.DevMode {
+		wg.Add(1)
+		go watchAreas(s, wg, quit)
+	}
+
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt, os.Kill)
 	select {
@@ -179,7 +814,7 @@ func handleRegistrations(s *Server, wg *sync.WaitGroup, quit chan struct{}, regR
 		case request := <-regRequest:
 			exists, err = s.loadPlayer(request.Username)
 			if err != nil {
-				io.WriteString(request.Conn, fmt.Sprintf("%s\n", err.Error()))
+				client.WriteAll(request.Conn, fmt.Sprintf("%s\n", err.Error()))
 				continue
 			}
 
@@ -222,12 +857,57 @@ func acceptConnections(
 			continue
 		}
 
+		s.setKeepAlive(conn)
+
+		if s.bans.isBanned(remoteIP(conn), time.Now()) {
+			log.Info(fmt.Sprintf("Refused connection from banned address %s", conn.RemoteAddr()))
+			client.WriteAll(conn, "You are banned from this server.\n")
+			conn.Close()
+			continue
+		}
+
 		// TODO: handleConnection is not terminating gracefully right now because it blocks on waiting
 		// ReadLinesInto to quit which in turn is blocked on user input.
 		go handleConnection(conn, s, wg, quit, clientCh, regRequest)
 	}
 }
 
+// defaultKeepAlivePeriod is how often keepalive probes are sent on player
+// connections when no period is configured.
+const defaultKeepAlivePeriod = time.Minute
+
+// parseKeepAlivePeriod parses the configured keepalive period. An empty
+// period means the default one.
+func parseKeepAlivePeriod(period string) (time.Duration, error) {
+	if period == "" {
+		return defaultKeepAlivePeriod, nil
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid keepalive period %q", period)
+	}
+	return d, nil
+}
+
+// setKeepAlive turns TCP keepalives on for the given connection, if they are
+// enabled. A connection whose other end stopped answering the probes fails
+// to read, which logs its player out.
+func (s *Server) setKeepAlive(conn net.Conn) {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok || s.keepAlivePeriod == 0 {
+		return
+	}
+
+	if err := tcpConn.SetKeepAlive(true); err != nil {
+		log.Warn(fmt.Sprintf("Keepalives could not be enabled for %s: %v", conn.RemoteAddr(), err))
+		return
+	}
+	if err := tcpConn.SetKeepAlivePeriod(s.keepAlivePeriod); err != nil {
+		log.Warn(fmt.Sprintf("Keepalive period could not be set for %s: %v", conn.RemoteAddr(), err))
+	}
+}
+
 // handleConnection should be invoked as a goroutine.
 func handleConnection(
 	conn net.Conn,
@@ -243,35 +923,88 @@ func handleConnection(
 	bufc := bufio.NewReader(conn)
 	defer conn.Close()
 
+	// A panic while serving the player logs the player out as if the
+	// connection broke, without taking the server down.
+	var c *client.Client
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic(fmt.Sprintf("connection from %v", conn.RemoteAddr()), r)
+			if c == nil {
+				return
+			}
+			c.Close()
+			select {
+			case s.Events <- client.Event{Client: c, Etype: "disconnect"}:
+			case <-quit:
+			}
+		}
+	}()
+
 	log.Info(fmt.Sprintf("New connection open: %s", conn.RemoteAddr()))
 
-	io.WriteString(conn, welcomePage)
+	client.WriteAll(conn, welcomePage)
+
+	if !s.passChallenge(conn, bufc) {
+		client.WriteAll(conn, "See you\n")
+		return
+	}
 
 	var username string
+	var player *area.Player
 	questions := 0
 
 out:
 	for {
 		if questions >= 3 {
-			io.WriteString(conn, "See you\n")
+			client.WriteAll(conn, "See you\n")
 			return
 		}
 
-		username = promptMessage(conn, bufc, "Whats your Nick?\n")
+		var err error
+		username, err = promptMessage(conn, bufc, "Whats your Nick?\n", s.Config.MaxLineLength)
+		if err != nil {
+			log.Info(fmt.Sprintf("Connection from %v closed during login: %v", conn.RemoteAddr(), err))
+			return
+		}
 		isValidName := IsValidUsername(username)
 		if !isValidName {
 			questions++
-			io.WriteString(conn, fmt.Sprintf("Username %s is not valid (0-9a-z_-).\n", username))
+			client.WriteAll(conn, fmt.Sprintf("Username %s is not valid (0-9a-z_-).\n", username))
 			continue
 		}
 
+		if s.bans.isBanned(username, time.Now()) {
+			log.Info(fmt.Sprintf("Refused banned player %q from %s", username, conn.RemoteAddr()))
+			client.WriteAll(conn, "You are banned from this server.\n")
+			return
+		}
+
+		if body, ok := s.takeOverLinkDead(username); ok {
+			log.Info(fmt.Sprintf("Player %q took over the link-dead body", username))
+			player = body
+			player.Notice = "You reconnected in the middle of a fight!"
+			break
+		}
+
+		if resumed, ok := s.resumeSession(username, time.Now()); ok {
+			log.Info(fmt.Sprintf("Player %q resumed the session", username))
+			player = resumed
+			player.Notice = "You reconnected and resume where you left off."
+			break
+		}
+
 		exists := false
 		replyCh := make(chan bool, 1)
 
+		// Connections are refused rather than left waiting when logins
+		// pile up, eg. during a flood of connections.
 		select {
 		case regRequest <- client.LoginRequest{Username: username, Conn: conn, Reply: replyCh}:
 		case <-quit:
 			return
+		default:
+			s.refuseRegistration(conn)
+			return
 		}
 
 		select {
@@ -285,8 +1018,16 @@ out:
 		}
 
 		questions++
-		io.WriteString(conn, fmt.Sprintf("Username %s does not exists.\n", username))
-		answer := promptMessage(conn, bufc, "Do you want to create that user? [y|n] ")
+		if problem := s.nickProblem(username); problem != "" {
+			client.WriteAll(conn, problem+"\n")
+			continue
+		}
+		client.WriteAll(conn, fmt.Sprintf("Username %s does not exists.\n", username))
+		answer, err := promptMessage(conn, bufc, "Do you want to create that user? [y|n] ", s.Config.MaxLineLength)
+		if err != nil {
+			log.Info(fmt.Sprintf("Connection from %v closed during login: %v", conn.RemoteAddr(), err))
+			return
+		}
 
 		if answer == "y" || answer == "yes" {
 			s.CreatePlayer(username)
@@ -294,26 +1035,70 @@ out:
 		}
 	}
 
-	player, _ := s.GetPlayerByNick(username)
-	c := client.NewClient(conn, &player, clientCh)
+	if player == nil {
+		loaded, _ := s.GetPlayerByNick(username)
+		player = &loaded
+	}
+	for _, notice := range []string{s.newsDigest(player.LastLogin), s.mailNotice(player.Nickname)} {
+		if notice == "" {
+			continue
+		}
+		if player.Notice != "" {
+			player.Notice += "\n"
+		}
+		player.Notice += notice
+	}
+	player.LastLogin = time.Now()
+	player.LastActive = player.LastLogin
+	c = client.NewClient(conn, player, clientCh)
+	c.MaxLineLength = s.Config.MaxLineLength
+	c.DefaultCharset = s.Config.Charset
 	log.Info(fmt.Sprintf("Player %q got connected", c.Player.Nickname))
 	s.clientLoggedIn(c.Player.Nickname, *c)
+	if c.Player.Notice != "" {
+		s.Events <- client.Event{Client: c, Etype: "notice"}
+	}
 
+	// Redraw keeps changing the terminal state of c, so input is read with a
+	// copy of the client taken before drawing starts.
+	input := *c
 	wg.Add(1)
 	go c.Redraw(wg, quit)
 
 	// TODO: Main client thread is not terminating gracefully right now because it blocks on waiting
 	// for the user to hit Enter before proceeding to check for quit.
-	c.ReadLinesInto(quit)
+	input.ReadLinesInto(quit)
+	c.Close()
 	log.Info(fmt.Sprintf("Connection from %v closed.", conn.RemoteAddr()))
+
+	select {
+	case s.Events <- client.Event{Client: c, Etype: "disconnect"}:
+	case <-quit:
+	}
 }
 
-func promptMessage(c net.Conn, bufc *bufio.Reader, message string) string {
+// promptMessage asks the user the given message until the user answers. Long
+// answers are read as a whole, up to the maximum line length, so that they
+// are never split into several answers.
+func promptMessage(c net.Conn, bufc *bufio.Reader, message string, max int) (string, error) {
+	if max <= 0 {
+		max = client.DefaultMaxLineLength
+	}
+
 	for {
-		io.WriteString(c, message)
-		answer, _, _ := bufc.ReadLine()
-		if string(answer) != "" {
-			return string(answer)
+		if err := client.WriteAll(c, message); err != nil {
+			return "", err
+		}
+		answer, tooLong, err := client.ReadLine(bufc, max)
+		if err != nil {
+			return "", err
+		}
+		if tooLong {
+			client.WriteAll(c, "That was too long.\n")
+			continue
+		}
+		if answer != "" {
+			return answer, nil
 		}
 	}
 }
@@ -329,7 +1114,11 @@ func broadcast(s *Server, wg *sync.WaitGroup, quit <-chan struct{}, reqChan <-ch
 	for {
 		select {
 		case request := <-reqChan:
-			s.HandleCommand(*request.Client, request.Cmd)
+			if request.TooLong {
+				s.Events <- client.Event{Client: request.Client, Etype: "too_long"}
+			} else {
+				s.HandleCommand(*request.Client, request.Cmd)
+			}
 		case <-quit:
 			log.Warn("broadcast quit")
 			return
@@ -337,16 +1126,20 @@ func broadcast(s *Server, wg *sync.WaitGroup, quit <-chan struct{}, reqChan <-ch
 	}
 }
 
+// getPlayerFileName returns the player file of the given player, whatever
+// the case the nickname is given in.
 func (s *Server) getPlayerFileName(playerName string) (bool, string) {
 	if !IsValidUsername(playerName) {
 		return false, ""
 	}
-	return true, s.staticDir + "/player/" + playerName + ".toml"
+	return true, foldedPath(s.playerDir(), playerName+".toml")
 }
 
-// IsValidUsername checks if the given player name is a valid one.
+// IsValidUsername checks if the given player name is a valid one. The
+// length of the nicks new players take is bounded further by nickProblem.
+// The regular expression is kept in line with maxNickLength.
 func IsValidUsername(playerName string) bool {
-	r, err := regexp.Compile(`^[a-zA-Z0-9_-]{1,40}$`)
+	r, err := regexp.Compile(`^[a-zA-Z0-9_-]{1,64}$`)
 	if err != nil {
 		return false
 	}
@@ -358,36 +1151,87 @@ func IsValidUsername(playerName string) bool {
 
 // loadPlayer loads the player into memory.
 func (s *Server) loadPlayer(playerName string) (bool, error) {
+	player, exists, err := s.readPlayer(playerName)
+	if !exists || err != nil {
+		return exists, err
+	}
+
+	player.FillMissingStats()
+
+	// The area, room or cube the player was saved in may have been removed
+	// since. Logins are handled outside God, which may be reloading areas.
+	s.RLock()
+	if !s.locationExists(player.Area, player.Room, player.Position) {
+		a, room, pos := s.relocate(player.Area, player.Room, player.Position)
+		log.Warn(fmt.Sprintf("Player %q was in %s/%s/%s which does not exist, moving to %s/%s/%s",
+			player.Nickname, player.Area, player.Room, player.Position, a, room, pos))
+		player.Notice = s.relocateNotice(a, room)
+		player.Area, player.Room, player.Position = a, room, pos
+	}
+	s.RUnlock()
+
+	log.Info(fmt.Sprintf("Loaded player %q", player.Nickname))
+	// TODO: Lock
+	s.Players[nickKey(player.Nickname)] = player
+
+	return true, nil
+}
+
+// readPlayer reads the player file of the given player without loading the
+// player into memory.
+func (s *Server) readPlayer(playerName string) (area.Player, bool, error) {
+	player := area.Player{Settings: area.DefaultSettings()}
 	ok, playerFileName := s.getPlayerFileName(playerName)
 	if !ok {
-		return false, nil
+		return player, false, nil
 	}
 	if _, err := os.Stat(playerFileName); err != nil {
-		return false, nil
+		return player, false, nil
 	}
 
 	fileContent, fileIoErr := ioutil.ReadFile(playerFileName)
 	if fileIoErr != nil {
-		log.Info(fmt.Sprintf("%s could not be loaded: %v", playerFileName, fileIoErr))
-		return true, fileIoErr
+		err := &ReadError{Path: playerFileName, Err: fileIoErr}
+		log.Info(err.Error())
+		return player, true, err
 	}
 
-	player := area.Player{}
-	if _, err := toml.Decode(string(fileContent), &player); err != nil {
-		log.Info(fmt.Sprintf("%s could not be unmarshaled: %v", playerFileName, err))
-		return true, err
+	player, err := decodePlayer(playerFileName, fileContent)
+	if err != nil {
+		log.Info(err.Error())
+		return player, true, err
 	}
+	return player, true, nil
+}
 
-	log.Info(fmt.Sprintf("Loaded player %q", player.Nickname))
-	// TODO: Lock
-	s.Players[player.Nickname] = player
+// decodePlayer decodes the contents of the player file at the given path.
+func decodePlayer(path string, content []byte) (area.Player, error) {
+	player := area.Player{Settings: area.DefaultSettings()}
+	md, err := toml.Decode(string(content), &player)
+	if err != nil {
+		return player, &ParseError{Path: path, Err: err}
+	}
+	// Older player files keep the settings at the top level.
+	if !md.IsDefined("settings") {
+		if _, err := toml.Decode(string(content), &player.Settings); err != nil {
+			return player, &ParseError{Path: path, Err: err}
+		}
+	}
+	return player, nil
+}
 
-	return true, nil
+// encodePlayer encodes the player the way player files are written.
+func encodePlayer(player area.Player) ([]byte, error) {
+	data := &bytes.Buffer{}
+	if err := toml.NewEncoder(data).Encode(player); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
 }
 
-// GetPlayerByNick returns the player by nickname.
+// GetPlayerByNick returns the player by nickname, whatever its case.
 func (s *Server) GetPlayerByNick(nickname string) (area.Player, bool) {
-	player, ok := s.Players[nickname]
+	player, ok := s.Players[nickKey(nickname)]
 	return player, ok
 }
 
@@ -404,43 +1248,70 @@ func (s *Server) CreatePlayer(nick string) {
 		}
 		return
 	}
+	a, room, pos := s.startLocation()
 	player := area.Player{
 		Nickname: nick,
-		PC:       *game.NewPC(),
-		Area:     "City",
-		Room:     "Inn",
-		Position: "1",
+		PC:       *game.NewPCWith(s.rand, s.Config.StartingStats),
+		Area:     a,
+		Room:     room,
+		Position: pos,
+		Settings: area.DefaultSettings(),
+	}
+	player.Skills = make(map[string]int)
+	for name, proficiency := range s.Config.StartingSkills {
+		player.Skills[name] = proficiency
+	}
+	player.Practices = s.Config.StartingPractices
+	if kit := s.Config.StartingKit; kit.Weapon != "" {
+		player.Wield(kit.Weapon)
+	}
+	if kit := s.Config.StartingKit; kit.Armor != "" {
+		player.WearArmor(kit.Armor)
+	}
+	for _, id := range s.Config.StartingKit.Items {
+		if item, ok := s.items[id]; ok {
+			player.Inventory = game.AddItem(player.Inventory, item)
+		}
 	}
+	player.Gold = s.Config.StartingKit.Gold
 	// TODO: Lock
-	s.Players[player.Nickname] = player
+	s.Players[nickKey(player.Nickname)] = player
 }
 
-// savePlayer saves the player back to the static directory.
+// savePlayer saves the player back to the static directory. It returns
+// true only if the player file was written successfully.
 // TODO: Add an autosave mechanism instead of saving Players
 // once they quit.
 func (s *Server) savePlayer(player area.Player) bool {
-	data := &bytes.Buffer{}
-	encoder := toml.NewEncoder(data)
-	err := encoder.Encode(player)
-	if err == nil {
-		ok, playerFileName := s.getPlayerFileName(player.Nickname)
-		if !ok {
-			return false
-		}
-
-		if ioerror := ioutil.WriteFile(playerFileName, data.Bytes(), 0644); ioerror != nil {
-			log.Info(ioerror.Error())
-			return true
-		}
-	} else {
+	data, err := encodePlayer(player)
+	if err != nil {
 		log.Info(err.Error())
+		return false
+	}
+
+	ok, playerFileName := s.getPlayerFileName(player.Nickname)
+	if !ok {
+		return false
+	}
+
+	// The player directory may have been removed while the server was running.
+	if err := s.ensurePlayerDir(); err != nil {
+		log.Error(err.Error())
+		return false
+	}
+
+	if ioerror := ioutil.WriteFile(playerFileName, data, 0644); ioerror != nil {
+		log.Info(ioerror.Error())
+		return false
 	}
-	return false
+	return true
 }
 
 // OnExit is a handler run by the server every time a player quits.
 func (s *Server) OnExit(client client.Client) {
+	client.Player.LastLogout = time.Now()
 	s.savePlayer(*client.Player)
+	s.releaseAreas(client.Player.Nickname)
 	s.clientLoggedOut(client.Player.Nickname)
 }
 
@@ -448,7 +1319,11 @@ func (s *Server) OnExit(client client.Client) {
 // all online players.
 func (s *Server) clientLoggedIn(name string, client client.Client) {
 	s.Lock()
-	s.onlineClients[name] = &client
+	if previous, ok := s.onlineClients[nickKey(name)]; ok {
+		s.unindexClient(name, previous.Player.Area, previous.Player.Room)
+	}
+	s.onlineClients[nickKey(name)] = &client
+	s.indexClient(&client)
 	s.Unlock()
 }
 
@@ -456,7 +1331,10 @@ func (s *Server) clientLoggedIn(name string, client client.Client) {
 // holds all online players.
 func (s *Server) clientLoggedOut(name string) {
 	s.Lock()
-	delete(s.onlineClients, name)
+	if c, ok := s.onlineClients[nickKey(name)]; ok {
+		s.unindexClient(name, c.Player.Area, c.Player.Room)
+	}
+	delete(s.onlineClients, nickKey(name))
 	s.Unlock()
 }
 
@@ -473,16 +1351,27 @@ func (s *Server) OnlineClients() []client.Client {
 	return online
 }
 
+// OnlineClientByNick returns the online player with the given nickname,
+// whatever its case.
+func (s *Server) OnlineClientByNick(nick string) (client.Client, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	c, ok := s.onlineClients[nickKey(nick)]
+	if !ok {
+		return client.Client{}, false
+	}
+	return *c, true
+}
+
 // OnlineClientsGetByRoom returns all the online players in the given room.
 func (s *Server) OnlineClientsGetByRoom(area, room string) []client.Client {
-	clients := s.OnlineClients()
-	var clientsSameRoom []client.Client
+	s.RLock()
+	defer s.RUnlock()
 
-	for i := range clients {
-		c := clients[i]
-		if area == c.Player.Area && room == c.Player.Room {
-			clientsSameRoom = append(clientsSameRoom, c)
-		}
+	var clientsSameRoom []client.Client
+	for _, c := range s.rooms[roomKey(area, room)] {
+		clientsSameRoom = append(clientsSameRoom, *c)
 	}
 
 	return clientsSameRoom
@@ -546,28 +1435,49 @@ func (s *Server) CreateRoom(a, room string) [][]area.Cube {
 
 // HandleCommand processes commands received by clients.
 func (s *Server) HandleCommand(c client.Client, command string) {
-	//TODO split command to get arguments
+	if !s.allowCommand(c, time.Now()) {
+		s.Events <- client.Event{Client: &c, Etype: "throttled"}
+		return
+	}
+
+	// Players reading long output page by page answer the pager first;
+	// anything else stops paging.
+	if c.Pager.Pending() {
+		if client.IsPagerKey(command) {
+			s.Events <- client.Event{Client: &c, Etype: "more", Cmd: command}
+			return
+		}
+		c.Pager.Stop()
+	}
+
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return
+	}
 
 	event := client.Event{
 		Client: &c,
+		Cmd:    fields[0],
+		Args:   fields[1:],
 	}
 
-	switch command {
-
-	case "l", "look", "map":
-		event.Etype = "look"
-	case "e", "east":
-		event.Etype = "move_east"
-	case "w", "west":
-		event.Etype = "move_west"
-	case "n", "north":
-		event.Etype = "move_north"
-	case "s", "south":
-		event.Etype = "move_south"
-	case "quit", "exit":
-		event.Etype = "quit"
+	// Admin commands are hidden from everybody else.
+	etype, candidates := s.matchCommand(c, event.Cmd)
+	switch {
+	case isNumber(event.Cmd):
+		// Numbers respond to NPCs.
+		event.Etype = "respond"
+	case etype != "":
+		event.Etype = etype
+	case len(candidates) > 1:
+		event.Etype = "ambiguous"
 	default:
 		event.Etype = "unknown"
 	}
+
+	if s.commandPermission(event.Etype) > area.PermissionPlayer {
+		s.audit.Info(event.Etype, "admin", c.Player.Nickname, "args", strings.Join(event.Args, " "))
+	}
+
 	s.Events <- event
 }
@@ -0,0 +1,606 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// testArea is a small area of two rooms linked by doors, for tests that need
+// somewhere to put players.
+const testArea = `name = "Town"
+intro = "A test town."
+
+[rooms.Square]
+name = "Square"
+description = "The town square."
+cubes = [
+  { id = "1", posx = "0", posy = "0" },
+  { id = "2", posx = "1", posy = "0" },
+  { id = "3", posx = "2", posy = "0", type = "door", exits = [ { toarea = "Town", toroom = "Inn", tocubeid = "1" } ] },
+  { id = "4", posx = "0", posy = "1" },
+]
+
+[rooms.Inn]
+name = "Inn"
+description = "A cosy inn."
+indoors = true
+cubes = [
+  { id = "1", posx = "1", posy = "0" },
+  { id = "2", posx = "0", posy = "0", type = "door", exits = [ { toarea = "Town", toroom = "Square", tocubeid = "2" } ] },
+  { id = "3", posx = "1", posy = "1" },
+]
+`
+
+// testConfig starts players in the square of testArea.
+const testConfig = `[config]
+startArea = "Town"
+startRoom = "Square"
+startPosition = "1"
+`
+
+// newStaticDir returns a fresh static directory holding the given files, by
+// path relative to the directory. The directory is removed once the test is
+// over.
+func newStaticDir(t testing.TB, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "thyra")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// withStatic runs f with THYRA_STATIC pointing to the given directory.
+func withStatic(dir string, f func()) {
+	old, had := os.LookupEnv("THYRA_STATIC")
+	os.Setenv("THYRA_STATIC", dir)
+	defer func() {
+		if had {
+			os.Setenv("THYRA_STATIC", old)
+		} else {
+			os.Unsetenv("THYRA_STATIC")
+		}
+	}()
+	f()
+}
+
+// newTestServer returns a server using a fresh static directory holding the
+// given files.
+func newTestServer(t testing.TB, files map[string]string) *Server {
+	var s *Server
+	withStatic(newStaticDir(t, files), func() { s = newServer() })
+	return s
+}
+
+// newLoadedTestServer returns a test server with testConfig and testArea
+// loaded.
+func newLoadedTestServer(t testing.TB) *Server {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": testArea,
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// addTestPlayer logs in a player with the given nickname at the given place
+// and returns the online client of the player.
+func addTestPlayer(t testing.TB, s *Server, nick, areaName, room, position string) client.Client {
+	p := &area.Player{
+		Nickname: nick,
+		PC:       *game.NewPC(s.rand),
+		Area:     areaName,
+		Room:     room,
+		Position: position,
+		Settings: area.DefaultSettings(),
+	}
+	s.Players[nickKey(nick)] = *p
+
+	conn, other := net.Pipe()
+	go io.Copy(ioutil.Discard, other)
+	t.Cleanup(func() { other.Close() })
+	s.clientLoggedIn(nick, *client.NewClient(conn, p, nil))
+
+	c, _ := s.OnlineClientByNick(nick)
+	return c
+}
+
+// drawRoom returns what God draws of the room of the player.
+func drawRoom(s *Server, c client.Client) client.Reply {
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer close(quit)
+
+	wg.Add(1)
+	go godPrintRoom(s, c, []client.Client{c}, &wg, quit, createRoomsMap(s), "", "")
+	reply := <-c.Reply
+	wg.Wait()
+	return reply
+}
+
+// place returns where the player is, as area/room/position.
+func place(p *area.Player) string {
+	return p.Area + "/" + p.Room + "/" + p.Position
+}
+
+func TestSavePlayerRoundTrip(t *testing.T) {
+	r := game.NewRand(1)
+
+	tests := []struct {
+		name   string
+		player area.Player
+	}{
+		{
+			name:   "new player",
+			player: area.Player{Nickname: "Dora", PC: *game.NewPC(r), Area: "Town", Room: "Square", Position: "1"},
+		},
+		{
+			name: "player who moved between areas",
+			player: area.Player{
+				Nickname:     "Alice",
+				PC:           *game.NewPC(r),
+				Area:         "Town",
+				Room:         "Inn",
+				Position:     "3",
+				PreviousArea: "Forest",
+				PreviousRoom: "Clearing",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newLoadedTestServer(t)
+			if !s.savePlayer(test.player) {
+				t.Fatal("player was not saved")
+			}
+
+			exists, err := s.loadPlayer(test.player.Nickname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !exists {
+				t.Fatal("saved player does not exist")
+			}
+			if got, _ := s.GetPlayerByNick(test.player.Nickname); !reflect.DeepEqual(got, test.player) {
+				t.Errorf("loaded player differs:\ngot  %+v\nwant %+v", got, test.player)
+			}
+		})
+	}
+}
+
+func TestSavePlayerInvalidNickname(t *testing.T) {
+	s := newTestServer(t, nil)
+	if s.savePlayer(area.Player{Nickname: "../Eve", PC: *game.NewPC(s.rand)}) {
+		t.Error("savePlayer reported success")
+	}
+}
+
+func TestLoadPlayerMissing(t *testing.T) {
+	s := newTestServer(t, nil)
+	for _, nick := range []string{"Nobody", "../Eve", ""} {
+		exists, err := s.loadPlayer(nick)
+		if exists || err != nil {
+			t.Errorf("loadPlayer(%q) = %v, %v, want false, nil", nick, exists, err)
+		}
+	}
+}
+
+func TestReadPlayerOldFormat(t *testing.T) {
+	// Older player files keep the settings at the top level, or have none.
+	tests := []struct {
+		name string
+		file string
+		want area.Settings
+	}{
+		{
+			name: "no settings at all",
+			file: "nickname = \"Old\"\narea = \"Town\"\nroom = \"Square\"\nposition = \"1\"\n",
+			want: area.DefaultSettings(),
+		},
+		{
+			name: "settings at the top level",
+			file: "nickname = \"Old\"\narea = \"Town\"\nroom = \"Square\"\nposition = \"1\"\nbrief = true\npageLength = 7\n",
+			want: func() area.Settings {
+				settings := area.DefaultSettings()
+				settings.Brief = true
+				settings.PageLength = 7
+				return settings
+			}(),
+		},
+		{
+			name: "settings block missing some settings",
+			file: "nickname = \"Old\"\narea = \"Town\"\nroom = \"Square\"\nposition = \"1\"\n[settings]\ncolor = false\ncompass = true\n",
+			want: func() area.Settings {
+				settings := area.DefaultSettings()
+				settings.Color = false
+				settings.Compass = true
+				return settings
+			}(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(t, map[string]string{"player/old.toml": test.file})
+
+			p, exists, err := s.readPlayer("Old")
+			if !exists || err != nil {
+				t.Fatalf("player was not read: exists=%v err=%v", exists, err)
+			}
+			if p.Nickname != "Old" || p.Room != "Square" {
+				t.Errorf("got player %q in %q", p.Nickname, p.Room)
+			}
+			if !reflect.DeepEqual(p.Settings, test.want) {
+				t.Errorf("got settings %+v, want %+v", p.Settings, test.want)
+			}
+		})
+	}
+}
+
+func TestCreatePlayerStartingKit(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.items = map[string]game.Item{
+		"rope":  {ID: "rope", Name: "Rope"},
+		"arrow": {ID: "arrow", Name: "Arrow", Stackable: true},
+	}
+	s.Config.StartingKit = StartingKit{Items: []string{"rope", "arrow", "arrow"}, Gold: 20}
+
+	s.CreatePlayer("Newbie")
+	p, ok := s.GetPlayerByNick("Newbie")
+	if !ok {
+		t.Fatal("the player was not created")
+	}
+	if got := strings.Join(itemNames(p.Inventory), ","); got != "Rope,2 Arrows" || p.Gold != 20 {
+		t.Errorf("new player carries %s and %d gold", got, p.Gold)
+	}
+
+	// Players who already exist are loaded as they were saved.
+	veteran := area.Player{Nickname: "Veteran", PC: *game.NewPC(s.rand), Area: "Town", Room: "Square", Position: "1"}
+	veteran.Gold = 3
+	if !s.savePlayer(veteran) {
+		t.Fatal("cannot save the player")
+	}
+	s.CreatePlayer("Veteran")
+	p, ok = s.GetPlayerByNick("Veteran")
+	if !ok {
+		t.Fatal("the player was not loaded")
+	}
+	if len(p.Inventory) != 0 || p.Gold != 3 {
+		t.Errorf("existing player carries %v and %d gold", itemNames(p.Inventory), p.Gold)
+	}
+}
+
+func TestStartingKitValidate(t *testing.T) {
+	tests := []struct {
+		kit StartingKit
+		ok  bool
+	}{
+		{kit: StartingKit{}, ok: true},
+		{kit: StartingKit{Weapon: "dagger", Armor: "Leather Armor", Items: []string{"rope"}, Gold: 20}, ok: true},
+		{kit: StartingKit{Weapon: "banana"}, ok: false},
+		{kit: StartingKit{Armor: "banana"}, ok: false},
+		{kit: StartingKit{Gold: -1}, ok: false},
+	}
+	for _, test := range tests {
+		if err := test.kit.validate(); (err == nil) != test.ok {
+			t.Errorf("%+v: got %v", test.kit, err)
+		}
+	}
+}
+
+func TestGetPlayerFileName(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	for _, nick := range []string{"", "a b", "a/b", "..", strings.Repeat("a", 65)} {
+		if ok, _ := s.getPlayerFileName(nick); ok {
+			t.Errorf("getPlayerFileName(%q) accepted an invalid nickname", nick)
+		}
+	}
+	if ok, path := s.getPlayerFileName("Alice"); !ok || path != filepath.Join(s.playerDir(), "alice.toml") {
+		t.Errorf("getPlayerFileName(Alice) = %v, %q", ok, path)
+	}
+}
+
+func TestStaticDirTrailingSlash(t *testing.T) {
+	dir := newStaticDir(t, map[string]string{"areas/town.toml": testArea})
+	var s *Server
+	withStatic(dir+string(filepath.Separator), func() { s = newServer() })
+
+	ok, path := s.getPlayerFileName("Alice")
+	if want := filepath.Join(dir, "player", "alice.toml"); !ok || path != want {
+		t.Errorf("getPlayerFileName(Alice) = %v, %q, want %q", ok, path, want)
+	}
+	if double := string(filepath.Separator) + string(filepath.Separator); strings.Contains(path, double) {
+		t.Errorf("player file path %q holds %q", path, double)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Areas["Town"]; !ok {
+		t.Error("area Town was not loaded")
+	}
+}
+
+func TestLoadAreasMergesFiles(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"areas/town-square.toml": "name = \"Town\"\nintro = \"A test town.\"\n\n[rooms.Square]\nname = \"Square\"\ncubes = [ { id = \"1\", posx = \"0\", posy = \"0\" } ]\n",
+		"areas/town-inn.toml":    "name = \"Town\"\n\n[rooms.Inn]\nname = \"Inn\"\ncubes = [ { id = \"1\", posx = \"0\", posy = \"0\" } ]\n",
+	})
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+
+	town, ok := s.Areas["Town"]
+	if !ok {
+		t.Fatal("area Town was not loaded")
+	}
+	for _, room := range []string{"Square", "Inn"} {
+		if _, ok := town.Rooms[room]; !ok {
+			t.Errorf("room %s is missing", room)
+		}
+	}
+	if town.Intro != "A test town." {
+		t.Errorf("got intro %q", town.Intro)
+	}
+}
+
+func TestLoadAreasDuplicateRoom(t *testing.T) {
+	room := "name = \"Town\"\n\n[rooms.Square]\nname = \"Square\"\ncubes = [ { id = \"1\", posx = \"0\", posy = \"0\" } ]\n"
+	s := newTestServer(t, map[string]string{
+		"areas/a.toml": room,
+		"areas/b.toml": room,
+	})
+
+	err := s.loadAreas()
+	if _, ok := err.(*ValidationError); !ok {
+		t.Fatalf("got %v, want a *ValidationError", err)
+	}
+}
+
+// testAreaJSON is testArea written in JSON.
+const testAreaJSON = `{
+  "name": "Town",
+  "intro": "A test town.",
+  "rooms": {
+    "Square": {
+      "name": "Square",
+      "description": "The town square.",
+      "cubes": [
+        {"id": "1", "posx": "0", "posy": "0"},
+        {"id": "2", "posx": "1", "posy": "0"},
+        {"id": "3", "posx": "2", "posy": "0", "type": "door", "exits": [{"toarea": "Town", "toroom": "Inn", "tocubeid": "1"}]},
+        {"id": "4", "posx": "0", "posy": "1"}
+      ]
+    },
+    "Inn": {
+      "name": "Inn",
+      "description": "A cosy inn.",
+      "indoors": true,
+      "cubes": [
+        {"id": "1", "posx": "1", "posy": "0"},
+        {"id": "2", "posx": "0", "posy": "0", "type": "door", "exits": [{"toarea": "Town", "toroom": "Square", "tocubeid": "2"}]},
+        {"id": "3", "posx": "1", "posy": "1"}
+      ]
+    }
+  }
+}`
+
+func TestLoadAreasJSON(t *testing.T) {
+	fromTOML := newTestServer(t, map[string]string{"areas/town.toml": testArea})
+	if err := fromTOML.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	fromJSON := newTestServer(t, map[string]string{"areas/town.json": testAreaJSON})
+	if err := fromJSON.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fromTOML.Areas, fromJSON.Areas) {
+		t.Errorf("areas differ:\nTOML: %+v\nJSON: %+v", fromTOML.Areas, fromJSON.Areas)
+	}
+}
+
+func TestLoadAreasMixedFormats(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"areas/town.toml": testArea,
+		"areas/cave.json": `{"name": "Cave", "rooms": {"Tunnel": {"name": "Tunnel", "cubes": [{"id": "1", "posx": "0", "posy": "0"}]}}}`,
+	})
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Town", "Cave"} {
+		if _, ok := s.Areas[name]; !ok {
+			t.Errorf("area %s was not loaded", name)
+		}
+	}
+}
+
+func TestLoadConfigMissingStaticDir(t *testing.T) {
+	dir := filepath.Join(newStaticDir(t, nil), "missing")
+
+	var err error
+	withStatic(dir, func() { _, err = LoadConfig() })
+	if err == nil {
+		t.Fatal("loaded a static directory that does not exist")
+	}
+	if !strings.Contains(err.Error(), dir+" does not exist") || !strings.Contains(err.Error(), "THYRA_STATIC") {
+		t.Errorf("got %q, want it to name the directory and THYRA_STATIC", err)
+	}
+}
+
+func TestCheckDirs(t *testing.T) {
+	dir := newStaticDir(t, map[string]string{"server.toml": testConfig})
+	s := newTestServer(t, nil)
+
+	if err := s.checkDirs(dir); err != nil {
+		t.Errorf("checkDirs(%s) = %v", dir, err)
+	}
+	if err := s.checkDirs(dir, filepath.Join(dir, "areas")); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("got %v for a missing areas directory", err)
+	}
+	if err := s.checkDirs(filepath.Join(dir, "server.toml")); err == nil || !strings.Contains(err.Error(), "is not a directory") {
+		t.Errorf("got %v for a file", err)
+	}
+}
+
+func TestValidateMissingAreasDir(t *testing.T) {
+	dir := newStaticDir(t, map[string]string{"server.toml": testConfig})
+
+	problems := validateStatic(t, dir)
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "areas does not exist") {
+		t.Errorf("got problems %v, want the missing areas directory", problems)
+	}
+}
+
+func TestSavePlayerCreatesPlayerDir(t *testing.T) {
+	s := newTestServer(t, map[string]string{"server.toml": testConfig})
+	if _, err := os.Stat(s.playerDir()); !os.IsNotExist(err) {
+		t.Fatalf("player directory exists already: %v", err)
+	}
+
+	if !s.savePlayer(area.Player{Nickname: "Fresh", Settings: area.DefaultSettings()}) {
+		t.Fatal("player was not saved")
+	}
+	if _, err := os.Stat(filepath.Join(s.playerDir(), "fresh.toml")); err != nil {
+		t.Errorf("player file was not written: %v", err)
+	}
+}
+
+func TestCreatePlayerStartingStats(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig + "\n[config.startingStats]\nhp = 42\nstr = 18\nclass = \"Fighter\"\n",
+		"areas/town.toml": testArea,
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.CreatePlayer("Newbie")
+	p, ok := s.GetPlayerByNick("Newbie")
+	if !ok {
+		t.Fatal("the player was not created")
+	}
+	if p.HP != 42 || p.MaxHP != 42 || p.STR != 18 || p.Class != "Fighter" {
+		t.Errorf("new player has %d/%d hp, %d str and class %s", p.HP, p.MaxHP, p.STR, p.Class)
+	}
+}
+
+func TestLoadConfigStartingStats(t *testing.T) {
+	for _, stats := range []string{"hp = -1", "str = 31", "class = \"Wizard\""} {
+		s := newTestServer(t, map[string]string{
+			"server.toml": testConfig + "\n[config.startingStats]\n" + stats + "\n",
+		})
+		if err := s.loadConfig(); err == nil || !strings.Contains(err.Error(), "startingStats") {
+			t.Errorf("%s: got %v", stats, err)
+		}
+	}
+}
+
+func TestPromptMessageLongAnswer(t *testing.T) {
+	server, conn := net.Pipe()
+	defer conn.Close()
+	go io.Copy(ioutil.Discard, conn)
+
+	// The answer is longer than the buffer of the reader, and is still read
+	// as a single answer.
+	long := strings.Repeat("a", 5000)
+	go io.WriteString(conn, long+"\n"+strings.Repeat("b", 9000)+"\nno\n")
+
+	bufc := bufio.NewReader(server)
+	for _, want := range []string{long, "no"} {
+		got, err := promptMessage(server, bufc, "Whats your Nick?\n", 8192)
+		if err != nil || got != want {
+			t.Errorf("got %d bytes, %v, want %d bytes", len(got), err, len(want))
+		}
+	}
+}
+
+func TestLoadPlayerRelocatesFromMissingArea(t *testing.T) {
+	s := newLoadedTestServer(t)
+	if !s.savePlayer(area.Player{Nickname: "Alice", PC: *game.NewPC(s.rand), Area: "Gone", Room: "Cellar", Position: "3"}) {
+		t.Fatal("player was not saved")
+	}
+
+	if exists, err := s.loadPlayer("Alice"); !exists || err != nil {
+		t.Fatalf("player was not loaded: exists=%v err=%v", exists, err)
+	}
+	p, _ := s.GetPlayerByNick("Alice")
+	if p.Area != "Town" || p.Room != "Square" || p.Position != "1" {
+		t.Errorf("got player at %s/%s/%s, want the start location", p.Area, p.Room, p.Position)
+	}
+	if p.Notice != "The place you were in is gone. You find yourself in Square." {
+		t.Errorf("got notice %q", p.Notice)
+	}
+}
+
+func TestParseKeepAlivePeriod(t *testing.T) {
+	tests := []struct {
+		period string
+		want   time.Duration
+		err    bool
+	}{
+		{period: "", want: defaultKeepAlivePeriod},
+		{period: "30s", want: 30 * time.Second},
+		{period: "0s", want: 0},
+		{period: "-1s", err: true},
+		{period: "often", err: true},
+	}
+	for _, test := range tests {
+		got, err := parseKeepAlivePeriod(test.period)
+		if (err != nil) != test.err {
+			t.Errorf("parseKeepAlivePeriod(%q): unexpected error %v", test.period, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("parseKeepAlivePeriod(%q) = %v, want %v", test.period, got, test.want)
+		}
+	}
+}
+
+func TestLoadConfigKeepAlivePeriod(t *testing.T) {
+	s := newTestServer(t, map[string]string{"server.toml": testConfig + "keepAlivePeriod = \"15s\"\n"})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if s.keepAlivePeriod != 15*time.Second {
+		t.Errorf("got a keepalive period of %v", s.keepAlivePeriod)
+	}
+
+	s = newTestServer(t, map[string]string{"server.toml": testConfig + "keepAlivePeriod = \"-5s\"\n"})
+	if err := s.loadConfig(); err == nil {
+		t.Error("loaded a negative keepalive period")
+	}
+}
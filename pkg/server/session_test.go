@@ -0,0 +1,24 @@
+package server
+
+import "testing"
+
+func TestLoginMoveQuit(t *testing.T) {
+	h := startHarness(t, map[string]string{"server.toml": testConfig, "areas/town.toml": testArea})
+	alice := h.connect()
+	alice.login("alice")
+
+	// From the east of the square the door to the inn is next door.
+	alice.send("e")
+	alice.expect("Exits  : [ East(Inn) West ]", "HP] >")
+
+	alice.send("quit")
+	alice.expectClosed()
+
+	p, ok, err := h.s.readPlayer("alice")
+	if err != nil || !ok {
+		t.Fatalf("reading alice: %t, %v", ok, err)
+	}
+	if p.Area != "Town" || p.Room != "Square" || p.Position != "2" {
+		t.Errorf("alice was saved at %s/%s/%s, want Town/Square/2", p.Area, p.Room, p.Position)
+	}
+}
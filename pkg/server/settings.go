@@ -0,0 +1,188 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// doSettings lists the preferences of the player.
+func doSettings(s *Server, c client.Client) string {
+	settings := c.Player.Settings
+
+	prompt := settings.Prompt
+	if prompt == "" {
+		prompt = client.DefaultPrompt
+	}
+	var muted []string
+	for _, channel := range channels {
+		if settings.Muted[channel] {
+			muted = append(muted, channel)
+		}
+	}
+	if len(muted) == 0 {
+		muted = []string{"none"}
+	}
+	ignored := settings.Ignored
+	if len(ignored) == 0 {
+		ignored = []string{"nobody"}
+	}
+
+	pager := "off"
+	if settings.PageLength > 0 {
+		pager = plural(settings.PageLength, "line")
+	}
+
+	lines := []string{
+		fmt.Sprintf("Ambient : %s", onOff(settings.Ambient)),
+		fmt.Sprintf("Autolook: %s", onOff(settings.AutoLook)),
+		fmt.Sprintf("Brief   : %s", onOff(settings.Brief)),
+		fmt.Sprintf("Charset : %s", charsetName(s, c)),
+		fmt.Sprintf("Color   : %s", onOff(settings.Color)),
+		fmt.Sprintf("Compass : %s", onOff(settings.Compass)),
+		fmt.Sprintf("Filter  : %s", onOff(settings.Filter)),
+		fmt.Sprintf("Pager   : %s", pager),
+		fmt.Sprintf("Prompt  : %q", prompt),
+		fmt.Sprintf("Muted   : %s", strings.Join(muted, ", ")),
+		fmt.Sprintf("Ignored : %s", strings.Join(ignored, ", ")),
+	}
+	return strings.Join(lines, "\n")
+}
+
+// onOff names a boolean setting.
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// doPager sets how many lines of long output the player sees at a time, or
+// turns paging off.
+func doPager(c client.Client, args []string) string {
+	if len(args) != 1 {
+		return "Usage: pager <lines|off>"
+	}
+	if args[0] == "off" {
+		c.Player.Settings.PageLength = 0
+		return "Pager is off."
+	}
+
+	length, err := strconv.Atoi(args[0])
+	if err != nil || length < minPageLength {
+		return fmt.Sprintf("Pages are at least %d lines long.", minPageLength)
+	}
+	c.Player.Settings.PageLength = length
+	return fmt.Sprintf("Pager shows %s at a time.", plural(length, "line"))
+}
+
+// minPageLength is the shortest page players can ask for.
+const minPageLength = 5
+
+// doCharset sets the charset the player is shown in, or goes back to the
+// default one of the server.
+func doCharset(s *Server, c client.Client, args []string) string {
+	if len(args) != 1 || (!client.IsCharset(args[0]) && args[0] != "default") {
+		return "Usage: charset <utf8|ascii|default>"
+	}
+
+	if args[0] == "default" {
+		c.Player.Settings.Charset = ""
+		return fmt.Sprintf("Charset is the default, %s.", charsetName(s, c))
+	}
+	c.Player.Settings.Charset = args[0]
+	return fmt.Sprintf("Charset is %s.", args[0])
+}
+
+// charsetName names the charset the player is shown in.
+func charsetName(s *Server, c client.Client) string {
+	switch {
+	case c.Player.Settings.Charset != "":
+		return c.Player.Settings.Charset
+	case s.Config.Charset != "":
+		return s.Config.Charset
+	}
+	return client.CharsetUTF8
+}
+
+// doColor turns colors on or off for the player.
+func doColor(c client.Client, args []string) string {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		return "Usage: color <on|off>"
+	}
+
+	c.Player.Settings.Color = args[0] == "on"
+	return fmt.Sprintf("Color is %s.", args[0])
+}
+
+// doCompass turns the compass rose of the player on or off.
+func doCompass(c client.Client, args []string) string {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		return "Usage: compass <on|off>"
+	}
+
+	c.Player.Settings.Compass = args[0] == "on"
+	return fmt.Sprintf("Compass is %s.", args[0])
+}
+
+// doAutoLook turns on or off showing the room every time the player moves.
+func doAutoLook(c client.Client, args []string) string {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		return "Usage: autolook <on|off>"
+	}
+
+	c.Player.Settings.AutoLook = args[0] == "on"
+	if c.Player.Settings.AutoLook {
+		c.Player.HideIntro = false
+	}
+	return fmt.Sprintf("Autolook is %s.", args[0])
+}
+
+// doAmbient turns the ambient messages of the player on or off.
+func doAmbient(c client.Client, args []string) string {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		return "Usage: ambient <on|off>"
+	}
+
+	c.Player.Settings.Ambient = args[0] == "on"
+	return fmt.Sprintf("Ambient messages are %s.", args[0])
+}
+
+// doBrief turns brief mode of the player on or off.
+func doBrief(c client.Client, args []string) string {
+	if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+		return "Usage: brief <on|off>"
+	}
+
+	c.Player.Settings.Brief = args[0] == "on"
+	if !c.Player.Settings.Brief {
+		c.Player.Glance = false
+	}
+	return fmt.Sprintf("Brief mode is %s.", args[0])
+}
+
+// doPrompt sets the prompt template of the player to the one given in args.
+// Without args it shows the current template, and "default" brings back the
+// default prompt.
+func doPrompt(c client.Client, args []string) string {
+	if len(args) == 0 {
+		template := c.Player.Settings.Prompt
+		if template == "" {
+			template = client.DefaultPrompt
+		}
+		return fmt.Sprintf("Your prompt is %q.\nUsage: prompt <template|default> (%%h HP, %%l level, %%r room)", template)
+	}
+
+	template := strings.Join(args, " ") + " "
+	if len(args) == 1 && args[0] == "default" {
+		template = ""
+	}
+	if len([]rune(template)) > client.MaxPromptLength {
+		return fmt.Sprintf("Your prompt can be up to %d characters long.", client.MaxPromptLength)
+	}
+
+	c.Player.Settings.Prompt = template
+	return "Prompt set."
+}
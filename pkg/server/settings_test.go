@@ -0,0 +1,150 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompass(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	steps := []struct {
+		args []string
+		want string
+		on   bool
+	}{
+		{args: []string{"on"}, want: "Compass is on.", on: true},
+		{args: []string{"sideways"}, want: "Usage: compass <on|off>", on: true},
+		{args: nil, want: "Usage: compass <on|off>", on: true},
+		{args: []string{"off"}, want: "Compass is off."},
+	}
+	for _, step := range steps {
+		if got := doCompass(c, step.args); got != step.want {
+			t.Errorf("compass %v: got %q, want %q", step.args, got, step.want)
+		}
+		if c.Player.Settings.Compass != step.on {
+			t.Errorf("compass %v: compass on is %t", step.args, c.Player.Settings.Compass)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	want := `Ambient : on
+Autolook: on
+Brief   : off
+Charset : utf8
+Color   : on
+Compass : off
+Filter  : on
+Pager   : 20 lines
+Prompt  : "[%h HP] > "
+Muted   : none
+Ignored : nobody`
+	if got := doSettings(s, c); got != want {
+		t.Errorf("defaults: got\n%s\nwant\n%s", got, want)
+	}
+
+	c.Player.Settings.Brief = true
+	c.Player.Settings.PageLength = 0
+	c.Player.Settings.Prompt = "%h> "
+	c.Player.Settings.Muted = map[string]bool{"ooc": true}
+	c.Player.Settings.Ignored = []string{"Bob", "Carol"}
+	s.Config.Charset = "ascii"
+	want = `Ambient : on
+Autolook: on
+Brief   : on
+Charset : ascii
+Color   : on
+Compass : off
+Filter  : on
+Pager   : off
+Prompt  : "%h> "
+Muted   : ooc
+Ignored : Bob, Carol`
+	if got := doSettings(s, c); got != want {
+		t.Errorf("changed: got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAutoLook(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	roomsMap := createRoomsMap(s)
+	const east, west = 0, 1
+
+	// Moving shows the room again by default.
+	if got := doMove(s, c, roomsMap, east); got != "" {
+		t.Errorf("move with autolook on: got %q", got)
+	}
+	if intro := string(drawRoom(s, c).Intro); !strings.Contains(intro, "The town square.") {
+		t.Errorf("move with autolook on: drew %q", intro)
+	}
+
+	if got := doAutoLook(c, []string{"off"}); got != "Autolook is off." {
+		t.Fatalf("got %q", got)
+	}
+	if got := doMove(s, c, roomsMap, west); got != "You move west." {
+		t.Errorf("move with autolook off: got %q", got)
+	}
+	if intro := string(drawRoom(s, c).Intro); strings.Contains(intro, "The town square.") {
+		t.Errorf("move with autolook off: drew %q", intro)
+	}
+
+	// Looking shows the room again.
+	doLook(s, c)
+	if intro := string(drawRoom(s, c).Intro); !strings.Contains(intro, "The town square.") {
+		t.Errorf("look with autolook off: drew %q", intro)
+	}
+
+	// The setting is kept with the player.
+	if !s.savePlayer(*c.Player) {
+		t.Fatal("cannot save the player")
+	}
+	p, _, err := s.readPlayer("Alice")
+	if err != nil || p.Settings.AutoLook {
+		t.Errorf("got autolook %t, %v after saving", p.Settings.AutoLook, err)
+	}
+	if got := doAutoLook(c, []string{"sometimes"}); got != "Usage: autolook <on|off>" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestBrief(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	if got := doBrief(c, []string{"on"}); got != "Brief mode is on." {
+		t.Fatalf("got %q", got)
+	}
+
+	steps := []struct {
+		do    func()
+		shows string
+		brief bool
+	}{
+		// Rooms are shown in full the first time.
+		{do: func() { s.movePlayer(c.Player, "Town", "Inn", "1") }, shows: "A cosy inn."},
+		// Only the name and the exits of rooms already seen are shown.
+		{do: func() { s.movePlayer(c.Player, "Town", "Square", "2") }, shows: "Square", brief: true},
+		// Moving around the room changes nothing.
+		{do: func() { s.movePlayer(c.Player, "Town", "Square", "1") }, shows: "Square", brief: true},
+		// Looking shows everything.
+		{do: func() { doLook(s, c) }, shows: "The town square."},
+		// So does every room with brief mode off.
+		{do: func() { doBrief(c, []string{"off"}); s.movePlayer(c.Player, "Town", "Inn", "1") }, shows: "A cosy inn."},
+	}
+	for i, step := range steps {
+		step.do()
+		reply := drawRoom(s, c)
+		intro := string(reply.Intro)
+		if !strings.Contains(intro, step.shows) || step.brief && strings.Contains(intro, "The town square.") {
+			t.Errorf("step %d: drew intro %q, want %q", i, intro, step.shows)
+		}
+		if brief := reply.World == nil; brief != step.brief || reply.Exits == "" {
+			t.Errorf("step %d: drew the map %t and exits %q", i, !brief, reply.Exits)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// Shop settings used when none are configured.
+const (
+	defaultMaxInventory = 20
+	defaultSellPercent  = 50
+)
+
+// canCarry returns true if the player can carry the given items along with
+// those already carried. Stacks of items count as one item.
+func (s *Server) canCarry(p *area.Player, items []game.Item) bool {
+	after := p.Inventory
+	for _, item := range items {
+		after = game.AddItem(after, item)
+	}
+	return len(after) <= len(p.Inventory) || len(after) <= limit(s.Config.MaxInventory, defaultMaxInventory)
+}
+
+// buyPrice returns how much gold count of the item cost.
+func buyPrice(item game.Item, count int) int {
+	return item.Value * count
+}
+
+// sellPrice returns the gold vendors pay for count of the item, given the
+// percent of its value they pay.
+func sellPrice(item game.Item, count, percent int) int {
+	return item.Value * count * percent / 100
+}
+
+// vendorIn returns the vendor in the given room, if there is one.
+func (s *Server) vendorIn(areaName, roomName string) (area.NPC, bool) {
+	for _, npc := range s.Areas[areaName].Rooms[roomName].NPCs {
+		if npc.Vendor {
+			return npc, true
+		}
+	}
+	return area.NPC{}, false
+}
+
+// wares returns the items the vendor sells.
+func (s *Server) wares(vendor area.NPC) []game.Item {
+	var items []game.Item
+	for _, id := range vendor.Sells {
+		if item, ok := s.items[id]; ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// doList shows what the vendor in the room of the player sells, and for how
+// much.
+func doList(s *Server, c client.Client) string {
+	vendor, ok := s.vendorIn(c.Player.Area, c.Player.Room)
+	if !ok {
+		return "There is nobody selling anything here."
+	}
+
+	lines := []string{fmt.Sprintf("%s sells:", vendor.Name)}
+	for _, item := range s.wares(vendor) {
+		lines = append(lines, fmt.Sprintf("  %-20s %d gold", item.Name, buyPrice(item, 1)))
+	}
+	if len(lines) == 1 {
+		return fmt.Sprintf("%s has nothing for sale.", vendor.Name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// purchase is what buying from a vendor would change.
+type purchase struct {
+	vendor area.NPC
+	item   game.Item
+	count  int
+	bought []game.Item
+	price  int
+}
+
+// planBuy works out what buying the item given in args would change, or why
+// it cannot be bought.
+func planBuy(s *Server, c client.Client, args []string) (purchase, bool, string) {
+	vendor, ok := s.vendorIn(c.Player.Area, c.Player.Room)
+	if !ok {
+		return purchase{}, false, "There is nobody selling anything here."
+	}
+	name, count, ok := parseItemArgs(args)
+	if !ok || count < 0 {
+		return purchase{}, false, "Usage: buy [<count>] <item>"
+	}
+
+	wares := s.wares(vendor)
+	i, ok := game.FindItem(wares, name)
+	if !ok {
+		return purchase{}, false, fmt.Sprintf("%s does not sell any %s.", vendor.Name, name)
+	}
+	item := wares[i]
+	if item.Value > 0 && count > c.Player.Gold/item.Value {
+		return purchase{}, false, fmt.Sprintf("You cannot afford %s with %d gold.", describeCount(item, count), c.Player.Gold)
+	}
+	var bought []game.Item
+	switch {
+	case item.Stackable:
+		stack := item
+		stack.Quantity = count
+		bought = []game.Item{stack}
+	case count > limit(s.Config.MaxInventory, defaultMaxInventory):
+		return purchase{}, false, "You cannot carry that much."
+	default:
+		for j := 0; j < count; j++ {
+			bought = append(bought, item)
+		}
+	}
+	if !s.canCarry(c.Player, bought) {
+		return purchase{}, false, "You cannot carry that much."
+	}
+	return purchase{vendor: vendor, item: item, count: count, bought: bought, price: buyPrice(item, count)}, true, ""
+}
+
+// doBuy buys the item given in args from the vendor in the room of the
+// player, eg. "buy dagger" or "buy 10 arrows".
+func doBuy(s *Server, c client.Client, args []string) string {
+	plan, ok, msg := planBuy(s, c, args)
+	if !ok {
+		return msg
+	}
+
+	c.Player.Gold -= plan.price
+	for _, item := range plan.bought {
+		c.Player.Inventory = game.AddItem(c.Player.Inventory, item)
+	}
+	return fmt.Sprintf("You buy %s from %s for %d gold.", describeCount(plan.item, plan.count), plan.vendor.Name, plan.price)
+}
+
+// sale is what selling to a vendor would change.
+type sale struct {
+	vendor area.NPC
+	// inventory holds what the player would be left carrying.
+	inventory []game.Item
+	sold      []game.Item
+	price     int
+}
+
+// planSell works out what selling the items given in args would change, or
+// why they cannot be sold.
+func planSell(s *Server, c client.Client, args []string) (sale, bool, string) {
+	vendor, ok := s.vendorIn(c.Player.Area, c.Player.Room)
+	if !ok {
+		return sale{}, false, "There is nobody buying anything here."
+	}
+	name, count, ok := parseItemArgs(args)
+	if !ok || name == "" {
+		return sale{}, false, "Usage: sell [all|<count>] <item>"
+	}
+
+	inventory, sold := game.TakeItems(c.Player.Inventory, name, count)
+	if len(sold) == 0 {
+		return sale{}, false, fmt.Sprintf("You are not carrying any %s.", name)
+	}
+	price := 0
+	for _, item := range sold {
+		if item.Value == 0 {
+			return sale{}, false, fmt.Sprintf("%s is not interested in %s.", vendor.Name, item.Name)
+		}
+		price += sellPrice(item, item.Count(), limit(s.Config.SellPercent, defaultSellPercent))
+	}
+	return sale{vendor: vendor, inventory: inventory, sold: sold, price: price}, true, ""
+}
+
+// doSell sells the items given in args to the vendor in the room of the
+// player, eg. "sell dagger", "sell 5 arrows" or "sell all arrows".
+func doSell(s *Server, c client.Client, args []string) string {
+	plan, ok, msg := planSell(s, c, args)
+	if !ok {
+		return msg
+	}
+
+	c.Player.Inventory = plan.inventory
+	c.Player.Gold += plan.price
+	return fmt.Sprintf("You sell %s to %s for %d gold.", strings.Join(itemNames(plan.sold), ", "), plan.vendor.Name, plan.price)
+}
+
+// describeCount describes count of the item, eg. "3 Arrows".
+func describeCount(item game.Item, count int) string {
+	if count == 1 {
+		return item.Name
+	}
+	return fmt.Sprintf("%d %s", count, item.PluralName())
+}
+
+// danglingWares returns a problem for every item a vendor sells that is not
+// defined.
+func (s *Server) danglingWares() []error {
+	var problems []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			for _, npc := range a.Rooms[roomName].NPCs {
+				for _, id := range npc.Sells {
+					if _, ok := s.items[id]; !ok {
+						problems = append(problems, fmt.Errorf("area %q room %q: %s sells item %q which is not defined", areaName, roomName, npc.Name, id))
+					}
+				}
+			}
+		}
+	}
+
+	return problems
+}
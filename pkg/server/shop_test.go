@@ -0,0 +1,155 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// newShopTestServer returns a loaded test server with a vendor selling a
+// dagger and arrows in the square, and a player standing next to it.
+func newShopTestServer(t *testing.T) (*Server, client.Client) {
+	s := newLoadedTestServer(t)
+	s.items = map[string]game.Item{
+		"dagger": {ID: "dagger", Name: "Dagger", Slot: game.SlotWeapon, Damage: 4, Value: 2},
+		"arrow":  {ID: "arrow", Name: "Arrow", Stackable: true, Value: 1},
+	}
+	square := s.Areas["Town"].Rooms["Square"]
+	square.NPCs = append(square.NPCs, area.NPC{Name: "Merchant", Vendor: true, Sells: []string{"dagger", "arrow"}})
+	s.Areas["Town"].Rooms["Square"] = square
+
+	return s, addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+}
+
+func TestSellPrice(t *testing.T) {
+	item := game.Item{Name: "Longsword", Value: 15}
+	tests := []struct {
+		count, percent, want int
+	}{
+		{count: 1, percent: 50, want: 7},
+		{count: 2, percent: 50, want: 15},
+		{count: 1, percent: 100, want: 15},
+		{count: 3, percent: 10, want: 4},
+	}
+	for _, test := range tests {
+		if got := sellPrice(item, test.count, test.percent); got != test.want {
+			t.Errorf("%d at %d%%: got %d, want %d", test.count, test.percent, got, test.want)
+		}
+	}
+	if got := buyPrice(item, 3); got != 45 {
+		t.Errorf("buy price: got %d, want 45", got)
+	}
+}
+
+func TestList(t *testing.T) {
+	s, c := newShopTestServer(t)
+
+	got := doList(s, c)
+	if !strings.HasPrefix(got, "Merchant sells:") || !strings.Contains(got, "Dagger") || !strings.Contains(got, "Arrow") {
+		t.Errorf("got %q", got)
+	}
+
+	movePlayer(c.Player, "Town", "Inn", "1")
+	if got := doList(s, c); got != "There is nobody selling anything here." {
+		t.Errorf("without a vendor: got %q", got)
+	}
+}
+
+func TestBuy(t *testing.T) {
+	s, c := newShopTestServer(t)
+	c.Player.Gold = 14
+
+	tests := []struct {
+		args []string
+		want string
+		gold int
+	}{
+		{args: []string{"hat"}, want: "Merchant does not sell any hat.", gold: 14},
+		{args: []string{"8", "daggers"}, want: "You cannot afford 8 Daggers with 14 gold.", gold: 14},
+		{args: []string{"dagger"}, want: "You buy Dagger from Merchant for 2 gold.", gold: 12},
+		{args: []string{"10", "arrows"}, want: "You buy 10 Arrows from Merchant for 10 gold.", gold: 2},
+		{args: []string{"2", "arrows"}, want: "You buy 2 Arrows from Merchant for 2 gold.", gold: 0},
+		{args: []string{"arrow"}, want: "You cannot afford Arrow with 0 gold.", gold: 0},
+		{args: nil, want: "Usage: buy [<count>] <item>", gold: 0},
+	}
+	for _, test := range tests {
+		if got := doBuy(s, c, test.args); got != test.want {
+			t.Errorf("buy %v: got %q, want %q", test.args, got, test.want)
+		}
+		if c.Player.Gold != test.gold {
+			t.Errorf("buy %v: left %d gold, want %d", test.args, c.Player.Gold, test.gold)
+		}
+	}
+	if got := strings.Join(itemNames(c.Player.Inventory), ","); got != "Dagger,12 Arrows" {
+		t.Errorf("carrying %s", got)
+	}
+}
+
+func TestBuyCapacity(t *testing.T) {
+	s, c := newShopTestServer(t)
+	s.Config.MaxInventory = 2
+	c.Player.Gold = 100
+
+	if got := doBuy(s, c, []string{"3", "daggers"}); got != "You cannot carry that much." {
+		t.Errorf("got %q", got)
+	}
+	if got := doBuy(s, c, []string{"2", "daggers"}); got != "You buy 2 Daggers from Merchant for 4 gold." {
+		t.Errorf("got %q", got)
+	}
+	if got := doBuy(s, c, []string{"arrow"}); got != "You cannot carry that much." {
+		t.Errorf("got %q", got)
+	}
+	if c.Player.Gold != 96 {
+		t.Errorf("left %d gold, want 96", c.Player.Gold)
+	}
+}
+
+func TestSell(t *testing.T) {
+	s, c := newShopTestServer(t)
+	c.Player.Inventory = []game.Item{
+		{Name: "Dagger", Slot: game.SlotWeapon, Damage: 4, Value: 2},
+		{Name: "Arrow", Stackable: true, Quantity: 10, Value: 1},
+		{Name: "Rope"},
+	}
+
+	tests := []struct {
+		args []string
+		want string
+		gold int
+	}{
+		{args: []string{"hat"}, want: "You are not carrying any hat.", gold: 0},
+		{args: []string{"rope"}, want: "Merchant is not interested in Rope.", gold: 0},
+		{args: []string{"dagger"}, want: "You sell Dagger to Merchant for 1 gold.", gold: 1},
+		{args: []string{"4", "arrows"}, want: "You sell 4 Arrows to Merchant for 2 gold.", gold: 3},
+		{args: []string{"all", "arrows"}, want: "You sell 6 Arrows to Merchant for 3 gold.", gold: 6},
+	}
+	for _, test := range tests {
+		if got := doSell(s, c, test.args); got != test.want {
+			t.Errorf("sell %v: got %q, want %q", test.args, got, test.want)
+		}
+		if c.Player.Gold != test.gold {
+			t.Errorf("sell %v: have %d gold, want %d", test.args, c.Player.Gold, test.gold)
+		}
+	}
+	if got := strings.Join(itemNames(c.Player.Inventory), ","); got != "Rope" {
+		t.Errorf("carrying %s", got)
+	}
+
+	movePlayer(c.Player, "Town", "Inn", "1")
+	if got := doSell(s, c, []string{"rope"}); got != "There is nobody buying anything here." {
+		t.Errorf("without a vendor: got %q", got)
+	}
+}
+
+func TestDanglingWares(t *testing.T) {
+	s, _ := newShopTestServer(t)
+	delete(s.items, "arrow")
+
+	problems := s.danglingWares()
+	if len(problems) != 1 || problems[0].Error() != `area "Town" room "Square": Merchant sells item "arrow" which is not defined` {
+		t.Errorf("got %v", problems)
+	}
+}
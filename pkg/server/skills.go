@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// skillNames returns the names of the skills players can learn, sorted.
+func (s *Server) skillNames() []string {
+	var names []string
+	for name := range s.Config.StartingSkills {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// doSkills shows the proficiency of the player in every skill and the
+// practice sessions the player has left.
+func doSkills(s *Server, c client.Client) string {
+	names := s.skillNames()
+	if len(names) == 0 {
+		return "There are no skills to learn."
+	}
+
+	lines := []string{"Skills:"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("  %-15s %3d%%", name, c.Player.Skills[name]))
+	}
+	lines = append(lines, fmt.Sprintf("You have %s left.", plural(c.Player.Practices, "practice session")))
+	return strings.Join(lines, "\n")
+}
+
+// doPractice spends a practice session of the player to improve the skill
+// given in args. Players can only practice in rooms with a trainer.
+func doPractice(s *Server, c client.Client, args []string) string {
+	if len(args) != 1 {
+		return "Usage: practice <skill>"
+	}
+
+	name := args[0]
+	if _, ok := s.Config.StartingSkills[name]; !ok {
+		return fmt.Sprintf("There is no such skill as %s.", name)
+	}
+	if !s.Areas[c.Player.Area].Rooms[c.Player.Room].Trainer {
+		return "There is nobody here to practice with."
+	}
+	if c.Player.Practices <= 0 {
+		return "You have no practice sessions left."
+	}
+
+	proficiency := c.Player.Skills[name]
+	if proficiency >= s.Config.SkillCap {
+		return fmt.Sprintf("You cannot get any better at %s by practicing.", name)
+	}
+
+	proficiency += s.Config.PracticeGain
+	if proficiency > s.Config.SkillCap {
+		proficiency = s.Config.SkillCap
+	}
+	if c.Player.Skills == nil {
+		c.Player.Skills = make(map[string]int)
+	}
+	c.Player.Skills[name] = proficiency
+	c.Player.Practices--
+
+	return fmt.Sprintf("You practice %s and are now at %d%%.", name, proficiency)
+}
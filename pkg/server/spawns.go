@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// defaultMaxSpawnedNPCs is the most NPCs spawn rules keep in rooms at once
+// when no limit is configured.
+const defaultMaxSpawnedNPCs = 1000
+
+// respawn is a killed NPC that a spawn rule puts back in its room once it
+// is due.
+type respawn struct {
+	areaName, roomName string
+	npcID              string
+	due                time.Time
+}
+
+// spawnCapReached returns true if spawn rules cannot put any more NPCs in
+// rooms.
+func (s *Server) spawnCapReached() bool {
+	return s.spawned >= limit(s.Config.MaxSpawnedNPCs, defaultMaxSpawnedNPCs)
+}
+
+// populate puts the NPCs of every spawn rule in their rooms, up to the cap
+// on spawned NPCs, and forgets the respawns still due. It is called on the
+// freshly loaded areas.
+func (s *Server) populate() {
+	s.spawned = 0
+	s.respawns = nil
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			for _, spawn := range a.Rooms[roomName].Spawns {
+				for i := 0; i < spawn.Count; i++ {
+					if s.spawnCapReached() {
+						log.Warn(fmt.Sprintf("Spawned the most NPCs allowed, %d; area %q room %q and those after it are not fully populated",
+							s.spawned, areaName, roomName))
+						return
+					}
+					s.spawnNPC(areaName, roomName, spawn.NPCID)
+				}
+			}
+		}
+	}
+	log.Info(fmt.Sprintf("Spawned %d NPCs", s.spawned))
+}
+
+// spawnNPC puts the NPC with the given ID in the given room. Unknown NPCs
+// and rooms are left for validation to report.
+func (s *Server) spawnNPC(areaName, roomName, id string) {
+	npc, ok := s.npcs[id]
+	room, found := s.Areas[areaName].Rooms[roomName]
+	if !ok || !found {
+		return
+	}
+
+	npc.Spawned = true
+	room.NPCs = append(room.NPCs, npc)
+	s.Areas[areaName].Rooms[roomName] = room
+	s.spawned++
+}
+
+// killNPC removes the NPC at index i of the NPCs in the given room, killed
+// at now. If a spawn rule put it there, it comes back once the respawn
+// delay of the rule passed.
+func (s *Server) killNPC(areaName, roomName string, i int, now time.Time) {
+	room := s.Areas[areaName].Rooms[roomName]
+	npc := room.NPCs[i]
+	room.NPCs = append(room.NPCs[:i:i], room.NPCs[i+1:]...)
+	s.Areas[areaName].Rooms[roomName] = room
+	if !npc.Spawned {
+		return
+	}
+
+	s.spawned--
+	for _, spawn := range room.Spawns {
+		if spawn.NPCID == npc.ID {
+			s.respawns = append(s.respawns, respawn{
+				areaName: areaName,
+				roomName: roomName,
+				npcID:    npc.ID,
+				due:      now.Add(time.Duration(spawn.RespawnSeconds) * time.Second),
+			})
+			return
+		}
+	}
+}
+
+// respawnNPCs puts back the killed NPCs that are due at now. Those going
+// over the cap on spawned NPCs wait until others are killed.
+func (s *Server) respawnNPCs(now time.Time) {
+	var pending []respawn
+	for _, r := range s.respawns {
+		if now.Before(r.due) || s.spawnCapReached() {
+			pending = append(pending, r)
+			continue
+		}
+		s.spawnNPC(r.areaName, r.roomName, r.npcID)
+	}
+	s.respawns = pending
+}
+
+// doSlay kills the NPC given in args in the room of the admin.
+func doSlay(s *Server, c client.Client, args []string, now time.Time) CommandResult {
+	if len(args) == 0 {
+		return CommandResult{Actor: "Usage: slay <npc>"}
+	}
+
+	name := strings.Join(args, " ")
+	for i, npc := range s.Areas[c.Player.Area].Rooms[c.Player.Room].NPCs {
+		if strings.EqualFold(npc.Name, name) {
+			s.killNPC(c.Player.Area, c.Player.Room, i, now)
+			return CommandResult{
+				Actor: fmt.Sprintf("You slay %s.", npc.Name),
+				Room:  fmt.Sprintf("%s slays %s.", c.Player.Nickname, npc.Name),
+			}
+		}
+	}
+	return CommandResult{Actor: fmt.Sprintf("There is no %s here.", name)}
+}
+
+// invalidSpawns returns a problem for every spawn rule for an NPC that is
+// not defined or that keeps no NPCs.
+func (s *Server) invalidSpawns() []error {
+	var problems []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			for _, spawn := range a.Rooms[roomName].Spawns {
+				if _, ok := s.npcs[spawn.NPCID]; !ok {
+					problems = append(problems, fmt.Errorf("area %q room %q: spawns NPC %q which is not defined", areaName, roomName, spawn.NPCID))
+				}
+				if spawn.Count < 1 || spawn.RespawnSeconds < 0 {
+					problems = append(problems, fmt.Errorf("area %q room %q: spawn of NPC %q needs a count of at least 1 and a respawn delay of at least 0 seconds",
+						areaName, roomName, spawn.NPCID))
+				}
+			}
+		}
+	}
+
+	return problems
+}
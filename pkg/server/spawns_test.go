@@ -0,0 +1,170 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// spawnArea is an area keeping rats in the square and a guard in the inn.
+const spawnArea = `name = "Town"
+
+[rooms.Square]
+name = "Square"
+npcIds = ["mayor"]
+spawns = [ { npc = "rat", count = 2, respawnSeconds = 60 } ]
+cubes = [ { id = "1", posx = "0", posy = "0" } ]
+
+[rooms.Inn]
+name = "Inn"
+spawns = [ { npc = "guard", count = 1, respawnSeconds = 10 } ]
+cubes = [ { id = "1", posx = "0", posy = "0" } ]
+`
+
+// newSpawnTestServer returns a test server with the spawn area loaded and
+// at most max spawned NPCs.
+func newSpawnTestServer(t *testing.T, max int) *Server {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": spawnArea,
+		"npcs/town.toml":  "[npcs.mayor]\nname = \"Mayor\"\n[npcs.rat]\nname = \"Rat\"\n[npcs.guard]\nname = \"Guard\"\n",
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	s.Config.MaxSpawnedNPCs = max
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// npcNamesIn lists the names of the NPCs in the given room of Town.
+func npcNamesIn(s *Server, roomName string) string {
+	var names []string
+	for _, npc := range s.Areas["Town"].Rooms[roomName].NPCs {
+		names = append(names, npc.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestPopulate(t *testing.T) {
+	s := newSpawnTestServer(t, 0)
+
+	if got := npcNamesIn(s, "Square"); got != "Mayor,Rat,Rat" {
+		t.Errorf("the square holds %s", got)
+	}
+	if got := npcNamesIn(s, "Inn"); got != "Guard" {
+		t.Errorf("the inn holds %s", got)
+	}
+	if s.spawned != 3 {
+		t.Errorf("spawned %d NPCs, want 3", s.spawned)
+	}
+}
+
+func TestRespawnAfterDelay(t *testing.T) {
+	s := newSpawnTestServer(t, 0)
+	now := time.Now()
+
+	s.killNPC("Town", "Square", 1, now)
+	s.killNPC("Town", "Square", 0, now.Add(30*time.Second))
+	if got := npcNamesIn(s, "Square"); got != "Rat" {
+		t.Fatalf("after killing, the square holds %s", got)
+	}
+	if len(s.respawns) != 1 {
+		t.Errorf("the mayor was scheduled to respawn: %+v", s.respawns)
+	}
+
+	s.respawnNPCs(now.Add(59 * time.Second))
+	if got := npcNamesIn(s, "Square"); got != "Rat" {
+		t.Errorf("before the delay, the square holds %s", got)
+	}
+
+	s.respawnNPCs(now.Add(60 * time.Second))
+	if got := npcNamesIn(s, "Square"); got != "Rat,Rat" {
+		t.Errorf("after the delay, the square holds %s", got)
+	}
+	if len(s.respawns) != 0 || s.spawned != 3 {
+		t.Errorf("%d respawns left, %d spawned", len(s.respawns), s.spawned)
+	}
+}
+
+func TestSpawnCap(t *testing.T) {
+	s := newSpawnTestServer(t, 2)
+
+	// Rooms are populated in order, so the inn gets its guard first and
+	// only one rat fits in the square.
+	if got := npcNamesIn(s, "Inn") + "/" + npcNamesIn(s, "Square"); got != "Guard/Mayor,Rat" {
+		t.Fatalf("populated %s", got)
+	}
+
+	now := time.Now()
+	s.Config.MaxSpawnedNPCs = 1
+	s.killNPC("Town", "Inn", 0, now)
+	s.respawnNPCs(now.Add(time.Minute))
+	if got := npcNamesIn(s, "Inn"); got != "" {
+		t.Errorf("over the cap, the inn holds %s", got)
+	}
+	if len(s.respawns) != 1 {
+		t.Fatalf("the guard is no longer due to respawn: %+v", s.respawns)
+	}
+
+	s.killNPC("Town", "Square", 1, now)
+	s.respawnNPCs(now.Add(time.Minute))
+	if got := npcNamesIn(s, "Inn") + "/" + npcNamesIn(s, "Square"); got != "Guard/Mayor" {
+		t.Errorf("once a rat was killed, populated %s", got)
+	}
+}
+
+func TestSlay(t *testing.T) {
+	s := newSpawnTestServer(t, 0)
+	admin := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	if got := doSlay(s, admin, []string{"dragon"}, time.Now()); got.Actor != "There is no dragon here." {
+		t.Errorf("got %q", got.Actor)
+	}
+	got := doSlay(s, admin, []string{"rat"}, time.Now())
+	if got.Actor != "You slay Rat." || got.Room != "Alice slays Rat." {
+		t.Errorf("got %+v", got)
+	}
+	if len(s.respawns) != 1 || s.respawns[0].npcID != "rat" {
+		t.Errorf("respawns %+v", s.respawns)
+	}
+}
+
+func TestSaveAreaLeavesSpawnedNPCsOut(t *testing.T) {
+	s := newSpawnTestServer(t, 0)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	if got := doSaveArea(s, c); got != "Area Town saved." {
+		t.Fatalf("got %q", got)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	if got := npcNamesIn(s, "Square"); got != "Mayor,Rat,Rat" {
+		t.Errorf("the square holds %s after saving", got)
+	}
+}
+
+func TestInvalidSpawns(t *testing.T) {
+	s := newSpawnTestServer(t, 0)
+	delete(s.npcs, "guard")
+	square := s.Areas["Town"].Rooms["Square"]
+	square.Spawns[0].Count = 0
+	s.Areas["Town"].Rooms["Square"] = square
+
+	problems := s.invalidSpawns()
+	want := []string{
+		`area "Town" room "Inn": spawns NPC "guard" which is not defined`,
+		`area "Town" room "Square": spawn of NPC "rat" needs a count of at least 1 and a respawn delay of at least 0 seconds`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %v", problems)
+	}
+	for i, problem := range problems {
+		if problem.Error() != want[i] {
+			t.Errorf("got %q, want %q", problem, want[i])
+		}
+	}
+}
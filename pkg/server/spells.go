@@ -0,0 +1,217 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gothyra/toml"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// spellsFile is the layout of spells.toml.
+type spellsFile struct {
+	Spells map[string]game.Spell `toml:"spells"`
+}
+
+// loadSpells loads the spells found in spells.toml in the static directory.
+// A missing spells.toml means there are no spells.
+func (s *Server) loadSpells() error {
+	s.spells = make(map[string]game.Spell)
+
+	fileName := s.resolvePath(s.Config.Paths.Spells, defaultPaths.Spells)
+	fileContent, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return &ReadError{Path: fileName, Err: err}
+	}
+
+	spells := spellsFile{}
+	if _, err := toml.Decode(string(fileContent), &spells); err != nil {
+		return &ParseError{Path: fileName, Err: err}
+	}
+
+	for name, spell := range spells.Spells {
+		spell.Name = name
+		if err := spell.Validate(); err != nil {
+			return &ValidationError{Path: fileName, Err: err}
+		}
+		s.spells[name] = spell
+	}
+	return nil
+}
+
+// castResult is the outcome of a cast, with what the caster, the target and
+// everybody else in the room get to see.
+type castResult struct {
+	target    *client.Client
+	msg       string
+	targetMsg string
+	roomMsg   string
+}
+
+// doCast casts the spell given in args on the player given in args, or on the
+// caster if no target is given and the spell is not harmful.
+func doCast(s *Server, c client.Client, args []string, now time.Time) castResult {
+	if len(args) < 1 || len(args) > 2 {
+		msg := "Usage: cast <spell> [target]"
+		if names := s.spellNames(); len(names) > 0 {
+			msg += "\nSpells: " + strings.Join(names, ", ")
+		}
+		return castResult{msg: msg}
+	}
+
+	spell, ok := s.spells[args[0]]
+	if !ok {
+		return castResult{msg: fmt.Sprintf("You know no spell called %s.", args[0])}
+	}
+
+	target := c
+	if len(args) == 2 {
+		t, ok := s.OnlineClientByNick(args[1])
+		if !ok || t.Player.Area != c.Player.Area || t.Player.Room != c.Player.Room {
+			return castResult{msg: fmt.Sprintf("%s is not here.", args[1])}
+		}
+		target = t
+	} else if spell.Effect == game.EffectDamage {
+		return castResult{msg: fmt.Sprintf("Cast %s on whom?", spell.Name)}
+	}
+
+	if spell.Effect == game.EffectDamage && target.Player.Nickname != c.Player.Nickname {
+		if ok, msg := s.canFight(c.Player, target.Player, now); !ok {
+			return castResult{msg: msg}
+		}
+	}
+
+	if c.Player.CooldownLeft(spell.Name, now) > 0 {
+		return castResult{msg: fmt.Sprintf("You cannot cast %s again yet.", spell.Name)}
+	}
+	if c.Player.Mana < spell.Cost {
+		return castResult{msg: fmt.Sprintf("You do not have enough mana to cast %s.", spell.Name)}
+	}
+
+	c.Player.Mana -= spell.Cost
+	c.Player.SetCooldown(spell.Name, time.Duration(spell.Cooldown)*time.Second)
+
+	effect := game.ResolveSpell(spell, target.Player.PC)
+	target.Player.HP += effect.HP
+	if spell.Effect == game.EffectDamage {
+		c.Player.LastCombat = now
+		target.Player.LastCombat = now
+	}
+	if effect.AC != 0 || effect.Light {
+		target.Player.AC += effect.AC
+		target.Player.Buffs = append(target.Player.Buffs, game.Buff{
+			Spell:   spell.Name,
+			AC:      effect.AC,
+			Light:   effect.Light,
+			Expires: now.Add(time.Duration(spell.Duration) * time.Second),
+		})
+	}
+
+	caster := c.Player.Nickname
+	if target.Player.Nickname == caster {
+		return castResult{
+			msg:     fmt.Sprintf("You cast %s on yourself.%s", spell.Name, describeEffect(effect, "You")),
+			roomMsg: fmt.Sprintf("%s casts %s on themselves.", caster, spell.Name),
+		}
+	}
+	return castResult{
+		target:    &target,
+		msg:       fmt.Sprintf("You cast %s on %s.%s", spell.Name, target.Player.Nickname, describeEffect(effect, target.Player.Nickname)),
+		targetMsg: fmt.Sprintf("%s casts %s on you.%s", caster, spell.Name, describeEffect(effect, "You")),
+		roomMsg:   fmt.Sprintf("%s casts %s on %s.", caster, spell.Name, target.Player.Nickname),
+	}
+}
+
+// describeEffect returns a sentence describing the effect on who, starting
+// with a space, or nothing if the spell had no effect.
+func describeEffect(effect game.SpellEffect, who string) string {
+	lose, gain := "loses", "gains"
+	if who == "You" {
+		lose, gain = "lose", "gain"
+	}
+
+	switch {
+	case effect.HP < 0:
+		return fmt.Sprintf(" %s %s %d HP.", who, lose, -effect.HP)
+	case effect.HP > 0:
+		return fmt.Sprintf(" %s %s %d HP.", who, gain, effect.HP)
+	case effect.AC > 0:
+		return fmt.Sprintf(" %s %s %d AC.", who, gain, effect.AC)
+	case effect.Light:
+		return fmt.Sprintf(" %s %s a light.", who, gain)
+	}
+	return ""
+}
+
+// godPrintCast shows the outcome of a cast to the caster, the target and
+// everybody else in the room.
+func godPrintCast(
+	s *Server,
+	cl client.Client,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+	result castResult,
+) {
+	var others []client.Client
+	for _, c := range s.OnlineClientsGetByRoom(cl.Player.Area, cl.Player.Room) {
+		if result.target == nil || c.Player.Nickname != result.target.Player.Nickname {
+			others = append(others, c)
+		}
+	}
+	if result.roomMsg == "" {
+		wg.Add(1)
+		godPrintRoom(s, cl, []client.Client{cl}, wg, quit, roomsMap, s.paint(theme.Error, result.msg), "")
+		return
+	}
+
+	wg.Add(1)
+	godPrintRoom(s, cl, others, wg, quit, roomsMap, s.paint(theme.Combat, result.msg), s.paint(theme.Combat, result.roomMsg))
+	if result.target != nil {
+		wg.Add(1)
+		godPrintRoom(s, cl, []client.Client{*result.target}, wg, quit, roomsMap, "", s.paint(theme.Combat, result.targetMsg))
+	}
+}
+
+// tickSpells regenerates the mana of online players and takes away their
+// expired buffs and cooldowns.
+func (s *Server) tickSpells(now time.Time, elapsed time.Duration) {
+	regen := 0
+	if s.Config.ManaRegenSeconds > 0 {
+		interval := time.Duration(s.Config.ManaRegenSeconds) * time.Second
+		s.manaRegenElapsed += elapsed
+		regen = int(s.manaRegenElapsed / interval)
+		s.manaRegenElapsed %= interval
+	}
+
+	for _, c := range s.OnlineClients() {
+		c.Player.ExpireBuffs(now)
+		c.Player.ExpireCooldowns(now)
+		c.Player.Mana += regen
+		if c.Player.Mana > c.Player.MaxMana {
+			c.Player.Mana = c.Player.MaxMana
+		}
+	}
+}
+
+// spellNames returns the names of all spells, sorted.
+func (s *Server) spellNames() []string {
+	var names []string
+	for name := range s.spells {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
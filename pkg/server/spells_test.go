@@ -0,0 +1,157 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// testSpells are the spells of spells.toml in the test static directories.
+const testSpells = `[spells.heal]
+cost = 4
+effect = "heal"
+magnitude = 5
+cooldown = 10
+
+[spells.spark]
+cost = 3
+effect = "damage"
+magnitude = 2
+
+[spells.shield]
+cost = 5
+effect = "buff"
+magnitude = 2
+duration = 30
+`
+
+// newSpellsTestServer returns a loaded test server with testSpells.
+func newSpellsTestServer(t *testing.T) *Server {
+	s := newTestServer(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": testArea,
+		"spells.toml":     testSpells,
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadAreas(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadSpells(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLoadSpells(t *testing.T) {
+	s := newSpellsTestServer(t)
+	if got := strings.Join(s.spellNames(), ","); got != "heal,shield,spark" {
+		t.Errorf("loaded %s", got)
+	}
+	if s.spells["heal"].Name != "heal" || s.spells["heal"].Magnitude != 5 {
+		t.Errorf("heal: got %+v", s.spells["heal"])
+	}
+
+	// A missing spells file means there are no spells.
+	s = newLoadedTestServer(t)
+	if err := s.loadSpells(); err != nil || len(s.spells) != 0 {
+		t.Errorf("got %v and %v", s.spells, err)
+	}
+}
+
+func TestLoadSpellsInvalid(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"server.toml": testConfig,
+		"spells.toml": "[spells.blink]\neffect = \"teleport\"\n",
+	})
+	if err := s.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	err := s.loadSpells()
+	if _, ok := err.(*ValidationError); !ok || !strings.Contains(err.Error(), `unknown effect "teleport"`) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestCast(t *testing.T) {
+	s := newSpellsTestServer(t)
+	now := time.Now()
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	addTestPlayer(t, s, "Carol", "Town", "Inn", "1")
+	alice.Player.Mana, alice.Player.HP, alice.Player.MaxHP = 8, 3, 10
+	bob.Player.HP = 10
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"fireball"}, want: "You know no spell called fireball."},
+		{args: []string{"heal", "Carol"}, want: "Carol is not here."},
+		{args: []string{"spark"}, want: "Cast spark on whom?"},
+		{args: []string{"spark", "Bob"}, want: "Bob is not flagged for PvP with you. Challenge them with duel Bob."},
+		{args: []string{"heal"}, want: "You cast heal on yourself. You gain 5 HP."},
+		{args: []string{"heal"}, want: "You cannot cast heal again yet."},
+		{args: []string{"shield", "Bob"}, want: "You do not have enough mana to cast shield."},
+	}
+	for _, test := range tests {
+		if got := doCast(s, alice, test.args, now).msg; got != test.want {
+			t.Errorf("cast %v: got %q, want %q", test.args, got, test.want)
+		}
+	}
+	if alice.Player.HP != 8 || alice.Player.Mana != 4 {
+		t.Errorf("Alice has %d HP and %d mana", alice.Player.HP, alice.Player.Mana)
+	}
+
+	s.pvp = pvpOn
+	result := doCast(s, alice, []string{"spark", "Bob"}, now)
+	if result.msg != "You cast spark on Bob. Bob loses 2 HP." || result.targetMsg != "Alice casts spark on you. You lose 2 HP." || result.roomMsg != "Alice casts spark on Bob." {
+		t.Errorf("got %+v", result)
+	}
+	if bob.Player.HP != 8 || !bob.Player.LastCombat.Equal(now) {
+		t.Errorf("Bob has %d HP and last fought at %v", bob.Player.HP, bob.Player.LastCombat)
+	}
+}
+
+func TestCastBuff(t *testing.T) {
+	s := newSpellsTestServer(t)
+	now := time.Now()
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	alice.Player.Mana, alice.Player.AC, alice.Player.Buffs = 10, 12, nil
+
+	if got := doCast(s, alice, []string{"shield"}, now).msg; got != "You cast shield on yourself. You gain 2 AC." {
+		t.Errorf("got %q", got)
+	}
+	if alice.Player.AC != 14 || len(alice.Player.Buffs) != 1 || !alice.Player.Buffs[0].Expires.Equal(now.Add(30*time.Second)) {
+		t.Errorf("got AC %d and buffs %+v", alice.Player.AC, alice.Player.Buffs)
+	}
+
+	s.tickSpells(now.Add(30*time.Second), 0)
+	if alice.Player.AC != 12 || len(alice.Player.Buffs) != 0 {
+		t.Errorf("after the buff: got AC %d and buffs %+v", alice.Player.AC, alice.Player.Buffs)
+	}
+}
+
+func TestTickSpellsRegeneratesMana(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Config.ManaRegenSeconds = 10
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	c.Player.PC = game.PC{Mana: 0, MaxMana: 3}
+
+	now := time.Now()
+	s.tickSpells(now, 7*time.Second)
+	if c.Player.Mana != 0 {
+		t.Errorf("after 7s: got %d mana", c.Player.Mana)
+	}
+	s.tickSpells(now, 7*time.Second)
+	if c.Player.Mana != 1 {
+		t.Errorf("after 14s: got %d mana", c.Player.Mana)
+	}
+	s.tickSpells(now, time.Minute)
+	if c.Player.Mana != 3 {
+		t.Errorf("after a minute: got %d mana, want the maximum", c.Player.Mana)
+	}
+}
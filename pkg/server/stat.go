@@ -0,0 +1,139 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// doStat shows what was loaded for an area or a room, the one the admin is
+// in unless another one is given in args, or how busy the server is.
+func doStat(s *Server, c client.Client, args []string) string {
+	usage := "Usage: stat area [area] | stat room [area room] | stat server"
+	if len(args) == 0 {
+		return usage
+	}
+	if args[0] == "server" && len(args) == 1 {
+		return strings.Join([]string{
+			fmt.Sprintf("Players online: %d", len(s.OnlineClients())),
+			s.formatRegistrationStat(),
+		}, "\n")
+	}
+
+	areaName, roomName := c.Player.Area, c.Player.Room
+	switch {
+	case args[0] == "area" && len(args) <= 2:
+		if len(args) == 2 {
+			areaName = args[1]
+		}
+		a, ok := s.Areas[areaName]
+		if !ok {
+			return fmt.Sprintf("There is no area %s.", areaName)
+		}
+		return formatAreaStat(areaName, a)
+	case args[0] == "room" && (len(args) == 1 || len(args) == 3):
+		if len(args) == 3 {
+			areaName, roomName = args[1], args[2]
+		}
+		room, ok := s.Areas[areaName].Rooms[roomName]
+		if !ok {
+			return fmt.Sprintf("There is no room %s in %s.", roomName, areaName)
+		}
+		return formatRoomStat(areaName, roomName, room)
+	}
+	return usage
+}
+
+// formatAreaStat describes the area and lists its rooms.
+func formatAreaStat(areaName string, a area.Area) string {
+	lines := []string{
+		fmt.Sprintf("Area %s (%s)", areaName, a.Name),
+		fmt.Sprintf("Intro   : %s", a.Intro),
+		fmt.Sprintf("Weather : %s", listOrNone(a.Weather)),
+		fmt.Sprintf("Access  : %s", formatAccess(a.Access)),
+		fmt.Sprintf("Ambient : %s", plural(len(a.Ambient), "message")),
+		fmt.Sprintf("Rooms   : %d", len(a.Rooms)),
+	}
+	for _, roomName := range sortedRoomNames(a.Rooms) {
+		room := a.Rooms[roomName]
+		lines = append(lines, fmt.Sprintf("  %s: %s, %s, %s", roomName,
+			plural(len(room.Cubes), "cube"), plural(len(roomExits(room)), "exit"), plural(len(room.NPCs), "NPC")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatRoomStat describes the room along with its exits, NPCs and spawn
+// rules.
+func formatRoomStat(areaName, roomName string, room area.Room) string {
+	occupants := "any"
+	if room.MaxOccupants > 0 {
+		occupants = fmt.Sprintf("%d", room.MaxOccupants)
+	}
+	var npcs []string
+	for _, npc := range room.NPCs {
+		npcs = append(npcs, npc.Name)
+	}
+	var spawns []string
+	for _, spawn := range room.Spawns {
+		spawns = append(spawns, fmt.Sprintf("%d %s every %ds", spawn.Count, spawn.NPCID, spawn.RespawnSeconds))
+	}
+
+	lines := []string{
+		fmt.Sprintf("Room %s/%s (%s)", areaName, roomName, room.Name),
+		fmt.Sprintf("Cubes     : %d", len(room.Cubes)),
+		fmt.Sprintf("Indoors   : %s", yesNo(room.Indoors)),
+		fmt.Sprintf("Trainer   : %s", yesNo(room.Trainer)),
+		fmt.Sprintf("Occupants : %s", occupants),
+		fmt.Sprintf("Access    : %s", formatAccess(room.Access)),
+		fmt.Sprintf("NPCs      : %s", listOrNone(npcs)),
+		fmt.Sprintf("Spawns    : %s", listOrNone(spawns)),
+		fmt.Sprintf("Ambient   : %s", plural(len(room.Ambient), "message")),
+	}
+	exits := roomExits(room)
+	lines = append(lines, fmt.Sprintf("Exits     : %d", len(exits)))
+	for _, exit := range exits {
+		lines = append(lines, "  "+exit)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// roomExits describes every exit of the room, from the cube it is on to the
+// cube it leads to.
+func roomExits(room area.Room) []string {
+	var exits []string
+	for _, cube := range room.Cubes {
+		for _, exit := range cube.Exits {
+			exits = append(exits, fmt.Sprintf("cube %s -> %s/%s cube %s", cube.ID, exit.ToArea, exit.ToRoom, exit.ToCubeID))
+		}
+	}
+	return exits
+}
+
+// formatAccess describes what players need to pass the access.
+func formatAccess(access area.Access) string {
+	var needs []string
+	if access.MinLevel > 0 {
+		needs = append(needs, fmt.Sprintf("level %d", access.MinLevel))
+	}
+	flags := make([]string, 0, len(access.Requires))
+	for key, value := range access.Requires {
+		flags = append(flags, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(flags)
+	needs = append(needs, flags...)
+	if len(needs) == 0 {
+		return "anybody"
+	}
+	return strings.Join(needs, ", ")
+}
+
+// yesNo names a boolean property.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatRoom(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Root", "Town", "Square", "1")
+
+	want := `Room Town/Square (Square)
+Cubes     : 4
+Indoors   : no
+Trainer   : no
+Occupants : any
+Access    : anybody
+NPCs      : none
+Spawns    : none
+Ambient   : 0 messages
+Exits     : 1
+  cube 3 -> Town/Inn cube 1`
+	if got := doStat(s, c, []string{"room"}); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got := doStat(s, c, []string{"room", "Town", "Inn"})
+	if !strings.Contains(got, "Cubes     : 3\nIndoors   : yes") || !strings.HasSuffix(got, "Exits     : 1\n  cube 2 -> Town/Square cube 2") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestStatArea(t *testing.T) {
+	s := newLoadedTestServer(t)
+	c := addTestPlayer(t, s, "Root", "Town", "Square", "1")
+
+	got := doStat(s, c, []string{"area", "Town"})
+	if !strings.HasSuffix(got, "Rooms   : 2\n  Inn: 3 cubes, 1 exit, 0 NPCs\n  Square: 4 cubes, 1 exit, 0 NPCs") {
+		t.Errorf("got:\n%s", got)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"area", "Nowhere"}, want: "There is no area Nowhere."},
+		{args: []string{"room", "Town", "Cellar"}, want: "There is no room Cellar in Town."},
+		{args: []string{"room", "Town"}, want: "Usage: stat area [area] | stat room [area room] | stat server"},
+		{args: nil, want: "Usage: stat area [area] | stat room [area room] | stat server"},
+	}
+	for _, test := range tests {
+		if got := doStat(s, c, test.args); got != test.want {
+			t.Errorf("stat %v: got %q, want %q", test.args, got, test.want)
+		}
+	}
+}
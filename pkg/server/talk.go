@@ -0,0 +1,121 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// doTalk starts a conversation of the player with the NPC given in args.
+func doTalk(s *Server, c client.Client, args []string) string {
+	if len(args) == 0 {
+		return "Talk to whom?"
+	}
+
+	name := strings.Join(args, " ")
+	npc, ok := s.Areas[c.Player.Area].Rooms[c.Player.Room].FindNPC(name)
+	if !ok {
+		return fmt.Sprintf("There is nobody called %s here.", name)
+	}
+	if _, ok := npc.Dialogue[area.StartNode]; !ok {
+		return fmt.Sprintf("%s has nothing to say.", npc.Name)
+	}
+
+	*c.Conversation = client.Conversation{
+		Area: c.Player.Area,
+		Room: c.Player.Room,
+		NPC:  npc.Name,
+		Node: area.StartNode,
+	}
+	return converse(c, npc, "")
+}
+
+// doRespond gives the numbered response the player typed to the NPC the
+// player is talking to.
+func doRespond(s *Server, c client.Client, number string) string {
+	conv := c.Conversation
+	if conv.NPC == "" {
+		return "You are not talking to anybody."
+	}
+
+	npc, ok := s.Areas[conv.Area].Rooms[conv.Room].FindNPC(conv.NPC)
+	if !ok || conv.Area != c.Player.Area || conv.Room != c.Player.Room {
+		name := conv.NPC
+		endConversation(c)
+		return fmt.Sprintf("You are no longer talking to %s.", name)
+	}
+
+	responses := npc.Dialogue[conv.Node].Available(c.Player)
+	i, err := strconv.Atoi(number)
+	if err != nil || i < 1 || i > len(responses) {
+		return fmt.Sprintf("Choose a response from 1 to %d.", len(responses))
+	}
+
+	response := responses[i-1]
+	for key, value := range response.SetFlags {
+		setFlag(c.Player, key, value)
+	}
+	conv.Node = response.Next
+	return converse(c, npc, response.Text)
+}
+
+// converse returns what the player and the NPC say at the current node of
+// the conversation, ending the conversation if the player cannot respond.
+func converse(c client.Client, npc area.NPC, said string) string {
+	var lines []string
+	if said != "" {
+		lines = append(lines, fmt.Sprintf("You say: %s", said))
+	}
+
+	node, ok := npc.Dialogue[c.Conversation.Node]
+	if !ok {
+		endConversation(c)
+		return strings.Join(append(lines, fmt.Sprintf("%s nods and turns away.", npc.Name)), "\n")
+	}
+
+	lines = append(lines, fmt.Sprintf("%s says: %s", npc.Name, node.Text))
+	responses := node.Available(c.Player)
+	if len(responses) == 0 {
+		endConversation(c)
+		return strings.Join(lines, "\n")
+	}
+	for i, r := range responses {
+		lines = append(lines, fmt.Sprintf("  %d. %s", i+1, r.Text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// endConversation ends the conversation the player is having with an NPC.
+func endConversation(c client.Client) {
+	*c.Conversation = client.Conversation{}
+}
+
+// danglingDialogue returns a problem for every NPC that has no start node or
+// has responses leading to dialogue nodes that do not exist.
+func (s *Server) danglingDialogue() []error {
+	var problems []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			for _, npc := range a.Rooms[roomName].NPCs {
+				if _, ok := npc.Dialogue[area.StartNode]; len(npc.Dialogue) > 0 && !ok {
+					problems = append(problems, fmt.Errorf("area %q room %q: NPC %s has no %s dialogue node", areaName, roomName, npc.Name, area.StartNode))
+				}
+				for nodeName, node := range npc.Dialogue {
+					for _, r := range node.Responses {
+						if _, ok := npc.Dialogue[r.Next]; r.Next != "" && !ok {
+							problems = append(problems, fmt.Errorf("area %q room %q: NPC %s node %s leads to node %s which does not exist",
+								areaName, roomName, npc.Name, nodeName, r.Next))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return problems
+}
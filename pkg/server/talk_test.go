@@ -0,0 +1,126 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// testMayor is an NPC with a dialogue tree starting a quest.
+var testMayor = area.NPC{
+	Name: "Mayor",
+	Dialogue: map[string]area.DialogueNode{
+		area.StartNode: {
+			Text: "Welcome to town.",
+			Responses: []area.Response{
+				{Text: "Any work?", Next: "work"},
+				{Text: "The rats are dead.", Next: "reward", Requires: map[string]string{"quest.rats": "done"}},
+				{Text: "Bye."},
+			},
+		},
+		"work": {
+			Text: "Kill the rats in the cellar.",
+			Responses: []area.Response{
+				{Text: "I will.", SetFlags: map[string]string{"quest.rats": "started"}},
+			},
+		},
+		"reward": {Text: "Thank you!"},
+	},
+}
+
+// newTalkTestServer returns a loaded test server with testMayor in the
+// square, along with a player there.
+func newTalkTestServer(t *testing.T) *Server {
+	s := newLoadedTestServer(t)
+	square := s.Areas["Town"].Rooms["Square"]
+	square.NPCs = []area.NPC{testMayor, {Name: "Beggar"}}
+	s.Areas["Town"].Rooms["Square"] = square
+	return s
+}
+
+func TestTalk(t *testing.T) {
+	s := newTalkTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	steps := []struct {
+		run  func() string
+		want string
+	}{
+		{run: func() string { return doRespond(s, c, "1") }, want: "You are not talking to anybody."},
+		{run: func() string { return doTalk(s, c, nil) }, want: "Talk to whom?"},
+		{run: func() string { return doTalk(s, c, []string{"guard"}) }, want: "There is nobody called guard here."},
+		{run: func() string { return doTalk(s, c, []string{"beggar"}) }, want: "Beggar has nothing to say."},
+		{run: func() string { return doTalk(s, c, []string{"mayor"}) }, want: "Mayor says: Welcome to town.\n  1. Any work?\n  2. Bye."},
+		{run: func() string { return doRespond(s, c, "3") }, want: "Choose a response from 1 to 2."},
+		{run: func() string { return doRespond(s, c, "1") }, want: "You say: Any work?\nMayor says: Kill the rats in the cellar.\n  1. I will."},
+		{run: func() string { return doRespond(s, c, "1") }, want: "You say: I will.\nMayor nods and turns away."},
+		{run: func() string { return doRespond(s, c, "1") }, want: "You are not talking to anybody."},
+	}
+	for i, step := range steps {
+		if got := step.run(); got != step.want {
+			t.Errorf("step %d: got %q, want %q", i, got, step.want)
+		}
+	}
+	if c.Player.Flags["quest.rats"] != "started" {
+		t.Errorf("got flags %v", c.Player.Flags)
+	}
+}
+
+func TestTalkRequiresFlags(t *testing.T) {
+	s := newTalkTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	setFlag(c.Player, "quest.rats", "done")
+
+	want := "Mayor says: Welcome to town.\n  1. Any work?\n  2. The rats are dead.\n  3. Bye."
+	if got := doTalk(s, c, []string{"Mayor"}); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// The conversation ends at nodes without responses.
+	if got := doRespond(s, c, "2"); got != "You say: The rats are dead.\nMayor says: Thank you!" {
+		t.Errorf("got %q", got)
+	}
+	if c.Conversation.NPC != "" {
+		t.Errorf("still talking: %+v", *c.Conversation)
+	}
+}
+
+func TestTalkEndsWhenLeaving(t *testing.T) {
+	s := newTalkTestServer(t)
+	c := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+
+	doTalk(s, c, []string{"mayor"})
+	s.movePlayer(c.Player, "Town", "Inn", "1")
+	if got := doRespond(s, c, "1"); got != "You are no longer talking to Mayor." {
+		t.Errorf("got %q", got)
+	}
+	if c.Conversation.NPC != "" {
+		t.Errorf("still talking: %+v", *c.Conversation)
+	}
+}
+
+func TestDanglingDialogue(t *testing.T) {
+	s := newLoadedTestServer(t)
+	square := s.Areas["Town"].Rooms["Square"]
+	square.NPCs = []area.NPC{
+		testMayor,
+		{Name: "Guard", Dialogue: map[string]area.DialogueNode{"hello": {Text: "Halt."}}},
+		{Name: "Baker", Dialogue: map[string]area.DialogueNode{
+			area.StartNode: {Text: "Bread?", Responses: []area.Response{{Text: "Yes.", Next: "buy"}}},
+		}},
+	}
+	s.Areas["Town"].Rooms["Square"] = square
+
+	problems := s.danglingDialogue()
+	if len(problems) != 2 {
+		t.Fatalf("got %v", problems)
+	}
+	want := map[string]bool{
+		`area "Town" room "Square": NPC Guard has no start dialogue node`:                        true,
+		`area "Town" room "Square": NPC Baker node start leads to node buy which does not exist`: true,
+	}
+	for _, problem := range problems {
+		if !want[problem.Error()] {
+			t.Errorf("unexpected problem %v", problem)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// trade is an exchange of items and gold between two players in the same
+// room. Nothing changes hands until both players accept it.
+type trade struct {
+	// offers holds what each of the players gives, by nickname.
+	offers map[string]*offer
+}
+
+// offer is what one of the players of a trade gives.
+type offer struct {
+	items    []game.Item
+	gold     int
+	accepted bool
+}
+
+// describe describes what the offer holds.
+func (o *offer) describe() string {
+	what := itemNames(o.items)
+	if o.gold > 0 {
+		what = append(what, fmt.Sprintf("%d gold", o.gold))
+	}
+	return listOrNone(what)
+}
+
+// tradeOf returns the trade the player with the given nickname is in, along
+// with the nickname of the other player.
+func (s *Server) tradeOf(nick string) (*trade, string, bool) {
+	t, ok := s.trades[nick]
+	if !ok {
+		return nil, "", false
+	}
+	for other := range t.offers {
+		if other != nick {
+			return t, other, true
+		}
+	}
+	return nil, "", false
+}
+
+// endTrade ends the trade the player with the given nickname is in.
+func (s *Server) endTrade(t *trade) {
+	for nick := range t.offers {
+		delete(s.trades, nick)
+	}
+}
+
+// tradePartner returns the other player of the trade the player with the
+// given nickname is in, if that player is still online and in the same room.
+func (s *Server) tradePartner(p *area.Player, partner string) (client.Client, bool) {
+	c, ok := s.OnlineClientByNick(partner)
+	if !ok || c.Player.Area != p.Area || c.Player.Room != p.Room {
+		return client.Client{}, false
+	}
+	return c, true
+}
+
+// doTrade starts a trade with the player given in args, or changes the trade
+// the player is in: "trade add [<count>] <item>", "trade add <amount> gold",
+// "trade accept" or "trade cancel". It shows the trade without args.
+func doTrade(s *Server, c client.Client, args []string) CommandResult {
+	me := c.Player.Nickname
+	t, them, trading := s.tradeOf(me)
+	if trading {
+		partner, ok := s.tradePartner(c.Player, them)
+		if !ok {
+			s.endTrade(t)
+			return CommandResult{Actor: fmt.Sprintf("%s is no longer here, so the trade is cancelled.", them)}
+		}
+		return changeTrade(s, c, partner, t, args)
+	}
+
+	if len(args) > 0 && (args[0] == "add" || args[0] == "accept" || args[0] == "cancel") {
+		return CommandResult{Actor: "You are not trading with anybody."}
+	}
+	if len(args) != 1 {
+		return CommandResult{Actor: "Usage: trade <nick>"}
+	}
+	target, ok := s.OnlineClientByNick(args[0])
+	if !ok || target.Player.Area != c.Player.Area || target.Player.Room != c.Player.Room {
+		return CommandResult{Actor: fmt.Sprintf("%s is not here.", args[0])}
+	}
+	them = target.Player.Nickname
+	if me == them {
+		return CommandResult{Actor: "You cannot trade with yourself."}
+	}
+	if _, _, ok := s.tradeOf(them); ok {
+		return CommandResult{Actor: fmt.Sprintf("%s is already trading with somebody else.", them)}
+	}
+
+	t = &trade{offers: map[string]*offer{me: {}, them: {}}}
+	s.trades[me], s.trades[them] = t, t
+	return CommandResult{
+		Actor: fmt.Sprintf("You start trading with %s. Use trade add to offer items or gold, then trade accept.", them),
+		Targets: []Target{{
+			Client: target,
+			Msg:    fmt.Sprintf("%s starts trading with you. Use trade add to offer items or gold, then trade accept, or trade cancel.", me),
+		}},
+	}
+}
+
+// changeTrade handles the trade command of a player already trading with
+// partner.
+func changeTrade(s *Server, c client.Client, partner client.Client, t *trade, args []string) CommandResult {
+	me, them := c.Player.Nickname, partner.Player.Nickname
+	mine, theirs := t.offers[me], t.offers[them]
+
+	if len(args) == 0 {
+		return CommandResult{Actor: strings.Join([]string{
+			fmt.Sprintf("You are trading with %s.", them),
+			fmt.Sprintf("You offer: %s%s", mine.describe(), acceptedMark(mine)),
+			fmt.Sprintf("%s offers: %s%s", them, theirs.describe(), acceptedMark(theirs)),
+		}, "\n")}
+	}
+
+	switch args[0] {
+	case "add":
+		msg, ok := addToOffer(c, mine, args[1:])
+		if !ok {
+			return CommandResult{Actor: msg}
+		}
+		// Changing an offer takes back every acceptance, so that nobody
+		// accepts something other than what they agreed to.
+		mine.accepted, theirs.accepted = false, false
+		return CommandResult{
+			Actor:   fmt.Sprintf("You offer %s.", msg),
+			Targets: []Target{{Client: partner, Msg: fmt.Sprintf("%s offers %s.", me, msg)}},
+		}
+
+	case "accept":
+		mine.accepted = true
+		if !theirs.accepted {
+			return CommandResult{
+				Actor:   fmt.Sprintf("You accept the trade. Waiting for %s to accept it too.", them),
+				Targets: []Target{{Client: partner, Msg: fmt.Sprintf("%s accepts the trade. Type trade accept to accept it too.", me)}},
+			}
+		}
+		s.endTrade(t)
+		if err := swapOffers(s, c.Player, partner.Player, mine, theirs); err != nil {
+			msg := fmt.Sprintf("The trade is cancelled, %v.", err)
+			return CommandResult{Actor: msg, Targets: []Target{{Client: partner, Msg: msg}}}
+		}
+		return CommandResult{
+			Actor:   fmt.Sprintf("You trade %s for %s with %s.", mine.describe(), theirs.describe(), them),
+			Targets: []Target{{Client: partner, Msg: fmt.Sprintf("You trade %s for %s with %s.", theirs.describe(), mine.describe(), me)}},
+		}
+
+	case "cancel":
+		s.endTrade(t)
+		return CommandResult{
+			Actor:   fmt.Sprintf("You cancel the trade with %s.", them),
+			Targets: []Target{{Client: partner, Msg: fmt.Sprintf("%s cancels the trade.", me)}},
+		}
+	}
+	return CommandResult{Actor: "Usage: trade [add [all|<count>] <item> | add <amount> gold | accept | cancel]"}
+}
+
+// acceptedMark marks offers whose player accepted the trade.
+func acceptedMark(o *offer) string {
+	if o.accepted {
+		return " (accepted)"
+	}
+	return ""
+}
+
+// addToOffer adds the gold or items given in args to the offer, returning
+// what was added. Only what the player has and did not offer yet can be
+// added.
+func addToOffer(c client.Client, o *offer, args []string) (string, bool) {
+	amount, isGold, err := parseGoldArgs(args)
+	if err != nil {
+		return err.Error(), false
+	}
+	if isGold {
+		left := c.Player.Gold - o.gold
+		if amount < 0 {
+			amount = left
+		}
+		if amount == 0 || amount > left {
+			return fmt.Sprintf("You only have %d more gold to offer.", left), false
+		}
+		o.gold += amount
+		return fmt.Sprintf("%d gold", amount), true
+	}
+
+	name, count, ok := parseItemArgs(args)
+	if !ok || name == "" {
+		return "Usage: trade add [all|<count>] <item> | trade add <amount> gold", false
+	}
+	left, _ := game.RemoveItems(c.Player.Inventory, o.items)
+	_, added := game.TakeItems(left, name, count)
+	if len(added) == 0 {
+		return fmt.Sprintf("You have no more %s to offer.", name), false
+	}
+	for _, item := range added {
+		o.items = game.AddItem(o.items, item)
+	}
+	return strings.Join(itemNames(added), ", "), true
+}
+
+// swap is what a trade would change: what each of its two players would
+// carry and how much gold they would have.
+type swap struct {
+	aItems, bItems []game.Item
+	aGold, bGold   int
+}
+
+// planSwap works out what giving what a offers to b and what b offers to a
+// would change. It returns an error when either of them no longer has what
+// they offered or cannot carry what they get.
+func planSwap(s *Server, a, b *area.Player, fromA, fromB *offer) (swap, error) {
+	aItems, aGold, err := s.afterTrade(a, fromA, fromB)
+	if err != nil {
+		return swap{}, err
+	}
+	bItems, bGold, err := s.afterTrade(b, fromB, fromA)
+	if err != nil {
+		return swap{}, err
+	}
+	return swap{aItems: aItems, bItems: bItems, aGold: aGold, bGold: bGold}, nil
+}
+
+// swapOffers gives what a offers to b and what b offers to a. Either all of
+// it changes hands or, when either of them no longer has what they offered
+// or cannot carry what they get, nothing does.
+func swapOffers(s *Server, a, b *area.Player, fromA, fromB *offer) error {
+	plan, err := planSwap(s, a, b, fromA, fromB)
+	if err != nil {
+		return err
+	}
+
+	a.Inventory, a.Gold = plan.aItems, plan.aGold
+	b.Inventory, b.Gold = plan.bItems, plan.bGold
+	return nil
+}
+
+// afterTrade returns what the player would carry after giving what gives
+// holds and getting what gets holds.
+func (s *Server) afterTrade(p *area.Player, gives, gets *offer) ([]game.Item, int, error) {
+	items, ok := game.RemoveItems(p.Inventory, gives.items)
+	if !ok || gives.gold > p.Gold {
+		return nil, 0, fmt.Errorf("%s no longer has what they offered", p.Nickname)
+	}
+	for _, item := range gets.items {
+		items = game.AddItem(items, item)
+	}
+	if len(items) > len(p.Inventory) && len(items) > limit(s.Config.MaxInventory, defaultMaxInventory) {
+		return nil, 0, fmt.Errorf("%s cannot carry that much", p.Nickname)
+	}
+	return items, p.Gold - gives.gold + gets.gold, nil
+}
+
+// godCancelTrades cancels the trades one of whose players logged out or left
+// the room of the other, telling whoever is left.
+func godCancelTrades(
+	s *Server,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	for nick := range s.trades {
+		t, other, ok := s.tradeOf(nick)
+		if !ok {
+			continue
+		}
+		a, aOnline := s.OnlineClientByNick(nick)
+		b, bOnline := s.OnlineClientByNick(other)
+		if aOnline && bOnline && a.Player.Area == b.Player.Area && a.Player.Room == b.Player.Room {
+			continue
+		}
+		s.endTrade(t)
+
+		for _, pair := range []struct {
+			c      client.Client
+			online bool
+			other  string
+		}{{a, aOnline, other}, {b, bOnline, nick}} {
+			if !pair.online {
+				continue
+			}
+			wg.Add(1)
+			godPrintRoom(s, pair.c, []client.Client{pair.c}, wg, quit, roomsMap,
+				fmt.Sprintf("%s is no longer here, so the trade is cancelled.", pair.other), "")
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// newTradeTestServer returns a loaded test server with Alice carrying a
+// sword and 10 gold and Bob carrying arrows and 5 gold, both in the square
+// and trading with each other.
+func newTradeTestServer(t *testing.T) (*Server, client.Client, client.Client) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	alice.Player.Inventory, alice.Player.Gold = []game.Item{testSword}, 10
+	bob.Player.Inventory, bob.Player.Gold = []game.Item{arrowStack(20)}, 5
+
+	if got := doTrade(s, alice, []string{"Bob"}).Actor; !strings.HasPrefix(got, "You start trading with Bob.") {
+		t.Fatalf("trade: got %q", got)
+	}
+	return s, alice, bob
+}
+
+// carrying describes the items and the gold the player carries.
+func carrying(c client.Client) string {
+	return fmt.Sprintf("%s and %d gold", strings.Join(itemNames(c.Player.Inventory), ","), c.Player.Gold)
+}
+
+func TestTradeSwap(t *testing.T) {
+	s, alice, bob := newTradeTestServer(t)
+
+	steps := []struct {
+		c    client.Client
+		args []string
+		want string
+	}{
+		{c: alice, args: []string{"add", "sword"}, want: "You offer Short Sword."},
+		{c: alice, args: []string{"add", "sword"}, want: "You have no more sword to offer."},
+		{c: alice, args: []string{"add", "20", "gold"}, want: "You only have 10 more gold to offer."},
+		{c: alice, args: []string{"add", "4", "gold"}, want: "You offer 4 gold."},
+		{c: bob, args: []string{"add", "15", "arrows"}, want: "You offer 15 Arrows."},
+		{c: alice, args: []string{"accept"}, want: "You accept the trade. Waiting for Bob to accept it too."},
+		{c: bob, args: nil, want: "You are trading with Alice.\nYou offer: 15 Arrows\nAlice offers: Short Sword, 4 gold (accepted)"},
+		{c: bob, args: []string{"accept"}, want: "You trade 15 Arrows for Short Sword, 4 gold with Alice."},
+	}
+	for _, step := range steps {
+		if got := doTrade(s, step.c, step.args).Actor; got != step.want {
+			t.Errorf("%s trade %v: got %q, want %q", step.c.Player.Nickname, step.args, got, step.want)
+		}
+	}
+
+	if got := carrying(alice); got != "15 Arrows and 6 gold" {
+		t.Errorf("Alice carries %s", got)
+	}
+	if got := carrying(bob); got != "5 Arrows,Short Sword and 9 gold" {
+		t.Errorf("Bob carries %s", got)
+	}
+	if len(s.trades) != 0 {
+		t.Errorf("the trade is still open: %v", s.trades)
+	}
+}
+
+func TestTradeChangeTakesBackAcceptance(t *testing.T) {
+	s, alice, bob := newTradeTestServer(t)
+
+	doTrade(s, alice, []string{"add", "sword"})
+	doTrade(s, alice, []string{"accept"})
+	doTrade(s, bob, []string{"add", "1", "gold"})
+	if got := doTrade(s, alice, []string{"accept"}).Actor; got != "You accept the trade. Waiting for Bob to accept it too." {
+		t.Errorf("got %q", got)
+	}
+	if got := carrying(alice); got != "Short Sword and 10 gold" {
+		t.Errorf("Alice carries %s", got)
+	}
+}
+
+func TestTradeIsAtomic(t *testing.T) {
+	s, alice, bob := newTradeTestServer(t)
+
+	doTrade(s, alice, []string{"add", "sword"})
+	doTrade(s, bob, []string{"add", "all", "arrows"})
+	doTrade(s, bob, []string{"add", "5", "gold"})
+	doTrade(s, alice, []string{"accept"})
+	// Bob spends some of the gold he offered before accepting.
+	bob.Player.Gold = 2
+
+	got := doTrade(s, bob, []string{"accept"})
+	if want := "The trade is cancelled, Bob no longer has what they offered."; got.Actor != want || len(got.Targets) != 1 || got.Targets[0].Msg != want {
+		t.Errorf("got %+v", got)
+	}
+	if got := carrying(alice); got != "Short Sword and 10 gold" {
+		t.Errorf("Alice carries %s", got)
+	}
+	if got := carrying(bob); got != "20 Arrows and 2 gold" {
+		t.Errorf("Bob carries %s", got)
+	}
+	if len(s.trades) != 0 {
+		t.Errorf("the trade is still open: %v", s.trades)
+	}
+}
+
+func TestTradeCapacity(t *testing.T) {
+	s, alice, bob := newTradeTestServer(t)
+	s.Config.MaxInventory = 1
+
+	doTrade(s, alice, []string{"add", "sword"})
+	doTrade(s, bob, []string{"add", "1", "arrow"})
+	doTrade(s, alice, []string{"accept"})
+	if got := doTrade(s, bob, []string{"accept"}).Actor; got != "The trade is cancelled, Bob cannot carry that much." {
+		t.Errorf("got %q", got)
+	}
+	if got := carrying(alice); got != "Short Sword and 10 gold" {
+		t.Errorf("Alice carries %s", got)
+	}
+	if got := carrying(bob); got != "20 Arrows and 5 gold" {
+		t.Errorf("Bob carries %s", got)
+	}
+}
+
+func TestTradeStart(t *testing.T) {
+	s, alice, _ := newTradeTestServer(t)
+	carol := addTestPlayer(t, s, "Carol", "Town", "Square", "3")
+	dave := addTestPlayer(t, s, "Dave", "Town", "Inn", "1")
+
+	tests := []struct {
+		c    client.Client
+		args []string
+		want string
+	}{
+		{c: carol, args: []string{"Carol"}, want: "You cannot trade with yourself."},
+		{c: carol, args: []string{"Alice"}, want: "Alice is already trading with somebody else."},
+		{c: carol, args: []string{"Dave"}, want: "Dave is not here."},
+		{c: carol, args: []string{"Erin"}, want: "Erin is not here."},
+		{c: carol, args: nil, want: "Usage: trade <nick>"},
+		{c: dave, args: []string{"accept"}, want: "You are not trading with anybody."},
+		{c: alice, args: []string{"Carol"}, want: "Usage: trade [add [all|<count>] <item> | add <amount> gold | accept | cancel]"},
+	}
+	for _, test := range tests {
+		if got := doTrade(s, test.c, test.args).Actor; got != test.want {
+			t.Errorf("%s trade %v: got %q, want %q", test.c.Player.Nickname, test.args, got, test.want)
+		}
+	}
+}
+
+func TestTradeCancel(t *testing.T) {
+	s, alice, bob := newTradeTestServer(t)
+
+	doTrade(s, alice, []string{"add", "sword"})
+	got := doTrade(s, bob, []string{"cancel"})
+	if got.Actor != "You cancel the trade with Alice." || len(got.Targets) != 1 || got.Targets[0].Msg != "Bob cancels the trade." {
+		t.Errorf("got %+v", got)
+	}
+	if len(s.trades) != 0 {
+		t.Errorf("the trade is still open: %v", s.trades)
+	}
+	if got := carrying(alice); got != "Short Sword and 10 gold" {
+		t.Errorf("Alice carries %s", got)
+	}
+}
+
+func TestTradeCancelledWhenPartnerLeaves(t *testing.T) {
+	s, alice, bob := newTradeTestServer(t)
+
+	doTrade(s, alice, []string{"add", "sword"})
+	movePlayer(bob.Player, "Town", "Inn", "1")
+	if got := doTrade(s, alice, []string{"accept"}).Actor; got != "Bob is no longer here, so the trade is cancelled." {
+		t.Errorf("got %q", got)
+	}
+	if len(s.trades) != 0 {
+		t.Errorf("the trade is still open: %v", s.trades)
+	}
+}
+
+func TestGodCancelTrades(t *testing.T) {
+	s, alice, bob := newTradeTestServer(t)
+	carol := addTestPlayer(t, s, "Carol", "Town", "Square", "3")
+	dave := addTestPlayer(t, s, "Dave", "Town", "Square", "4")
+	doTrade(s, carol, []string{"Dave"})
+
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer close(quit)
+	roomsMap := createRoomsMap(s)
+
+	// Trades between players still together are left alone.
+	godCancelTrades(s, &wg, quit, roomsMap)
+	wg.Wait()
+	if len(s.trades) != 4 {
+		t.Fatalf("got %d players trading, want 4", len(s.trades))
+	}
+
+	// Logging out cancels the trade, and so does walking away.
+	s.clientLoggedOut(bob.Player.Nickname)
+	movePlayer(dave.Player, "Town", "Inn", "1")
+	godCancelTrades(s, &wg, quit, roomsMap)
+	wg.Wait()
+	if len(s.trades) != 0 {
+		t.Errorf("trades are still open: %v", s.trades)
+	}
+	if _, _, ok := s.tradeOf(alice.Player.Nickname); ok {
+		t.Error("Alice is still trading")
+	}
+}
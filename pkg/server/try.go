@@ -0,0 +1,211 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// preview tells what an event would change without changing anything.
+type preview func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string
+
+// previews holds the events that can be tried, by event.
+var previews = map[string]preview{
+	"move_east":  previewMove(0),
+	"move_west":  previewMove(1),
+	"move_north": previewMove(2),
+	"move_south": previewMove(3),
+	"goto": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		_, to, ok, msg := planGoto(s, c, args)
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would go to %s/%s at %s.", to.area, to.room, to.position)
+	},
+	"summon": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		target, to, ok, msg := planSummon(s, c, args)
+		if !ok {
+			return msg
+		}
+		t := target.Player
+		return fmt.Sprintf("%s would move from %s/%s at %s to %s/%s at %s.",
+			t.Nickname, t.Area, t.Room, t.Position, to.area, to.room, to.position)
+	},
+	"recall": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		target, ok, msg := planRecall(s, c, time.Now())
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would recall to %s/%s at %s.", target.area, target.room, target.position)
+	},
+	"attack": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		target, ok, msg := planAttack(s, c, args, time.Now())
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would fight %s.\n%s\n%s",
+			target.Player.Nickname, attackOdds(c, target), attackOdds(target, c))
+	},
+	"dig": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		if len(args) != 2 {
+			return "Usage: dig <east|west|north|south> <roomname>"
+		}
+		dir, ok := directionIndex(args[0])
+		if !ok {
+			return fmt.Sprintf("%s is not a direction.", args[0])
+		}
+		// The room is dug in a copy of the area.
+		a := s.Areas[c.Player.Area]
+		rooms := make(map[string]area.Room, len(a.Rooms))
+		for name, room := range a.Rooms {
+			rooms[name] = room
+		}
+		a.Rooms = rooms
+		if ok, msg := digRoom(a, c.Player.Room, c.Player.Position, dir, args[1]); !ok {
+			return msg
+		}
+		door := a.Rooms[c.Player.Room].Cubes[len(a.Rooms[c.Player.Room].Cubes)-1]
+		return fmt.Sprintf("A door would be put at %s,%s leading %s to a new room called %s.",
+			door.POSX, door.POSY, directionNames[dir], args[1])
+	},
+	"setdesc": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		if len(args) == 0 {
+			return "Usage: setdesc <text>"
+		}
+		return fmt.Sprintf("The description of %s would change from:\n%s\nto:\n%s",
+			c.Player.Room, strings.TrimSuffix(s.Areas[c.Player.Area].Rooms[c.Player.Room].Description, "\n"), strings.Join(args, " "))
+	},
+	"get": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		if len(args) == 1 && args[0] == "corpse" {
+			return "get corpse cannot be tried."
+		}
+		plan, ok, msg := planGet(s, c, args)
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would pick up %s, leaving %s here.",
+			strings.Join(itemNames(plan.taken), ", "), listOrNone(itemNames(plan.ground)))
+	},
+	"drop": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		plan, ok, msg := planDrop(c, args)
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would drop %s, still carrying %s.",
+			strings.Join(itemNames(plan.dropped), ", "), listOrNone(itemNames(plan.inventory)))
+	},
+	"buy": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		plan, ok, msg := planBuy(s, c, args)
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would buy %s from %s for %d gold, leaving you %d gold.",
+			describeCount(plan.item, plan.count), plan.vendor.Name, plan.price, c.Player.Gold-plan.price)
+	},
+	"sell": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		plan, ok, msg := planSell(s, c, args)
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would sell %s to %s for %d gold, giving you %d gold.",
+			strings.Join(itemNames(plan.sold), ", "), plan.vendor.Name, plan.price, c.Player.Gold+plan.price)
+	},
+	"deposit": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		plan, ok, msg := planDeposit(s, c, args)
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would deposit %s with %s.", plan.describe(), plan.banker)
+	},
+	"withdraw": func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		plan, ok, msg := planWithdraw(s, c, args)
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would withdraw %s from %s.", plan.describe(), plan.banker)
+	},
+	"trade": previewTrade,
+}
+
+// previewMove returns the preview of moving in the given direction.
+func previewMove(direction int) preview {
+	return func(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+		target, ok, msg := planMove(s, c, roomsMap, direction)
+		if !ok {
+			return msg
+		}
+		via := ""
+		if target.door {
+			via = " through a door"
+		}
+		return fmt.Sprintf("You would move %s%s to %s/%s at %s.", directionNames[direction], via, target.area, target.room, target.position)
+	}
+}
+
+// previewTrade tells what adding to or accepting the trade the player is in
+// would change.
+func previewTrade(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+	me := c.Player.Nickname
+	t, them, trading := s.tradeOf(me)
+	if !trading {
+		return "You are not trading with anybody."
+	}
+	partner, ok := s.tradePartner(c.Player, them)
+	if !ok {
+		return fmt.Sprintf("%s is no longer here, so the trade would be cancelled.", them)
+	}
+	mine, theirs := t.offers[me], t.offers[them]
+
+	switch {
+	case len(args) > 0 && args[0] == "add":
+		// What is added goes to a copy of the offer.
+		next := *mine
+		msg, ok := addToOffer(c, &next, args[1:])
+		if !ok {
+			return msg
+		}
+		return fmt.Sprintf("You would offer %s, for %s in all.", msg, next.describe())
+	case len(args) == 1 && args[0] == "accept":
+		if !theirs.accepted {
+			return fmt.Sprintf("You would accept the trade and wait for %s to accept it too.", them)
+		}
+		if _, err := planSwap(s, c.Player, partner.Player, mine, theirs); err != nil {
+			return fmt.Sprintf("The trade would be cancelled, %v.", err)
+		}
+		return fmt.Sprintf("You would trade %s for %s with %s.", mine.describe(), theirs.describe(), them)
+	}
+	return "Only trade add and trade accept can be tried."
+}
+
+// attackOdds describes how likely the attacks of one player against another
+// are to hit, and how hard they hit.
+func attackOdds(attacker, defender client.Client) string {
+	chance, min, max := game.AttackOdds(&attacker.Player.PC, &defender.Player.PC)
+	if chance == 0 {
+		return fmt.Sprintf("%s cannot hit %s.", attacker.Player.Nickname, defender.Player.Nickname)
+	}
+	return fmt.Sprintf("%s hits %s %d%% of the time for %d-%d HP, with %d HP left.",
+		attacker.Player.Nickname, defender.Player.Nickname, chance, min, max, defender.Player.HP)
+}
+
+// doTry tells the admin what the command given in args would change, without
+// changing anything.
+func doTry(s *Server, c client.Client, args []string, roomsMap map[string]map[string][][]area.Cube) string {
+	if len(args) == 0 {
+		return "Usage: try <command> [args]"
+	}
+
+	etype, _ := s.matchCommand(c, args[0])
+	if etype == "" {
+		return fmt.Sprintf("%s is not a command you can use.", args[0])
+	}
+	run, ok := previews[etype]
+	if !ok {
+		return fmt.Sprintf("%s cannot be tried.", args[0])
+	}
+	return "[try] " + run(s, c, args[1:], roomsMap)
+}
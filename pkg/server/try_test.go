@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/game"
+)
+
+// worldState returns what commands can change of the world and of the given
+// players, to tell whether anything changed.
+func worldState(s *Server, clients ...client.Client) string {
+	state := fmt.Sprintf("%v %v %v %v %v", s.Areas, s.combats, s.editors, s.buildHistories, s.ground)
+	for _, c := range clients {
+		p := c.Player
+		state += fmt.Sprintf(" %s@%s HP%d %v carrying %v and %d gold, keeping %v and %d gold",
+			p.Nickname, place(p), p.HP, p.Cooldowns, itemNames(p.Inventory), p.Gold, itemNames(p.Bank.Items), p.Bank.Gold)
+		if t, _, ok := s.tradeOf(p.Nickname); ok {
+			o := t.offers[p.Nickname]
+			state += fmt.Sprintf(" offering %s%s", o.describe(), acceptedMark(o))
+		}
+	}
+	return state
+}
+
+func TestTry(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.pvp = pvpOn
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "2")
+	alice.Player.SetPermissionLevel(area.PermissionAdmin)
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "4")
+	addTestPlayer(t, s, "Carol", "Town", "Inn", "3")
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"e"}, want: "[try] You would move east through a door to Town/Inn at 1."},
+		{args: []string{"w"}, want: "[try] You would move west to Town/Square at 1."},
+		{args: []string{"goto", "Carol"}, want: "[try] You would go to Town/Inn at "},
+		{args: []string{"summon", "Carol"}, want: "[try] Carol would move from Town/Inn at 3 to Town/Square at "},
+		{args: []string{"attack", "Bob"}, want: "[try] You would fight Bob.\n"},
+		{args: []string{"dig", "s", "Cellar"}, want: "[try] A door would be put at 1,1 leading south to a new room called Cellar."},
+		{args: []string{"setdesc", "A", "busy", "square."}, want: "[try] The description of Square would change from:\nThe town square.\nto:\nA busy square."},
+		{args: []string{"look"}, want: "look cannot be tried."},
+		{args: []string{"xyzzy"}, want: "xyzzy is not a command you can use."},
+		{want: "Usage: try <command> [args]"},
+	}
+	for _, test := range tests {
+		before := worldState(s, alice, bob)
+		replies := handle(t, s, client.Event{Client: &alice, Etype: "try", Cmd: "try", Args: test.args}, alice, bob)
+		if got := replies[0].Events; !strings.Contains(got, test.want) {
+			t.Errorf("try %v: got %q, want %q", test.args, got, test.want)
+		}
+		if replies[1].Events != "" {
+			t.Errorf("try %v: Bob read %q", test.args, replies[1].Events)
+		}
+		if after := worldState(s, alice, bob); after != before {
+			t.Errorf("try %v changed\n%s\nto\n%s", test.args, before, after)
+		}
+	}
+
+	if s.canUse(bob, "try") {
+		t.Error("players can try commands")
+	}
+}
+
+func TestTryItemTransfers(t *testing.T) {
+	s, alice := newShopTestServer(t)
+	alice.Player.SetPermissionLevel(area.PermissionAdmin)
+	square := s.Areas["Town"].Rooms["Square"]
+	square.NPCs = append(square.NPCs, area.NPC{Name: "Banker", Banker: true})
+	s.Areas["Town"].Rooms["Square"] = square
+	arrows := func(count int) game.Item {
+		item := s.items["arrow"]
+		item.Quantity = count
+		return item
+	}
+	s.setItemsIn("Town", "Square", []game.Item{arrows(5)})
+	alice.Player.Inventory, alice.Player.Gold = []game.Item{testSword, arrows(3)}, 10
+	alice.Player.Bank.Items, alice.Player.Bank.Gold = []game.Item{arrows(2)}, 7
+
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	bob.Player.Gold = 5
+	doTrade(s, alice, []string{"Bob"})
+	doTrade(s, bob, []string{"add", "5", "gold"})
+	doTrade(s, bob, []string{"accept"})
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"get", "2", "arrows"}, want: "[try] You would pick up 2 Arrows, leaving 3 Arrows here."},
+		{args: []string{"get", "rope"}, want: "[try] There is no rope here."},
+		{args: []string{"get", "corpse"}, want: "[try] get corpse cannot be tried."},
+		{args: []string{"drop", "sword"}, want: "[try] You would drop Short Sword, still carrying 3 Arrows."},
+		{args: []string{"buy", "dagger"}, want: "[try] You would buy Dagger from Merchant for 2 gold, leaving you 8 gold."},
+		{args: []string{"buy", "6", "daggers"}, want: "[try] You cannot afford 6 Daggers with 10 gold."},
+		{args: []string{"sell", "2", "arrows"}, want: "[try] You would sell 2 Arrows to Merchant for 1 gold, giving you 11 gold."},
+		{args: []string{"deposit", "4", "gold"}, want: "[try] You would deposit 4 gold with Banker."},
+		{args: []string{"withdraw", "all", "arrows"}, want: "[try] You would withdraw 2 Arrows from Banker."},
+		{args: []string{"withdraw", "8", "gold"}, want: "[try] You only have 7 gold in the bank."},
+		{args: []string{"trade", "add", "3", "gold"}, want: "[try] You would offer 3 gold, for 3 gold in all."},
+		{args: []string{"trade", "accept"}, want: "[try] You would trade none for 5 gold with Bob."},
+		{args: []string{"trade", "cancel"}, want: "[try] Only trade add and trade accept can be tried."},
+	}
+	for _, test := range tests {
+		before := worldState(s, alice, bob)
+		replies := handle(t, s, client.Event{Client: &alice, Etype: "try", Cmd: "try", Args: test.args}, alice, bob)
+		if got := replies[0].Events; !strings.Contains(got, test.want) {
+			t.Errorf("try %v: got %q, want %q", test.args, got, test.want)
+		}
+		if after := worldState(s, alice, bob); after != before {
+			t.Errorf("try %v changed\n%s\nto\n%s", test.args, before, after)
+		}
+	}
+}
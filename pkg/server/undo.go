@@ -0,0 +1,220 @@
+package server
+
+import (
+	"fmt"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/client"
+)
+
+// maxBuildHistory is how many edits every builder can undo.
+const maxBuildHistory = 20
+
+// Fields of rooms builders edit.
+const (
+	// fieldDescription holds the description of the room, as a string.
+	fieldDescription = "description"
+	// fieldCubes holds the cubes of the room, along with their exits, as a
+	// []area.Cube.
+	fieldCubes = "cubes"
+	// fieldRoom holds the whole room as an *area.Room, which is nil when
+	// the room does not exist.
+	fieldRoom = "room"
+)
+
+// roomChange is a change to one field of a room, with the value of the field
+// before and after the change.
+type roomChange struct {
+	area, room    string
+	field         string
+	before, after interface{}
+}
+
+// buildEdit is what a builder changed with a single command.
+type buildEdit []roomChange
+
+// buildHistory holds the edits of a builder, most recent last.
+type buildHistory struct {
+	undo []buildEdit
+	redo []buildEdit
+}
+
+// push adds an edit that can be undone, forgetting the edits that were
+// undone and the oldest edits beyond maxBuildHistory.
+func (h *buildHistory) push(edit buildEdit) {
+	h.undo = append(h.undo, edit)
+	if len(h.undo) > maxBuildHistory {
+		h.undo = h.undo[len(h.undo)-maxBuildHistory:]
+	}
+	h.redo = nil
+}
+
+// forget drops the edits made in the given area.
+func (h *buildHistory) forget(areaName string) {
+	h.undo = editsOutside(h.undo, areaName)
+	h.redo = editsOutside(h.redo, areaName)
+}
+
+// editsOutside returns the edits that changed nothing in the given area.
+func editsOutside(edits []buildEdit, areaName string) []buildEdit {
+	var kept []buildEdit
+	for _, edit := range edits {
+		if edit[0].area != areaName {
+			kept = append(kept, edit)
+		}
+	}
+	return kept
+}
+
+// copyCubes returns a copy of the cubes that later edits to the cubes cannot
+// change.
+func copyCubes(cubes []area.Cube) []area.Cube {
+	return append([]area.Cube(nil), cubes...)
+}
+
+// forgetBuilding forgets who is building which area and all the edits that
+// can be undone, for when the areas are replaced.
+func (s *Server) forgetBuilding() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.editors = make(map[string]string)
+	s.buildHistories = make(map[string]*buildHistory)
+}
+
+// recordEdit adds an edit the builder can undo.
+func (s *Server) recordEdit(nick string, edit buildEdit) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.buildHistories == nil {
+		s.buildHistories = make(map[string]*buildHistory)
+	}
+	h, ok := s.buildHistories[nickKey(nick)]
+	if !ok {
+		h = &buildHistory{}
+		s.buildHistories[nickKey(nick)] = h
+	}
+	h.push(edit)
+}
+
+// setField sets the field of the room the change is about to the given value,
+// as it was before or after the change.
+func setField(areas map[string]area.Area, change roomChange, value interface{}) {
+	a, ok := areas[change.area]
+	if !ok {
+		return
+	}
+	if change.field == fieldRoom {
+		if room := value.(*area.Room); room != nil {
+			a.Rooms[change.room] = *room
+		} else {
+			delete(a.Rooms, change.room)
+		}
+		return
+	}
+
+	room, ok := a.Rooms[change.room]
+	if !ok {
+		return
+	}
+	switch change.field {
+	case fieldDescription:
+		room.Description = value.(string)
+	case fieldCubes:
+		room.Cubes = copyCubes(value.([]area.Cube))
+	}
+	a.Rooms[change.room] = room
+}
+
+// removedRooms returns the rooms the edit removes when undone, or redone if
+// redo is true.
+func removedRooms(edit buildEdit, redo bool) []roomChange {
+	var removed []roomChange
+	for _, change := range edit {
+		if change.field != fieldRoom {
+			continue
+		}
+		value := change.before
+		if redo {
+			value = change.after
+		}
+		if value.(*area.Room) == nil {
+			removed = append(removed, change)
+		}
+	}
+	return removed
+}
+
+// doUndo undoes the last edit of the builder, or redoes the last edit undone
+// if redo is true. Edits removing rooms players are in are refused. It
+// returns the edit along with what to tell the builder.
+func doUndo(s *Server, c client.Client, redo bool, roomsMap map[string]map[string][][]area.Cube) (buildEdit, string) {
+	cmd := "undo"
+	if redo {
+		cmd = "redo"
+	}
+
+	s.RLock()
+	var edit buildEdit
+	if h, ok := s.buildHistories[nickKey(c.Player.Nickname)]; ok {
+		stack := h.undo
+		if redo {
+			stack = h.redo
+		}
+		if len(stack) > 0 {
+			edit = stack[len(stack)-1]
+		}
+	}
+	s.RUnlock()
+	if edit == nil {
+		return nil, fmt.Sprintf("There is nothing to %s.", cmd)
+	}
+
+	if editor, ok := s.claimArea(edit[0].area, c.Player.Nickname); !ok {
+		return nil, fmt.Sprintf("%s is building this area.", editor)
+	}
+	for _, change := range removedRooms(edit, redo) {
+		if len(s.OnlineClientsGetByRoom(change.area, change.room)) > 0 {
+			return nil, fmt.Sprintf("Somebody is in %s, which would be gone.", change.room)
+		}
+	}
+
+	// Undoing goes through the changes backwards.
+	for i := range edit {
+		change := edit[len(edit)-1-i]
+		value := change.before
+		if redo {
+			change = edit[i]
+			value = change.after
+		}
+		s.Lock()
+		setField(s.Areas, change, value)
+		s.Unlock()
+		if _, ok := s.Areas[change.area].Rooms[change.room]; ok {
+			roomsMap[change.area][change.room] = s.CreateRoom(change.area, change.room)
+		} else {
+			delete(roomsMap[change.area], change.room)
+		}
+	}
+
+	s.Lock()
+	h := s.buildHistories[nickKey(c.Player.Nickname)]
+	if redo {
+		h.redo = h.redo[:len(h.redo)-1]
+		h.undo = append(h.undo, edit)
+	} else {
+		h.undo = h.undo[:len(h.undo)-1]
+		h.redo = append(h.redo, edit)
+	}
+	s.Unlock()
+
+	if redo {
+		log.Info(fmt.Sprintf("%s redid an edit in %s", c.Player.Nickname, edit[0].area))
+		return edit, "Edit redone."
+	}
+	log.Info(fmt.Sprintf("%s undid an edit in %s", c.Player.Nickname, edit[0].area))
+	return edit, "Edit undone."
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestBuildHistory(t *testing.T) {
+	h := &buildHistory{}
+	for i := 0; i < maxBuildHistory+5; i++ {
+		h.push(buildEdit{{area: "Town", room: "Square", field: fieldDescription, after: fmt.Sprint(i)}})
+	}
+	if len(h.undo) != maxBuildHistory {
+		t.Fatalf("%d edits can be undone, want %d", len(h.undo), maxBuildHistory)
+	}
+	if got := h.undo[0][0].after; got != "5" {
+		t.Errorf("the oldest edit kept is %v, want 5", got)
+	}
+
+	h.redo = []buildEdit{{{area: "Town", room: "Inn", field: fieldDescription}}}
+	h.push(buildEdit{{area: "Cave", room: "Tunnel", field: fieldDescription}})
+	if len(h.redo) != 0 {
+		t.Error("a new edit left edits to redo")
+	}
+
+	h.redo = []buildEdit{{{area: "Town", room: "Inn", field: fieldDescription}}}
+	h.forget("Town")
+	if len(h.undo) != 1 || h.undo[0][0].area != "Cave" || len(h.redo) != 0 {
+		t.Errorf("forgetting Town left %+v to undo and %+v to redo", h.undo, h.redo)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	s := newLoadedTestServer(t)
+	roomsMap := createRoomsMap(s)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "4")
+	alice.Player.SetPermissionLevel(area.PermissionBuilder)
+	bob := addTestPlayer(t, s, "Bob", "Town", "Inn", "1")
+	bob.Player.SetPermissionLevel(area.PermissionBuilder)
+	square := s.Areas["Town"].Rooms["Square"]
+
+	steps := []struct {
+		do     func() string
+		want   string
+		desc   string
+		cellar bool
+	}{
+		{do: func() string { return doSetDesc(s, alice, []string{"A", "busy", "square."}) }, want: "Description changed.", desc: "A busy square.\n"},
+		{do: func() string { return doDig(s, alice, []string{"s", "Cellar"}, roomsMap) }, want: "You dig south to Cellar.", desc: "A busy square.\n", cellar: true},
+		// Edits are undone last first.
+		{do: func() string { _, msg := doUndo(s, alice, false, roomsMap); return msg }, want: "Edit undone.", desc: "A busy square.\n"},
+		{do: func() string { _, msg := doUndo(s, alice, false, roomsMap); return msg }, want: "Edit undone.", desc: square.Description},
+		{do: func() string { _, msg := doUndo(s, alice, false, roomsMap); return msg }, want: "There is nothing to undo.", desc: square.Description},
+		// And redone first first.
+		{do: func() string { _, msg := doUndo(s, alice, true, roomsMap); return msg }, want: "Edit redone.", desc: "A busy square.\n"},
+		{do: func() string { _, msg := doUndo(s, alice, true, roomsMap); return msg }, want: "Edit redone.", desc: "A busy square.\n", cellar: true},
+		{do: func() string { _, msg := doUndo(s, alice, true, roomsMap); return msg }, want: "There is nothing to redo.", desc: "A busy square.\n", cellar: true},
+		// Every builder has a history of their own.
+		{do: func() string { _, msg := doUndo(s, bob, false, roomsMap); return msg }, want: "There is nothing to undo.", desc: "A busy square.\n", cellar: true},
+		// A new edit forgets the edits undone.
+		{do: func() string { _, msg := doUndo(s, alice, false, roomsMap); return msg }, want: "Edit undone.", desc: "A busy square.\n"},
+		{do: func() string { return doSetDesc(s, alice, []string{"A", "quiet", "square."}) }, want: "Description changed.", desc: "A quiet square.\n"},
+		{do: func() string { _, msg := doUndo(s, alice, true, roomsMap); return msg }, want: "There is nothing to redo.", desc: "A quiet square.\n"},
+	}
+	for i, step := range steps {
+		if got := step.do(); got != step.want {
+			t.Errorf("step %d: got %q, want %q", i, got, step.want)
+		}
+		room := s.Areas["Town"].Rooms["Square"]
+		if room.Description != step.desc {
+			t.Errorf("step %d: the square reads %q, want %q", i, room.Description, step.desc)
+		}
+		_, cellar := s.Areas["Town"].Rooms["Cellar"]
+		_, door := cubeAt(room, 0, 2)
+		_, drawn := roomsMap["Town"]["Cellar"]
+		if cellar != step.cellar || door != step.cellar || drawn != step.cellar {
+			t.Errorf("step %d: cellar %v, door %v, drawn %v, want %v", i, cellar, door, drawn, step.cellar)
+		}
+	}
+}
+
+func TestUndoOccupiedRoom(t *testing.T) {
+	s := newLoadedTestServer(t)
+	roomsMap := createRoomsMap(s)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "4")
+	alice.Player.SetPermissionLevel(area.PermissionBuilder)
+
+	doDig(s, alice, []string{"s", "Cellar"}, roomsMap)
+	addTestPlayer(t, s, "Bob", "Town", "Cellar", "1")
+	if _, msg := doUndo(s, alice, false, roomsMap); msg != "Somebody is in Cellar, which would be gone." {
+		t.Errorf("got %q", msg)
+	}
+	if _, ok := s.Areas["Town"].Rooms["Cellar"]; !ok {
+		t.Error("the cellar is gone with Bob in it")
+	}
+
+	// Saving the area forgets what could be undone in it.
+	if msg := doSaveArea(s, alice); msg != "Area Town saved." {
+		t.Fatalf("got %q", msg)
+	}
+	if _, msg := doUndo(s, alice, false, roomsMap); msg != "There is nothing to undo." {
+		t.Errorf("got %q after saving", msg)
+	}
+}
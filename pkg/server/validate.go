@@ -0,0 +1,208 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// Validate loads all areas from the static directory and returns every
+// problem found with them. It does not start the server, so it can be used
+// to check content before deploying it.
+func (s *Server) Validate() []error {
+	if err := s.checkDirs(s.areasDir()); err != nil {
+		return []error{err}
+	}
+
+	if err := s.loadAreas(); err != nil {
+		return []error{err}
+	}
+
+	if err := s.loadSpells(); err != nil {
+		return []error{err}
+	}
+
+	return s.validateAreas()
+}
+
+// validateAreas runs all the checks on the loaded areas and returns the
+// problems found, as ValidationErrors.
+func (s *Server) validateAreas() []error {
+	var problems []error
+
+	if a, room, pos := s.startLocation(); !s.locationExists(a, room, pos) {
+		problems = append(problems, fmt.Errorf("start location %s/%s/%s does not exist", a, room, pos))
+	}
+
+	problems = append(problems, s.danglingExits()...)
+	problems = append(problems, s.missingSpawns()...)
+	problems = append(problems, s.badDarkness()...)
+	problems = append(problems, s.unknownWeather()...)
+	problems = append(problems, s.invalidItems()...)
+	problems = append(problems, s.danglingNPCs()...)
+	problems = append(problems, s.invalidSpawns()...)
+	problems = append(problems, s.danglingDialogue()...)
+	problems = append(problems, s.danglingItems()...)
+	problems = append(problems, s.danglingWares()...)
+	problems = append(problems, s.danglingKitItems()...)
+
+	if s.Config.WarnOneWayExits {
+		for _, warning := range s.oneWayExits() {
+			log.Warn(warning.Error())
+		}
+	}
+
+	for i, problem := range problems {
+		problems[i] = &ValidationError{Err: problem}
+	}
+	return problems
+}
+
+// danglingExits returns a problem for every door that has no exit or has an
+// exit leading to a cube that does not exist.
+func (s *Server) danglingExits() []error {
+	var problems []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			for _, cube := range a.Rooms[roomName].Cubes {
+				if cube.Type == "door" && len(cube.Exits) == 0 {
+					problems = append(problems, fmt.Errorf("area %q room %q: door %s has no exits", areaName, roomName, cube.ID))
+				}
+				for _, exit := range cube.Exits {
+					if !s.locationExists(exit.ToArea, exit.ToRoom, exit.ToCubeID) {
+						problems = append(problems, fmt.Errorf("area %q room %q: cube %s exits to %s/%s/%s which does not exist",
+							areaName, roomName, cube.ID, exit.ToArea, exit.ToRoom, exit.ToCubeID))
+					}
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// oneWayExits returns a warning for every exit leading to a room that has no
+// exit back to the room the exit is in.
+func (s *Server) oneWayExits() []error {
+	var warnings []error
+
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		a := s.Areas[areaName]
+		for _, roomName := range sortedRoomNames(a.Rooms) {
+			for _, cube := range a.Rooms[roomName].Cubes {
+				for _, exit := range cube.Exits {
+					if !s.hasExitTo(exit.ToArea, exit.ToRoom, areaName, roomName) {
+						warnings = append(warnings, fmt.Errorf("area %q room %q: exit from cube %s to %s/%s has no way back",
+							areaName, roomName, cube.ID, exit.ToArea, exit.ToRoom))
+					}
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// hasExitTo returns true if any cube in the given room has an exit to the
+// target room.
+func (s *Server) hasExitTo(areaName, roomName, toArea, toRoom string) bool {
+	room, ok := s.Areas[areaName].Rooms[roomName]
+	if !ok {
+		return false
+	}
+
+	for _, cube := range room.Cubes {
+		for _, exit := range cube.Exits {
+			if exit.ToArea == toArea && exit.ToRoom == toRoom {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func sortedAreaNames(areas map[string]area.Area) []string {
+	names := make([]string, 0, len(areas))
+	for name := range areas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedRoomNames(rooms map[string]area.Room) []string {
+	names := make([]string, 0, len(rooms))
+	for name := range rooms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedCubes returns the cubes sorted by ID, numeric IDs first and in
+// numeric order.
+func sortedCubes(cubes []area.Cube) []area.Cube {
+	sorted := append([]area.Cube(nil), cubes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, errA := strconv.Atoi(sorted[i].ID)
+		b, errB := strconv.Atoi(sorted[j].ID)
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil || errB == nil:
+			return errA == nil
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// locationExists returns true if the given cube exists in the given room and area.
+func (s *Server) locationExists(areaName, roomName, cubeID string) bool {
+	a, ok := s.Areas[areaName]
+	if !ok {
+		return false
+	}
+
+	room, ok := a.Rooms[roomName]
+	if !ok {
+		return false
+	}
+
+	for _, cube := range room.Cubes {
+		if cube.ID == cubeID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// duplicateCubes returns a problem for every cube in the given area that
+// shares its position with another cube in the same room. Rooms are checked
+// by name and cubes by ID, so problems are always reported in the same
+// order.
+func duplicateCubes(a area.Area) []error {
+	var problems []error
+
+	for _, roomName := range sortedRoomNames(a.Rooms) {
+		positions := make(map[string]string)
+		for _, cube := range sortedCubes(a.Rooms[roomName].Cubes) {
+			pos := fmt.Sprintf("(%s,%s)", cube.POSX, cube.POSY)
+			if id, ok := positions[pos]; ok {
+				problems = append(problems, fmt.Errorf("area %q room %q: cubes %s and %s are both at %s", a.Name, roomName, id, cube.ID, pos))
+				continue
+			}
+			positions[pos] = cube.ID
+		}
+	}
+
+	return problems
+}
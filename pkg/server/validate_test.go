@@ -0,0 +1,194 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestDuplicateCubes(t *testing.T) {
+	a := area.Area{
+		Name: "Town",
+		Rooms: map[string]area.Room{
+			"Square": {Cubes: []area.Cube{
+				{ID: "10", POSX: "1", POSY: "1"},
+				{ID: "2", POSX: "0", POSY: "0"},
+				{ID: "9", POSX: "1", POSY: "1"},
+			}},
+			"Inn": {Cubes: []area.Cube{
+				{ID: "1", POSX: "0", POSY: "0"},
+				{ID: "2", POSX: "0", POSY: "0"},
+			}},
+			"Cellar": {Cubes: []area.Cube{
+				{ID: "1", POSX: "0", POSY: "0"},
+				{ID: "2", POSX: "1", POSY: "0"},
+			}},
+		},
+	}
+	want := []string{
+		`area "Town" room "Inn": cubes 1 and 2 are both at (0,0)`,
+		`area "Town" room "Square": cubes 9 and 10 are both at (1,1)`,
+	}
+
+	// Map iteration order changes between runs, so check a few times.
+	for i := 0; i < 20; i++ {
+		var got []string
+		for _, err := range duplicateCubes(a) {
+			got = append(got, err.Error())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+}
+
+// validateStatic validates the content of the given static directory.
+func validateStatic(t *testing.T, dir string) []error {
+	var s *Server
+	var err error
+	withStatic(dir, func() { s, err = LoadConfig() })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Validate()
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name:   "valid content",
+			config: testConfig,
+		},
+		{
+			name:   "missing start location",
+			config: "[config]\nstartArea = \"Town\"\nstartRoom = \"Cellar\"\nstartPosition = \"1\"\n",
+			want:   []string{"start location Town/Cellar/1 does not exist"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := newStaticDir(t, map[string]string{
+				"server.toml":     test.config,
+				"areas/town.toml": testArea,
+			})
+
+			problems := validateStatic(t, dir)
+
+			var got []string
+			for _, problem := range problems {
+				if _, ok := problem.(*ValidationError); !ok {
+					t.Errorf("problem %v is a %T, want a *ValidationError", problem, problem)
+				}
+				got = append(got, problem.Error())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got problems %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateBrokenArea(t *testing.T) {
+	dir := newStaticDir(t, map[string]string{
+		"server.toml":     testConfig,
+		"areas/town.toml": testArea,
+		"areas/bad.toml":  "name = \"Bad\"\nrooms = [",
+	})
+
+	problems := validateStatic(t, dir)
+
+	if len(problems) != 1 {
+		t.Fatalf("got problems %v, want one", problems)
+	}
+	if _, ok := problems[0].(*ParseError); !ok {
+		t.Errorf("got %T, want a *ParseError", problems[0])
+	}
+}
+
+// exitArea returns an area with a room Hall whose door leads to the given
+// room and cube, and a room Yard with a door back to Hall when back is true.
+func exitArea(toRoom, toCube string, back bool) area.Area {
+	yard := area.Room{Name: "Yard", Cubes: []area.Cube{{ID: "1", POSX: "0", POSY: "0"}}}
+	if back {
+		yard.Cubes = append(yard.Cubes, area.Cube{ID: "2", POSX: "1", POSY: "0", Type: "door",
+			Exits: []area.Exit{{ToArea: "Keep", ToRoom: "Hall", ToCubeID: "1"}}})
+	}
+
+	return area.Area{
+		Name: "Keep",
+		Rooms: map[string]area.Room{
+			"Hall": {Name: "Hall", Cubes: []area.Cube{
+				{ID: "1", POSX: "0", POSY: "0"},
+				{ID: "2", POSX: "1", POSY: "0", Type: "door",
+					Exits: []area.Exit{{ToArea: "Keep", ToRoom: toRoom, ToCubeID: toCube}}},
+			}},
+			"Yard": yard,
+		},
+	}
+}
+
+func TestDanglingExits(t *testing.T) {
+	tests := []struct {
+		name string
+		a    area.Area
+		want []string
+	}{
+		{
+			name: "exits leading somewhere",
+			a:    exitArea("Yard", "1", true),
+		},
+		{
+			name: "exit to a missing room",
+			a:    exitArea("Cellar", "1", true),
+			want: []string{`area "Keep" room "Hall": cube 2 exits to Keep/Cellar/1 which does not exist`},
+		},
+		{
+			name: "exit to a missing cube",
+			a:    exitArea("Yard", "9", true),
+			want: []string{`area "Keep" room "Hall": cube 2 exits to Keep/Yard/9 which does not exist`},
+		},
+		{
+			name: "door without exits",
+			a: area.Area{Name: "Keep", Rooms: map[string]area.Room{
+				"Hall": {Name: "Hall", Cubes: []area.Cube{{ID: "1", POSX: "0", POSY: "0", Type: "door"}}},
+			}},
+			want: []string{`area "Keep" room "Hall": door 1 has no exits`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newTestServer(t, nil)
+			s.Areas = map[string]area.Area{test.a.Name: test.a}
+
+			var got []string
+			for _, problem := range s.danglingExits() {
+				got = append(got, problem.Error())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestOneWayExits(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	s.Areas = map[string]area.Area{"Keep": exitArea("Yard", "1", true)}
+	if warnings := s.oneWayExits(); len(warnings) != 0 {
+		t.Errorf("got warnings %v for exits both ways", warnings)
+	}
+
+	s.Areas = map[string]area.Area{"Keep": exitArea("Yard", "1", false)}
+	want := `area "Keep" room "Hall": exit from cube 2 to Keep/Yard has no way back`
+	if warnings := s.oneWayExits(); len(warnings) != 1 || warnings[0].Error() != want {
+		t.Errorf("got warnings %v, want %q", warnings, want)
+	}
+}
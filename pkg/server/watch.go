@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+// reloadDelay is how long watchAreas waits for area files to stop changing
+// before reloading them. Editors tend to write a file several times in a row
+// when saving it.
+const reloadDelay = 500 * time.Millisecond
+
+// watchAreas reloads all areas every time an area file changes and hands
+// them over to God. If the changed files cannot be loaded, the areas already
+// loaded are kept, and files written by save area are not reloaded at all.
+// watchAreas should be invoked as a goroutine.
+func watchAreas(s *Server, wg *sync.WaitGroup, quit <-chan struct{}) {
+	log.Info("watchAreas started")
+	defer wg.Done()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Error(fmt.Sprintf("Cannot watch areas: %v", err))
+		return
+	}
+	defer watcher.Close()
+
+	if err := watchTree(watcher, s.areasDir()); err != nil {
+		log.Error(fmt.Sprintf("Cannot watch %s: %v", s.areasDir(), err))
+		return
+	}
+
+	var reload <-chan time.Time
+	// changed holds the files changed since the last reload.
+	changed := make(map[string]bool)
+
+	for {
+		select {
+		case <-quit:
+			log.Warn("watchAreas quit")
+			return
+
+		case ev := <-watcher.Events:
+			// Directories created later are watched too, and may already
+			// hold area files when they were moved in.
+			if ev.Op&fsnotify.Create != 0 && isDir(ev.Name) {
+				if err := watchTree(watcher, ev.Name); err != nil {
+					log.Error(fmt.Sprintf("Cannot watch %s: %v", ev.Name, err))
+				}
+				changed[ev.Name] = true
+				reload = time.After(reloadDelay)
+				continue
+			}
+			if !isAreaFile(ev.Name) {
+				continue
+			}
+			log.Debug(fmt.Sprintf("Area file changed: %s", ev))
+			changed[ev.Name] = true
+			reload = time.After(reloadDelay)
+
+		case err := <-watcher.Errors:
+			log.Error(fmt.Sprintf("Error while watching areas: %v", err))
+
+		case <-reload:
+			reload = nil
+			files := changed
+			changed = make(map[string]bool)
+
+			// Areas saved in the game are already loaded, and reloading
+			// them would throw away what other builders did not save yet.
+			if s.savedInGame(files) {
+				log.Debug("Area files saved in the game, not reloading them")
+				continue
+			}
+
+			log.Info("Reloading areas ...")
+			areas, err := s.readAreas()
+			if err != nil {
+				log.Error(fmt.Sprintf("Areas could not be reloaded, keeping the loaded ones: %v", err))
+				continue
+			}
+
+			candidate := &Server{Areas: areas, Config: s.Config, npcs: s.npcs, items: s.items}
+			if problems := candidate.validateAreas(); len(problems) > 0 {
+				for _, problem := range problems {
+					log.Error(problem.Error())
+				}
+				log.Error("Areas could not be reloaded, keeping the loaded ones")
+				continue
+			}
+
+			select {
+			case s.areaUpdates <- areas:
+			case <-quit:
+				log.Warn("watchAreas quit")
+				return
+			}
+		}
+	}
+}
+
+// watchTree adds the given directory and every directory below it to the
+// watcher, since area files can be kept in subdirectories.
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		return watcher.Add(path)
+	})
+}
+
+// isDir returns true if the given path is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// savedInGame returns true if every one of the given files holds what save
+// area last wrote to it.
+func (s *Server) savedInGame(files map[string]bool) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	for path := range files {
+		saved, ok := s.savedAreaFiles[filepath.Clean(path)]
+		if !ok {
+			return false
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil || !bytes.Equal(content, saved) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+func TestWatchAreasSubdirectories(t *testing.T) {
+	s := newLoadedTestServer(t)
+
+	wg := &sync.WaitGroup{}
+	quit := make(chan struct{})
+	defer func() {
+		close(quit)
+		wg.Wait()
+	}()
+	wg.Add(1)
+	go watchAreas(s, wg, quit)
+
+	// Give the watcher time to start before anything changes.
+	time.Sleep(100 * time.Millisecond)
+
+	dir := filepath.Join(s.areasDir(), "extra")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	cave := "name = \"Cave\"\n\n[rooms.Tunnel]\nname = \"Tunnel\"\ncubes = [ { id = \"1\", posx = \"0\", posy = \"0\" } ]\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "cave.toml"), []byte(cave), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		var areas map[string]area.Area
+		select {
+		case areas = <-s.areaUpdates:
+		case <-deadline:
+			t.Fatal("areas were not reloaded")
+		}
+		if _, ok := areas["Cave"].Rooms["Tunnel"]; ok {
+			if _, ok := areas["Town"]; !ok {
+				t.Error("area Town is missing after the reload")
+			}
+			return
+		}
+	}
+}
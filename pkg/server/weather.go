@@ -0,0 +1,139 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// weatherType describes a kind of weather to the players.
+type weatherType struct {
+	// state is shown while looking at a room.
+	state string
+	// change is shown to the players outdoors when the weather turns into
+	// this one.
+	change string
+}
+
+// weatherTypes holds the kinds of weather areas can have.
+var weatherTypes = map[string]weatherType{
+	"clear":  {"The sky is clear.", "The sky clears up."},
+	"cloudy": {"It is cloudy.", "Clouds gather overhead."},
+	"rain":   {"It is raining.", "It starts to rain."},
+	"storm":  {"A storm is raging.", "A storm breaks out."},
+	"snow":   {"It is snowing.", "It starts to snow."},
+	"fog":    {"It is foggy.", "Fog rolls in."},
+}
+
+// nextWeather returns the weather following the current one. The weather
+// changes when roll is less than chance, into the allowed weather at pick
+// modulo the number of the other allowed weathers. A current weather that is
+// not allowed turns into the first allowed one.
+func nextWeather(current string, allowed []string, chance, roll float64, pick int) string {
+	if len(allowed) == 0 {
+		return ""
+	}
+
+	var others []string
+	isAllowed := false
+	for _, w := range allowed {
+		if w == current {
+			isAllowed = true
+			continue
+		}
+		others = append(others, w)
+	}
+	if !isAllowed {
+		return allowed[0]
+	}
+	if roll >= chance || len(others) == 0 {
+		return current
+	}
+	return others[pick%len(others)]
+}
+
+// changeChance returns the chance of a change to happen within the elapsed
+// time, given the chance of it happening every second.
+func changeChance(perSecond float64, elapsed time.Duration) float64 {
+	if perSecond >= 1 {
+		return 1
+	}
+	return 1 - math.Pow(1-perSecond, elapsed.Seconds())
+}
+
+// tickWeather advances the weather of every area by the elapsed time and
+// returns the areas whose weather changed.
+func (s *Server) tickWeather(elapsed time.Duration) []string {
+	chance := changeChance(s.Config.WeatherChangeChance, elapsed)
+
+	var changed []string
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		current := s.weather[areaName]
+		next := nextWeather(current, s.Areas[areaName].Weather, chance, s.rand.Float64(), s.rand.Int())
+		if next == current {
+			continue
+		}
+		s.weather[areaName] = next
+		if current != "" {
+			changed = append(changed, areaName)
+		}
+	}
+	return changed
+}
+
+// roomDescription returns the description of the room, followed by the
+// weather if the room is outdoors.
+func (s *Server) roomDescription(areaName, roomName string) string {
+	room := s.Areas[areaName].Rooms[roomName]
+	w, ok := weatherTypes[s.weather[areaName]]
+	if room.Indoors || !ok {
+		return room.Description
+	}
+	return strings.TrimRight(room.Description, "\n") + "\n" + w.state + "\n"
+}
+
+// godPrintWeather lets the players outdoors in the given area know that the
+// weather changed.
+func godPrintWeather(
+	s *Server,
+	areaName string,
+	wg *sync.WaitGroup,
+	quit <-chan struct{},
+	roomsMap map[string]map[string][][]area.Cube,
+) {
+	msg := s.paint(theme.System, weatherTypes[s.weather[areaName]].change)
+	for _, clients := range onlineClientsByRoom(s) {
+		p := clients[0].Player
+		if p.Area != areaName || s.Areas[p.Area].Rooms[p.Room].Indoors {
+			continue
+		}
+		wg.Add(1)
+		godPrintRoom(s, clients[0], clients, wg, quit, roomsMap, msg, msg)
+	}
+}
+
+// unknownWeather returns a problem for every weather of an area that is not
+// one of the weatherTypes.
+func (s *Server) unknownWeather() []error {
+	var known []string
+	for w := range weatherTypes {
+		known = append(known, w)
+	}
+	sort.Strings(known)
+
+	var problems []error
+	for _, areaName := range sortedAreaNames(s.Areas) {
+		for _, w := range s.Areas[areaName].Weather {
+			if _, ok := weatherTypes[w]; !ok {
+				problems = append(problems, fmt.Errorf("area %q: unknown weather %q, expected one of %s", areaName, w, strings.Join(known, ", ")))
+			}
+		}
+	}
+	return problems
+}
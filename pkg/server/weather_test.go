@@ -0,0 +1,75 @@
+package server
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextWeather(t *testing.T) {
+	allowed := []string{"clear", "rain", "fog"}
+
+	tests := []struct {
+		current string
+		allowed []string
+		roll    float64
+		pick    int
+		want    string
+	}{
+		// No weather at all.
+		{current: "rain", allowed: nil, want: ""},
+		// Weather that is not allowed, or none yet, becomes the first one.
+		{current: "", allowed: allowed, roll: 0.9, want: "clear"},
+		{current: "snow", allowed: allowed, roll: 0.9, want: "clear"},
+		// The weather stays unless the roll is under the chance.
+		{current: "rain", allowed: allowed, roll: 0.5, want: "rain"},
+		// Changes pick one of the other allowed weathers.
+		{current: "rain", allowed: allowed, roll: 0.1, pick: 0, want: "clear"},
+		{current: "rain", allowed: allowed, roll: 0.1, pick: 1, want: "fog"},
+		{current: "rain", allowed: allowed, roll: 0.1, pick: 5, want: "fog"},
+		// With only one allowed weather there is nothing to change into.
+		{current: "fog", allowed: []string{"fog"}, roll: 0, want: "fog"},
+	}
+	for _, test := range tests {
+		got := nextWeather(test.current, test.allowed, 0.5, test.roll, test.pick)
+		if got != test.want {
+			t.Errorf("nextWeather(%q, %v, roll %v, pick %d) = %q, want %q", test.current, test.allowed, test.roll, test.pick, got, test.want)
+		}
+	}
+}
+
+func TestChangeChance(t *testing.T) {
+	tests := []struct {
+		perSecond float64
+		elapsed   time.Duration
+		want      float64
+	}{
+		{perSecond: 0, elapsed: time.Hour, want: 0},
+		{perSecond: 1, elapsed: time.Millisecond, want: 1},
+		{perSecond: 0.5, elapsed: time.Second, want: 0.5},
+		{perSecond: 0.5, elapsed: 2 * time.Second, want: 0.75},
+		{perSecond: 0.5, elapsed: 500 * time.Millisecond, want: 1 - math.Sqrt(0.5)},
+	}
+	for _, test := range tests {
+		if got := changeChance(test.perSecond, test.elapsed); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("changeChance(%v, %v) = %v, want %v", test.perSecond, test.elapsed, got, test.want)
+		}
+	}
+}
+
+func TestRoomDescriptionWeather(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.weather["Town"] = "rain"
+
+	if got := s.roomDescription("Town", "Square"); !strings.HasSuffix(got, "\nIt is raining.\n") {
+		t.Errorf("no weather outdoors: %q", got)
+	}
+
+	inn := s.Areas["Town"].Rooms["Inn"]
+	inn.Indoors = true
+	s.Areas["Town"].Rooms["Inn"] = inn
+	if got, want := s.roomDescription("Town", "Inn"), inn.Description; got != want {
+		t.Errorf("got %q indoors, want %q", got, want)
+	}
+}
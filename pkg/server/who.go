@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gothyra/thyra/pkg/client"
+	"github.com/gothyra/thyra/pkg/theme"
+)
+
+// whoUsage tells players how to use who.
+const whoUsage = "Usage: who [admins | level <min>-<max> | <area>]"
+
+// doWho lists the players who are online, or only those matching the filter
+// given in args.
+func doWho(s *Server, c client.Client, args []string) string {
+	match, ok := s.whoFilter(args)
+	if !ok {
+		return whoUsage
+	}
+
+	var online []client.Client
+	for _, o := range s.OnlineClients() {
+		if match(o) {
+			online = append(online, o)
+		}
+	}
+	sort.Slice(online, func(i, j int) bool {
+		return online[i].Player.Nickname < online[j].Player.Nickname
+	})
+
+	table := theme.NewTable("Name", "Level", "Class", "Area", "Flags")
+	table.Theme = s.Config.Theme
+	for _, o := range online {
+		table.AddRow(o.Player.Nickname, strconv.Itoa(o.Player.Level), o.Player.Class, o.Player.Area, s.whoFlags(o))
+	}
+
+	return fmt.Sprintf("%s\n%s online.", table.Render(c.Player.Settings.Color), plural(len(online), "player"))
+}
+
+// whoFilter returns the filter given in args, which matches everybody when
+// args are empty. It returns false if args are not a filter.
+func (s *Server) whoFilter(args []string) (func(client.Client) bool, bool) {
+	switch {
+	case len(args) == 0:
+		return func(client.Client) bool { return true }, true
+
+	case len(args) == 1 && args[0] == "admins":
+		return func(o client.Client) bool { return o.Player.IsAdmin() }, true
+
+	case len(args) == 2 && args[0] == "level":
+		min, max, ok := parseLevelRange(args[1])
+		if !ok {
+			return nil, false
+		}
+		return func(o client.Client) bool {
+			return o.Player.Level >= min && o.Player.Level <= max
+		}, true
+
+	case len(args) == 1:
+		for name := range s.Areas {
+			if strings.EqualFold(name, args[0]) {
+				return func(o client.Client) bool { return o.Player.Area == name }, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseLevelRange parses a range of levels such as 3-5, or a single level
+// such as 4.
+func parseLevelRange(r string) (int, int, bool) {
+	parts := strings.SplitN(r, "-", 2)
+	min, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	if len(parts) == 1 {
+		return min, min, true
+	}
+	max, err := strconv.Atoi(parts[1])
+	if err != nil || max < min {
+		return 0, 0, false
+	}
+	return min, max, true
+}
+
+// whoFlags returns what is worth knowing about the player at a glance.
+func (s *Server) whoFlags(o client.Client) string {
+	var flags []string
+	if o.Player.AFK {
+		flags = append(flags, "AFK")
+	}
+	if role := roleName(o.Player.PermissionLevel()); role != "" {
+		flags = append(flags, role)
+	}
+	if s.isFighting(o.Player.Nickname) {
+		flags = append(flags, "fighting")
+	}
+	return strings.Join(flags, ", ")
+}
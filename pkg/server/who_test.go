@@ -0,0 +1,132 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gothyra/thyra/pkg/area"
+)
+
+// whoRow returns the row of the who table naming the given player, or
+// nothing if the player is not listed.
+func whoRow(table, nick string) string {
+	for _, line := range strings.Split(table, "\n") {
+		if strings.Contains(line, nick) {
+			return line
+		}
+	}
+	return ""
+}
+
+func TestWhoFilters(t *testing.T) {
+	s := newLoadedTestServer(t)
+	s.Areas["Keep"] = area.Area{Name: "Keep"}
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Inn", "1")
+	carol := addTestPlayer(t, s, "Carol", "Keep", "Hall", "1")
+	alice.Player.Level, bob.Player.Level, carol.Player.Level = 1, 5, 3
+	bob.Player.Admin = true
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: nil, want: []string{"Alice", "Bob", "Carol"}},
+		{args: []string{"admins"}, want: []string{"Bob"}},
+		{args: []string{"level", "2-5"}, want: []string{"Bob", "Carol"}},
+		{args: []string{"level", "3"}, want: []string{"Carol"}},
+		{args: []string{"level", "6-9"}, want: nil},
+		{args: []string{"town"}, want: []string{"Alice", "Bob"}},
+		{args: []string{"Keep"}, want: []string{"Carol"}},
+	}
+	for _, test := range tests {
+		got := doWho(s, alice, test.args)
+		for _, nick := range []string{"Alice", "Bob", "Carol"} {
+			want := false
+			for _, w := range test.want {
+				want = want || w == nick
+			}
+			if listed := whoRow(got, nick) != ""; listed != want {
+				t.Errorf("who %v: %s listed is %v, want %v", test.args, nick, listed, want)
+			}
+		}
+		if !strings.HasSuffix(got, plural(len(test.want), "player")+" online.") {
+			t.Errorf("who %v: got\n%s", test.args, got)
+		}
+	}
+
+	for _, args := range [][]string{{"level"}, {"level", "5-2"}, {"level", "high"}, {"Nowhere"}, {"admins", "now"}} {
+		if got := doWho(s, alice, args); got != whoUsage {
+			t.Errorf("who %v: got %q", args, got)
+		}
+	}
+}
+
+func TestParseLevelRange(t *testing.T) {
+	tests := []struct {
+		r        string
+		min, max int
+		ok       bool
+	}{
+		{r: "3-5", min: 3, max: 5, ok: true},
+		{r: "4", min: 4, max: 4, ok: true},
+		{r: "5-5", min: 5, max: 5, ok: true},
+		{r: "5-3", ok: false},
+		{r: "a-3", ok: false},
+		{r: "3-", ok: false},
+		{r: "", ok: false},
+	}
+	for _, test := range tests {
+		min, max, ok := parseLevelRange(test.r)
+		if min != test.min || max != test.max || ok != test.ok {
+			t.Errorf("%q: got %d, %d, %v", test.r, min, max, ok)
+		}
+	}
+}
+
+func TestWhoFlags(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	addTestPlayer(t, s, "Carol", "Town", "Square", "4")
+	alice.Player.AFK = true
+	bob.Player.Admin = true
+	s.combats[duelBetween("Bob", "Carol")] = time.Now()
+
+	got := doWho(s, alice, nil)
+	for nick, flags := range map[string]string{"Alice": "AFK", "Bob": "admin, fighting", "Carol": "fighting"} {
+		if row := whoRow(got, nick); !strings.HasSuffix(strings.TrimSpace(row), flags) {
+			t.Errorf("%s: got row %q, want flags %q", nick, row, flags)
+		}
+	}
+}
+
+func TestMarkIdleAFK(t *testing.T) {
+	s := newLoadedTestServer(t)
+	alice := addTestPlayer(t, s, "Alice", "Town", "Square", "1")
+	bob := addTestPlayer(t, s, "Bob", "Town", "Square", "2")
+	now := time.Now()
+	alice.Player.LastActive = now.Add(-10 * time.Minute)
+	bob.Player.LastActive = now.Add(-time.Minute)
+
+	// Nobody is marked as away unless configured.
+	s.markIdleAFK(now)
+	if alice.Player.AFK {
+		t.Error("Alice marked as away with afkIdleMinutes unset")
+	}
+
+	s.Config.AFKIdleMinutes = 5
+	s.markIdleAFK(now)
+	if !alice.Player.AFK || bob.Player.AFK {
+		t.Errorf("got Alice away %v and Bob away %v, want only Alice", alice.Player.AFK, bob.Player.AFK)
+	}
+
+	// A command brings Alice back.
+	if !markActive(alice, "look", now) || alice.Player.AFK {
+		t.Error("a command did not clear the away mark")
+	}
+	if !alice.Player.LastActive.Equal(now) {
+		t.Errorf("last active at %v, want %v", alice.Player.LastActive, now)
+	}
+}
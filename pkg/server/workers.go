@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	log "gopkg.in/inconshreveable/log15.v2"
+)
+
+// roomEvents holds the events that only involve the player causing them and
+// the room the player is in, without moving anybody. Events of different
+// rooms can be handled at the same time by roomWorkers.
+var roomEvents = map[string]bool{
+	"look":      true,
+	"clear":     true,
+	"say":       true,
+	"emote":     true,
+	"exits":     true,
+	"areamap":   true,
+	"areas":     true,
+	"compass":   true,
+	"charset":   true,
+	"autolook":  true,
+	"ambient":   true,
+	"brief":     true,
+	"afk":       true,
+	"color":     true,
+	"pager":     true,
+	"prompt":    true,
+	"settings":  true,
+	"channel":   true,
+	"quiet":     true,
+	"filter":    true,
+	"skills":    true,
+	"cooldowns": true,
+	"time":      true,
+	"more":      true,
+	"notice":    true,
+	"too_long":  true,
+	"throttled": true,
+	"ambiguous": true,
+	"unknown":   true,
+}
+
+// roomWorkers handles events in a pool of goroutines, one queue per
+// goroutine. Events of the same room always go to the same queue so they
+// are handled one at a time and in order.
+type roomWorkers struct {
+	queues []chan func()
+	busy   sync.WaitGroup
+}
+
+// newRoomWorkers starts the given number of workers, which stop once quit
+// is closed. It returns nil for less than two workers, in which case God
+// handles every event itself.
+func newRoomWorkers(n int, wg *sync.WaitGroup, quit <-chan struct{}) *roomWorkers {
+	if n < 2 {
+		return nil
+	}
+
+	w := &roomWorkers{queues: make([]chan func(), n)}
+	for i := range w.queues {
+		w.queues[i] = make(chan func(), 100)
+		wg.Add(1)
+		go w.work(i, wg, quit)
+	}
+	log.Info(fmt.Sprintf("God handles room events with %d workers", n))
+	return w
+}
+
+// work runs the jobs of the i-th queue until quit is closed.
+func (w *roomWorkers) work(i int, wg *sync.WaitGroup, quit <-chan struct{}) {
+	defer wg.Done()
+
+	for {
+		select {
+		case job := <-w.queues[i]:
+			w.runJob(i, job)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// runJob runs the job, recovering from panics so that the worker keeps on
+// working.
+func (w *roomWorkers) runJob(i int, job func()) {
+	defer w.busy.Done()
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic(fmt.Sprintf("God worker %d", i), r)
+		}
+	}()
+	job()
+}
+
+// run queues the job on the queue of the given room.
+func (w *roomWorkers) run(room string, job func()) {
+	h := fnv.New32a()
+	h.Write([]byte(room))
+
+	w.busy.Add(1)
+	w.queues[h.Sum32()%uint32(len(w.queues))] <- job
+}
+
+// wait blocks until every queued job is done, so that God can go on with
+// events involving more than one room.
+func (w *roomWorkers) wait() {
+	if w != nil {
+		w.busy.Wait()
+	}
+}
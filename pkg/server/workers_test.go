@@ -0,0 +1,114 @@
+package server
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRoomWorkersKeepRoomOrder(t *testing.T) {
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer func() {
+		close(quit)
+		wg.Wait()
+	}()
+	workers := newRoomWorkers(4, &wg, quit)
+
+	const rooms, events = 10, 200
+	// Each room is only touched by the worker of its queue.
+	applied := make([][]int, rooms)
+	for i := 0; i < events; i++ {
+		for room := 0; room < rooms; room++ {
+			i, room := i, room
+			workers.run(roomKey("Town", fmt.Sprintf("Room%d", room)), func() {
+				applied[room] = append(applied[room], i)
+			})
+		}
+	}
+	workers.wait()
+
+	for room, got := range applied {
+		if len(got) != events {
+			t.Fatalf("room %d: %d events applied, want %d", room, len(got), events)
+		}
+		for i := range got {
+			if got[i] != i {
+				t.Fatalf("room %d: event %d applied as number %d", room, got[i], i)
+			}
+		}
+	}
+}
+
+func TestRoomWorkersSurvivePanics(t *testing.T) {
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer func() {
+		close(quit)
+		wg.Wait()
+	}()
+	workers := newRoomWorkers(2, &wg, quit)
+
+	done := false
+	workers.run("Town/Square", func() { panic("boom") })
+	workers.run("Town/Square", func() { done = true })
+	workers.wait()
+	if !done {
+		t.Error("the worker stopped after a panic")
+	}
+}
+
+func TestNewRoomWorkers(t *testing.T) {
+	var wg sync.WaitGroup
+	quit := make(chan struct{})
+	defer close(quit)
+
+	for _, n := range []int{-1, 0, 1} {
+		if w := newRoomWorkers(n, &wg, quit); w != nil {
+			t.Errorf("got workers for %d", n)
+		}
+	}
+	// God waits for no workers when there are none.
+	var none *roomWorkers
+	none.wait()
+}
+
+// busyEvent stands for handling an event, which mostly means drawing the
+// room for everybody in it.
+func busyEvent() {
+	data := make([]byte, 4096)
+	for i := 0; i < 16; i++ {
+		sum := sha256.Sum256(data)
+		copy(data, sum[:])
+	}
+}
+
+func BenchmarkRoomEvents(b *testing.B) {
+	const rooms = 16
+	keys := make([]string, rooms)
+	for i := range keys {
+		keys[i] = roomKey("Town", fmt.Sprintf("Room%d", i))
+	}
+
+	b.Run("god", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			busyEvent()
+		}
+	})
+	for _, n := range []int{2, 4, 8} {
+		b.Run(fmt.Sprintf("workers-%d", n), func(b *testing.B) {
+			var wg sync.WaitGroup
+			quit := make(chan struct{})
+			workers := newRoomWorkers(n, &wg, quit)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				workers.run(keys[i%rooms], busyEvent)
+			}
+			workers.wait()
+			b.StopTimer()
+			close(quit)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package theme
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Table lays text out in aligned columns.
+type Table struct {
+	// Theme colors the headers of the table. The default theme is used
+	// when it is nil.
+	Theme   Theme
+	headers []string
+	rows    [][]string
+}
+
+// NewTable creates a table with the given column headers.
+func NewTable(headers ...string) *Table {
+	return &Table{headers: headers}
+}
+
+// AddRow adds a row with the given cells to the table. Missing cells are
+// left blank and extra cells are dropped.
+func (t *Table) AddRow(cells ...string) *Table {
+	row := make([]string, len(t.headers))
+	copy(row, cells)
+	t.rows = append(t.rows, row)
+	return t
+}
+
+// widths returns the width of every column, which is the width of its
+// widest cell or header.
+func (t *Table) widths() []int {
+	widths := make([]int, len(t.headers))
+	for i, header := range t.headers {
+		widths[i] = runewidth.StringWidth(header)
+	}
+	for _, row := range t.rows {
+		for i, cell := range row {
+			if w := runewidth.StringWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	return widths
+}
+
+// Render returns the table as lines of padded columns, headers first. The
+// headers are colored as Header when color is on.
+func (t *Table) Render(color bool) string {
+	widths := t.widths()
+	line := func(cells []string) string {
+		padded := make([]string, len(cells))
+		for i, cell := range cells {
+			padded[i] = cell
+			if i < len(cells)-1 {
+				padded[i] += strings.Repeat(" ", widths[i]-runewidth.StringWidth(cell))
+			}
+		}
+		return strings.Join(padded, "  ")
+	}
+
+	header := line(t.headers)
+	if color {
+		header = t.Theme.Paint(Header, header)
+	}
+	lines := []string{header}
+	for _, row := range t.rows {
+		lines = append(lines, line(row))
+	}
+	return strings.Join(lines, "\n")
+}
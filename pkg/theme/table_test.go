@@ -0,0 +1,58 @@
+package theme
+
+import "testing"
+
+func TestTableRender(t *testing.T) {
+	table := NewTable("Name", "Level", "Area").
+		AddRow("Alexandra", "3", "City").
+		AddRow("Bo", "12", "Town").
+		// Wide characters take two columns each.
+		AddRow("風", "1", "Forest").
+		// Missing cells are blank and extra cells are dropped.
+		AddRow("Cy").
+		AddRow("Di", "5", "Cave", "extra")
+
+	want := "Name       Level  Area\n" +
+		"Alexandra  3      City\n" +
+		"Bo         12     Town\n" +
+		"風         1      Forest\n" +
+		"Cy                \n" +
+		"Di         5      Cave"
+	if got := table.Render(false); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTableWidths(t *testing.T) {
+	tests := []struct {
+		name  string
+		table *Table
+		want  []int
+	}{
+		{name: "headers only", table: NewTable("Name", "Level"), want: []int{4, 5}},
+		{name: "wider cells", table: NewTable("A", "B").AddRow("abc", "").AddRow("a", "abcdef"), want: []int{3, 6}},
+		{name: "wide characters", table: NewTable("A").AddRow("日本"), want: []int{4}},
+	}
+	for _, test := range tests {
+		got := test.table.widths()
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestTableRenderColor(t *testing.T) {
+	table := NewTable("A", "B").AddRow("x", "y")
+	table.Theme = Theme{Header: "4"}
+
+	if got, want := table.Render(true), "\033[4mA  B\033[0m\nx  y"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+// Package theme colors the text players see according to the role the text
+// plays in the game.
+package theme
+
+import "strings"
+
+// Roles text can play.
+const (
+	System = "system"
+	Chat   = "chat"
+	Combat = "combat"
+	Error  = "error"
+	Header = "header"
+)
+
+// Theme maps roles to ANSI SGR codes, eg. "31" for red or "1;32" for bold
+// green.
+type Theme map[string]string
+
+// Default is the theme used for roles a server does not configure.
+var Default = Theme{
+	System: "36",
+	Chat:   "32",
+	Combat: "31",
+	Error:  "1;31",
+	Header: "1",
+}
+
+// Code returns the ANSI SGR code of the role, falling back to the default
+// theme when the theme does not have one.
+func (t Theme) Code(role string) string {
+	if code, ok := t[role]; ok {
+		return code
+	}
+	return Default[role]
+}
+
+// Paint wraps text in the ANSI escape sequences coloring it as the role.
+// Every line is wrapped on its own, so that multi-line text stays colored
+// line by line.
+func (t Theme) Paint(role, text string) string {
+	code := t.Code(role)
+	if code == "" || text == "" {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "\033[" + code + "m" + line + "\033[0m"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,31 @@
+package theme
+
+import "testing"
+
+func TestPaint(t *testing.T) {
+	th := Theme{Chat: "35", Error: ""}
+
+	tests := []struct {
+		role, text string
+		want       string
+	}{
+		// Configured roles use the configured code.
+		{role: Chat, text: "Bob says hi.", want: "\033[35mBob says hi.\033[0m"},
+		// Roles the theme does not have use the default one.
+		{role: Combat, text: "Ouch!", want: "\033[31mOuch!\033[0m"},
+		// Roles configured without a code are not colored.
+		{role: Error, text: "No.", want: "No."},
+		{role: "unknown", text: "Hello.", want: "Hello."},
+		// Every line is colored on its own.
+		{role: Chat, text: "a\n\nb", want: "\033[35ma\033[0m\n\n\033[35mb\033[0m"},
+		{role: Chat, text: "", want: ""},
+	}
+	for _, test := range tests {
+		if got := th.Paint(test.role, test.text); got != test.want {
+			t.Errorf("Paint(%q, %q) = %q, want %q", test.role, test.text, got, test.want)
+		}
+	}
+	if got := Theme(nil).Code(System); got != Default[System] {
+		t.Errorf("nil theme: got code %q for %s", got, System)
+	}
+}